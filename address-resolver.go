@@ -0,0 +1,490 @@
+/**
+ * resolution of the Exchange addresses (address type EX, the address is the legacyExchangeDN of the user) to SMTP
+ * addresses: the TNEF objects of Exchange carry the X500 DN of the senders, the recipients and the distribution list
+ * members, often without their SMTP address
+ */
+
+package tnefdecoder
+
+import (
+	"container/list"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrAddressNotResolved = errors.New("address not resolved")
+
+/**
+ * the address of a directory user
+ */
+type ResolvedAddress struct {
+	DisplayName string
+	SmtpAddress string
+}
+
+/**
+ * map a legacyExchangeDN (/O=ORG/OU=.../CN=RECIPIENTS/CN=USER) to the address of the user; the DNs are case insensitive
+ * return ErrAddressNotResolved if the user is unknown
+ */
+type AddressResolver interface {
+	ResolveAddress(legacyDn string) (*ResolvedAddress, error)
+}
+
+/**
+ * return the address of an EX address, or of the address-book entry ID if the address is not an EX address; nil
+ * without resolver or if the address is not resolved
+ */
+func (t *TnefObject) resolveAddress(addressType string, address string, entryId []byte) *ResolvedAddress {
+	if t.AddressResolver == nil {
+		return nil
+	}
+	if !strings.EqualFold(addressType, "EX") || address == "" {
+		a, err := DecodeEntryIdAddressCodepage(entryId, t.Codepage)
+		if err != nil || !strings.EqualFold(a.AddressType, "EX") {
+			return nil
+		}
+		address = a.EmailAddress
+	}
+	resolved, err := t.AddressResolver.ResolveAddress(address)
+	if err != nil || resolved == nil || resolved.SmtpAddress == "" {
+		return nil
+	}
+	return resolved
+}
+
+/**
+ * replace an EX address with the SMTP address given by the address resolver
+ */
+func (t *TnefObject) resolveEntryIdAddress(a *EntryIdAddress) *EntryIdAddress {
+	if a == nil || a.GetSmtpAddress() != "" {
+		return a
+	}
+	resolved := t.resolveAddress(a.AddressType, a.EmailAddress, nil)
+	if resolved == nil {
+		return a
+	}
+	name := a.DisplayName
+	if name == "" {
+		name = resolved.DisplayName
+	}
+	return &EntryIdAddress{DisplayName: name, AddressType: "SMTP", EmailAddress: resolved.SmtpAddress}
+}
+
+/**
+ * an address resolver backed by a table legacyExchangeDN -> address (ex: loaded from an export of the directory)
+ */
+type MapAddressResolver map[string]*ResolvedAddress
+
+/**
+ * add an address; the DN is stored in upper case
+ */
+func (m MapAddressResolver) Add(legacyDn string, smtpAddress string, displayName string) {
+	m[strings.ToUpper(legacyDn)] = &ResolvedAddress{DisplayName: displayName, SmtpAddress: smtpAddress}
+}
+
+func (m MapAddressResolver) ResolveAddress(legacyDn string) (*ResolvedAddress, error) {
+	if a, ok := m[strings.ToUpper(legacyDn)]; ok {
+		return a, nil
+	}
+	return nil, ErrAddressNotResolved
+}
+
+/**
+ * the column names (case insensitive, by priority) of the CSV and JSON address files; the names of the Active Directory
+ * attributes are recognized, so an export of csvde can be used as is
+ */
+var (
+	addressFileDnNames    = []string{"legacyexchangedn", "legacydn", "dn", "x500"}
+	addressFileSmtpNames  = []string{"mail", "smtp", "smtpaddress", "email", "emailaddress"}
+	addressFileNameNames  = []string{"displayname", "name", "cn"}
+	addressFileProxyNames = []string{"proxyaddresses"}
+)
+
+/**
+ * load a CSV or a JSON (.json) address file
+ */
+func LoadAddressResolver(path string) (MapAddressResolver, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return ReadJsonAddressResolver(f)
+	}
+	return ReadCsvAddressResolver(f)
+}
+
+/**
+ * read a CSV address file: legacyExchangeDN, SMTP address and display name (optional) or, with a header row, the
+ * columns legacyExchangeDN, mail, displayName and proxyAddresses (the X500: proxy addresses are the previous DNs of
+ * the user; the multiple values are separated by ";")
+ */
+func ReadCsvAddressResolver(r io.Reader) (MapAddressResolver, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	m := MapAddressResolver{}
+	columns := map[string]int{"dn": 0, "smtp": 1, "name": 2, "proxy": -1}
+	for line := 0; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if line == 0 && len(record) > 0 && !strings.HasPrefix(strings.TrimSpace(record[0]), "/") {
+			// header row
+			for key, names := range map[string][]string{"dn": addressFileDnNames, "smtp": addressFileSmtpNames, "name": addressFileNameNames, "proxy": addressFileProxyNames} {
+				columns[key] = -1
+				for _, name := range names {
+					for i, field := range record {
+						if columns[key] < 0 && strings.EqualFold(strings.TrimSpace(field), name) {
+							columns[key] = i
+						}
+					}
+				}
+			}
+			if (columns["dn"] < 0 && columns["proxy"] < 0) || columns["smtp"] < 0 {
+				return nil, fmt.Errorf("address file: no DN or SMTP address column in %q", strings.Join(record, ","))
+			}
+			continue
+		}
+
+		field := func(key string) string {
+			if i := columns[key]; i >= 0 && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		m.addEntry(field("dn"), field("smtp"), field("name"), strings.Split(field("proxy"), ";"))
+	}
+	return m, nil
+}
+
+/**
+ * read a JSON address file: an object {"legacyExchangeDN": "SMTP address"} or an array of objects having the names
+ * of the CSV columns (proxyAddresses is an array)
+ */
+func ReadJsonAddressResolver(r io.Reader) (MapAddressResolver, error) {
+	var v interface{}
+	if err := json.NewDecoder(r).Decode(&v); err != nil {
+		return nil, err
+	}
+
+	m := MapAddressResolver{}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for dn, address := range v {
+			if s, ok := address.(string); ok {
+				m.addEntry(dn, s, "", nil)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			entry, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			field := func(names []string) string {
+				for _, name := range names {
+					for k, value := range entry {
+						if s, ok := value.(string); ok && strings.EqualFold(k, name) {
+							return s
+						}
+					}
+				}
+				return ""
+			}
+			var proxies []string
+			for k, value := range entry {
+				if values, ok := value.([]interface{}); ok && containsFold(addressFileProxyNames, k) {
+					for _, p := range values {
+						if s, ok := p.(string); ok {
+							proxies = append(proxies, s)
+						}
+					}
+				}
+			}
+			m.addEntry(field(addressFileDnNames), field(addressFileSmtpNames), field(addressFileNameNames), proxies)
+		}
+	default:
+		return nil, errors.New("address file: a JSON object or array is expected")
+	}
+	return m, nil
+}
+
+/**
+ * add the DN and the X500 proxy addresses of a user
+ */
+func (m MapAddressResolver) addEntry(dn string, smtpAddress string, displayName string, proxies []string) {
+	if smtpAddress == "" {
+		return
+	}
+	if dn != "" {
+		m.Add(dn, smtpAddress, displayName)
+	}
+	for _, p := range proxies {
+		if p = strings.TrimSpace(p); len(p) > 5 && strings.EqualFold(p[:5], "X500:") {
+			m.Add(p[5:], smtpAddress, displayName)
+		}
+	}
+}
+
+func containsFold(values []string, v string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * a directory queried with LDAP search filters (RFC 4515), ex: Active Directory; the implementation binds to the
+ * directory and searches the subtree of its base DN
+ */
+type DirectorySearcher interface {
+	// return the values of the requested attributes of the entries matching the filter; the search is abandoned when
+	// the context is done
+	Search(ctx context.Context, filter string, attributes []string) ([]map[string][]string, error)
+}
+
+// the default filter of LdapAddressResolver: the current DN of the user or one of the previous DNs
+const DefaultLdapAddressFilter = "(|(legacyExchangeDN=%[1]s)(proxyAddresses=X500:%[1]s))"
+
+/**
+ * the defaults of LdapAddressResolver: the timeout of a search, the number of cached DNs and how long the users and
+ * the unknown DNs are cached
+ */
+const (
+	DefaultLdapTimeout          = 10 * time.Second
+	DefaultLdapCacheSize        = 10000
+	DefaultLdapCacheTtl         = time.Hour
+	DefaultLdapNegativeCacheTtl = 5 * time.Minute
+)
+
+/**
+ * an address resolver querying a directory; the results are cached (least recently used DNs are evicted first), the
+ * unknown DNs for a shorter time since the user may be added meanwhile
+ */
+type LdapAddressResolver struct {
+	Directory DirectorySearcher
+
+	// the search filter; %[1]s is the escaped legacyExchangeDN
+	Filter string
+
+	// the attributes of the SMTP address and of the display name
+	MailAttribute string
+	NameAttribute string
+
+	// the limit of a search; DefaultLdapTimeout if not set (<= 0)
+	Timeout time.Duration
+
+	// the maximum number of cached DNs and the lifetime of the users and of the unknown DNs in the cache; the defaults
+	// if not set (<= 0)
+	CacheSize        int
+	CacheTtl         time.Duration
+	NegativeCacheTtl time.Duration
+
+	mu    sync.Mutex
+	cache map[string]*list.Element
+	lru   *list.List // of *ldapCacheEntry, the most recently used first
+	now   func() time.Time
+}
+
+type ldapCacheEntry struct {
+	key     string
+	address *ResolvedAddress // nil for an unknown DN
+	expires time.Time
+}
+
+func NewLdapAddressResolver(directory DirectorySearcher) *LdapAddressResolver {
+	return &LdapAddressResolver{
+		Directory:     directory,
+		Filter:        DefaultLdapAddressFilter,
+		MailAttribute: "mail",
+		NameAttribute: "displayName",
+	}
+}
+
+func (l *LdapAddressResolver) ResolveAddress(legacyDn string) (*ResolvedAddress, error) {
+	key := strings.ToUpper(legacyDn)
+	a, ok := l.cached(key)
+	if !ok {
+		timeout := l.Timeout
+		if timeout <= 0 {
+			timeout = DefaultLdapTimeout
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		entries, err := l.Directory.Search(ctx, fmt.Sprintf(l.Filter, EscapeLdapFilterValue(legacyDn)), []string{l.MailAttribute, l.NameAttribute})
+		cancel()
+		if err != nil {
+			// not cached: the directory may be temporarily unavailable
+			return nil, err
+		}
+		for _, entry := range entries {
+			if mail := ldapAttributeValue(entry, l.MailAttribute); mail != "" {
+				a = &ResolvedAddress{DisplayName: ldapAttributeValue(entry, l.NameAttribute), SmtpAddress: mail}
+				break
+			}
+		}
+		l.store(key, a)
+	}
+
+	if a == nil {
+		return nil, ErrAddressNotResolved
+	}
+	return a, nil
+}
+
+/**
+ * the cached address of a DN (nil for an unknown DN); false if the DN is not cached or expired
+ */
+func (l *LdapAddressResolver) cached(key string) (*ResolvedAddress, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.cache[key]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*ldapCacheEntry)
+	if !l.clock().Before(entry.expires) {
+		l.lru.Remove(e)
+		delete(l.cache, key)
+		return nil, false
+	}
+	l.lru.MoveToFront(e)
+	return entry.address, true
+}
+
+/**
+ * cache the result of a search, evicting the least recently used DNs beyond CacheSize
+ */
+func (l *LdapAddressResolver) store(key string, a *ResolvedAddress) {
+	ttl, size := l.CacheTtl, l.CacheSize
+	if ttl <= 0 {
+		ttl = DefaultLdapCacheTtl
+	}
+	if a == nil {
+		ttl = l.NegativeCacheTtl
+		if ttl <= 0 {
+			ttl = DefaultLdapNegativeCacheTtl
+		}
+	}
+	if size <= 0 {
+		size = DefaultLdapCacheSize
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cache == nil {
+		l.cache, l.lru = map[string]*list.Element{}, list.New()
+	}
+	entry := &ldapCacheEntry{key: key, address: a, expires: l.clock().Add(ttl)}
+	if e, ok := l.cache[key]; ok {
+		e.Value = entry
+		l.lru.MoveToFront(e)
+	} else {
+		l.cache[key] = l.lru.PushFront(entry)
+	}
+	for l.lru.Len() > size {
+		oldest := l.lru.Back()
+		l.lru.Remove(oldest)
+		delete(l.cache, oldest.Value.(*ldapCacheEntry).key)
+	}
+}
+
+func (l *LdapAddressResolver) clock() time.Time {
+	if l.now != nil {
+		return l.now()
+	}
+	return time.Now()
+}
+
+// the default maximum number of addresses of a message given to the address resolver (see TnefDecoder.MaxAddressLookups)
+const DefaultMaxAddressLookups = 500
+
+/**
+ * the address resolver of a decoded message: the addresses are resolved once and at most max addresses are given to
+ * the resolver, so a message with thousands of distribution list members or attendees does not flood the directory
+ */
+type messageAddressResolver struct {
+	resolver AddressResolver
+	max      int
+
+	mu      sync.Mutex
+	results map[string]*ResolvedAddress // nil for the unresolved addresses
+}
+
+func newMessageAddressResolver(resolver AddressResolver, max int) AddressResolver {
+	if resolver == nil {
+		return nil
+	}
+	if max <= 0 {
+		max = DefaultMaxAddressLookups
+	}
+	return &messageAddressResolver{resolver: resolver, max: max, results: map[string]*ResolvedAddress{}}
+}
+
+func (m *messageAddressResolver) ResolveAddress(legacyDn string) (*ResolvedAddress, error) {
+	key := strings.ToUpper(legacyDn)
+	m.mu.Lock()
+	a, ok := m.results[key]
+	lookups := len(m.results)
+	m.mu.Unlock()
+	if !ok {
+		if lookups >= m.max {
+			return nil, ErrAddressNotResolved
+		}
+		a, _ = m.resolver.ResolveAddress(legacyDn)
+		m.mu.Lock()
+		m.results[key] = a
+		m.mu.Unlock()
+	}
+	if a == nil {
+		return nil, ErrAddressNotResolved
+	}
+	return a, nil
+}
+
+/**
+ * the first value of an attribute; the attribute names are case insensitive
+ */
+func ldapAttributeValue(entry map[string][]string, name string) string {
+	for k, values := range entry {
+		if strings.EqualFold(k, name) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+/**
+ * escape the value of an LDAP filter assertion (RFC 4515 section 3): *, (, ), \ and NUL
+ */
+func EscapeLdapFilterValue(v string) string {
+	var b strings.Builder
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; c {
+		case '*', '(', ')', '\\', 0:
+			fmt.Fprintf(&b, "\\%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
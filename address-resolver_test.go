@@ -0,0 +1,235 @@
+package tnefdecoder
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReadCsvAddressResolver(t *testing.T) {
+	tests := []struct {
+		name string
+		csv  string
+	}{
+		{"without header", "/o=Example/cn=Recipients/cn=jane, jane@example.com, Jane Doe\n"},
+		{"csvde export", "DN,displayName,mail,legacyExchangeDN,proxyAddresses\n" +
+			`"CN=Jane,DC=example",Jane Doe,jane@example.com,/o=Example/cn=Recipients/cn=jane,SMTP:jane@example.com;X500:/o=Old/cn=jdoe` + "\n"},
+	}
+	for _, tt := range tests {
+		m, err := ReadCsvAddressResolver(strings.NewReader(tt.csv))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		a, err := m.ResolveAddress("/O=EXAMPLE/CN=RECIPIENTS/CN=JANE")
+		if err != nil || *a != (ResolvedAddress{DisplayName: "Jane Doe", SmtpAddress: "jane@example.com"}) {
+			t.Errorf("%s: %+v %v", tt.name, a, err)
+		}
+		if _, err := m.ResolveAddress("/o=Example/cn=Recipients/cn=john"); err != ErrAddressNotResolved {
+			t.Errorf("%s: unknown DN: %v", tt.name, err)
+		}
+	}
+
+	if _, err := ReadCsvAddressResolver(strings.NewReader("name,phone\nJane,123\n")); err == nil {
+		t.Error("no DN and SMTP columns: no error")
+	}
+}
+
+func TestReadJsonAddressResolver(t *testing.T) {
+	m, err := ReadJsonAddressResolver(strings.NewReader(`[{"legacyExchangeDN": "/o=Example/cn=jane", "mail": "jane@example.com",
+		"displayName": "Jane Doe", "proxyAddresses": ["X500:/o=Old/cn=jdoe", "smtp:doe@example.com"]}, "ignored"]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := MapAddressResolver{}
+	want.Add("/o=Example/cn=jane", "jane@example.com", "Jane Doe")
+	want.Add("/o=Old/cn=jdoe", "jane@example.com", "Jane Doe")
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("%+v", m)
+	}
+
+	if m, err = ReadJsonAddressResolver(strings.NewReader(`{"/o=Example/cn=john": "john@example.com"}`)); err != nil || len(m) != 1 {
+		t.Errorf("object: %v %v", m, err)
+	}
+	if _, err = ReadJsonAddressResolver(strings.NewReader(`"text"`)); err == nil {
+		t.Error("string: no error")
+	}
+}
+
+/**
+ * a directory with the users jane and john; the searches are counted, the search of "broken" fails and the search of
+ * "slow" waits for the end of the context
+ */
+type addressResolverTestDirectory struct {
+	mu       sync.Mutex
+	searches map[string]int
+}
+
+func (d *addressResolverTestDirectory) Search(ctx context.Context, filter string, attributes []string) ([]map[string][]string, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		return nil, errors.New("search without deadline")
+	}
+	d.mu.Lock()
+	if d.searches == nil {
+		d.searches = map[string]int{}
+	}
+	d.searches[filter]++
+	d.mu.Unlock()
+
+	switch {
+	case strings.Contains(filter, "cn=slow"):
+		<-ctx.Done()
+		return nil, ctx.Err()
+	case strings.Contains(filter, "cn=broken"):
+		return nil, errors.New("directory unavailable")
+	case strings.Contains(filter, "cn=jane"):
+		return []map[string][]string{{"Mail": {"jane@example.com"}, "displayName": {"Jane Doe"}}}, nil
+	case strings.Contains(filter, "cn=john"):
+		return []map[string][]string{{"mail": {"john@example.com"}}}, nil
+	}
+	return nil, nil
+}
+
+func (d *addressResolverTestDirectory) count(dn string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.searches[fmtLdapTestFilter(dn)]
+}
+
+func fmtLdapTestFilter(dn string) string {
+	return strings.ReplaceAll(DefaultLdapAddressFilter, "%[1]s", EscapeLdapFilterValue(dn))
+}
+
+func TestLdapAddressResolverCache(t *testing.T) {
+	directory := &addressResolverTestDirectory{}
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewLdapAddressResolver(directory)
+	l.CacheSize = 2
+	l.CacheTtl = time.Hour
+	l.NegativeCacheTtl = time.Minute
+	l.now = func() time.Time { return now }
+
+	resolve := func(dn string) *ResolvedAddress {
+		a, err := l.ResolveAddress(dn)
+		if err != nil && err != ErrAddressNotResolved {
+			t.Fatalf("%s: %v", dn, err)
+		}
+		return a
+	}
+
+	if a := resolve("/o=Example/cn=jane"); a == nil || *a != (ResolvedAddress{DisplayName: "Jane Doe", SmtpAddress: "jane@example.com"}) {
+		t.Errorf("jane: %+v", a)
+	}
+	resolve("/O=EXAMPLE/CN=JANE")
+	if n := directory.count("/o=Example/cn=jane"); n != 1 {
+		t.Errorf("jane: %d searches, want 1 (cached)", n)
+	}
+
+	// the unknown DNs expire after NegativeCacheTtl
+	if a := resolve("/o=Example/cn=unknown"); a != nil {
+		t.Errorf("unknown: %+v", a)
+	}
+	now = now.Add(30 * time.Second)
+	resolve("/o=Example/cn=unknown")
+	now = now.Add(time.Minute)
+	resolve("/o=Example/cn=unknown")
+	if n := directory.count("/o=Example/cn=unknown"); n != 2 {
+		t.Errorf("unknown: %d searches, want 2", n)
+	}
+
+	// jane is the least recently used: evicted by john
+	resolve("/o=Example/cn=john")
+	resolve("/o=Example/cn=jane")
+	if n := directory.count("/o=Example/cn=jane"); n != 2 {
+		t.Errorf("jane after eviction: %d searches, want 2", n)
+	}
+	if len(l.cache) != 2 || l.lru.Len() != 2 {
+		t.Errorf("%d cached DNs, want 2", len(l.cache))
+	}
+
+	// the users expire after CacheTtl
+	now = now.Add(2 * time.Hour)
+	resolve("/o=Example/cn=jane")
+	if n := directory.count("/o=Example/cn=jane"); n != 3 {
+		t.Errorf("jane after expiry: %d searches, want 3", n)
+	}
+
+	// the errors are not cached
+	for i := 0; i < 2; i++ {
+		if _, err := l.ResolveAddress("/o=Example/cn=broken"); err == nil || err == ErrAddressNotResolved {
+			t.Errorf("broken: error %v", err)
+		}
+	}
+	if n := directory.count("/o=Example/cn=broken"); n != 2 {
+		t.Errorf("broken: %d searches, want 2", n)
+	}
+}
+
+func TestLdapAddressResolverTimeout(t *testing.T) {
+	l := NewLdapAddressResolver(&addressResolverTestDirectory{})
+	l.Timeout = 10 * time.Millisecond
+	start := time.Now()
+	if _, err := l.ResolveAddress("/o=Example/cn=slow"); err != context.DeadlineExceeded {
+		t.Errorf("error %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("search of %v", d)
+	}
+}
+
+func TestMessageAddressResolverLimit(t *testing.T) {
+	directory := &addressResolverTestDirectory{}
+	tObj := &TnefObject{}
+	for _, name := range []string{"jane", "john", "unknown", "jane"} {
+		r := NewRecipient()
+		r.SetAttribute(NewMapiStringAttribute(MapiPidTagDisplayName, name))
+		r.SetAttribute(NewMapiStringAttribute(MapiPidTagAddressType, "EX"))
+		r.SetAttribute(NewMapiStringAttribute(MapiPidTagEmailAddress, "/o=Example/cn="+name))
+		tObj.Recipients = append(tObj.Recipients, r)
+	}
+	e := NewEncoder()
+	data, err := e.Encode(tObj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDecoder()
+	d.AddressResolver = NewLdapAddressResolver(directory)
+	d.MaxAddressLookups = 2
+	tObj, err = d.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// resolved twice: the second time from the results of the message
+	got := []string{}
+	for i := 0; i < 2; i++ {
+		got = got[:0]
+		for _, r := range tObj.Recipients {
+			got = append(got, r.GetSmtpAddress())
+		}
+	}
+	if want := []string{"jane@example.com", "john@example.com", "", "jane@example.com"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("%q, want %q", got, want)
+	}
+	if n := directory.count("/o=Example/cn=unknown"); n != 0 {
+		t.Errorf("%d searches beyond the limit", n)
+	}
+	if n := directory.count("/o=Example/cn=jane"); n != 1 {
+		t.Errorf("jane: %d searches, want 1", n)
+	}
+
+	d.AddressResolver = nil
+	if tObj, err = d.Decode(data); err != nil || tObj.AddressResolver != nil {
+		t.Errorf("no resolver: %v %v", tObj.AddressResolver, err)
+	}
+}
+
+func TestEscapeLdapFilterValue(t *testing.T) {
+	if v := EscapeLdapFilterValue("/o=Example/cn=*)(mail=*\\\x00"); v != `/o=Example/cn=\2a\29\28mail=\2a\5c\00` {
+		t.Errorf("%q", v)
+	}
+}
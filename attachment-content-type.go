@@ -0,0 +1,594 @@
+/**
+ * attachment content type detection: declared type (PidTagAttachMimeTag), extension and content sniffing (magic bytes)
+ */
+
+package tnefdecoder
+
+import (
+	"archive/zip"
+	"bytes"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+/**
+ * content types of the detected formats
+ */
+const (
+	ContentTypeOctetStream = "application/octet-stream"
+	ContentTypeZip         = "application/zip"
+	ContentTypeCfb         = "application/x-ole-storage"
+	ContentTypeMsg         = "application/vnd.ms-outlook"
+	ContentTypeTnef        = "application/vnd.ms-tnef"
+	ContentTypeDoc         = "application/msword"
+	ContentTypeXls         = "application/vnd.ms-excel"
+	ContentTypePpt         = "application/vnd.ms-powerpoint"
+	ContentTypeDocx        = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	ContentTypeXlsx        = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	ContentTypePptx        = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	ContentTypeExe         = "application/x-msdownload"
+	ContentTypeElf         = "application/x-executable"
+	ContentTypeMachO       = "application/x-mach-binary"
+)
+
+/**
+ * the result of the content type detection
+ */
+type ContentTypeDetection struct {
+	// PidTagAttachMimeTag (without parameters)
+	Declared string
+
+	// the extension of the attachment (PidTagAttachExtension or the filename) and the associated content type
+	Extension   string
+	ByExtension string
+
+	// the content type detected from the data; "" if the data was not recognized
+	Sniffed string
+
+	// the resolved content type
+	ContentType string
+
+	// set if the declared type or the extension do not match the content
+	Conflict       bool
+	ConflictReason string
+}
+
+/**
+ * content types of the extensions not always known by the system mime types
+ */
+var extensionContentTypes = map[string]string{
+	".7z":   "application/x-7z-compressed",
+	".bmp":  "image/bmp",
+	".cab":  "application/vnd.ms-cab-compressed",
+	".com":  ContentTypeExe,
+	".dat":  ContentTypeTnef,
+	".dll":  ContentTypeExe,
+	".doc":  ContentTypeDoc,
+	".docm": "application/vnd.ms-word.document.macroEnabled.12",
+	".docx": ContentTypeDocx,
+	".dot":  ContentTypeDoc,
+	".dotx": "application/vnd.openxmlformats-officedocument.wordprocessingml.template",
+	".eml":  "message/rfc822",
+	".exe":  ContentTypeExe,
+	".gz":   "application/gzip",
+	".ics":  "text/calendar",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".msg":  ContentTypeMsg,
+	".msi":  "application/x-msi",
+	".odp":  "application/vnd.oasis.opendocument.presentation",
+	".ods":  "application/vnd.oasis.opendocument.spreadsheet",
+	".odt":  "application/vnd.oasis.opendocument.text",
+	".pdf":  "application/pdf",
+	".png":  "image/png",
+	".pps":  ContentTypePpt,
+	".ppsx": "application/vnd.openxmlformats-officedocument.presentationml.slideshow",
+	".ppt":  ContentTypePpt,
+	".pptm": "application/vnd.ms-powerpoint.presentation.macroEnabled.12",
+	".pptx": ContentTypePptx,
+	".rar":  "application/vnd.rar",
+	".rtf":  "application/rtf",
+	".scr":  ContentTypeExe,
+	".tif":  "image/tiff",
+	".tiff": "image/tiff",
+	".txt":  "text/plain",
+	".vcf":  "text/vcard",
+	".vsd":  "application/vnd.visio",
+	".xls":  ContentTypeXls,
+	".xlsm": "application/vnd.ms-excel.sheet.macroEnabled.12",
+	".xlsx": ContentTypeXlsx,
+	".xlt":  ContentTypeXls,
+	".xltx": "application/vnd.openxmlformats-officedocument.spreadsheetml.template",
+	".zip":  ContentTypeZip,
+}
+
+/**
+ * aliases of the same content type
+ */
+var contentTypeAliases = map[string]string{
+	"application/x-zip-compressed":    ContentTypeZip,
+	"application/x-zip":               ContentTypeZip,
+	"application/x-pdf":               "application/pdf",
+	"application/x-msdos-program":     ContentTypeExe,
+	"application/x-dosexec":           ContentTypeExe,
+	"application/x-msdownload":        ContentTypeExe,
+	"application/x-ms-dos-executable": ContentTypeExe,
+	"application/exe":                 ContentTypeExe,
+	"application/x-gzip":              "application/gzip",
+	"application/x-rar-compressed":    "application/vnd.rar",
+	"application/x-tnef":              ContentTypeTnef,
+	"image/jpg":                       "image/jpeg",
+	"image/pjpeg":                     "image/jpeg",
+	"image/x-png":                     "image/png",
+	"image/x-ms-bmp":                  "image/bmp",
+	"image/x-bmp":                     "image/bmp",
+	"text/rtf":                        "application/rtf",
+	"text/x-vcard":                    "text/vcard",
+	"text/directory":                  "text/vcard",
+}
+
+/**
+ * content types of the files stored as Compound File Binary
+ */
+var cfbContentTypes = map[string]bool{
+	ContentTypeCfb:          true,
+	ContentTypeMsg:          true,
+	ContentTypeDoc:          true,
+	ContentTypeXls:          true,
+	ContentTypePpt:          true,
+	"application/x-msi":     true,
+	"application/vnd.visio": true,
+}
+
+/**
+ * executable content types
+ */
+var executableContentTypes = map[string]bool{
+	ContentTypeExe:   true,
+	ContentTypeElf:   true,
+	ContentTypeMachO: true,
+}
+
+/**
+ * return the content type of the attachment, see DetectContentType
+ */
+func (a *Attachment) ContentType() string {
+	return a.DetectContentType().ContentType
+}
+
+/**
+ * detect the content type of the attachment combining PidTagAttachMimeTag, the extension (PidTagAttachExtension or
+ * the filename) and the magic bytes of the data
+ * if the data does not match the declared type or the extension the detection has Conflict set and ContentType
+ * is the sniffed type
+ */
+func (a *Attachment) DetectContentType() *ContentTypeDetection {
+	result := &ContentTypeDetection{}
+
+	if mediaType, _, err := mime.ParseMediaType(a.GetAttributeStringValue(MapiPidTagAttachMimeTag, "mapi")); err == nil {
+		result.Declared = NormalizeContentType(mediaType)
+	}
+
+	result.Extension = strings.ToLower(a.GetAttributeStringValue(MapiPidTagAttachExtension, "mapi"))
+	if result.Extension == "" {
+		result.Extension = strings.ToLower(filepath.Ext(a.GetFilename()))
+	}
+	result.ByExtension = ContentTypeByExtension(result.Extension)
+
+	result.Sniffed = SniffContentType(a.GetData())
+
+	switch {
+	case result.Declared != "" && result.Declared != ContentTypeOctetStream:
+		result.ContentType = result.Declared
+	case result.ByExtension != "":
+		result.ContentType = result.ByExtension
+	case result.Sniffed != "":
+		result.ContentType = result.Sniffed
+	default:
+		result.ContentType = ContentTypeOctetStream
+	}
+
+	if result.Sniffed != "" {
+		if !IsCompatibleContentType(result.Declared, result.Sniffed) {
+			result.Conflict = true
+			result.ConflictReason = "declared type " + result.Declared + " does not match the content (" + result.Sniffed + ")"
+		} else if !IsCompatibleContentType(result.ByExtension, result.Sniffed) {
+			result.Conflict = true
+			result.ConflictReason = "extension " + result.Extension + " does not match the content (" + result.Sniffed + ")"
+		}
+		// the sniffer does not see the macros: a macro-enabled type is not replaced with the type of the plain document
+		keepMacros := isMacroEnabledContentType(result.ContentType) && isZipBasedContentType(result.Sniffed) && !isMacroEnabledContentType(result.Sniffed)
+		if result.Conflict && !keepMacros {
+			result.ContentType = result.Sniffed
+		}
+	}
+
+	return result
+}
+
+/**
+ * return the canonical name of a content type
+ */
+func NormalizeContentType(contentType string) string {
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	if alias, ok := contentTypeAliases[contentType]; ok {
+		return alias
+	}
+	return contentType
+}
+
+/**
+ * return the content type associated with an extension (with or without the leading dot)
+ */
+func ContentTypeByExtension(extension string) string {
+	if extension == "" {
+		return ""
+	}
+	extension = strings.ToLower(extension)
+	if !strings.HasPrefix(extension, ".") {
+		extension = "." + extension
+	}
+	if contentType, ok := extensionContentTypes[extension]; ok {
+		return contentType
+	}
+	if mediaType, _, err := mime.ParseMediaType(mime.TypeByExtension(extension)); err == nil {
+		return NormalizeContentType(mediaType)
+	}
+	return ""
+}
+
+/**
+ * check if the declared (or extension) content type can describe the sniffed content
+ * a generic or missing declared type, and a generic sniffed type (text, unknown binary) are always compatible
+ */
+func IsCompatibleContentType(declared, sniffed string) bool {
+	declared = NormalizeContentType(declared)
+	sniffed = NormalizeContentType(sniffed)
+
+	if declared == "" || sniffed == "" || declared == sniffed {
+		return true
+	}
+
+	if declared == ContentTypeOctetStream || sniffed == ContentTypeOctetStream {
+		return true
+	}
+
+	if executableContentTypes[sniffed] || executableContentTypes[declared] {
+		// an executable must be declared as an executable
+		return executableContentTypes[declared] && executableContentTypes[sniffed]
+	}
+
+	if strings.HasPrefix(sniffed, "text/") && strings.HasPrefix(declared, "text/") {
+		return true
+	}
+
+	// the XML based formats (application/vcard+xml, image/svg+xml, etc.)
+	if (sniffed == "text/xml" || sniffed == "application/xml") && (strings.HasSuffix(declared, "+xml") || declared == "application/xml") {
+		return true
+	}
+
+	if sniffed == "text/plain" {
+		// text is recognized only because it has no binary data; any text based format (json, xml, csv...) matches it
+		return !strings.HasPrefix(declared, "image/") && !strings.HasPrefix(declared, "audio/") && !strings.HasPrefix(declared, "video/")
+	}
+
+	// the OOXML formats of the same application (macro-enabled, template, slideshow...) are sniffed as the base document
+	if family := ooxmlFamily(declared); family != "" && family == ooxmlFamily(sniffed) {
+		return true
+	}
+
+	// zip based formats (Office OOXML, OpenDocument, jar, etc.)
+	if (sniffed == ContentTypeZip && isZipBasedContentType(declared)) || (declared == ContentTypeZip && isZipBasedContentType(sniffed)) {
+		return true
+	}
+
+	// Compound File Binary based formats
+	if (sniffed == ContentTypeCfb && cfbContentTypes[declared]) || (declared == ContentTypeCfb && cfbContentTypes[sniffed]) {
+		return true
+	}
+
+	return false
+}
+
+func isZipBasedContentType(contentType string) bool {
+	return strings.Contains(contentType, "openxmlformats") ||
+		strings.Contains(contentType, "opendocument") ||
+		strings.Contains(contentType, "macroenabled") ||
+		strings.HasSuffix(contentType, "+zip") ||
+		contentType == "application/java-archive" ||
+		contentType == "application/epub+zip" ||
+		contentType == "application/vnd.ms-xpsdocument"
+}
+
+/**
+ * return the application of an OOXML content type (word, excel, powerpoint), "" for the other types; the legacy binary
+ * types (application/msword, application/vnd.ms-excel...) are not OOXML
+ */
+func ooxmlFamily(contentType string) string {
+	contentType = NormalizeContentType(contentType)
+	switch {
+	case strings.Contains(contentType, "officedocument.wordprocessingml."), strings.HasPrefix(contentType, "application/vnd.ms-word."):
+		return "word"
+	case strings.Contains(contentType, "officedocument.spreadsheetml."), strings.HasPrefix(contentType, "application/vnd.ms-excel."):
+		return "excel"
+	case strings.Contains(contentType, "officedocument.presentationml."), strings.HasPrefix(contentType, "application/vnd.ms-powerpoint."):
+		return "powerpoint"
+	}
+	return ""
+}
+
+func isMacroEnabledContentType(contentType string) bool {
+	return strings.Contains(NormalizeContentType(contentType), "macroenabled")
+}
+
+/**
+ * detect the content type of the data using the magic bytes
+ * return "" if the format is not recognized
+ */
+func SniffContentType(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+
+	signatures := []struct {
+		offset      int
+		magic       string
+		contentType string
+	}{
+		{0, "%PDF-", "application/pdf"},
+		{0, "\x89PNG\r\n\x1a\n", "image/png"},
+		{0, "\xFF\xD8\xFF", "image/jpeg"},
+		{0, "GIF87a", "image/gif"},
+		{0, "GIF89a", "image/gif"},
+		{0, "II*\x00", "image/tiff"},
+		{0, "MM\x00*", "image/tiff"},
+		{8, "WEBP", "image/webp"},
+		{0, "\x78\x9F\x3E\x22", ContentTypeTnef},
+		{0, "\x1F\x8B", "application/gzip"},
+		{0, "BZh", "application/x-bzip2"},
+		{0, "7z\xBC\xAF\x27\x1C", "application/x-7z-compressed"},
+		{0, "Rar!\x1A\x07", "application/vnd.rar"},
+		{0, "\xFD7zXZ\x00", "application/x-xz"},
+		{0, "MSCF", "application/vnd.ms-cab-compressed"},
+		{0, "{\\rtf", "application/rtf"},
+		{0, "\x7FELF", ContentTypeElf},
+		{0, "\xFE\xED\xFA\xCE", ContentTypeMachO},
+		{0, "\xFE\xED\xFA\xCF", ContentTypeMachO},
+		{0, "\xCE\xFA\xED\xFE", ContentTypeMachO},
+		{0, "\xCF\xFA\xED\xFE", ContentTypeMachO},
+		{0, "BEGIN:VCARD", "text/vcard"},
+		{0, "BEGIN:VCALENDAR", "text/calendar"},
+	}
+	for _, s := range signatures {
+		if len(data) >= s.offset+len(s.magic) && string(data[s.offset:s.offset+len(s.magic)]) == s.magic {
+			return s.contentType
+		}
+	}
+
+	switch {
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		return sniffZip(data)
+	case bytes.HasPrefix(data, []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1")):
+		return sniffCfb(data)
+	case bytes.HasPrefix(data, []byte("MZ")) && isPortableExecutable(data):
+		return ContentTypeExe
+	case bytes.HasPrefix(data, []byte("BM")) && len(data) > 14 && int(new(LittleEndianDecoder).Uint32(data[2:6])) == len(data):
+		return "image/bmp"
+	}
+
+	contentType := http.DetectContentType(data)
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType != ContentTypeOctetStream {
+		return NormalizeContentType(mediaType)
+	}
+
+	return ""
+}
+
+/**
+ * MZ header with a PE / NE / LE header, or a DOS executable with a valid header size
+ */
+func isPortableExecutable(data []byte) bool {
+	if len(data) < 64 {
+		return false
+	}
+	leDecoder := new(LittleEndianDecoder)
+	peOffset := int(leDecoder.Uint32(data[0x3C:0x40]))
+	if peOffset > 0 && peOffset+4 <= len(data) {
+		switch string(data[peOffset : peOffset+2]) {
+		case "PE", "NE", "LE", "LX":
+			return true
+		}
+	}
+	// plain DOS executable: the header size (in paragraphs) must be inside the file
+	return int(leDecoder.Uint16(data[8:10]))*16 <= len(data) && leDecoder.Uint16(data[8:10]) >= 2
+}
+
+/**
+ * identify the zip based formats by the names of the entries
+ */
+func sniffZip(data []byte) string {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return ContentTypeZip
+	}
+
+	hasContentTypes := false
+	for _, f := range r.File {
+		if f.Name == "[Content_Types].xml" {
+			hasContentTypes = true
+		}
+		if f.Name == "mimetype" {
+			if rc, err := f.Open(); err == nil {
+				buf := make([]byte, 128)
+				n, _ := rc.Read(buf)
+				rc.Close()
+				// the archive content is not trusted: only the zip based document types are returned
+				mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(string(buf[:n])))
+				if err == nil && isZipMimetype(mediaType) {
+					return mediaType
+				}
+				return ContentTypeZip
+			}
+		}
+	}
+
+	if hasContentTypes {
+		for _, f := range r.File {
+			switch {
+			case strings.HasPrefix(f.Name, "word/"):
+				return ContentTypeDocx
+			case strings.HasPrefix(f.Name, "xl/"):
+				return ContentTypeXlsx
+			case strings.HasPrefix(f.Name, "ppt/"):
+				return ContentTypePptx
+			}
+		}
+	}
+
+	for _, f := range r.File {
+		if f.Name == "META-INF/MANIFEST.MF" {
+			return "application/java-archive"
+		}
+	}
+
+	return ContentTypeZip
+}
+
+/**
+ * the media types accepted from the mimetype entry of a zip: OpenDocument, EPUB and OOXML
+ */
+func isZipMimetype(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "application/vnd.oasis.opendocument.") ||
+		strings.HasPrefix(mediaType, "application/vnd.openxmlformats-officedocument.") ||
+		mediaType == "application/epub+zip"
+}
+
+/**
+ * identify the Compound File Binary formats by the names of the streams and storages of the root storage
+ */
+func sniffCfb(data []byte) string {
+	streams := []struct {
+		name        string
+		contentType string
+	}{
+		{"__substg1.0_", ContentTypeMsg},
+		{"__properties_version1.0", ContentTypeMsg},
+		{"WordDocument", ContentTypeDoc},
+		{"Workbook", ContentTypeXls},
+		{"Book", ContentTypeXls},
+		{"PowerPoint Document", ContentTypePpt},
+		{"VisioDocument", "application/vnd.visio"},
+	}
+
+	names := cfbRootEntryNames(data)
+	for _, s := range streams {
+		for _, name := range names {
+			// the property streams of the messages are named __substg1.0_<tag>
+			if strings.EqualFold(name, s.name) || (strings.HasSuffix(s.name, "_") && strings.HasPrefix(name, s.name)) {
+				return s.contentType
+			}
+		}
+	}
+
+	return ContentTypeCfb
+}
+
+/**
+ * return the names of the entries of the root storage of a Compound File Binary ([MS-CFB] sections 2.2 - 2.6): the
+ * directory sectors are followed through the FAT and the children of the root entry are read from their tree
+ * nil if the file is not valid
+ */
+func cfbRootEntryNames(data []byte) []string {
+	const (
+		maxRegularSector = 0xFFFFFFFA
+		entrySize        = 128
+	)
+	if len(data) < 512 {
+		return nil
+	}
+	le := new(LittleEndianDecoder)
+	shift := le.Uint16(data[0x1E:0x20])
+	if shift != 9 && shift != 12 {
+		return nil
+	}
+	sectorSize := 1 << shift
+	maxSectors := len(data) / sectorSize
+
+	sector := func(id uint32) []byte {
+		if id >= maxRegularSector || (int(id)+2)*sectorSize > len(data) {
+			return nil
+		}
+		return data[(int(id)+1)*sectorSize : (int(id)+2)*sectorSize]
+	}
+
+	// the FAT sectors: the 109 of the header, then the DIFAT chain
+	fatSectors := []uint32{}
+	addFatSectors := func(b []byte) {
+		for i := 0; i+4 <= len(b); i += 4 {
+			if id := le.Uint32(b[i : i+4]); id < maxRegularSector {
+				fatSectors = append(fatSectors, id)
+			}
+		}
+	}
+	addFatSectors(data[0x4C:0x200])
+	difat := le.Uint32(data[0x44:0x48])
+	for n := 0; n < maxSectors; n++ {
+		b := sector(difat)
+		if b == nil {
+			break
+		}
+		addFatSectors(b[:sectorSize-4])
+		difat = le.Uint32(b[sectorSize-4:])
+	}
+
+	next := func(id uint32) uint32 {
+		perSector := uint32(sectorSize / 4)
+		if int(id/perSector) >= len(fatSectors) {
+			return maxRegularSector
+		}
+		b := sector(fatSectors[id/perSector])
+		if b == nil {
+			return maxRegularSector
+		}
+		return le.Uint32(b[(id%perSector)*4:])
+	}
+
+	// the directory entries; the chain is limited to the number of sectors of the file
+	entries := [][]byte{}
+	id := le.Uint32(data[0x30:0x34])
+	for n := 0; n < maxSectors; n++ {
+		b := sector(id)
+		if b == nil {
+			break
+		}
+		for i := 0; i+entrySize <= len(b); i += entrySize {
+			entries = append(entries, b[i:i+entrySize])
+		}
+		id = next(id)
+	}
+	if len(entries) == 0 || entries[0][0x42] != 5 {
+		// no root storage
+		return nil
+	}
+
+	// the children of the root: the tree of the entry of its child (left sibling, right sibling)
+	names := []string{}
+	visited := map[uint32]bool{0: true}
+	stack := []uint32{le.Uint32(entries[0][0x4C:0x50])}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if int64(id) >= int64(len(entries)) || visited[id] {
+			continue
+		}
+		visited[id] = true
+		e := entries[id]
+		if n := int(le.Uint16(e[0x40:0x42])); n >= 2 && n <= 64 {
+			names = append(names, le.Utf16(e[:n-2]))
+		}
+		stack = append(stack, le.Uint32(e[0x44:0x48]), le.Uint32(e[0x48:0x4C]))
+	}
+	return names
+}
@@ -0,0 +1,209 @@
+package tnefdecoder
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+/**
+ * a zip of the entries given as name, content pairs
+ */
+func contentTypeTestZip(entries ...string) []byte {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for i := 0; i+1 < len(entries); i += 2 {
+		f, _ := w.Create(entries[i])
+		f.Write([]byte(entries[i+1]))
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+/**
+ * a Compound File Binary (512 bytes sectors: the header, the FAT, then the directory) whose root storage has the given
+ * entries; the entries listed in storages are storages with the given children
+ */
+func contentTypeTestCfb(root []string, storages map[string][]string) []byte {
+	const noStream = 0xFFFFFFFF
+	type entry struct {
+		name         string
+		objectType   byte
+		right, child uint32
+	}
+	entries := []*entry{{"Root Entry", 5, noStream, noStream}}
+	var add func(names []string) uint32
+	add = func(names []string) uint32 {
+		first := uint32(noStream)
+		var previous *entry
+		for _, name := range names {
+			e := &entry{name, 2, noStream, noStream}
+			id := uint32(len(entries))
+			entries = append(entries, e)
+			if previous == nil {
+				first = id
+			} else {
+				previous.right = id
+			}
+			previous = e
+			if children, ok := storages[name]; ok {
+				e.objectType = 1
+				e.child = add(children)
+			}
+		}
+		return first
+	}
+	entries[0].child = add(root)
+
+	le := binary.LittleEndian
+	directorySectors := (len(entries) + 3) / 4
+	b := make([]byte, 512*(2+directorySectors))
+	copy(b, "\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1")
+	le.PutUint16(b[0x18:], 0x3E)
+	le.PutUint16(b[0x1A:], 3)
+	le.PutUint16(b[0x1C:], 0xFFFE)
+	le.PutUint16(b[0x1E:], 9)
+	le.PutUint16(b[0x20:], 6)
+	le.PutUint32(b[0x2C:], 1)
+	le.PutUint32(b[0x30:], 1)
+	le.PutUint32(b[0x38:], 4096)
+	le.PutUint32(b[0x3C:], 0xFFFFFFFE)
+	le.PutUint32(b[0x44:], 0xFFFFFFFE)
+	for i := 0x50; i < 0x200; i += 4 {
+		le.PutUint32(b[i:], noStream)
+	}
+
+	// FAT: the FAT sector, then the chain of the directory sectors
+	fat := b[512:1024]
+	for i := 0; i < 128; i++ {
+		le.PutUint32(fat[i*4:], noStream)
+	}
+	le.PutUint32(fat, 0xFFFFFFFD)
+	for i := 1; i <= directorySectors; i++ {
+		le.PutUint32(fat[i*4:], uint32(i+1))
+	}
+	le.PutUint32(fat[directorySectors*4:], 0xFFFFFFFE)
+
+	for i, e := range entries {
+		d := b[1024+i*128:]
+		name := utf16.Encode([]rune(e.name + "\x00"))
+		for j, c := range name {
+			le.PutUint16(d[j*2:], c)
+		}
+		le.PutUint16(d[0x40:], uint16(len(name)*2))
+		d[0x42] = e.objectType
+		d[0x43] = 1
+		le.PutUint32(d[0x44:], noStream)
+		le.PutUint32(d[0x48:], e.right)
+		le.PutUint32(d[0x4C:], e.child)
+	}
+	return b
+}
+
+/**
+ * a PE executable header
+ */
+func contentTypeTestExe() []byte {
+	b := make([]byte, 128)
+	copy(b, "MZ")
+	binary.LittleEndian.PutUint32(b[0x3C:], 64)
+	copy(b[64:], "PE\x00\x00")
+	return b
+}
+
+func TestSniffContentTypeZip(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"docx", contentTypeTestZip("[Content_Types].xml", "<Types/>", "word/document.xml", "<w:document/>"), ContentTypeDocx},
+		{"xlsx", contentTypeTestZip("[Content_Types].xml", "<Types/>", "xl/workbook.xml", "<workbook/>"), ContentTypeXlsx},
+		{"pptx", contentTypeTestZip("[Content_Types].xml", "<Types/>", "ppt/presentation.xml", "<p:presentation/>"), ContentTypePptx},
+		{"odt", contentTypeTestZip("mimetype", "application/vnd.oasis.opendocument.text", "content.xml", "<office:document-content/>"), "application/vnd.oasis.opendocument.text"},
+		{"epub", contentTypeTestZip("mimetype", "application/epub+zip", "META-INF/container.xml", "<container/>"), "application/epub+zip"},
+		{"jar", contentTypeTestZip("META-INF/MANIFEST.MF", "Manifest-Version: 1.0\n"), "application/java-archive"},
+		{"zip", contentTypeTestZip("readme.txt", "hello"), ContentTypeZip},
+		{"mimetype with parameters", contentTypeTestZip("mimetype", "Application/Vnd.Oasis.Opendocument.Spreadsheet; x=y"), "application/vnd.oasis.opendocument.spreadsheet"},
+		{"html mimetype", contentTypeTestZip("mimetype", "text/html"), ContentTypeZip},
+		{"executable mimetype", contentTypeTestZip("mimetype", "application/x-msdownload", "[Content_Types].xml", "<Types/>", "word/document.xml", ""), ContentTypeZip},
+		{"invalid mimetype", contentTypeTestZip("mimetype", "application/epub+zip\r\nContent-Type: text/html"), ContentTypeZip},
+		{"truncated zip", contentTypeTestZip("readme.txt", "hello")[:20], ContentTypeZip},
+	}
+	for _, test := range tests {
+		if got := SniffContentType(test.data); got != test.want {
+			t.Errorf("%s: %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSniffContentTypeCfb(t *testing.T) {
+	// a stream whose name is only in the content of another stream
+	workbookInContent := contentTypeTestCfb([]string{"Contents"}, nil)
+	for i, c := range utf16.Encode([]rune("Workbook")) {
+		binary.LittleEndian.PutUint16(workbookInContent[len(workbookInContent)-64+i*2:], c)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"doc", contentTypeTestCfb([]string{"1Table", "WordDocument", "\x05SummaryInformation"}, nil), ContentTypeDoc},
+		{"xls", contentTypeTestCfb([]string{"Workbook"}, nil), ContentTypeXls},
+		{"xls 5.0", contentTypeTestCfb([]string{"Book"}, nil), ContentTypeXls},
+		{"ppt", contentTypeTestCfb([]string{"Current User", "PowerPoint Document"}, nil), ContentTypePpt},
+		{"msg", contentTypeTestCfb([]string{"__nameid_version1.0", "__substg1.0_0037001F", "__properties_version1.0"}, map[string][]string{"__nameid_version1.0": {"__substg1.0_00020102"}}), ContentTypeMsg},
+		{"vsd", contentTypeTestCfb([]string{"VisioDocument"}, nil), "application/vnd.visio"},
+		{"many entries", contentTypeTestCfb([]string{"a", "b", "c", "d", "e", "f", "g", "h", "WordDocument"}, nil), ContentTypeDoc},
+		{"doc with an embedded workbook", contentTypeTestCfb([]string{"ObjectPool", "WordDocument"}, map[string][]string{"ObjectPool": {"_1234"}, "_1234": {"Workbook"}}), ContentTypeDoc},
+		{"workbook in a storage", contentTypeTestCfb([]string{"Objects"}, map[string][]string{"Objects": {"Workbook"}}), ContentTypeCfb},
+		{"workbook in the content", workbookInContent, ContentTypeCfb},
+		{"prefix of a name", contentTypeTestCfb([]string{"WordDocumentBackup", "Bookmarks"}, nil), ContentTypeCfb},
+		{"truncated", contentTypeTestCfb([]string{"WordDocument"}, nil)[:600], ContentTypeCfb},
+	}
+	for _, test := range tests {
+		if got := SniffContentType(test.data); got != test.want {
+			t.Errorf("%s: %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDetectContentTypeConflicts(t *testing.T) {
+	tests := []struct {
+		filename    string
+		declared    string
+		data        []byte
+		contentType string
+		conflict    bool
+	}{
+		{"report.pdf", "application/pdf", []byte("%PDF-1.7\n"), "application/pdf", false},
+		{"report.pdf", "", contentTypeTestExe(), ContentTypeExe, true},
+		{"setup.exe", "application/x-msdos-program", contentTypeTestExe(), ContentTypeExe, false},
+		{"letter.doc", "application/msword", contentTypeTestCfb([]string{"Workbook"}, nil), ContentTypeXls, true},
+		{"letter.doc", "", contentTypeTestCfb([]string{"Unknown"}, nil), ContentTypeDoc, false},
+		{"letter.docm", "", contentTypeTestZip("[Content_Types].xml", "<Types/>", "word/document.xml", ""), "application/vnd.ms-word.document.macroEnabled.12", false},
+		{"letter.docx", "", contentTypeTestZip("[Content_Types].xml", "<Types/>", "xl/workbook.xml", ""), ContentTypeXlsx, true},
+		{"book.odt", "", contentTypeTestZip("mimetype", "text/html"), "application/vnd.oasis.opendocument.text", false},
+		{"page.html", "text/html", contentTypeTestZip("mimetype", "text/html"), ContentTypeZip, true},
+		{"notes", "", []byte("plain text"), "text/plain", false},
+	}
+	for _, test := range tests {
+		a := NewAttachment()
+		a.SetFilename(test.filename)
+		if test.declared != "" {
+			a.SetAttribute(NewMapiStringAttribute(MapiPidTagAttachMimeTag, test.declared))
+		}
+		a.SetData(test.data)
+		d := a.DetectContentType()
+		if d.ContentType != test.contentType || d.Conflict != test.conflict {
+			t.Errorf("%s (%s): %q, conflict %v (%s), want %q, conflict %v", test.filename, test.declared, d.ContentType, d.Conflict, d.ConflictReason, test.contentType, test.conflict)
+		}
+	}
+}
+
+func TestSniffContentTypeMalformed(t *testing.T) {
+	checkMalformed(t, func(b []byte) { SniffContentType(b) }, contentTypeTestCfb([]string{"ObjectPool", "WordDocument"}, map[string][]string{"ObjectPool": {"Workbook"}}))
+}
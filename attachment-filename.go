@@ -0,0 +1,236 @@
+/**
+ * attachment filename resolution
+ */
+
+package tnefdecoder
+
+import (
+	"mime"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// the maximum length (bytes) of a filename on most file systems
+const MaxFilenameLength = 255
+
+// the base name used if the attachment has no name
+const DefaultAttachmentName = "attachment"
+
+/**
+ * preferred extensions for common content types (mime.ExtensionsByType returns the extensions sorted alphabetically)
+ */
+var preferredExtensions = map[string]string{
+	"application/msword":            ".doc",
+	"application/octet-stream":      "",
+	"application/pdf":               ".pdf",
+	"application/rtf":               ".rtf",
+	"application/vcard+json":        ".json",
+	"application/vcard+xml":         ".xml",
+	"application/vnd.ms-excel":      ".xls",
+	"application/vnd.ms-outlook":    ".msg",
+	"application/vnd.ms-powerpoint": ".ppt",
+	"application/vnd.ms-tnef":       ".dat",
+	"application/zip":               ".zip",
+	"image/bmp":                     ".bmp",
+	"image/gif":                     ".gif",
+	"image/jpeg":                    ".jpg",
+	"image/png":                     ".png",
+	"image/tiff":                    ".tif",
+	"message/rfc822":                ".eml",
+	"text/calendar":                 ".ics",
+	"text/html":                     ".html",
+	"text/plain":                    ".txt",
+	"text/rtf":                      ".rtf",
+	"text/vcard":                    ".vcf",
+	"text/x-vcard":                  ".vcf",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   ".docx",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         ".xlsx",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": ".pptx",
+}
+
+/**
+ * names reserved by Windows (with or without extension)
+ */
+var reservedFilenames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+/**
+ * resolve the filename of the attachment
+ *
+ * the name is taken from the first non empty value of ([MS-OXCMSG] section 2.2.2.11 - 2.2.2.13, [MS-OXTNEF] section 2.1.3.4):
+ *  PidTagAttachLongFilename, attAttachTitle (long filename or 8.3 name), PidTagAttachFilename (8.3 name),
+ *  PidTagDisplayName, attAttachTransportFilename
+ * 8-bit names are decoded using the code page of the message; a name that cannot be decoded (contains "?")
+ * is replaced by the next one
+ * the result is sanitized (see SanitizeFilename); if it has no extension, the extension is taken from PidTagAttachExtension,
+ * PidTagAttachMimeTag or from the content of the attachment
+ */
+func (a *Attachment) ResolveFilename() string {
+	candidates := []string{
+		a.GetAttributeStringValue(MapiPidTagAttachLongFilename, "mapi"),
+		a.GetAttributeStringValue(AttAttachTitle, "mapped"),
+		a.GetAttributeStringValue(MapiPidTagAttachFilename, "mapi"),
+		a.GetAttributeStringValue(MapiPidTagDisplayName, "mapi"),
+		a.GetAttributeStringValue(AttAttachTransportFilename, "mapped"),
+	}
+
+	filename := ""
+	for _, candidate := range candidates {
+		sanitized := SanitizeFilename(candidate)
+		if sanitized == "" {
+			continue
+		}
+		if filename == "" {
+			// keep the first name, even if it was not decoded correctly, if there is nothing better
+			filename = sanitized
+		}
+		if !strings.ContainsAny(candidate, "?\uFFFD") {
+			filename = sanitized
+			break
+		}
+	}
+
+	if filepath.Ext(filename) == "" {
+		extension := a.guessExtension()
+		if filename == "" {
+			filename = DefaultAttachmentName
+		}
+		filename = truncateFilename(filename+extension, MaxFilenameLength)
+	}
+
+	return filename
+}
+
+/**
+ * guess the extension of the file: PidTagAttachExtension, PidTagAttachMimeTag or the type of the content
+ */
+func (a *Attachment) guessExtension() string {
+	extension := SanitizeFilename(a.GetAttributeStringValue(MapiPidTagAttachExtension, "mapi"))
+	if extension != "" {
+		if !strings.HasPrefix(extension, ".") {
+			extension = "." + extension
+		}
+		return extension
+	}
+
+	if extension = ExtensionByContentType(a.GetAttributeStringValue(MapiPidTagAttachMimeTag, "mapi")); extension != "" {
+		return extension
+	}
+
+	if data := a.GetData(); len(data) > 0 {
+		return ExtensionByContentType(SniffContentType(data))
+	}
+
+	return ""
+}
+
+/**
+ * return the preferred extension for a content type, or "" if the type is unknown
+ */
+func ExtensionByContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if extension, ok := preferredExtensions[mediaType]; ok {
+		return extension
+	}
+	if extensions, err := mime.ExtensionsByType(mediaType); err == nil && len(extensions) > 0 {
+		return extensions[0]
+	}
+	return ""
+}
+
+/**
+ * make a filename safe to be written on Linux and Windows:
+ * - remove the path (both / and \ separators)
+ * - replace the control characters and the characters reserved on Windows ( < > : " | ? * ) with "_"
+ * - remove the dots and spaces from the end of the name
+ * - prefix the names reserved on Windows (CON, PRN, NUL, COM1, ...) with "_", whatever their extensions (Windows
+ *   ignores them: CON.tar.gz is the device CON)
+ * - truncate the name to MaxFilenameLength bytes, keeping the extension
+ */
+func SanitizeFilename(filename string) string {
+	filename = strings.ToValidUTF8(filename, "\uFFFD")
+
+	if idx := strings.LastIndexAny(filename, "/\\"); idx >= 0 {
+		filename = filename[idx+1:]
+	}
+
+	filename = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune("<>:\"|?*", r) {
+			return '_'
+		}
+		return r
+	}, filename)
+
+	filename = strings.TrimSpace(filename)
+	filename = strings.TrimRight(filename, ". ")
+	if filename == "" {
+		return ""
+	}
+
+	// the part before the first dot, without the trailing spaces (ignored by Windows too)
+	base := filename
+	if idx := strings.IndexByte(base, '.'); idx >= 0 {
+		base = base[:idx]
+	}
+	if reservedFilenames[strings.ToUpper(strings.TrimRight(base, " "))] {
+		filename = "_" + filename
+	}
+
+	return truncateFilename(filename, MaxFilenameLength)
+}
+
+/**
+ * truncate the filename to the given number of bytes, preserving the extension and the UTF-8 characters
+ */
+func truncateFilename(filename string, max int) string {
+	if len(filename) <= max {
+		return filename
+	}
+
+	extension := filepath.Ext(filename)
+	if len(extension) >= max/2 {
+		extension = ""
+	}
+	base := filename[:len(filename)-len(extension)]
+
+	limit := max - len(extension)
+	for limit > 0 && !utf8.RuneStart(base[limit]) {
+		limit--
+	}
+
+	return base[:limit] + extension
+}
+
+/**
+ * resolve the filenames of all attachments of the message and make them unique (case insensitive):
+ * a duplicated name gets a counter before the extension, ex: "image.png", "image (2).png"
+ */
+func (t *TnefObject) ResolveAttachmentFilenames() {
+	used := map[string]bool{}
+
+	for _, a := range t.Attachments {
+		filename := SanitizeFilename(a.GetFilename())
+		if filename == "" {
+			filename = DefaultAttachmentName
+		}
+
+		extension := filepath.Ext(filename)
+		base := strings.TrimSuffix(filename, extension)
+		for i := 2; used[strings.ToLower(filename)]; i++ {
+			suffix := " (" + strconv.Itoa(i) + ")"
+			filename = truncateFilename(base, MaxFilenameLength-len(suffix)-len(extension)) + suffix + extension
+		}
+
+		used[strings.ToLower(filename)] = true
+		a.SetFilename(filename)
+	}
+}
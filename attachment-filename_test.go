@@ -0,0 +1,39 @@
+package tnefdecoder
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"report.pdf", "report.pdf"},
+		{"../../etc/passwd", "passwd"},
+		{`C:\Users\jane\report.pdf`, "report.pdf"},
+		{"a<b>c:d\"e|f?g*h.txt", "a_b_c_d_e_f_g_h.txt"},
+		{"line\r\nbreak\x00.txt", "line__break_.txt"},
+		{"trailing. . ", "trailing"},
+		{"CON", "_CON"},
+		{"con.txt", "_con.txt"},
+		{"CON.tar.gz", "_CON.tar.gz"},
+		{"NUL .txt", "_NUL .txt"},
+		{"COM1.", "_COM1"},
+		{"CONSOLE.txt", "CONSOLE.txt"},
+		{"invalid \xff utf-8", "invalid \uFFFD utf-8"},
+		{"..", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if v := SanitizeFilename(tt.filename); v != tt.want {
+			t.Errorf("%q: %q, want %q", tt.filename, v, tt.want)
+		}
+	}
+
+	long := SanitizeFilename(strings.Repeat("é", MaxFilenameLength) + ".docx")
+	if len(long) > MaxFilenameLength || !strings.HasSuffix(long, "é.docx") {
+		t.Errorf("truncated %q", long)
+	}
+}
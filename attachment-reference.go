@@ -0,0 +1,112 @@
+/**
+ * attachments by reference (PidTagAttachMethod 2, 3, 4) and web references / cloud attachments (PidTagAttachMethod 7)
+ * these attachments have no data; the message contains only the path or the URL of the file
+ */
+
+package tnefdecoder
+
+import (
+	"net/url"
+	"strings"
+)
+
+/**
+ * an attachment by reference
+ */
+type ReferenceAttachment struct {
+	// PidTagAttachMethod value
+	Method int
+
+	// the fully qualified path of the file (attachments by reference) or the URL (web references)
+	Path string
+
+	// set if the path is an URL (always for web references)
+	Url string
+
+	// the service hosting the file (PidNameAttachmentProviderType), ex: OneDrivePro, Dropbox
+	ProviderType string
+
+	// AttachmentPermissionNone / AttachmentPermissionView / AttachmentPermissionEdit
+	PermissionType int
+	OriginalPermissionType int
+}
+
+/**
+ * return PidTagAttachMethod value; if the property is missing the attachment is by value
+ */
+func (a *Attachment) GetAttachMethod() int {
+	attr := a.GetAttribute(MapiPidTagAttachMethod, "mapi")
+	if attr != nil {
+		return attr.GetIntValue()
+	}
+	return AttachMethodByValue
+}
+
+/**
+ * check if the attachment is a reference to a file / URL (the message does not contain the data)
+ */
+func (a *Attachment) IsReference() bool {
+	switch a.GetAttachMethod() {
+		case AttachMethodByReference, AttachMethodByReferenceResolve, AttachMethodByReferenceOnly, AttachMethodByWebReference:
+			return true
+	}
+	return false
+}
+
+/**
+ * return the reference of the attachment, or nil if the attachment is not a reference
+ */
+func (a *Attachment) GetReference() *ReferenceAttachment {
+	if !a.IsReference() {
+		return nil
+	}
+
+	ref := &ReferenceAttachment{
+		Method: a.GetAttachMethod(),
+	}
+
+	for _, id := range []int{MapiPidTagAttachLongPathname, MapiPidTagAttachPathname} {
+		attr := a.GetAttribute(id, "mapi")
+		if attr != nil && attr.GetStringValue() != "" {
+			ref.Path = attr.GetStringValue()
+			break
+		}
+	}
+
+	if ref.Method == AttachMethodByWebReference {
+		ref.Url = ref.Path
+	} else if u, err := url.Parse(ref.Path); err == nil && (strings.EqualFold(u.Scheme, "http") || strings.EqualFold(u.Scheme, "https")) {
+		// some clients put links into PidTagAttachLongPathname for attachments by reference
+		ref.Url = ref.Path
+	}
+
+	attr := a.GetNamedAttribute(PsetidAttachment, NamedAttachmentProviderType)
+	if attr != nil {
+		ref.ProviderType = attr.GetStringValue()
+	}
+
+	attr = a.GetNamedAttribute(PsetidAttachment, NamedAttachmentPermissionType)
+	if attr != nil {
+		ref.PermissionType = attr.GetIntValue()
+	}
+
+	attr = a.GetNamedAttribute(PsetidAttachment, NamedAttachmentOriginalPermissionType)
+	if attr != nil {
+		ref.OriginalPermissionType = attr.GetIntValue()
+	}
+
+	return ref
+}
+
+/**
+ * human readable permission
+ */
+func (r *ReferenceAttachment) GetPermissionName() string {
+	switch r.PermissionType {
+		case AttachmentPermissionView:
+			return "view"
+		case AttachmentPermissionEdit:
+			return "edit"
+	}
+	return ""
+}
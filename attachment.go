@@ -4,11 +4,6 @@
 
 package tnefdecoder
 
-import (
-	"strings"
-//	"fmt"
-)
-
 func NewAttachment() *Attachment {
 	return &Attachment{
 		TnefObject: &TnefObject {},
@@ -26,6 +21,12 @@ type Attachment struct {
 
 	// custom attributes that needs specific decoders
 	DecodedAttRendData map[string]int
+
+	// the decoded TNEF object of an embedded message, or of a TNEF file attached to the message (see TnefDecoder.DecodeNestedTnef)
+	Embedded *TnefObject
+
+	// the error of the decoding of the embedded TNEF object (ex: ErrMaxNestingDepth), nil if it was decoded
+	EmbeddedError error
 }
 
 
@@ -60,58 +61,13 @@ func (a *Attachment) GetData() []byte {
 }
 
 
+/**
+ * get attachment filename; if it was not set (using SetFilename or previously requested) it is resolved from attributes
+ * see ResolveFilename
+ */
 func (a *Attachment) GetFilename() string {
-	var attr *Attribute
-
-	/*
-	attr  = a.GetAttribute(AttAttachTransportFilename, "mapped")
-
-	if attr != nil {
-		fmt.Println("Transport Name:", attr.GetStringValue())
-	}
-	*/
-
-	if a.Filename == "" {
-		// if the Filename is not already set (custom, using SetFilename, or previously requested) try to extract the data from attributes
-		attr  = a.GetAttribute(AttAttachTitle, "mapped")
-		if attr != nil && attr.GetStringValue() != "" {
-			a.SetFilename(attr.GetStringValue())
-		}
-	}
-
-	if a.Filename == "" {
-		// if the Filename is not already set (custom, using SetFilename, or previously requested) try to extract the data from attributes
-		attr  = a.GetAttribute(MapiPidTagDisplayName, "mapi")
-		if attr != nil && attr.GetStringValue() != "" {
-			a.SetFilename(attr.GetStringValue())
-		}
-	}
-
-	if a.Filename == "" {
-		// if the Filename is not already set (custom, using SetFilename, or previously requested) try to extract the data from attributes
-		attr  = a.GetAttribute(MapiPidTagAttachFilename, "mapi")
-		if attr != nil && attr.GetStringValue() != "" {
-			a.SetFilename(attr.GetStringValue())
-		}
-	}
-
 	if a.Filename == "" {
-		extension := ""
-		// if the Filename is not already set (custom, using SetFilename, or previously requested) try to extract the data from attributes
-		attr  = a.GetAttribute(MapiPidTagAttachExtension, "mapi")
-		if attr != nil && attr.GetStringValue() != "" {
-			extension = attr.GetStringValue()
-		}
-		a.SetFilename("unkown"+extension)
-	}
-
-
-	if strings.Count(a.Filename, "?") != 0 || len(a.Filename) > 255 {
-		// the filename has invalid characters or is too long
-		attr  = a.GetAttribute(MapiPidTagAttachFilename, "mapi")
-		if attr != nil && attr.GetStringValue() != "" {
-			a.SetFilename(attr.GetStringValue())
-		}
+		a.SetFilename(a.ResolveFilename())
 	}
 
 	return a.Filename
@@ -150,3 +106,38 @@ func (a *Attachment) GetRenderType() int {
 }
 
 
+
+/**
+ * return the WMF rendering of the attachment (the icon of the file or the preview of an OLE object)
+ * extracted from attAttachMetaFile or PidTagAttachRendering
+ */
+func (a *Attachment) GetMetafile() []byte {
+	attr := a.GetAttribute(AttAttachMetaFile, "mapped")
+	if attr != nil && len(attr.Data) > 0 {
+		return attr.Data
+	}
+
+	attr = a.GetAttribute(MapiPidTagAttachRendering, "mapi")
+	if attr != nil {
+		return attr.GetBinaryValue()
+	}
+
+	return nil
+}
+
+/**
+ * render the attachment metafile (icon / preview) as PNG
+ */
+func (a *Attachment) GetRenderingPng() ([]byte, error) {
+	data := a.GetMetafile()
+	if len(data) == 0 {
+		return nil, ErrWmfEmpty
+	}
+
+	metafile, err := DecodeMetafile(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return metafile.RenderPng()
+}
@@ -2,6 +2,7 @@ package tnefdecoder
 
 import (
 	"strings"
+	"time"
 )
 
 /**
@@ -22,6 +23,99 @@ import (
 	PropMapValue GenericValue
 }
 
+/**
+ * create a MAPI PtypUnicode attribute
+ */
+func NewMapiStringAttribute(id int, value string) *Attribute {
+	return &Attribute{
+		Type: "mapi",
+		Level: AttrLevelMessage,
+		Id: id,
+		DataType: MapiTypeUnicode,
+		Data: MapiEncodeUnicode(value),
+	}
+}
+
+/**
+ * create a MAPI attribute of the given type from the encoded value (see the MapiEncode* functions)
+ */
+func NewMapiAttribute(id int, dataType int, data []byte) *Attribute {
+	return &Attribute{
+		Type: "mapi",
+		Level: AttrLevelMessage,
+		Id: id,
+		DataType: dataType,
+		Data: data,
+	}
+}
+
+/**
+ * create a MAPI PtypInteger32 attribute
+ */
+func NewMapiIntAttribute(id int, value int) *Attribute {
+	return NewMapiAttribute(id, MapiTypeInt32, MapiEncodeInt32(value))
+}
+
+/**
+ * create a MAPI PtypBoolean attribute
+ */
+func NewMapiBoolAttribute(id int, value bool) *Attribute {
+	v := 0
+	if value {
+		v = 1
+	}
+	return NewMapiAttribute(id, MapiTypeBoolean, MapiEncodeInt32(v))
+}
+
+/**
+ * create a MAPI PtypTime attribute
+ */
+func NewMapiTimeAttribute(id int, value time.Time) *Attribute {
+	return NewMapiAttribute(id, MapiTypeSystime, MapiEncodeTime(value))
+}
+
+/**
+ * create a MAPI PtypBinary attribute
+ */
+func NewMapiBinaryAttribute(id int, value []byte) *Attribute {
+	return NewMapiAttribute(id, MapiTypeBinary, MapiEncodeBinary(value))
+}
+
+/**
+ * create a MAPI PtypMultipleString attribute
+ */
+func NewMapiStringArrayAttribute(id int, values []string) *Attribute {
+	return NewMapiAttribute(id, MapiTypeMVUnicode, MapiEncodeUnicodeArray(values))
+}
+
+/**
+ * create a mapped (TNEF) attribute holding an 8-bit null terminated string (attMessageClass, attAttachTitle, ...)
+ */
+func NewMappedStringAttribute(level int, id int, value string) *Attribute {
+	return &Attribute{
+		Type: "mapped",
+		Level: level,
+		Id: id,
+		Data: append([]byte(value), 0),
+	}
+}
+
+/**
+ * make the attribute a named property of the property set guid; name is the LID (int) or the string name
+ * the ID is assigned by the encoder
+ */
+func (a *Attribute) Named(guid string, name GenericValue) *Attribute {
+	a.Id = 0x8000
+	a.GUID = guid
+	a.PropMapValue = name
+	if _, ok := name.(string); ok {
+		a.PropMapValueType = 1
+	} else {
+		a.PropMapValueType = 0
+	}
+	return a
+}
+
 func (a *Attribute) GetStringValue() string {
 	v := ""
    switch a.DataType {
@@ -35,6 +129,22 @@ func (a *Attribute) GetStringValue() string {
    return strings.TrimSuffix(v, "\x00")
 }
 
+/**
+ * decode a string value; 8-bit strings are converted from the given code page (see attOemCodepage)
+ */
+func (a *Attribute) GetStringValueCodepage(codepage int) string {
+   v := ""
+   switch a.DataType {
+	   case MapiTypeUnicode:
+		   v = MapiDecodeUnicode(a.Data)
+	   case MapiTypeString8:
+		   v = DecodeCodepage([]byte(strings.TrimRight(MapiDecodeString8(a.Data), "\x00")), codepage)
+	   default:
+		   v = DecodeCodepage([]byte(strings.TrimRight(string(a.Data), "\x00")), codepage)
+   }
+   return strings.TrimSuffix(v, "\x00")
+}
+
 func (a *Attribute) GetStringValueArray() []string {
    result := []string{""}
   switch a.DataType {
@@ -65,3 +175,29 @@ func (a *Attribute) GetBinaryValue() []byte {
 func (a *Attribute) GetBinaryValueArray() [][]byte {
    return MapiDecodeBinaryArray(a.Data)
 }
+
+/**
+ * decode a date value: a TNEF Date (mapped attributes like attDateSent) or a MAPI PtypTime (FILETIME)
+ */
+func (a *Attribute) GetTimeValue() time.Time {
+   if a.Type == "mapped" {
+	   return TnefDecodeDate(a.Data)
+   }
+   return MapiDecodeTime(a.Data)
+}
+
+/**
+ * decode a TNEF Date (DTR) structure
+ * Date = wYear wMonth wDay wHour wMinute wSecond wDayOfWeek ; all UINT16
+ */
+func TnefDecodeDate(b []byte) time.Time {
+   if len(b) < 12 {
+	   return time.Time{}
+   }
+   leReader := new(LittleEndianDecoder)
+   v := make([]int, 6)
+   for i := range v {
+	   v[i] = int(leReader.Uint16(b[i*2:i*2+2]))
+   }
+   return time.Date(v[0], time.Month(v[1]), v[2], v[3], v[4], v[5], 0, time.UTC)
+}
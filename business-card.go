@@ -0,0 +1,590 @@
+/**
+ * electronic business cards: PidLidBusinessCardDisplayDefinition ([MS-OXOCNTC] section 2.2.1.7.1) decoded into the
+ * X-MS-OL-DESIGN XML of the vCards and rendered as a PNG preview
+ */
+
+package tnefdecoder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"strings"
+)
+
+var (
+	ErrInvalidBusinessCard = errors.New("invalid business card display definition")
+	ErrNoBusinessCard      = errors.New("the object has no business card display definition")
+)
+
+/**
+ * TemplateID: the position of the image on the card
+ */
+const (
+	BusinessCardTemplateImageLeft   = 0x00
+	BusinessCardTemplateImageRight  = 0x01
+	BusinessCardTemplateImageTop    = 0x02
+	BusinessCardTemplateImageBottom = 0x03
+	BusinessCardTemplateNoImage     = 0x04
+	BusinessCardTemplateBackground  = 0x05 // the image is the background of the card
+)
+
+/**
+ * ImageSource: the image of the card
+ */
+const (
+	BusinessCardImageSourceContactPhoto = 0x00
+	BusinessCardImageSourceCardPicture  = 0x01 // PidLidBusinessCardCardPicture
+)
+
+// ImageAlignment: 0 stretches the image, 1 - 9 align it top-left .. bottom-right
+const BusinessCardImageStretch = 0x00
+
+/**
+ * TextFormat flags of the fields
+ */
+const (
+	BusinessCardTextMultiline   = 0x01
+	BusinessCardTextBold        = 0x02
+	BusinessCardTextItalic      = 0x04
+	BusinessCardTextUnderline   = 0x08
+	BusinessCardTextAlignCenter = 0x10
+	BusinessCardTextAlignRight  = 0x20
+)
+
+/**
+ * LabelFormat of the fields
+ */
+const (
+	BusinessCardLabelNone  = 0x00
+	BusinessCardLabelLeft  = 0x01
+	BusinessCardLabelRight = 0x02
+)
+
+const (
+	BusinessCardFieldEmpty   = 0x0000 // TextPropertyID of an empty line
+	businessCardNoLabel      = 0xFFFE // LabelOffset of the fields without label
+	businessCardHeaderSize   = 17
+	businessCardFieldMinSize = 16
+)
+
+// the size of the rendered business cards (pixels)
+const (
+	BusinessCardWidth  = 350
+	BusinessCardHeight = 200
+)
+
+// the namespace of the X-MS-OL-DESIGN XML
+const BusinessCardNamespace = "http://schemas.microsoft.com/office/outlook/12/electronicbusinesscards"
+
+var businessCardLayouts = map[int]string{
+	BusinessCardTemplateImageLeft:   "left",
+	BusinessCardTemplateImageRight:  "right",
+	BusinessCardTemplateImageTop:    "top",
+	BusinessCardTemplateImageBottom: "bottom",
+	BusinessCardTemplateNoImage:     "none",
+	BusinessCardTemplateBackground:  "back",
+}
+
+var businessCardImageAlignments = []string{"stretch", "tleft", "tcenter", "tright", "mleft", "mcenter", "mright", "bleft", "bcenter", "bright"}
+
+/**
+ * the contact properties shown on the cards -> the prop attribute of the X-MS-OL-DESIGN fields
+ * the IDs from 0x8000 are the LIDs of PSETID_Address
+ */
+var businessCardFieldNames = map[int]string{
+	BusinessCardFieldEmpty:             "blank",
+	MapiPidTagDisplayName:              "name",
+	MapiPidLidFileUnder:                "fileas",
+	MapiPidTagCompanyName:              "org",
+	MapiPidTagDepartmentName:           "dept",
+	MapiPidTagTitle:                    "title",
+	MapiPidTagOfficeLocation:           "office",
+	MapiPidTagProfession:               "profession",
+	MapiPidTagNickname:                 "nickname",
+	MapiPidTagBusinessTelephoneNumber:  "telwork",
+	MapiPidTagHomeTelephoneNumber:      "telhome",
+	MapiPidTagMobileTelephoneNumber:    "telcell",
+	MapiPidTagPagerTelephoneNumber:     "pager",
+	MapiPidTagBusinessFaxNumber:        "faxwork",
+	MapiPidTagHomeFaxNumber:            "faxhome",
+	MapiPidTagAssistantTelephoneNumber: "telassistant",
+	MapiPidLidEmail1EmailAddress:       "email",
+	MapiPidLidEmail2EmailAddress:       "email2",
+	MapiPidLidEmail3EmailAddress:       "email3",
+	MapiPidLidWorkAddress:              "addrwork",
+	MapiPidLidHomeAddress:              "addrhome",
+	MapiPidLidOtherAddress:             "addrother",
+	MapiPidTagBusinessHomePage:         "webwork",
+	MapiPidTagPersonalHomePage:         "webhome",
+	MapiPidLidInstantMessagingAddress:  "im",
+}
+
+/**
+ * the decoded PidLidBusinessCardDisplayDefinition
+ */
+type BusinessCardDisplayDefinition struct {
+	MajorVersion    int
+	MinorVersion    int
+	TemplateId      int // BusinessCardTemplate*
+	ImageAlignment  int // BusinessCardImageStretch or 1 - 9 (top-left .. bottom-right)
+	ImageSource     int // BusinessCardImageSource*
+	BackgroundColor color.RGBA
+	ImageArea       int // the percentage of the card used by the image
+	Fields          []*BusinessCardField
+}
+
+/**
+ * a text field of the card
+ */
+type BusinessCardField struct {
+	TextPropertyId int // the property shown in the field; BusinessCardFieldEmpty for an empty line
+	TextFormat     int // BusinessCardText* flags
+	LabelFormat    int // BusinessCardLabel*
+	FontSize       int // points
+	Label          string
+	ValueFontColor color.RGBA
+	LabelFontColor color.RGBA
+}
+
+/**
+ * decode PidLidBusinessCardDisplayDefinition: the header, the FieldInfo structures and the ExtraInfo holding the
+ * labels (null terminated UTF-16 strings referenced by LabelOffset)
+ */
+func DecodeBusinessCardDisplayDefinition(b []byte) (*BusinessCardDisplayDefinition, error) {
+	if len(b) < businessCardHeaderSize {
+		return nil, ErrInvalidBusinessCard
+	}
+
+	d := &BusinessCardDisplayDefinition{
+		MajorVersion:    int(b[0]),
+		MinorVersion:    int(b[1]),
+		TemplateId:      int(b[2]),
+		ImageAlignment:  int(b[6]),
+		ImageSource:     int(b[7]),
+		BackgroundColor: wmfColorRef(b[8:12]),
+		ImageArea:       int(b[12]),
+	}
+	count, fieldSize, extraSize := int(b[3]), int(b[4]), int(b[5])
+	if fieldSize < businessCardFieldMinSize || len(b) < businessCardHeaderSize+count*fieldSize {
+		return nil, ErrInvalidBusinessCard
+	}
+
+	extraInfo := b[businessCardHeaderSize+count*fieldSize:]
+	if len(extraInfo) > extraSize {
+		extraInfo = extraInfo[:extraSize]
+	}
+
+	leDecoder := new(LittleEndianDecoder)
+	for i := 0; i < count; i++ {
+		fb := b[businessCardHeaderSize+i*fieldSize:]
+		f := &BusinessCardField{
+			TextPropertyId: int(leDecoder.Uint16(fb[0:2])),
+			TextFormat:     int(fb[2]),
+			LabelFormat:    int(fb[3]),
+			FontSize:       int(fb[4]),
+			ValueFontColor: wmfColorRef(fb[8:12]),
+			LabelFontColor: wmfColorRef(fb[12:16]),
+		}
+		if offset := int(leDecoder.Uint16(fb[6:8])); offset != businessCardNoLabel && offset < len(extraInfo) {
+			f.Label = businessCardString(extraInfo[offset:])
+		}
+		d.Fields = append(d.Fields, f)
+	}
+
+	return d, nil
+}
+
+/**
+ * a null terminated UTF-16 string
+ */
+func businessCardString(b []byte) string {
+	for i := 0; i+1 < len(b); i += 2 {
+		if b[i] == 0 && b[i+1] == 0 {
+			return new(LittleEndianDecoder).Utf16(b[:i])
+		}
+	}
+	return ""
+}
+
+/**
+ * return the business card display definition of the contact; nil if the contact has none or it cannot be decoded
+ */
+func (t *TnefObject) GetBusinessCardDisplayDefinition() *BusinessCardDisplayDefinition {
+	b := t.GetNamedBinaryValue(PsetidAddress, MapiPidLidBusinessCardDisplayDefinition)
+	if len(b) == 0 {
+		return nil
+	}
+	d, err := DecodeBusinessCardDisplayDefinition(b)
+	if err != nil {
+		return nil
+	}
+	return d
+}
+
+/**
+ * the X-MS-OL-DESIGN XML of the card: the card element (layout, background), the image and the text fields
+ */
+func (d *BusinessCardDisplayDefinition) DesignXml() string {
+	var buf strings.Builder
+
+	layout, ok := businessCardLayouts[d.TemplateId]
+	if !ok {
+		layout = businessCardLayouts[BusinessCardTemplateImageLeft]
+	}
+	buf.WriteString(`<card xmlns="` + BusinessCardNamespace + `" ver="1.0" layout="` + layout + `" bgcolor="` + htmlColor(d.BackgroundColor) + `">`)
+
+	if d.TemplateId != BusinessCardTemplateNoImage {
+		align := businessCardImageAlignments[0]
+		if d.ImageAlignment < len(businessCardImageAlignments) {
+			align = businessCardImageAlignments[d.ImageAlignment]
+		}
+		use := "photo"
+		if d.ImageSource == BusinessCardImageSourceCardPicture {
+			use = "cardpicture"
+		}
+		buf.WriteString(fmt.Sprintf(`<img xmlns="" align="%s" area="%d" use="%s"/>`, align, d.ImageArea, use))
+	}
+
+	for _, f := range d.Fields {
+		prop, ok := businessCardFieldNames[f.TextPropertyId]
+		if !ok {
+			continue
+		}
+		if f.TextPropertyId == BusinessCardFieldEmpty {
+			buf.WriteString(fmt.Sprintf(`<fld xmlns="" prop="%s" size="%d"/>`, prop, f.FontSize))
+			continue
+		}
+
+		buf.WriteString(fmt.Sprintf(`<fld xmlns="" prop="%s" align="%s" dir="ltr"`, prop, f.align()))
+		if style := f.style(); style != "" {
+			buf.WriteString(` style="` + style + `"`)
+		}
+		buf.WriteString(fmt.Sprintf(` color="%s" size="%d"`, htmlColor(f.ValueFontColor), f.FontSize))
+
+		if f.LabelFormat == BusinessCardLabelNone || f.Label == "" {
+			buf.WriteString("/>")
+			continue
+		}
+		labelAlign := "left"
+		if f.LabelFormat == BusinessCardLabelRight {
+			labelAlign = "right"
+		}
+		buf.WriteString(`><label align="` + labelAlign + `" color="` + htmlColor(f.LabelFontColor) + `">` + xcardEscape(f.Label) + `</label></fld>`)
+	}
+
+	buf.WriteString("</card>")
+	return buf.String()
+}
+
+/**
+ * the alignment of the text of the field: left, center or right
+ */
+func (f *BusinessCardField) align() string {
+	switch {
+	case f.TextFormat&BusinessCardTextAlignCenter != 0:
+		return "center"
+	case f.TextFormat&BusinessCardTextAlignRight != 0:
+		return "right"
+	}
+	return "left"
+}
+
+/**
+ * the style of the text of the field: a combination of b (bold), i (italic) and u (underline)
+ */
+func (f *BusinessCardField) style() string {
+	style := ""
+	if f.TextFormat&BusinessCardTextBold != 0 {
+		style += "b"
+	}
+	if f.TextFormat&BusinessCardTextItalic != 0 {
+		style += "i"
+	}
+	if f.TextFormat&BusinessCardTextUnderline != 0 {
+		style += "u"
+	}
+	return style
+}
+
+/**
+ * RRGGBB
+ */
+func htmlColor(c color.RGBA) string {
+	return fmt.Sprintf("%02x%02x%02x", c.R, c.G, c.B)
+}
+
+/**
+ * return the text shown in a field: the value of the contact property (the IDs from 0x8000 are LIDs of PSETID_Address)
+ */
+func (t *TnefObject) businessCardFieldValue(id int) string {
+	if id >= 0x8000 {
+		return t.GetNamedStringValue(PsetidAddress, id)
+	}
+	return t.GetAttributeStringValue(id, "mapi")
+}
+
+/**
+ * return the image of the card: PidLidBusinessCardCardPicture or the contact photo, as selected by ImageSource
+ */
+func (t *TnefObject) businessCardImage(d *BusinessCardDisplayDefinition) image.Image {
+	var data []byte
+	if d.ImageSource == BusinessCardImageSourceCardPicture {
+		data = t.GetNamedBinaryValue(PsetidAddress, MapiPidLidBusinessCardCardPicture)
+	} else {
+		for _, att := range t.Attachments {
+			if attr := att.GetAttribute(MapiPidTagAttachmentContactPhoto, "mapi"); attr != nil && attr.GetBoolValue() {
+				data = att.GetData()
+				break
+			}
+		}
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	// the size is checked before decoding: the images larger than the WMF bitmaps are not drawn
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > WmfMaxBitmapPixels {
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	return img
+}
+
+/**
+ * render the business card of the contact: the background, the image in the image area and the text fields
+ * (drawn with a 5x7 bitmap font; the characters outside ASCII are drawn as '?')
+ */
+func (t *TnefObject) RenderBusinessCard() (*image.RGBA, error) {
+	d := t.GetBusinessCardDisplayDefinition()
+	if d == nil {
+		return nil, ErrNoBusinessCard
+	}
+
+	card := image.NewRGBA(image.Rect(0, 0, BusinessCardWidth, BusinessCardHeight))
+	draw.Draw(card, card.Bounds(), &image.Uniform{d.BackgroundColor}, image.Point{}, draw.Src)
+
+	imageRect, textRect := d.areas(card.Bounds())
+	if !imageRect.Empty() {
+		if img := t.businessCardImage(d); img != nil {
+			drawScaled(card, img, d.imagePlacement(imageRect, img.Bounds()))
+		}
+	}
+
+	const padding = 6
+	textRect = textRect.Inset(padding)
+	text := card.SubImage(textRect).(*image.RGBA)
+	y := textRect.Min.Y
+	for _, f := range d.Fields {
+		scale := f.FontSize / 8
+		if scale < 1 {
+			scale = 1
+		}
+		lineHeight := 10 * scale
+
+		lines := []string{""}
+		if f.TextPropertyId != BusinessCardFieldEmpty {
+			value := strings.Replace(t.businessCardFieldValue(f.TextPropertyId), "\r\n", "\n", -1)
+			if value == "" {
+				continue
+			}
+			lines = strings.Split(value, "\n")
+			if f.TextFormat&BusinessCardTextMultiline == 0 {
+				lines = lines[:1]
+			}
+		}
+
+		for i, line := range lines {
+			label, value := "", line
+			if i == 0 && f.LabelFormat != BusinessCardLabelNone && f.Label != "" {
+				label = f.Label + " "
+				if f.LabelFormat == BusinessCardLabelRight {
+					label = " " + f.Label
+				}
+			}
+			width := (len([]rune(label)) + len([]rune(value))) * 6 * scale
+
+			x := textRect.Min.X
+			switch f.align() {
+			case "center":
+				x += (textRect.Dx() - width) / 2
+			case "right":
+				x += textRect.Dx() - width
+			}
+
+			if f.LabelFormat == BusinessCardLabelRight {
+				x = drawBusinessCardText(text, x, y, value, scale, f.ValueFontColor, f.TextFormat)
+				drawBusinessCardText(text, x, y, label, scale, f.LabelFontColor, 0)
+			} else {
+				x = drawBusinessCardText(text, x, y, label, scale, f.LabelFontColor, 0)
+				drawBusinessCardText(text, x, y, value, scale, f.ValueFontColor, f.TextFormat)
+			}
+			y += lineHeight
+		}
+	}
+
+	return card, nil
+}
+
+/**
+ * render the business card as PNG
+ */
+func (t *TnefObject) RenderBusinessCardPng() ([]byte, error) {
+	img, err := t.RenderBusinessCard()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	if err = png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+/**
+ * render the business card of an attached contact as PNG
+ */
+func (a *Attachment) GetBusinessCardPng() ([]byte, error) {
+	if a.Embedded == nil {
+		return nil, ErrNoBusinessCard
+	}
+	return a.Embedded.RenderBusinessCardPng()
+}
+
+/**
+ * the image area and the text area of the card, following the template
+ */
+func (d *BusinessCardDisplayDefinition) areas(r image.Rectangle) (image.Rectangle, image.Rectangle) {
+	area := d.ImageArea
+	if area <= 0 || area > 100 {
+		area = 100
+	}
+	w, h := r.Dx()*area/100, r.Dy()*area/100
+
+	switch d.TemplateId {
+	case BusinessCardTemplateImageRight:
+		return image.Rect(r.Max.X-w, r.Min.Y, r.Max.X, r.Max.Y), image.Rect(r.Min.X, r.Min.Y, r.Max.X-w, r.Max.Y)
+	case BusinessCardTemplateImageTop:
+		return image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+h), image.Rect(r.Min.X, r.Min.Y+h, r.Max.X, r.Max.Y)
+	case BusinessCardTemplateImageBottom:
+		return image.Rect(r.Min.X, r.Max.Y-h, r.Max.X, r.Max.Y), image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Max.Y-h)
+	case BusinessCardTemplateNoImage:
+		return image.Rectangle{}, r
+	case BusinessCardTemplateBackground:
+		return r, r
+	}
+	return image.Rect(r.Min.X, r.Min.Y, r.Min.X+w, r.Max.Y), image.Rect(r.Min.X+w, r.Min.Y, r.Max.X, r.Max.Y)
+}
+
+/**
+ * the rectangle of the image inside the image area: the whole area when stretched, else the image scaled to fit the
+ * area (keeping the aspect ratio) and aligned
+ */
+func (d *BusinessCardDisplayDefinition) imagePlacement(area image.Rectangle, src image.Rectangle) image.Rectangle {
+	if d.ImageAlignment == BusinessCardImageStretch || d.ImageAlignment >= len(businessCardImageAlignments) || src.Dx() == 0 || src.Dy() == 0 {
+		return area
+	}
+
+	w, h := area.Dx(), src.Dy()*area.Dx()/src.Dx()
+	if h > area.Dy() {
+		w, h = src.Dx()*area.Dy()/src.Dy(), area.Dy()
+	}
+
+	row, col := (d.ImageAlignment-1)/3, (d.ImageAlignment-1)%3
+	x := area.Min.X + (area.Dx()-w)*col/2
+	y := area.Min.Y + (area.Dy()-h)*row/2
+	return image.Rect(x, y, x+w, y+h)
+}
+
+/**
+ * draw the image scaled into the rectangle (nearest neighbor)
+ */
+func drawScaled(dst *image.RGBA, src image.Image, r image.Rectangle) {
+	sb := src.Bounds()
+	if r.Dx() == 0 || r.Dy() == 0 {
+		return
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		sy := sb.Min.Y + (y-r.Min.Y)*sb.Dy()/r.Dy()
+		for x := r.Min.X; x < r.Max.X; x++ {
+			sx := sb.Min.X + (x-r.Min.X)*sb.Dx()/r.Dx()
+			dst.Set(x, y, src.At(sx, sy))
+		}
+	}
+}
+
+/**
+ * draw a text with the bitmap font; return the x position after the text
+ */
+func drawBusinessCardText(dst *image.RGBA, x, y int, text string, scale int, c color.RGBA, format int) int {
+	for _, r := range text {
+		if r < 0x20 || r > 0x7E {
+			r = '?'
+		}
+		glyph := businessCardFont[r-0x20]
+		for col := 0; col < 5; col++ {
+			for row := 0; row < 8; row++ {
+				if glyph[col]&(1<<uint(row)) == 0 {
+					continue
+				}
+				px, py := x+col*scale, y+row*scale
+				if format&BusinessCardTextItalic != 0 {
+					px += (7 - row) * scale / 3
+				}
+				fillRect(dst, image.Rect(px, py, px+scale, py+scale), c)
+				if format&BusinessCardTextBold != 0 {
+					fillRect(dst, image.Rect(px+1, py, px+scale+1, py+scale), c)
+				}
+			}
+		}
+		if format&BusinessCardTextUnderline != 0 {
+			fillRect(dst, image.Rect(x, y+8*scale, x+6*scale, y+9*scale), c)
+		}
+		x += 6 * scale
+	}
+	return x
+}
+
+func fillRect(dst *image.RGBA, r image.Rectangle, c color.RGBA) {
+	draw.Draw(dst, r.Intersect(dst.Bounds()), &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+/**
+ * 5x7 font for the ASCII characters 0x20 - 0x7E: 5 columns per character, the low bit is the top row
+ */
+var businessCardFont = [95][5]byte{
+	{0x00, 0x00, 0x00, 0x00, 0x00}, {0x00, 0x00, 0x5F, 0x00, 0x00}, {0x00, 0x07, 0x00, 0x07, 0x00}, {0x14, 0x7F, 0x14, 0x7F, 0x14},
+	{0x24, 0x2A, 0x7F, 0x2A, 0x12}, {0x23, 0x13, 0x08, 0x64, 0x62}, {0x36, 0x49, 0x56, 0x20, 0x50}, {0x00, 0x08, 0x07, 0x03, 0x00},
+	{0x00, 0x1C, 0x22, 0x41, 0x00}, {0x00, 0x41, 0x22, 0x1C, 0x00}, {0x2A, 0x1C, 0x7F, 0x1C, 0x2A}, {0x08, 0x08, 0x3E, 0x08, 0x08},
+	{0x00, 0x80, 0x70, 0x30, 0x00}, {0x08, 0x08, 0x08, 0x08, 0x08}, {0x00, 0x00, 0x60, 0x60, 0x00}, {0x20, 0x10, 0x08, 0x04, 0x02},
+	{0x3E, 0x51, 0x49, 0x45, 0x3E}, {0x00, 0x42, 0x7F, 0x40, 0x00}, {0x72, 0x49, 0x49, 0x49, 0x46}, {0x21, 0x41, 0x49, 0x4D, 0x33},
+	{0x18, 0x14, 0x12, 0x7F, 0x10}, {0x27, 0x45, 0x45, 0x45, 0x39}, {0x3C, 0x4A, 0x49, 0x49, 0x31}, {0x41, 0x21, 0x11, 0x09, 0x07},
+	{0x36, 0x49, 0x49, 0x49, 0x36}, {0x46, 0x49, 0x49, 0x29, 0x1E}, {0x00, 0x00, 0x14, 0x00, 0x00}, {0x00, 0x40, 0x34, 0x00, 0x00},
+	{0x00, 0x08, 0x14, 0x22, 0x41}, {0x14, 0x14, 0x14, 0x14, 0x14}, {0x00, 0x41, 0x22, 0x14, 0x08}, {0x02, 0x01, 0x59, 0x09, 0x06},
+	{0x3E, 0x41, 0x5D, 0x59, 0x4E}, {0x7C, 0x12, 0x11, 0x12, 0x7C}, {0x7F, 0x49, 0x49, 0x49, 0x36}, {0x3E, 0x41, 0x41, 0x41, 0x22},
+	{0x7F, 0x41, 0x41, 0x41, 0x3E}, {0x7F, 0x49, 0x49, 0x49, 0x41}, {0x7F, 0x09, 0x09, 0x09, 0x01}, {0x3E, 0x41, 0x41, 0x51, 0x73},
+	{0x7F, 0x08, 0x08, 0x08, 0x7F}, {0x00, 0x41, 0x7F, 0x41, 0x00}, {0x20, 0x40, 0x41, 0x3F, 0x01}, {0x7F, 0x08, 0x14, 0x22, 0x41},
+	{0x7F, 0x40, 0x40, 0x40, 0x40}, {0x7F, 0x02, 0x1C, 0x02, 0x7F}, {0x7F, 0x04, 0x08, 0x10, 0x7F}, {0x3E, 0x41, 0x41, 0x41, 0x3E},
+	{0x7F, 0x09, 0x09, 0x09, 0x06}, {0x3E, 0x41, 0x51, 0x21, 0x5E}, {0x7F, 0x09, 0x19, 0x29, 0x46}, {0x26, 0x49, 0x49, 0x49, 0x32},
+	{0x03, 0x01, 0x7F, 0x01, 0x03}, {0x3F, 0x40, 0x40, 0x40, 0x3F}, {0x1F, 0x20, 0x40, 0x20, 0x1F}, {0x3F, 0x40, 0x38, 0x40, 0x3F},
+	{0x63, 0x14, 0x08, 0x14, 0x63}, {0x03, 0x04, 0x78, 0x04, 0x03}, {0x61, 0x59, 0x49, 0x4D, 0x43}, {0x00, 0x7F, 0x41, 0x41, 0x41},
+	{0x02, 0x04, 0x08, 0x10, 0x20}, {0x00, 0x41, 0x41, 0x41, 0x7F}, {0x04, 0x02, 0x01, 0x02, 0x04}, {0x40, 0x40, 0x40, 0x40, 0x40},
+	{0x00, 0x03, 0x07, 0x08, 0x00}, {0x20, 0x54, 0x54, 0x78, 0x40}, {0x7F, 0x28, 0x44, 0x44, 0x38}, {0x38, 0x44, 0x44, 0x44, 0x28},
+	{0x38, 0x44, 0x44, 0x28, 0x7F}, {0x38, 0x54, 0x54, 0x54, 0x18}, {0x00, 0x08, 0x7E, 0x09, 0x02}, {0x18, 0xA4, 0xA4, 0x9C, 0x78},
+	{0x7F, 0x08, 0x04, 0x04, 0x78}, {0x00, 0x44, 0x7D, 0x40, 0x00}, {0x20, 0x40, 0x40, 0x3D, 0x00}, {0x7F, 0x10, 0x28, 0x44, 0x00},
+	{0x00, 0x41, 0x7F, 0x40, 0x00}, {0x7C, 0x04, 0x78, 0x04, 0x78}, {0x7C, 0x08, 0x04, 0x04, 0x78}, {0x38, 0x44, 0x44, 0x44, 0x38},
+	{0xFC, 0x18, 0x24, 0x24, 0x18}, {0x18, 0x24, 0x24, 0x18, 0xFC}, {0x7C, 0x08, 0x04, 0x04, 0x08}, {0x48, 0x54, 0x54, 0x54, 0x24},
+	{0x04, 0x04, 0x3F, 0x44, 0x24}, {0x3C, 0x40, 0x40, 0x20, 0x7C}, {0x1C, 0x20, 0x40, 0x20, 0x1C}, {0x3C, 0x40, 0x30, 0x40, 0x3C},
+	{0x44, 0x28, 0x10, 0x28, 0x44}, {0x4C, 0x90, 0x90, 0x90, 0x7C}, {0x44, 0x64, 0x54, 0x4C, 0x44}, {0x00, 0x08, 0x36, 0x41, 0x00},
+	{0x00, 0x00, 0x77, 0x00, 0x00}, {0x00, 0x41, 0x36, 0x08, 0x00}, {0x02, 0x01, 0x02, 0x04, 0x02},
+}
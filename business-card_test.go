@@ -0,0 +1,272 @@
+package tnefdecoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+/**
+ * a field of the test definitions: the property, the format and the label ("" for no label)
+ */
+type businessCardTestField struct {
+	PropertyId int
+	TextFormat int
+	Label      string
+}
+
+/**
+ * encode a PidLidBusinessCardDisplayDefinition ([MS-OXOCNTC] section 2.2.1.7.1) with the labels in the ExtraInfo
+ */
+func businessCardTestDefinition(fields ...businessCardTestField) []byte {
+	var extraInfo []byte
+	buf := &bytes.Buffer{}
+	buf.Write([]byte{1, 0, BusinessCardTemplateImageLeft, byte(len(fields)), businessCardFieldMinSize, 0, 5, BusinessCardImageSourceContactPhoto})
+	buf.Write([]byte{0xF0, 0xE0, 0xD0, 0, 30, 0, 0, 0, 0})
+
+	for _, f := range fields {
+		labelFormat, offset := BusinessCardLabelNone, businessCardNoLabel
+		if f.Label != "" {
+			labelFormat, offset = BusinessCardLabelLeft, len(extraInfo)
+			for _, c := range utf16.Encode([]rune(f.Label + "\x00")) {
+				extraInfo = binary.LittleEndian.AppendUint16(extraInfo, c)
+			}
+		}
+		buf.Write(binary.LittleEndian.AppendUint16(nil, uint16(f.PropertyId)))
+		buf.Write([]byte{byte(f.TextFormat), byte(labelFormat), 10, 0})
+		buf.Write(binary.LittleEndian.AppendUint16(nil, uint16(offset)))
+		buf.Write([]byte{0x00, 0x00, 0x80, 0, 0x40, 0x40, 0x40, 0})
+	}
+
+	b := buf.Bytes()
+	b[5] = byte(len(extraInfo))
+	return append(b, extraInfo...)
+}
+
+func businessCardTestSeed() []byte {
+	return businessCardTestDefinition(
+		businessCardTestField{MapiPidTagDisplayName, BusinessCardTextBold, ""},
+		businessCardTestField{BusinessCardFieldEmpty, 0, ""},
+		businessCardTestField{MapiPidTagCompanyName, BusinessCardTextAlignRight, "Firma"},
+		businessCardTestField{MapiPidLidEmail1EmailAddress, 0, "E-Mail"},
+	)
+}
+
+func TestDecodeBusinessCardDisplayDefinition(t *testing.T) {
+	d, err := DecodeBusinessCardDisplayDefinition(businessCardTestSeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.MajorVersion != 1 || d.TemplateId != BusinessCardTemplateImageLeft || d.ImageAlignment != 5 || d.ImageArea != 30 ||
+		d.BackgroundColor != (color.RGBA{0xF0, 0xE0, 0xD0, 255}) {
+		t.Errorf("%+v", d)
+	}
+
+	want := []*BusinessCardField{
+		{MapiPidTagDisplayName, BusinessCardTextBold, BusinessCardLabelNone, 10, "", color.RGBA{0, 0, 0x80, 255}, color.RGBA{0x40, 0x40, 0x40, 255}},
+		{BusinessCardFieldEmpty, 0, BusinessCardLabelNone, 10, "", color.RGBA{0, 0, 0x80, 255}, color.RGBA{0x40, 0x40, 0x40, 255}},
+		{MapiPidTagCompanyName, BusinessCardTextAlignRight, BusinessCardLabelLeft, 10, "Firma", color.RGBA{0, 0, 0x80, 255}, color.RGBA{0x40, 0x40, 0x40, 255}},
+		{MapiPidLidEmail1EmailAddress, 0, BusinessCardLabelLeft, 10, "E-Mail", color.RGBA{0, 0, 0x80, 255}, color.RGBA{0x40, 0x40, 0x40, 255}},
+	}
+	if !reflect.DeepEqual(d.Fields, want) {
+		for i, f := range d.Fields {
+			t.Errorf("field %d: %+v", i, f)
+		}
+	}
+
+	const design = `<card xmlns="` + BusinessCardNamespace + `" ver="1.0" layout="left" bgcolor="f0e0d0">` +
+		`<img xmlns="" align="mcenter" area="30" use="photo"/>` +
+		`<fld xmlns="" prop="name" align="left" dir="ltr" style="b" color="000080" size="10"/>` +
+		`<fld xmlns="" prop="blank" size="10"/>` +
+		`<fld xmlns="" prop="org" align="right" dir="ltr" color="000080" size="10"><label align="left" color="404040">Firma</label></fld>` +
+		`<fld xmlns="" prop="email" align="left" dir="ltr" color="000080" size="10"><label align="left" color="404040">E-Mail</label></fld>` +
+		`</card>`
+	if xml := d.DesignXml(); xml != design {
+		t.Errorf("design %s", xml)
+	}
+}
+
+func TestDecodeBusinessCardInvalid(t *testing.T) {
+	valid := businessCardTestSeed()
+	withByte := func(offset int, v byte) []byte {
+		b := append([]byte{}, valid...)
+		b[offset] = v
+		return b
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"truncated header", valid[:businessCardHeaderSize-1]},
+		{"more fields than the data", withByte(3, 0xFF)},
+		{"field size too small", withByte(4, businessCardFieldMinSize-1)},
+		{"truncated field", valid[:businessCardHeaderSize+4*businessCardFieldMinSize-1]},
+	}
+	for _, tt := range tests {
+		if _, err := DecodeBusinessCardDisplayDefinition(tt.data); err != ErrInvalidBusinessCard {
+			t.Errorf("%s: error %v, want %v", tt.name, err, ErrInvalidBusinessCard)
+		}
+	}
+
+	// an ExtraInfo size larger than the data: the labels in the data are kept
+	if d, err := DecodeBusinessCardDisplayDefinition(withByte(5, 0xFF)); err != nil || d.Fields[3].Label != "E-Mail" {
+		t.Errorf("ExtraInfo larger than the data: %v", err)
+	}
+
+	// labels outside the ExtraInfo or without terminating null are dropped
+	tests = []struct {
+		name string
+		data []byte
+	}{
+		{"ExtraInfo smaller than the labels", withByte(5, 4)},
+		{"no ExtraInfo", valid[:businessCardHeaderSize+4*businessCardFieldMinSize]},
+		{"label offset outside the ExtraInfo", withByte(businessCardHeaderSize+2*businessCardFieldMinSize+6, 0xF0)},
+	}
+	for _, tt := range tests {
+		d, err := DecodeBusinessCardDisplayDefinition(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if d.Fields[2].Label != "" {
+			t.Errorf("%s: label %q", tt.name, d.Fields[2].Label)
+		}
+	}
+}
+
+func TestBusinessCardDesignXmlEscape(t *testing.T) {
+	b := businessCardTestDefinition(businessCardTestField{MapiPidTagCompanyName, 0, `"/><fld prop="x"/>&amp;` + "\x01\uffff"})
+	d, err := DecodeBusinessCardDisplayDefinition(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkBusinessCardXml(t, d)
+
+	var card struct {
+		Fields []struct {
+			Prop  string `xml:"prop,attr"`
+			Label string `xml:"label"`
+		} `xml:"fld"`
+	}
+	if err := xml.Unmarshal([]byte(d.DesignXml()), &card); err != nil {
+		t.Fatal(err)
+	}
+	if len(card.Fields) != 1 || card.Fields[0].Prop != "org" || card.Fields[0].Label != `"/><fld prop="x"/>&amp;`+"\ufffd\ufffd" {
+		t.Errorf("%+v", card)
+	}
+}
+
+func TestRenderBusinessCard(t *testing.T) {
+	tObj := &TnefObject{}
+	if _, err := tObj.RenderBusinessCard(); err != ErrNoBusinessCard {
+		t.Errorf("no definition: error %v", err)
+	}
+
+	tObj.SetAttribute(NewMapiStringAttribute(MapiPidTagDisplayName, "Jane Doe"))
+	tObj.SetAttribute(NewMapiStringAttribute(MapiPidTagCompanyName, "Example GmbH"))
+	tObj.SetAttribute(NewMapiBinaryAttribute(0, businessCardTestSeed()).Named(PsetidAddress, MapiPidLidBusinessCardDisplayDefinition))
+	card, err := tObj.RenderBusinessCard()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if card.Bounds().Dx() != BusinessCardWidth || card.Bounds().Dy() != BusinessCardHeight {
+		t.Errorf("size %v", card.Bounds())
+	}
+	if c := card.RGBAAt(BusinessCardWidth-1, BusinessCardHeight-1); c != (color.RGBA{0xF0, 0xE0, 0xD0, 255}) {
+		t.Errorf("background %v", c)
+	}
+	if png, err := tObj.RenderBusinessCardPng(); err != nil || !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Errorf("PNG: %v", err)
+	}
+}
+
+/**
+ * a PNG of the given size: a red 2x2 image whose header declares the size (the pixels are not decoded by DecodeConfig)
+ */
+func businessCardTestPng(width int, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{255, 0, 0, 255}}, image.Point{}, draw.Src)
+	buf := &bytes.Buffer{}
+	png.Encode(buf, img)
+	b := buf.Bytes()
+
+	// signature (8), IHDR length and type (8), width, height; the CRC follows the 13 bytes of IHDR data
+	binary.BigEndian.PutUint32(b[16:20], uint32(width))
+	binary.BigEndian.PutUint32(b[20:24], uint32(height))
+	binary.BigEndian.PutUint32(b[29:33], crc32.ChecksumIEEE(b[12:29]))
+	return b
+}
+
+func TestRenderBusinessCardPhotoSize(t *testing.T) {
+	for _, test := range []struct {
+		width, height int
+		drawn         bool
+	}{
+		{2, 2, true},
+		{100000, 100000, false},
+		{1 << 30, 1, false},
+	} {
+		tObj := &TnefObject{}
+		tObj.SetAttribute(NewMapiBinaryAttribute(0, businessCardTestSeed()).Named(PsetidAddress, MapiPidLidBusinessCardDisplayDefinition))
+		photo := NewAttachment()
+		photo.SetAttribute(NewMapiBoolAttribute(MapiPidTagAttachmentContactPhoto, true))
+		photo.SetData(businessCardTestPng(test.width, test.height))
+		tObj.Attachments = append(tObj.Attachments, photo)
+
+		d := tObj.GetBusinessCardDisplayDefinition()
+		if img := tObj.businessCardImage(d); (img != nil) != test.drawn {
+			t.Errorf("%dx%d: image %v, want drawn %v", test.width, test.height, img != nil, test.drawn)
+		}
+		if _, err := tObj.RenderBusinessCard(); err != nil {
+			t.Errorf("%dx%d: %v", test.width, test.height, err)
+		}
+	}
+}
+
+func TestDecodeBusinessCardMalformed(t *testing.T) {
+	checkMalformed(t, func(b []byte) { decodeBusinessCard(t, b) }, businessCardTestSeed())
+}
+
+func FuzzDecodeBusinessCard(f *testing.F) {
+	fuzzDecoder(f, func(b []byte) {
+		if d, err := DecodeBusinessCardDisplayDefinition(b); err == nil {
+			d.DesignXml()
+		}
+	}, businessCardTestSeed())
+}
+
+/**
+ * decode the definition; the design XML must be well-formed whatever the labels
+ */
+func decodeBusinessCard(t *testing.T, data []byte) {
+	if d, err := DecodeBusinessCardDisplayDefinition(data); err == nil {
+		checkBusinessCardXml(t, d)
+		for _, f := range d.Fields {
+			f.align()
+			f.style()
+		}
+	}
+}
+
+func checkBusinessCardXml(t *testing.T, d *BusinessCardDisplayDefinition) {
+	t.Helper()
+	decoder := xml.NewDecoder(bytes.NewReader([]byte(d.DesignXml())))
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			return
+		} else if err != nil {
+			t.Fatalf("design %q: %v", d.DesignXml(), err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+/**
+ * code page conversions for 8-bit strings (PtypString8 MAPI properties and TNEF string attributes)
+ * the code page of the 8-bit strings is given by the attOemCodepage attribute
+ */
+
+package tnefdecoder
+
+import (
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+/**
+ * Windows code page identifiers
+ */
+const (
+	CodepageUsAscii = 20127
+	CodepageUtf8    = 65001
+)
+
+/**
+ * supported Windows code pages
+ */
+var codepageEncodings = map[int]encoding.Encoding{
+	437:   charmap.CodePage437,
+	850:   charmap.CodePage850,
+	852:   charmap.CodePage852,
+	855:   charmap.CodePage855,
+	858:   charmap.CodePage858,
+	860:   charmap.CodePage860,
+	862:   charmap.CodePage862,
+	863:   charmap.CodePage863,
+	865:   charmap.CodePage865,
+	866:   charmap.CodePage866,
+	874:   charmap.Windows874,
+	932:   japanese.ShiftJIS,
+	936:   simplifiedchinese.GBK,
+	949:   korean.EUCKR,
+	950:   traditionalchinese.Big5,
+	1250:  charmap.Windows1250,
+	1251:  charmap.Windows1251,
+	1252:  charmap.Windows1252,
+	1253:  charmap.Windows1253,
+	1254:  charmap.Windows1254,
+	1255:  charmap.Windows1255,
+	1256:  charmap.Windows1256,
+	1257:  charmap.Windows1257,
+	1258:  charmap.Windows1258,
+	10000: charmap.Macintosh,
+	10007: charmap.MacintoshCyrillic,
+	20866: charmap.KOI8R,
+	21866: charmap.KOI8U,
+	28591: charmap.ISO8859_1,
+	28592: charmap.ISO8859_2,
+	28593: charmap.ISO8859_3,
+	28594: charmap.ISO8859_4,
+	28595: charmap.ISO8859_5,
+	28596: charmap.ISO8859_6,
+	28597: charmap.ISO8859_7,
+	28598: charmap.ISO8859_8,
+	28599: charmap.ISO8859_9,
+	28603: charmap.ISO8859_13,
+	28605: charmap.ISO8859_15,
+	50220: japanese.ISO2022JP,
+	50221: japanese.ISO2022JP,
+	50222: japanese.ISO2022JP,
+	51932: japanese.EUCJP,
+	51949: korean.EUCKR,
+	52936: simplifiedchinese.HZGB2312,
+	54936: simplifiedchinese.GB18030,
+}
+
+/**
+ * the 7-bit code pages switching the character set with escape sequences: their ASCII bytes must be decoded
+ */
+var statefulCodepages = map[int]bool{
+	50220: true,
+	50221: true,
+	50222: true,
+	52936: true,
+}
+
+/**
+ * convert an 8-bit string from the given Windows code page to UTF-8
+ * if the code page is unknown (or 0) the bytes are returned unchanged
+ */
+func DecodeCodepage(b []byte, codepage int) string {
+	if codepage == CodepageUtf8 || codepage == CodepageUsAscii {
+		return string(b)
+	}
+
+	enc, ok := codepageEncodings[codepage]
+	if !ok {
+		return string(b)
+	}
+
+	if isAscii(b) && !statefulCodepages[codepage] {
+		return string(b)
+	}
+
+	result, err := enc.NewDecoder().Bytes(b)
+	if err != nil {
+		return string(b)
+	}
+	return string(result)
+}
+
+func isAscii(b []byte) bool {
+	for _, c := range b {
+		if c >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,27 @@
+package tnefdecoder
+
+import "testing"
+
+func TestDecodeCodepage(t *testing.T) {
+	tests := []struct {
+		data     string
+		codepage int
+		want     string
+	}{
+		{"plain ascii", 1252, "plain ascii"},
+		{"caf\xe9", 1252, "café"},
+		{"\x8e\xa9", 1250, "Ž©"},
+		{"\x82\xb1\x82\xf1\x82\xc9\x82\xbf\x82\xcd", 932, "こんにちは"},
+		{"\x1b$B$3$s$K$A$O\x1b(B", 50220, "こんにちは"},
+		{"Re: \x1b$B$3$s$K$A$O\x1b(B", 50221, "Re: こんにちは"},
+		{"~{VP~}", 52936, "中"},
+		{"caf\xc3\xa9", CodepageUtf8, "café"},
+		{"caf\xe9", 0, "caf\xe9"},
+		{"caf\xe9", 12345, "caf\xe9"},
+	}
+	for _, test := range tests {
+		if got := DecodeCodepage([]byte(test.data), test.codepage); got != test.want {
+			t.Errorf("%q (%d): %q, want %q", test.data, test.codepage, got, test.want)
+		}
+	}
+}
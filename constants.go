@@ -167,12 +167,14 @@ const (
 	MapiPidTagAttachEncoding = 0x3702 //TAG Type: 258 (0x0102) -> PidTagAttachEncoding | value: empty!!?? ->  If the attachment is in MacBinary format, this property is set to "{0x2A,86,48,86,F7,14,03,0B,01}"; otherwise, it is unset.
 	MapiPidTagAttachExtension = 0x3703 // TAG Type: 30 (0x001e) -> PidTagAttachExtension (type: 0x001e) | value: .jpg
 	MapiPidTagAttachMethod = 0x3705 //TAG Type: 3 (0x0003) -> PidTagAttachMethod | value: 1
+	MapiPidTagAttachRendering = 0x3709 // binary - Contains a Windows Metafile (WMF) with the icon of the attachment or the preview of an OLE object (mapped to attAttachMetaFile)
 	MapiPidTagAttachLongFilename = 0x3707 // TAG Type: 30 (0x1e) -> PidTagAttachLongFilename (0x001F) | value: image001.jpg - (string) Contains the full filename and extension of the Attachment object.
 	MapiPidTagAttachFilename =  0x3704 // string -contains the 8.3 name of the filename
 
 	MapiPidTagRenderingPosition = 0x370b // TAG Type: 3 (0x0003) -> PidTagRenderingPosition | value: -1 (-1 e de fapt 0xffffff, decoded as signed) ->  0xFFFFFFFF indicates a hidden attachment that is not to be rendered in the main text
 	MapiPidTagAttachMimeTag = 0x370e // TAG Type: 30 (0x001e) -> PidTagAttachMimeTag | value: image/jpeg  (string) - Contains a content-type MIME header.
 	MapiPidTagAttachFlags = 0x3714 // TAG Type: 3 (0x3) -> PidTagAttachFlags | value: 4 (4 means attRenderedInBody)
+	MapiPidTagExceptionReplaceTime = 0x7ff9 // PtypTime - PidTagExceptionReplaceTime: the original start (UTC) of the occurrence replaced by an exception attachment
 	MapiPidTagAttachmentLinkId = 0x7ffa //, TAG Type: 3 (0x3) -> PidTagAttachmentLinkId| value: 0 (must be 0, if is not overwriten)
 	MapiPidTagExceptionStartTime = 0x7ffb // TAG Type: 64 (0x0040) ->	PidTagExceptionStartTime|value: 915151392000000000
 	MapiPidTagExceptionEndTime =  0x7ffc // TAG Type: 64 (0x40) -> PidTagExceptionEndTime | value: 915151392000000000
@@ -258,9 +260,361 @@ const (
 	MapiPidTagBusinessHomePage = 0x3A51 //string
 	MapiPidTagSensitivity = 0x0036 // int32
 	MapiPidLidBusinessCardDisplayDefinition = 0x00008040 // binary -> decoding info [MS-OXOCNTC] 2.2.1.7.1
+	MapiPidLidBusinessCardCardPicture = 0x8041 // PSETID_Address - binary - the image of the business card (PNG)
+	MapiPidLidFileUnder = 0x8005 // PSETID_Address - string - the name under which the contact is filed
+	MapiPidLidHomeAddress = 0x801A // PSETID_Address - string - the full home address
+	MapiPidLidWorkAddress = 0x801B // PSETID_Address - string - the full work address
+	MapiPidLidOtherAddress = 0x801C // PSETID_Address - string - the full other address
 	MapiPidLidFreeBusyLocation = 0x000080D8 //string
 	MapiPidLidHasPicture = 0x00008015 // bool - The PidLidHasPicture property ([MS-OXPROPS] section 2.144) indicates whether a contact photo attachment, specified in section 2.2.1.8.3, exists. If this property is set to nonzero (TRUE), then the contact photo attachment exists and the client uses it as the contact photo.
 	MapiPidTagLastModificationTime = 0x3008 //PT_SYSTIME -> int64
+	MapiPidTagWeddingAnniversary = 0x3A41 // PtypTime - the wedding anniversary of the contact
+	MapiPidTagGender = 0x3A4D // int16 - 0 = unspecified, 1 = female, 2 = male
+	MapiPidLidInstantMessagingAddress = 0x8062 // PSETID_Address - string - the instant messaging address of the contact
+	MapiPidTagTitle = 0x3A17 // string - the job title of the contact
+	MapiPidTagOfficeLocation = 0x3A19 // string
+	MapiPidTagSpouseName = 0x3A48 // string
+	MapiPidTagManagerName = 0x3A4E // string
+	MapiPidTagAssistant = 0x3A30 // string - the name of the assistant
+	MapiPidTagChildrensNames = 0x3A58 // multiple string
+	MapiPidTagUserX509Certificate = 0x3A70 // multiple binary - the certificates of the contact
+	MapiPidTagPrimaryTelephoneNumber = 0x3A1A // string
+	MapiPidTagMobileTelephoneNumber = 0x3A1C // string
+	MapiPidTagRadioTelephoneNumber = 0x3A1D // string
+	MapiPidTagCarTelephoneNumber = 0x3A1E // string
+	MapiPidTagPagerTelephoneNumber = 0x3A21 // string
+	MapiPidTagPrimaryFaxNumber = 0x3A23 // string
+	MapiPidTagBusinessFaxNumber = 0x3A24 // string
+	MapiPidTagHomeFaxNumber = 0x3A25 // string
+	MapiPidTagTelexNumber = 0x3A2C // string
+	MapiPidTagIsdnNumber = 0x3A2D // string
+	MapiPidTagAssistantTelephoneNumber = 0x3A2E // string
+	MapiPidTagCallbackTelephoneNumber = 0x3A02 // string
+	MapiPidTagTelecommunicationsDeviceForDeafTelephoneNumber = 0x3A4B // string
+	MapiPidTagCompanyMainTelephoneNumber = 0x3A57 // string
+	MapiPidLidEmail1DisplayName = 0x8080 // PSETID_Address - string
+	MapiPidLidEmail1AddressType = 0x8082 // PSETID_Address - string
+	MapiPidLidEmail2DisplayName = 0x8090 // PSETID_Address - string
+	MapiPidLidEmail2AddressType = 0x8092 // PSETID_Address - string
+	MapiPidLidEmail3DisplayName = 0x80A0 // PSETID_Address - string
+	MapiPidLidEmail3AddressType = 0x80A2 // PSETID_Address - string
+	MapiPidLidEmail1OriginalDisplayName = 0x8084 // PSETID_Address - string
+	MapiPidLidEmail1OriginalEntryId = 0x8085 // PSETID_Address - binary - the One-Off EntryID of the address
+	MapiPidLidEmail2OriginalDisplayName = 0x8094 // PSETID_Address - string
+	MapiPidLidEmail2OriginalEntryId = 0x8095 // PSETID_Address - binary
+	MapiPidLidEmail3OriginalDisplayName = 0x80A4 // PSETID_Address - string
+	MapiPidLidEmail3OriginalEntryId = 0x80A5 // PSETID_Address - binary
+	MapiPidLidAddressBookProviderEmailList = 0x8028 // PSETID_Address - multiple int32 - the email addresses of the contact shown in the address book
+	MapiPidLidAddressBookProviderArrayType = 0x8029 // PSETID_Address - int32 - bit field of the email addresses set
 
  )
 
+
+/**
+ * PidTagAttachMethod values ([MS-OXCMSG] section 2.2.2.9)
+ */
+const (
+	AttachMethodNone = 0x00000000 // the attachment has just been created
+	AttachMethodByValue = 0x00000001 // the data is in PidTagAttachDataBinary
+	AttachMethodByReference = 0x00000002 // PidTagAttachLongPathname / PidTagAttachPathname contains a fully qualified path identifying the attachment
+	AttachMethodByReferenceResolve = 0x00000003 // obsolete: same as by reference, the path is resolved by the client
+	AttachMethodByReferenceOnly = 0x00000004 // the path is only accessible to the recipient, there is no data in the message
+	AttachMethodEmbeddedMessage = 0x00000005 // the attachment is an embedded message (PidTagAttachDataObject)
+	AttachMethodOle = 0x00000006 // the attachment is an OLE storage (PidTagAttachDataObject)
+	AttachMethodByWebReference = 0x00000007 // the attachment is a web reference (cloud attachment) and PidTagAttachLongPathname contains the URL
+)
+
+ /**
+  * MAPI attachment reference properties
+  */
+ const (
+	MapiPidTagAttachPathname = 0x3708 // string - Contains the 8.3 name of the PidTagAttachLongPathname property
+	MapiPidTagAttachLongPathname = 0x370D // string - Contains the fully qualified path and file name with extension (the URL for web references)
+ )
+
+/**
+ * property sets of the named properties ([MS-OXPROPS] section 1.3.2)
+ */
+const (
+	PsetidAttachment = "96357F7F-59E1-47D0-99A7-46515C183B54"
+	PsPublicStrings = "00020329-0000-0000-C000-000000000046"
+	PsetidAppointment = "00062002-0000-0000-C000-000000000046"
+	PsetidMeeting = "6ED8DA90-450B-101B-98DA-00AA003F1305"
+	PsetidCommon = "00062008-0000-0000-C000-000000000046"
+	PsetidTask = "00062003-0000-0000-C000-000000000046"
+	PsetidAddress = "00062004-0000-0000-C000-000000000046"
+	PsInternetHeaders = "00020386-0000-0000-C000-000000000046" // the string name of the properties is the name of an internet header
+)
+
+/**
+ * string named properties (PropMapValue) from PSETID_Attachment
+ */
+const (
+	NamedAttachmentProviderType = "AttachmentProviderType" // PidNameAttachmentProviderType - string - the web service that hosts the attachment (ex: OneDrivePro, OneDriveConsumer, Dropbox, Box)
+	NamedAttachmentPermissionType = "AttachmentPermissionType" // PidNameAttachmentPermissionType - int32 - the permission given to the recipients
+	NamedAttachmentOriginalPermissionType = "AttachmentOriginalPermissionType" // PidNameAttachmentOriginalPermissionType - int32 - the permission the attachment had when it was attached
+)
+
+/**
+ * PidNameAttachmentPermissionType / PidNameAttachmentOriginalPermissionType values
+ */
+const (
+	AttachmentPermissionNone = 0
+	AttachmentPermissionView = 1
+	AttachmentPermissionEdit = 2
+)
+
+/**
+ * MAPI message envelope properties
+ */
+const (
+	MapiPidTagSubject = 0x0037 // string - PidTagSubject property ([MS-OXCMSG] section 2.2.1.46) contains the full subject
+	MapiPidTagClientSubmitTime = 0x0039 // PtypTime - PidTagClientSubmitTime property ([MS-OXOMSG] section 2.2.3.11) the time the sender submitted the message
+	MapiPidTagImportance = 0x0017 // int32 - PidTagImportance: 0 = low, 1 = normal, 2 = high
+	MapiPidTagResponseRequested = 0x0063 // bool - PidTagResponseRequested: a response is requested (meeting requests)
+	MapiPidTagReplyRequested = 0x0C17 // bool - PidTagReplyRequested: a reply is requested
+	MapiPidTagStartDate = 0x0060 // PtypTime - PidTagStartDate: the start of the appointment (UTC)
+	MapiPidTagEndDate = 0x0061 // PtypTime - PidTagEndDate: the end of the appointment (UTC)
+
+	MapiPidTagSenderName = 0x0C1A // string - PidTagSenderName
+	MapiPidTagSenderAddressType = 0x0C1E // string - PidTagSenderAddressType
+	MapiPidTagSenderEmailAddress = 0x0C1F // string - PidTagSenderEmailAddress
+	MapiPidTagSenderSmtpAddress = 0x5D01 // string - PidTagSenderSmtpAddress
+	MapiPidTagSentRepresentingName = 0x0042 // string - PidTagSentRepresentingName: the user on whose behalf the message was sent
+	MapiPidTagSentRepresentingAddressType = 0x0064 // string - PidTagSentRepresentingAddressType
+	MapiPidTagSentRepresentingEmailAddress = 0x0065 // string - PidTagSentRepresentingEmailAddress
+	MapiPidTagSentRepresentingSmtpAddress = 0x5D02 // string - PidTagSentRepresentingSmtpAddress
+	MapiPidTagSenderEntryId = 0x0C19 // binary - PidTagSenderEntryId
+	MapiPidTagSentRepresentingEntryId = 0x0041 // binary - PidTagSentRepresentingEntryId
+	MapiPidTagSenderSearchKey = 0x0C1D // binary - PidTagSenderSearchKey: ADDRTYPE:ADDRESS (upper case)
+	MapiPidTagSentRepresentingSearchKey = 0x003B // binary - PidTagSentRepresentingSearchKey
+	MapiPidTagReceivedRepresentingName = 0x0044 // string - PidTagReceivedRepresentingName: the user on whose behalf the message was received (delegate)
+	MapiPidTagReceivedRepresentingAddressType = 0x0077 // string - PidTagReceivedRepresentingAddressType
+	MapiPidTagReceivedRepresentingEmailAddress = 0x0078 // string - PidTagReceivedRepresentingEmailAddress
+	MapiPidTagReceivedRepresentingSmtpAddress = 0x5D08 // string - PidTagReceivedRepresentingSmtpAddress
+	MapiPidTagReceivedRepresentingEntryId = 0x0043 // binary - PidTagReceivedRepresentingEntryId
+	MapiPidTagReceivedRepresentingSearchKey = 0x0052 // binary - PidTagReceivedRepresentingSearchKey
+)
+
+/**
+ * MAPI recipient properties (attRecipTable rows)
+ */
+const (
+	MapiPidTagRecipientType = 0x0C15 // int32 - PidTagRecipientType ([MS-OXOMSG] section 2.2.3.1): 1 = To, 2 = Cc, 3 = Bcc (for meetings: required, optional, resource)
+	MapiPidTagAddressType = 0x3002 // string - PidTagAddressType: the type of the email address (SMTP, EX, ...)
+	MapiPidTagEmailAddress = 0x3003 // string - PidTagEmailAddress: the email address (in the format given by PidTagAddressType)
+	MapiPidTagSmtpAddress = 0x39FE // string - PidTagSmtpAddress: the SMTP address
+	MapiPidTagRecipientEntryId = 0x5FF7 // binary - PidTagRecipientEntryId: the entry ID of the recipient
+	MapiPidTagRecipientFlags = 0x5FFD // int32 - PidTagRecipientFlags ([MS-OXOCAL] section 2.2.4.10.1): 0x00000002 = meeting organizer
+	MapiPidTagRecipientTrackStatus = 0x5FFF // int32 - PidTagRecipientTrackStatus ([MS-OXOCAL] section 2.2.4.10.2): the response of the attendee
+	MapiPidTagEntryId = 0x0FFF // binary - PidTagEntryId
+)
+
+/**
+ * PidTagRecipientType values
+ */
+const (
+	RecipientTypeOriginator = 0x00000000
+	RecipientTypeTo = 0x00000001
+	RecipientTypeCc = 0x00000002
+	RecipientTypeBcc = 0x00000003
+)
+
+/**
+ * PidTagRecipientFlags bits
+ */
+const (
+	RecipientFlagSendable = 0x00000001
+	RecipientFlagOrganizer = 0x00000002
+)
+
+/**
+ * calendar named properties (LIDs); PSETID_Appointment unless specified otherwise ([MS-OXOCAL] section 2.2)
+ */
+const (
+	MapiPidLidAppointmentSequence = 0x8201 // int32 - the sequence number of the meeting
+	MapiPidLidBusyStatus = 0x8205 // int32 - the availability of the user: 0 free, 1 tentative, 2 busy, 3 out of office, 4 working elsewhere
+	MapiPidLidLocation = 0x8208 // string - the location of the event
+	MapiPidLidAppointmentStartWhole = 0x820D // PtypTime - start date and time (UTC)
+	MapiPidLidAppointmentEndWhole = 0x820E // PtypTime - end date and time (UTC)
+	MapiPidLidAppointmentDuration = 0x8213 // int32 - duration in minutes
+	MapiPidLidAppointmentSubType = 0x8215 // bool - all day event
+	MapiPidLidAppointmentRecur = 0x8216 // binary - the recurrence pattern (AppointmentRecurrencePattern)
+	MapiPidLidAppointmentStateFlags = 0x8217 // int32 - asfMeeting 0x01, asfReceived 0x02, asfCanceled 0x04
+	MapiPidLidResponseStatus = 0x8218 // int32 - the response of the attendee
+	MapiPidLidRecurring = 0x8223 // bool - the event is recurring
+	MapiPidLidIntendedBusyStatus = 0x8224 // int32 - the busy status the organizer intended (meeting requests)
+	MapiPidLidExceptionReplaceTime = 0x8228 // PtypTime - the original start of an exception (RECURRENCE-ID)
+	MapiPidLidTimeZoneStruct = 0x8233 // binary - TZSTRUCT: the time zone of the recurrence
+	MapiPidLidTimeZoneDescription = 0x8234 // string - the display name of the time zone
+	MapiPidLidAppointmentTimeZoneDefinitionStartDisplay = 0x825E // binary - TZDEFINITION: the time zone of the start
+	MapiPidLidAppointmentTimeZoneDefinitionEndDisplay = 0x825F // binary - TZDEFINITION: the time zone of the end
+	MapiPidLidAppointmentTimeZoneDefinitionRecur = 0x8260 // binary - TZDEFINITION: the time zone of the recurrence
+	MapiPidLidAppointmentReplyTime = 0x8220 // PtypTime - when the attendee replied to the meeting request
+	MapiPidLidFInvited = 0x8229 // bool - a meeting request has been sent to the attendees
+	MapiPidLidAppointmentReplyName = 0x8230 // string - the name of the attendee who replied
+	MapiPidLidRecurrenceType = 0x8231 // int32 - 0 none, 1 daily, 2 weekly, 3 monthly, 4 yearly
+	MapiPidLidClipStart = 0x8235 // PtypTime - the start of the first instance (midnight, local time, for the recurring appointments)
+	MapiPidLidClipEnd = 0x8236 // PtypTime - the end of the last instance (midnight, local time, for the recurring appointments)
+	MapiPidLidToAttendeesString = 0x823B // string - the names of the required attendees, separated by "; "
+	MapiPidLidCcAttendeesString = 0x823C // string - the names of the optional attendees, separated by "; "
+
+	MapiPidLidAttendeeCriticalChange = 0x0001 // PSETID_Meeting - PtypTime - when the meeting request was sent (DTSTAMP)
+	MapiPidLidGlobalObjectId = 0x0003 // PSETID_Meeting - binary - the unique identifier of the meeting (and the instance)
+	MapiPidLidCleanGlobalObjectId = 0x0023 // PSETID_Meeting - binary - the unique identifier of the meeting (without instance date)
+	MapiPidLidWhere = 0x0002 // PSETID_Meeting - string - the location of the meeting
+	MapiPidLidIsRecurring = 0x0005 // PSETID_Meeting - bool - the meeting is recurring
+	MapiPidLidIsException = 0x000A // PSETID_Meeting - bool - the object is an exception of a recurring meeting
+	MapiPidLidOwnerCriticalChange = 0x001A // PSETID_Meeting - PtypTime - when the meeting was last changed by the organizer
+	MapiPidLidMeetingType = 0x0026 // PSETID_Meeting - int32 - the type of the meeting request (MeetingType*)
+)
+
+/**
+ * PidLidAppointmentStateFlags bits
+ */
+const (
+	AppointmentStateMeeting = 0x00000001
+	AppointmentStateReceived = 0x00000002
+	AppointmentStateCanceled = 0x00000004
+)
+
+/**
+ * PidLidMeetingType values
+ */
+const (
+	MeetingTypeRequest = 0x00000001 // mtgRequest: a new meeting request
+	MeetingTypeFull = 0x00010000 // mtgFull: a full update of a meeting
+	MeetingTypeInfo = 0x00020000 // mtgInfo: an informational update of a meeting
+)
+
+/**
+ * PidLidBusyStatus values
+ */
+const (
+	BusyStatusFree = 0
+	BusyStatusTentative = 1
+	BusyStatusBusy = 2
+	BusyStatusOutOfOffice = 3
+	BusyStatusWorkingElsewhere = 4
+)
+
+/**
+ * task named properties (LIDs); PSETID_Task unless specified otherwise ([MS-OXOTASK] section 2.2.2.2)
+ */
+const (
+	MapiPidLidTaskStatus = 0x8101 // int32 - not started, in progress, complete, waiting on other, deferred
+	MapiPidLidPercentComplete = 0x8102 // float64 - 0.0 - 1.0
+	MapiPidLidTeamTask = 0x8103 // bool
+	MapiPidLidTaskStartDate = 0x8104 // PtypTime - the start date (midnight, local time)
+	MapiPidLidTaskDueDate = 0x8105 // PtypTime - the due date (midnight, local time)
+	MapiPidLidTaskDateCompleted = 0x810F // PtypTime - the date when the task was completed
+	MapiPidLidTaskState = 0x8113 // int32
+	MapiPidLidTaskLastUpdate = 0x8115 // PtypTime - the last change of the task
+	MapiPidLidTaskRecurrence = 0x8116 // binary - RecurrencePattern
+	MapiPidLidTaskComplete = 0x811C // bool - the task is completed
+	MapiPidLidTaskOwner = 0x811F // string - the name of the owner
+	MapiPidLidTaskAssigner = 0x8121 // string - the name of the user who assigned the task
+	MapiPidLidTaskFRecurring = 0x8126 // bool - the task is recurring
+	MapiPidLidTaskOwnership = 0x8129 // int32 - 0 not assigned, 1 assigned by the user, 2 assigned to the user
+	MapiPidLidTaskAcceptanceState = 0x812A // int32
+
+	MapiPidLidTaskMode = 0x8518 // PSETID_Common - int32 - the assignment status of the embedded task
+	MapiPidLidTaskGlobalId = 0x8519 // PSETID_Common - binary - the unique identifier of the task (GUID)
+)
+
+/**
+ * reminder named properties (LIDs) from PSETID_Common ([MS-OXORMDR] section 2.2.1)
+ */
+const (
+	MapiPidLidReminderDelta = 0x8501 // int32 - the number of minutes before the start of the appointment when the reminder is signaled
+	MapiPidLidReminderTime = 0x8502 // PtypTime - the start of the appointment, the due date of the task or the follow-up date of the message
+	MapiPidLidReminderSet = 0x8503 // bool - a reminder is set on the object
+	MapiPidLidReminderSignalTime = 0x8560 // PtypTime - when the reminder is signaled (next signal for the recurring objects)
+)
+
+/**
+ * follow-up flag properties ([MS-OXOFLAG] section 2.2.1)
+ */
+const (
+	MapiPidTagReplyTime = 0x0030 // PtypTime - PidTagReplyTime: the date by which a reply is expected
+	MapiPidTagFlagStatus = 0x1090 // int32 - PidTagFlagStatus: 0 = not flagged, 1 = complete, 2 = flagged
+	MapiPidTagFlagCompleteTime = 0x1091 // PtypTime - PidTagFlagCompleteTime: when the flag was marked complete
+	MapiPidLidFlagRequest = 0x8530 // PSETID_Common - string - the requested action (ex: Follow up, Reply, Call)
+
+	NamedKeywords = "Keywords" // PS_PUBLIC_STRINGS - string array - PidNameKeywords: the categories of the object
+)
+
+/**
+ * PidTagFlagStatus values
+ */
+const (
+	FlagStatusNone = 0
+	FlagStatusComplete = 1
+	FlagStatusFlagged = 2
+)
+
+/**
+ * voting named properties (LIDs) from PSETID_Common ([MS-OXOMSG] sections 2.2.1.73 - 2.2.1.74)
+ */
+const (
+	MapiPidLidVerbStream = 0x8520 // binary - the verbs of the message: the standard replies and the voting options (VerbStream)
+	MapiPidLidVerbResponse = 0x8524 // string - the voting option chosen in a reply
+)
+
+/**
+ * report properties: read receipts, delivery and non-delivery reports ([MS-OXOMSG] section 2.2.2)
+ */
+const (
+	MapiPidTagDeliverTime = 0x0010 // PtypTime - PidTagDeliverTime: when the message was delivered to the recipient
+	MapiPidTagReportTime = 0x0032 // PtypTime - PidTagReportTime: when the report was generated
+	MapiPidTagOriginalSubject = 0x0049 // string - PidTagOriginalSubject: the subject of the message the report is about
+	MapiPidTagOriginalSubmitTime = 0x004E // PtypTime - PidTagOriginalSubmitTime: when the original message was submitted
+	MapiPidTagNonDeliveryReportReasonCode = 0x0C04 // int32 - PidTagNonDeliveryReportReasonCode: why the message was not delivered
+	MapiPidTagNonDeliveryReportDiagCode = 0x0C05 // int32 - PidTagNonDeliveryReportDiagCode (recipients): the diagnostic of the failure
+	MapiPidTagSupplementaryInfo = 0x0C1B // string - PidTagSupplementaryInfo (recipients): the text of the remote server
+	MapiPidTagNonDeliveryReportStatusCode = 0x0C20 // int32 - PidTagNonDeliveryReportStatusCode (recipients)
+	MapiPidTagReportText = 0x1001 // string - PidTagReportText: the text of the report
+	MapiPidTagInternetMessageId = 0x1035 // string - PidTagInternetMessageId: the Message-ID of the message
+	MapiPidTagOriginalMessageId = 0x1046 // string - PidTagOriginalMessageId: the Message-ID of the message the report is about
+	MapiPidTagReportingMessageTransferAgent = 0x6820 // string - PidTagReportingMessageTransferAgent: the server that generated the report
+)
+
+/**
+ * PidTagNonDeliveryReportDiagCode values (MAPI_DIAG_*)
+ */
+const (
+	NdrDiagNoDiagnostic = -1
+	NdrDiagOrNameUnrecognized = 0
+	NdrDiagOrNameAmbiguous = 1
+	NdrDiagMtsCongested = 2
+	NdrDiagLoopDetected = 3
+	NdrDiagRecipientUnavailable = 4
+	NdrDiagMaximumTimeExpired = 5
+	NdrDiagContentTooLong = 7
+	NdrDiagTooManyRecipients = 16
+	NdrDiagExpansionFailed = 30
+	NdrDiagMailAddressIncorrect = 32
+	NdrDiagMailRecipientUnknown = 35
+)
+
+/**
+ * distribution list named properties (LIDs) from PSETID_Address ([MS-OXOCNTC] section 2.2.2.2)
+ */
+const (
+	MapiPidLidDistributionListName = 0x8053 // string - the name of the personal distribution list
+	MapiPidLidDistributionListOneOffMembers = 0x8054 // binary array - One-Off EntryIDs of the members (same order as PidLidDistributionListMembers)
+	MapiPidLidDistributionListMembers = 0x8055 // binary array - WrappedEntryIds of the members
+)
+
+/**
+ * MAPI properties of the internet headers ([MS-OXCMAIL] section 2.2.3.2)
+ */
+const (
+	MapiPidTagTransportMessageHeaders = 0x007D // string - PidTagTransportMessageHeaders: the header of the message as received
+	MapiPidTagConversationIndex = 0x0071 // binary - PidTagConversationIndex: the Thread-Index header (base64 encoded)
+	MapiPidTagInternetReferences = 0x1039 // string - PidTagInternetReferences: the References header
+	MapiPidTagInReplyToId = 0x1042 // string - PidTagInReplyToId: the In-Reply-To header
+	MapiPidTagListHelp = 0x1043 // string - PidTagListHelp: the List-Help header
+	MapiPidTagListSubscribe = 0x1044 // string - PidTagListSubscribe: the List-Subscribe header
+	MapiPidTagListUnsubscribe = 0x1045 // string - PidTagListUnsubscribe: the List-Unsubscribe header
+)
@@ -7,12 +7,20 @@ import (
 	"io/ioutil"
 	"vcard"
 	"fmt"
+	"strings"
 )
 
 
+// the default limit of nested TNEF objects (embedded messages, winmail.dat attachments)
+const DefaultMaxNestingDepth = 10
+
+var ErrMaxNestingDepth = errors.New("maximum nesting depth of TNEF objects exceeded")
+
 func NewDecoder() TnefDecoder {
 	d := TnefDecoder{}
-	d.VcardVersion = "3.0"
+	d.VcardVersion = VcardVersion3
+	d.VcardFormat = VcardFormatText
+	d.MaxNestingDepth = DefaultMaxNestingDepth
 	d.leDecoder = new(LittleEndianDecoder)
 
 	return d
@@ -20,12 +28,72 @@ func NewDecoder() TnefDecoder {
 
 
 type TnefDecoder struct {
+	// the version of the vCards generated for the contacts and the contact groups: VcardVersion3 (default) or VcardVersion4;
+	// it can be changed between two calls of Decode
 	VcardVersion string
+
+	// the format of the contact attachments: VcardFormatText (default), VcardFormatJson (jCard) or VcardFormatXml (xCard);
+	// jCard and xCard are generated from a vCard 4.0 whatever VcardVersion
+	VcardFormat string
+
+	// decode the attachments having a TNEF payload (ex: a winmail.dat attached as a file) into Attachment.Embedded
+	DecodeNestedTnef bool
+
+	// the maximum depth of the nested TNEF objects; the objects deeper than this limit are not decoded and their
+	// attachment gets ErrMaxNestingDepth in EmbeddedError; DefaultMaxNestingDepth if not set (<= 0)
+	MaxNestingDepth int
+
+	// add the voting options of the message to the HTML and text bodies (for the recipients not using Outlook); off by
+	// default, the bodies are kept as sent
+	RenderVotingButtons bool
+
+	// map the EX addresses (legacyExchangeDN) and the address-book entry IDs of the recipients, the senders, the attendees
+	// and the distribution list members to SMTP addresses (ex: LoadAddressResolver, NewLdapAddressResolver); optional
+	AddressResolver AddressResolver
+
+	// the maximum number of addresses of a message (and of each embedded message) given to the AddressResolver, the
+	// other addresses are not resolved; DefaultMaxAddressLookups if not set (<= 0)
+	MaxAddressLookups int
+
 	leDecoder *LittleEndianDecoder
 }
 
 
 
+/**
+ * create the vCard builder of the configured version (3.0 by default)
+ */
+func (d *TnefDecoder) newVCardBuilder() vcard.IVCard {
+	if d.vcardVersion() == VcardVersion4 {
+		return vcard.NewVCardV4()
+	}
+	return vcard.NewVCardV3()
+}
+
+/**
+ * the vCard version to generate: VcardVersion4 if requested or if the format is jCard / xCard, else VcardVersion3
+ */
+func (d *TnefDecoder) vcardVersion() string {
+	if d.VcardVersion == VcardVersion4 || d.VcardFormat == VcardFormatJson || d.VcardFormat == VcardFormatXml {
+		return VcardVersion4
+	}
+	return VcardVersion3
+}
+
+/**
+ * the filename of a vCard attachment: the formatted name, else vcard.vcf
+ */
+func vcardFilename(vc vcard.IVCard) string {
+	fnArr := vc.GetProperty("fn")
+	if len(fnArr) > 0 {
+		fnValue := fnArr[0].GetFirstValue()
+		if fnValue != nil && fnValue.GetValue() != "" {
+			return fnValue.GetValue() + ".vcf"
+		}
+	}
+	return "vcard.vcf"
+}
+
 /** DecodeFile is a utility function that reads the file into memory
  *  before calling the normal Decode function on the data.
  */
@@ -42,16 +110,31 @@ func (d *TnefDecoder) DecodeFile(path string) (*TnefObject, error) {
  * decode TNEF bytes
  */
  func (d *TnefDecoder) Decode(data []byte) (*TnefObject, error) {
+	return d.decode(data, 0)
+ }
+
+/**
+ * decode a TNEF object found at the given nesting depth (0 for the message)
+ */
+ func (d *TnefDecoder) decode(data []byte, depth int) (*TnefObject, error) {
 	var tAttachment *Attachment
 
-	tObj := &TnefObject{}
+	maxDepth := d.MaxNestingDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxNestingDepth
+	}
+	if depth > maxDepth {
+		return nil, ErrMaxNestingDepth
+	}
+
+	tObj := &TnefObject{AddressResolver: newMessageAddressResolver(d.AddressResolver, d.MaxAddressLookups)}
 
 	offset := 0
 	// TNEFSignature signals that the file did not start with the fixed TNEF marker,
 	// meaning it's not in the TNEF file format we recognize (e.g. it just has the
 	// .tnef extension, or a wrong MIME type).
-	if d.leDecoder.Int(data[0:4]) != TnefSignature {
-		return nil, errors.New("file did not begin with a TNEF marker")
+	if !IsTnef(data) {
+		return nil, ErrNoMarker
 	}
 	offset += 4
 
@@ -61,7 +144,12 @@ func (d *TnefDecoder) DecodeFile(path string) (*TnefObject, error) {
 	offset += 2
 
 	// TNEFVersion
-	for {
+	for offset < dataLength {
+		// Level(1) ID(4) Length(4) Data Checksum(2)
+		if dataLength - offset < 11 || d.leDecoder.Int(data[offset+5:offset+9]) > dataLength - offset - 11 {
+			return nil, errors.New("truncated TNEF attribute")
+		}
+
 		attr, noByteRead := d.DecodeAttributeStructure(data[offset:])
 
 		//fmt.Printf("\r\n ATTR: type: %s Level: %v ID: 0x%X | %v, Length: %v  => %s",  attr.Type,attr.Level, attr.Id, attr.Id, len(attr.Data), attr.Data)
@@ -80,6 +168,11 @@ func (d *TnefDecoder) DecodeFile(path string) (*TnefObject, error) {
 			 * some attributes require special decoding
 			 */
 			switch (attr.Id) {
+				case AttOEMCodepage:
+					// OemCodePage = PrimaryCodePage SecondaryCodePage ; both UINT32
+					if len(attr.Data) >= 4 {
+						tObj.Codepage = int(d.leDecoder.Uint32(attr.Data[0:4]))
+					}
 				case AttMessageID:
 					// is tnef attribute (mapped MAPI)
 				case AttMsgProps:
@@ -90,12 +183,20 @@ func (d *TnefDecoder) DecodeFile(path string) (*TnefObject, error) {
 					}
 				case AttRecipTable:
 					// recipient table
+					recipients, err := d.DecodeRecipientTable(attr.Data)
+					if err == nil {
+						for _, recipient := range recipients {
+							recipient.Codepage = tObj.Codepage
+							recipient.AddressResolver = tObj.AddressResolver
+						}
+						tObj.Recipients = append(tObj.Recipients, recipients...)
+					}
 				default:
 					tObj.Attributes = append(tObj.Attributes, attr)
 			}
 
-		} else if  (attr.Level == AttrLevelAttachment) {
-			// attachment attributes
+		} else if  (attr.Level == AttrLevelAttachment && (tAttachment != nil || attr.Id == AttAttachRendData)) {
+			// attachment attributes (the attributes found before the first attAttachRendData are ignored)
 
 			/**
 			 * Each set of attachment attributes MUST begin with the attAttachRendData attribute, followed by any
@@ -118,6 +219,8 @@ func (d *TnefDecoder) DecodeFile(path string) (*TnefObject, error) {
 					FileDataMacBinary=%x01.00.00.00
 					*/
 					tAttachment = NewAttachment()
+					tAttachment.Codepage = tObj.Codepage
+					tAttachment.AddressResolver = tObj.AddressResolver
 					tObj.Attachments = append(tObj.Attachments, tAttachment)
 
 					// it's not required, the property seems to be a summary of some other MAPI Attributes; we should find all info in decoded mapi attributes
@@ -138,7 +241,7 @@ func (d *TnefDecoder) DecodeFile(path string) (*TnefObject, error) {
 
 					pidTagAttachMethodAttr := tAttachment.GetAttribute(MapiPidTagAttachMethod, "mapi")
 
-					if pidTagAttachMethodAttr != nil && (pidTagAttachMethodAttr.GetIntValue() == 5 || pidTagAttachMethodAttr.GetIntValue() == 6) {
+					if pidTagAttachMethodAttr != nil && (pidTagAttachMethodAttr.GetIntValue() == AttachMethodEmbeddedMessage || pidTagAttachMethodAttr.GetIntValue() == AttachMethodOle) {
 
 						// decode VCARD
 						binaryDataAttr := tAttachment.GetAttribute(MapiPidTagAttachDataBinary, "mapi")
@@ -151,31 +254,46 @@ func (d *TnefDecoder) DecodeFile(path string) (*TnefObject, error) {
 								// the binary data of the attachment is a tnef object
 								streamValue = bytes.TrimPrefix(streamValue, objectPrefix)
 
-								attTnefObj, errD := d.Decode(streamValue)
+								attTnefObj, errD := d.decode(streamValue, depth + 1)
+								if errD == nil {
+									tAttachment.Embedded = attTnefObj
+								} else {
+									tAttachment.EmbeddedError = errD
+								}
 
-								if (errD == nil && attTnefObj != nil && attTnefObj.GetMessageClass() == "IPM.Contact") {
-									// the attachment is a vcard.vcf
-									var vcBuilder vcard.IVCard
+								if (errD == nil && attTnefObj != nil && attTnefObj.IsMeetingMessage()) {
+									// the attachment is an invite.ics
+									if calendar := ExtractICalendar(attTnefObj); calendar != nil {
+										attTnefObj.RemoveCalendarExceptionAttachments()
+										tAttachment.SetICalendar(calendar)
+										tAttachment.SetFilename(ICalendarFilename)
+									}
+								}
 
-									switch (d.VcardVersion) {
-										case "3.0":
-											vcBuilder = vcard.NewVCardV3()
-										default:
-											vcBuilder = vcard.NewVCardV3()
+								if (errD == nil && attTnefObj != nil && attTnefObj.IsTask()) {
+									// the attachment is a task (ex: the task of a task request)
+									tAttachment.SetICalendar(ExtractICalendarTask(attTnefObj, tObj))
+									tAttachment.SetFilename(ICalendarTaskFilename)
+								}
 
+								if (errD == nil && attTnefObj != nil && attTnefObj.GetMessageClass() == "IPM.Contact") {
+									// the attachment is a vcard.vcf
+									vcBuilder := d.newVCardBuilder()
+									ExtractVCard(attTnefObj, vcBuilder, d.vcardVersion())
+									if errV := tAttachment.SetVCard(vcBuilder, d.VcardFormat); errV != nil {
+										// keep the vCard text
+										tAttachment.SetVCard(vcBuilder, VcardFormatText)
 									}
-									ExtractVCard(attTnefObj, vcBuilder)
-									tAttachment.SetData([]byte(vcBuilder.Build()))
-
-									vcardFilename := "vcard.vcf"
-									fnArr := vcBuilder.GetProperty("fn")
-									if len(fnArr) > 0 {
-										fnValue := fnArr[0].GetFirstValue()
-										if fnValue != nil && fnValue.GetValue() != "" {
-											vcardFilename = fnValue.GetValue() + ".vcf"
-										}
+								}
+
+								if (errD == nil && attTnefObj != nil && attTnefObj.IsDistributionList()) {
+									// the contact group is a vCard group
+									vcBuilder := d.newVCardBuilder()
+									ExtractVCardGroup(attTnefObj, vcBuilder, d.vcardVersion())
+									if errV := tAttachment.SetVCard(vcBuilder, d.VcardFormat); errV != nil {
+										// keep the vCard text
+										tAttachment.SetVCard(vcBuilder, VcardFormatText)
 									}
-									tAttachment.SetFilename(vcardFilename)
 								}
 							}
 						}
@@ -191,14 +309,41 @@ func (d *TnefDecoder) DecodeFile(path string) (*TnefObject, error) {
 		}
 
 		offset += noByteRead
-		if offset >= dataLength {
-			break
+	}
+
+	if d.DecodeNestedTnef {
+		// attachments with a TNEF payload (winmail.dat attached as a regular file)
+		for _, a := range tObj.Attachments {
+			if a.Embedded == nil && IsTnef(a.GetData()) {
+				if nestedObj, err := d.decode(a.GetData(), depth + 1); err == nil {
+					a.Embedded = nestedObj
+				} else {
+					a.EmbeddedError = err
+				}
+			}
 		}
 	}
 
 	// check if we the TNEF has RTF
 	tObj.DecodeRtf()
 
+	if d.RenderVotingButtons {
+		tObj.RenderVotingOptions()
+	}
+
+	// meeting messages and tasks get the meeting / task as an iCalendar attachment
+	if calendarAttachment := NewICalendarAttachment(tObj); calendarAttachment != nil {
+		// the modified occurrences are exported in the iCalendar object
+		tObj.RemoveCalendarExceptionAttachments()
+		tObj.Attachments = append(tObj.Attachments, calendarAttachment)
+	}
+	if taskAttachment := NewICalendarTaskAttachment(tObj); taskAttachment != nil {
+		tObj.Attachments = append(tObj.Attachments, taskAttachment)
+	}
+
+	// resolve the attachment filenames (safe & unique names)
+	tObj.ResolveAttachmentFilenames()
+
 /*
 	fmt.Println("\r\n------------------ START TNEF -----------------------")
 	for _, a := range tObj.Attachments {
@@ -235,6 +380,13 @@ func (d *TnefDecoder) DecodeFile(path string) (*TnefObject, error) {
 	return tObj, nil
 }
 
+/**
+ * check if the data starts with the TNEF signature
+ */
+func IsTnef(data []byte) bool {
+	return len(data) >= 6 && new(LittleEndianDecoder).Int(data[0:4]) == TnefSignature
+}
+
 /**
  *  return the attribute and the total of bytes read used to create attribute
  *  the function decodes the pattern:
@@ -293,36 +445,54 @@ func (d *TnefDecoder) DecodeFile(path string) (*TnefObject, error) {
  * @return {[type]}      [description]
  */
  func (d *TnefDecoder) DecodeMapiProperties(data []byte) ([]*Attribute, error) {
+	list, _, err := d.decodeMapiPropertyList(data)
+	return list, err
+}
+
+/**
+ * decode a MsgPropertyList and return the attributes and the number of bytes read
+ */
+func (d *TnefDecoder) decodeMapiPropertyList(data []byte) ([]*Attribute, int, error) {
 
 	dataLength := len(data)
 
 	if dataLength < 4 {
-		return nil, fmt.Errorf("decodeMsgPropertyList: data too short")
+		return nil, 0, fmt.Errorf("decodeMsgPropertyList: data too short")
 	}
 
 	offset := 0
 
+	// check that n bytes can be read at the offset (the lengths and the counts are not trusted)
+	errTruncated := func(what string) error {
+		return fmt.Errorf("decodeMsgPropertyList: truncated %s at offset %d", what, offset)
+	}
+	canRead := func(n int) bool {
+		return n >= 0 && n <= dataLength - offset
+	}
+
 	// no of properties encoded
 	noOfAttributes := int(d.leDecoder.Uint32(data[offset:offset+4]))
+	offset += 4
 
-	list := make([]*Attribute, noOfAttributes)
+	// a property takes at least its tag (4 bytes)
+	if noOfAttributes > (dataLength - offset) / 4 {
+		return nil, 0, fmt.Errorf("decodeMsgPropertyList: too many properties: %d", noOfAttributes)
+	}
 
-	offset += 4
+	list := make([]*Attribute, noOfAttributes)
 
 	//MsgPropertyValue = MsgPropertyTag MsgPropertyData
 
-	//fmt.Printf("\r\nData MAPI Length: %v")
-
 	for aidx:=0; aidx < noOfAttributes; aidx++ {
 		attrDataBuf := bytes.NewBuffer([]byte{})
 
 		attr := &Attribute{}
 		attr.Type = "mapi"
 
-		if offset >= dataLength {
-			return nil, fmt.Errorf("offset is too large: %d", offset)
-		}
 		/* MsgPropertyTag = MsgPropertyType MsgPropertyId [NamedPropSpec] */
+		if !canRead(4) {
+			return nil, 0, errTruncated("property tag")
+		}
 
 		// MAPI property value type
 		attr.DataType = int(d.leDecoder.Uint16(data[offset:offset+2])) // 2 bytes
@@ -334,7 +504,10 @@ func (d *TnefDecoder) DecodeFile(path string) (*TnefObject, error) {
 
 		if attr.Id >= 0x8000 {
 			// has  NamedPropSpec; NamedPropSpec = PropNameSpace PropIDType PropMap
-			attr.GUID = d.leDecoder.String(data[offset:offset + 16])
+			if !canRead(24) {
+				return nil, 0, errTruncated("named property")
+			}
+			attr.GUID = d.leDecoder.Guid(data[offset:offset + 16])
 			offset += 16
 
 			attr.PropMapValueType = int(d.leDecoder.Uint32(data[offset:offset+4]))
@@ -351,9 +524,10 @@ func (d *TnefDecoder) DecodeFile(path string) (*TnefObject, error) {
 				readLength := int(d.leDecoder.Uint32(data[offset:offset+4])) // the length includes the padding
 				offset+=4
 
-				attr.PropMapValue = d.leDecoder.Utf16(data[offset:offset+readLength])
-				//fmt.Printf("\r\n Custom: Len: %v Value: %v", readLength, attr.PropMapValue)
-				//fmt.Printf("\r\nHEX1: %v \r\nHex2: %v", hex.Dump(data[offset:offset + readLength]), hex.Dump(data[offset + readLength: offset + readLength+8]))
+				if !canRead(readLength) {
+					return nil, 0, errTruncated("property name")
+				}
+				attr.PropMapValue = strings.TrimSuffix(d.leDecoder.Utf16(data[offset:offset+readLength]), "\x00")
 				offset += readLength
 
 				// be sure valueLength is; valueLength should be equal with bytesRead + padd
@@ -365,32 +539,46 @@ func (d *TnefDecoder) DecodeFile(path string) (*TnefObject, error) {
 
 		valueBytesLength, isMultiValue := GetTypeSize(attr.DataType)
 
-		//fmt.Printf("\r\nDataType: %#x LengthBytes: %v IsMulti: %v Offset: %v", attr.DataType, valueBytesLength, isMultiValue, offset)
-
 		countAttrValues := 1
 		if isMultiValue {
+			if !canRead(4) {
+				return nil, 0, errTruncated("value count")
+			}
 			countAttrValues = int(d.leDecoder.Uint32(data[offset:offset + 4]))
-			//fmt.Printf("\r\nValue Count: %#x ", countAttrValues)
 			attrDataBuf.Write(data[offset:offset+4])
 			offset += 4
+
+			// a value takes at least 4 bytes (its length or its padded content), 1 for the unknown types
+			minValueLength := 4
+			if valueBytesLength == 0 {
+				minValueLength = 1
+			}
+			if offset > dataLength || countAttrValues > (dataLength - offset) / minValueLength {
+				return nil, 0, fmt.Errorf("decodeMsgPropertyList: too many values: %d", countAttrValues)
+			}
 		}
 
 		for i := 0; i < countAttrValues; i++ {
-			if (valueBytesLength == -1) {
+			// the variable content values have each their own length
+			valueLength := valueBytesLength
+			if (valueLength == -1) {
 				// variable content
-				valueBytesLength = int(d.leDecoder.Uint32(data[offset:offset + 4]))
+				if !canRead(4) {
+					return nil, 0, errTruncated("value length")
+				}
+				valueLength = int(d.leDecoder.Uint32(data[offset:offset + 4]))
 				attrDataBuf.Write(data[offset:offset+4])
 				offset += 4
 			}
-			if padd := 4 - (valueBytesLength % 4); padd < 4 {
-				valueBytesLength += padd
+			if padd := 4 - (valueLength % 4); padd < 4 {
+				valueLength += padd
 			}
 
-			if offset + valueBytesLength > dataLength {
-				return nil, fmt.Errorf("offset is too large when extracting value : %d", offset + valueBytesLength)
+			if !canRead(valueLength) {
+				return nil, 0, fmt.Errorf("offset is too large when extracting value : %d", offset + valueLength)
 			}
-			attrDataBuf.Write(data[offset:offset+valueBytesLength])
-			offset += valueBytesLength
+			attrDataBuf.Write(data[offset:offset+valueLength])
+			offset += valueLength
 		}
 
 
@@ -400,17 +588,52 @@ func (d *TnefDecoder) DecodeFile(path string) (*TnefObject, error) {
 		 */
 		attr.Data = attrDataBuf.Bytes()
 
-
-		//fmt.Printf("\r\nDecode -> MAPI Type: %#x, ID: %#x Data: %v Offset: %v", attr.DataType, attr.Id, hex.Dump(attr.Data), offset)
-
 		list[aidx] = attr
 	}
 
-	return list, nil
+	if offset > dataLength {
+		// the padding of the last property name
+		return nil, 0, errTruncated("property name")
+	}
+
+	return list, offset, nil
 }
 
 
 
+/**
+ * decode the recipient table (attRecipTable)
+ *
+ * RecipientTable = RecipientCount *RecipientRow
+ * RecipientCount = UINT32
+ * RecipientRow = RecipientPropertyCount *MsgPropertyValue
+ * RecipientPropertyCount = UINT32
+ */
+func (d *TnefDecoder) DecodeRecipientTable(data []byte) ([]*Recipient, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("decodeRecipientTable: data too short")
+	}
+
+	offset := 0
+	noOfRecipients := int(d.leDecoder.Uint32(data[offset:offset+4]))
+	offset += 4
+
+	recipients := []*Recipient{}
+	for i := 0; i < noOfRecipients; i++ {
+		attrList, bytesRead, err := d.decodeMapiPropertyList(data[offset:])
+		if err != nil {
+			return recipients, err
+		}
+		offset += bytesRead
+
+		recipient := NewRecipient()
+		recipient.Attributes = attrList
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, nil
+}
+
 /**
  * attAttachRendData = AttachType AttachPosition RenderWidth RenderHeight DataFlags
  * AttachType = AttachTypeFile / AttachTypeOle
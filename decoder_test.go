@@ -0,0 +1,197 @@
+package tnefdecoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/**
+ * a MsgPropertyList: a multi-valued string property whose values have different lengths, then an integer property
+ */
+func decoderTestPropertyList(values ...string) []byte {
+	buf := &bytes.Buffer{}
+	le := func(v interface{}) { binary.Write(buf, binary.LittleEndian, v) }
+
+	le(uint32(2))
+	le(uint16(MapiTypeMVString8))
+	le(uint16(0x1001))
+	le(uint32(len(values)))
+	for _, v := range values {
+		le(uint32(len(v) + 1))
+		buf.WriteString(v + "\x00")
+		for buf.Len()%4 != 0 {
+			buf.WriteByte(0)
+		}
+	}
+	le(uint16(MapiTypeInt32))
+	le(uint16(0x1002))
+	le(uint32(7))
+	return buf.Bytes()
+}
+
+func TestDecodeMapiPropertiesMultiValued(t *testing.T) {
+	values := []string{"a", "a longer value", "", "abc"}
+	d := NewDecoder()
+	list, err := d.DecodeMapiProperties(decoderTestPropertyList(values...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("%d properties, want 2", len(list))
+	}
+	got := []string{}
+	for _, v := range list[0].GetStringValueArray() {
+		got = append(got, strings.TrimRight(v, "\x00"))
+	}
+	if !reflect.DeepEqual(got, values) {
+		t.Errorf("values %q, want %q", got, values)
+	}
+	if list[1].Id != 0x1002 || list[1].GetIntValue() != 7 {
+		t.Errorf("property after the multi-valued property: %#x = %d", list[1].Id, list[1].GetIntValue())
+	}
+}
+
+/**
+ * a message with a recipient, named properties, an attachment and an embedded message
+ */
+func decoderTestMessage() []byte {
+	embedded := &TnefObject{}
+	embedded.SetAttribute(NewMapiStringAttribute(MapiPidTagSubject, "embedded"))
+
+	t := &TnefObject{}
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagSubject, "subject"))
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagBody, "body"))
+	t.SetAttribute(NewMapiStringAttribute(0, "location").Named(PsetidCommon, MapiPidLidLocation))
+	t.SetAttribute(NewMapiStringArrayAttribute(0, []string{"a", "bc"}).Named(PsetidCommon, "Keywords"))
+	t.Recipients = append(t.Recipients, NewSmtpRecipient("Recipient", "recipient@example.com", 1))
+
+	a := NewAttachment()
+	a.SetFilename("file.txt")
+	a.SetData([]byte("content"))
+	t.Attachments = append(t.Attachments, a, NewEmbeddedMessageAttachment(embedded, "embedded"))
+
+	e := NewEncoder()
+	data, err := e.Encode(t)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func TestDecodeMapiPropertiesTooManyValues(t *testing.T) {
+	d := NewDecoder()
+	for _, data := range [][]byte{
+		// 0xFFFFFFFF properties
+		{0xFF, 0xFF, 0xFF, 0xFF, 0x03, 0x00, 0x01, 0x10},
+		// a string value of 0xFFFFFFF0 bytes
+		{0x01, 0x00, 0x00, 0x00, 0x1E, 0x00, 0x01, 0x10, 0x01, 0x00, 0x00, 0x00, 0xF0, 0xFF, 0xFF, 0xFF, 0x61, 0x00, 0x00, 0x00},
+		// 0xFFFFFFFF integer values
+		{0x01, 0x00, 0x00, 0x00, 0x03, 0x10, 0x01, 0x10, 0xFF, 0xFF, 0xFF, 0xFF, 0x01, 0x00, 0x00, 0x00},
+		// a named property name of 0xFFFFFF00 bytes
+		append(append([]byte{0x01, 0x00, 0x00, 0x00, 0x1F, 0x00, 0x00, 0x80}, make([]byte, 16)...), 0x01, 0x00, 0x00, 0x00, 0x00, 0xFF, 0xFF, 0xFF),
+	} {
+		if _, err := d.DecodeMapiProperties(data); err == nil {
+			t.Errorf("%x: no error", data)
+		}
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	data := decoderTestMessage()
+	d := NewDecoder()
+	tObj, err := d.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tObj.GetAttributeStringValue(MapiPidTagSubject, "mapi") != "subject" || len(tObj.Recipients) != 1 || len(tObj.Attachments) != 2 || tObj.Attachments[1].Embedded == nil {
+		t.Fatalf("seed message not decoded: %q, %d recipients, %d attachments", tObj.GetAttributeStringValue(MapiPidTagSubject, "mapi"), len(tObj.Recipients), len(tObj.Attachments))
+	}
+
+	checkMalformed(t, func(b []byte) { d.Decode(b) }, data)
+}
+
+func FuzzDecode(f *testing.F) {
+	d := NewDecoder()
+	fuzzDecoder(f, func(b []byte) { d.Decode(b) }, decoderTestMessage())
+}
+
+/**
+ * a message with a winmail.dat file attachment holding a message with a winmail.dat... levels times; the innermost
+ * message has the subject "innermost"
+ */
+func decoderTestNested(levels int) []byte {
+	inner := &TnefObject{}
+	inner.SetAttribute(NewMapiStringAttribute(MapiPidTagSubject, "innermost"))
+	e := NewEncoder()
+	data, err := e.Encode(inner)
+	if err != nil {
+		panic(err)
+	}
+	for i := 0; i < levels; i++ {
+		t := &TnefObject{}
+		a := NewAttachment()
+		a.SetFilename("winmail.dat")
+		a.SetData(data)
+		t.Attachments = append(t.Attachments, a)
+		if data, err = e.Encode(t); err != nil {
+			panic(err)
+		}
+	}
+	return data
+}
+
+func TestDecodeNestedTnef(t *testing.T) {
+	data := decoderTestNested(3)
+
+	d := NewDecoder()
+	tObj, err := d.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := tObj.Attachments[0]; a.Embedded != nil || a.EmbeddedError != nil {
+		t.Errorf("decoded without DecodeNestedTnef: %v", a.EmbeddedError)
+	}
+
+	d.DecodeNestedTnef = true
+	if tObj, err = d.Decode(data); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		a := tObj.Attachments[0]
+		if a.Embedded == nil {
+			t.Fatalf("level %d: not decoded: %v", i+1, a.EmbeddedError)
+		}
+		tObj = a.Embedded
+	}
+	if subject := tObj.GetAttributeStringValue(MapiPidTagSubject, "mapi"); subject != "innermost" {
+		t.Errorf("innermost subject %q", subject)
+	}
+}
+
+func TestDecodeNestedTnefMaxDepth(t *testing.T) {
+	tests := []struct {
+		name    string
+		decoder *TnefDecoder
+		depth   int
+	}{
+		{"MaxNestingDepth", &TnefDecoder{DecodeNestedTnef: true, MaxNestingDepth: 2}, 2},
+		{"default depth of a literal decoder", &TnefDecoder{DecodeNestedTnef: true}, DefaultMaxNestingDepth},
+	}
+	for _, tt := range tests {
+		tObj, err := tt.decoder.Decode(decoderTestNested(tt.depth + 2))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for i := 0; i < tt.depth; i++ {
+			if tObj = tObj.Attachments[0].Embedded; tObj == nil {
+				t.Fatalf("%s: level %d not decoded", tt.name, i+1)
+			}
+		}
+		if a := tObj.Attachments[0]; a.Embedded != nil || a.EmbeddedError != ErrMaxNestingDepth {
+			t.Errorf("%s: level %d: error %v, want %v", tt.name, tt.depth+1, a.EmbeddedError, ErrMaxNestingDepth)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+/**
+ * TNEF encoder: the inverse of TnefDecoder; writes the mapped attributes, the MAPI properties (attMsgProps), the
+ * recipient table (attRecipTable) and the attachments of a TnefObject ([MS-OXTNEF] section 2.1.3)
+ */
+
+package tnefdecoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+)
+
+// the TNEF version written in attTnefVersion
+const TnefVersion = 0x00010000
+
+// the code page written in attOemCodepage when the object has none
+const DefaultEncoderCodepage = 1252
+
+// the MessageClass of the objects without one
+const DefaultMessageClass = "IPM.Note"
+
+// IID_IMessage: the prefix of PidTagAttachDataObject for the embedded messages
+var iidIMessage = []byte{7, 3, 2, 0, 0, 0, 0, 0, 192, 0, 0, 0, 0, 0, 0, 70}
+
+/**
+ * the mapped attributes generated by the encoder; the copies found in the object are not written
+ */
+var encoderGeneratedAttributes = map[int]bool{
+	AttTnefVersion:    true,
+	AttOEMCodepage:    true,
+	AttMessageClass:   true,
+	AttMsgProps:       true,
+	AttRecipTable:     true,
+	AttAttachRendData: true,
+	AttAttachTitle:    true,
+	AttAttachData:     true,
+	AttAttachment:     true,
+}
+
+func NewEncoder() TnefEncoder {
+	e := TnefEncoder{}
+	return e
+}
+
+type TnefEncoder struct {
+	// the LegacyKey of the stream (the key of the attachments of the original message; any value for a new message)
+	LegacyKey uint16
+
+	// the IDs assigned to the named properties (property set + name) of the stream being encoded
+	namedIds map[string]int
+}
+
+/**
+ * encode the object as a TNEF stream (winmail.dat)
+ */
+func (e *TnefEncoder) Encode(t *TnefObject) ([]byte, error) {
+	e.namedIds = map[string]int{}
+	return e.encode(t)
+}
+
+func (e *TnefEncoder) encode(t *TnefObject) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, uint32(TnefSignature))
+	binary.Write(buf, binary.LittleEndian, e.LegacyKey)
+
+	codepage := t.Codepage
+	if codepage == 0 {
+		codepage = DefaultEncoderCodepage
+	}
+	messageClass := t.GetMessageClass()
+	if messageClass == "" {
+		messageClass = DefaultMessageClass
+	}
+
+	writeTnefAttribute(buf, AttrLevelMessage, AttTnefVersion, MapiEncodeInt32(TnefVersion))
+	writeTnefAttribute(buf, AttrLevelMessage, AttOEMCodepage, append(MapiEncodeInt32(codepage), 0, 0, 0, 0))
+	writeTnefAttribute(buf, AttrLevelMessage, AttMessageClass, append([]byte(messageClass), 0))
+
+	// the other mapped attributes of the message (attSubject, attDateSent, ...)
+	for _, attr := range uniqueMappedAttributes(t.Attributes, AttrLevelMessage) {
+		writeTnefAttribute(buf, AttrLevelMessage, attr.Id, attr.Data)
+	}
+
+	if len(t.Recipients) > 0 {
+		table := MapiEncodeInt32(len(t.Recipients))
+		for _, recipient := range t.Recipients {
+			table = append(table, e.EncodeMapiProperties(recipient.Attributes)...)
+		}
+		writeTnefAttribute(buf, AttrLevelMessage, AttRecipTable, table)
+	}
+
+	writeTnefAttribute(buf, AttrLevelMessage, AttMsgProps, e.EncodeMapiProperties(t.Attributes))
+
+	for _, a := range t.Attachments {
+		if err := e.encodeAttachment(buf, a); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+/**
+ * the attachment attributes: attAttachRendData first, the mapped attributes, attAttachData and the attachment
+ * properties (attAttachment) last; the embedded messages are encoded into PidTagAttachDataObject
+ */
+func (e *TnefEncoder) encodeAttachment(buf *bytes.Buffer, a *Attachment) error {
+	attachMethod := a.GetAttachMethod()
+
+	// AttachType AttachPosition RenderWidth RenderHeight DataFlags
+	rendData := make([]byte, 14)
+	binary.LittleEndian.PutUint16(rendData[0:2], 1)
+	if attachMethod == AttachMethodOle {
+		binary.LittleEndian.PutUint16(rendData[0:2], 2)
+	}
+	binary.LittleEndian.PutUint32(rendData[2:6], 0xFFFFFFFF)
+	binary.LittleEndian.PutUint16(rendData[6:8], 0xFFFF)
+	binary.LittleEndian.PutUint16(rendData[8:10], 0xFFFF)
+	writeTnefAttribute(buf, AttrLevelAttachment, AttAttachRendData, rendData)
+
+	if filename := a.GetFilename(); filename != "" {
+		writeTnefAttribute(buf, AttrLevelAttachment, AttAttachTitle, append([]byte(filename), 0))
+	}
+	for _, attr := range uniqueMappedAttributes(a.Attributes, AttrLevelAttachment) {
+		writeTnefAttribute(buf, AttrLevelAttachment, attr.Id, attr.Data)
+	}
+
+	attributes := a.Attributes
+	// the attachments created with an embedded object and no attach method are embedded messages
+	if a.Embedded != nil && (attachMethod == AttachMethodEmbeddedMessage || a.GetAttribute(MapiPidTagAttachMethod, "mapi") == nil) {
+		embedded, err := e.encode(a.Embedded)
+		if err != nil {
+			return err
+		}
+		attributes = []*Attribute{
+			NewMapiIntAttribute(MapiPidTagAttachMethod, AttachMethodEmbeddedMessage),
+			NewMapiAttribute(MapiPidTagAttachDataBinary, MapiTypeObject, MapiEncodeBinary(append(append([]byte{}, iidIMessage...), embedded...))),
+		}
+		for _, attr := range a.Attributes {
+			if attr.Type == "mapi" && attr.Id != MapiPidTagAttachMethod && attr.Id != MapiPidTagAttachDataBinary {
+				attributes = append(attributes, attr)
+			}
+		}
+	} else if data := a.GetData(); len(data) > 0 {
+		writeTnefAttribute(buf, AttrLevelAttachment, AttAttachData, data)
+	}
+
+	writeTnefAttribute(buf, AttrLevelAttachment, AttAttachment, e.EncodeMapiProperties(attributes))
+	return nil
+}
+
+/**
+ * encode the MAPI attributes as a MsgPropertyList: the count, then the tag, the named property specification and the
+ * value of each property; the named properties get the ID assigned to their name in the stream
+ */
+func (e *TnefEncoder) EncodeMapiProperties(attributes []*Attribute) []byte {
+	if e.namedIds == nil {
+		e.namedIds = map[string]int{}
+	}
+
+	list := &bytes.Buffer{}
+	count := 0
+	for _, attr := range attributes {
+		if attr.Type != "mapi" {
+			continue
+		}
+		count++
+
+		id := attr.Id
+		if attr.GUID != "" && attr.Id >= 0x8000 {
+			key := strings.ToUpper(attr.GUID) + "/" + stringValue(attr.PropMapValue)
+			if _, ok := e.namedIds[key]; !ok {
+				e.namedIds[key] = 0x8000 + len(e.namedIds)
+			}
+			id = e.namedIds[key]
+		}
+
+		binary.Write(list, binary.LittleEndian, uint16(attr.DataType))
+		binary.Write(list, binary.LittleEndian, uint16(id))
+		if id >= 0x8000 {
+			list.Write(encodeGuid(attr.GUID))
+			if name, ok := attr.PropMapValue.(string); ok {
+				binary.Write(list, binary.LittleEndian, uint32(1))
+				value := MapiEncodeUnicode(name)
+				// MapiEncodeUnicode: count, length, null terminated string, padding
+				list.Write(value[4:])
+			} else {
+				binary.Write(list, binary.LittleEndian, uint32(0))
+				lid, _ := attr.PropMapValue.(int)
+				binary.Write(list, binary.LittleEndian, uint32(lid))
+			}
+		}
+		list.Write(attr.Data)
+	}
+
+	return append(MapiEncodeInt32(count), list.Bytes()...)
+}
+
+/**
+ * the mapped attributes of the given level not generated by the encoder, each attribute ID once
+ */
+func uniqueMappedAttributes(attributes []*Attribute, level int) []*Attribute {
+	result := []*Attribute{}
+	seen := map[int]bool{}
+	for _, attr := range attributes {
+		if attr.Type != "mapped" || attr.Level != level || encoderGeneratedAttributes[attr.Id] || seen[attr.Id] {
+			continue
+		}
+		seen[attr.Id] = true
+		result = append(result, attr)
+	}
+	return result
+}
+
+/**
+ * Level(1) ID(4) Length(4) Data Checksum(2); the checksum is the sum of the data bytes modulo 65536
+ */
+func writeTnefAttribute(buf *bytes.Buffer, level int, id int, data []byte) {
+	buf.WriteByte(byte(level))
+	binary.Write(buf, binary.LittleEndian, uint32(id))
+	binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+
+	checksum := uint16(0)
+	for _, b := range data {
+		checksum += uint16(b)
+	}
+	binary.Write(buf, binary.LittleEndian, checksum)
+}
+
+/**
+ * encode a GUID given in the canonical form (see LittleEndianDecoder.Guid)
+ */
+func encodeGuid(guid string) []byte {
+	raw, err := hex.DecodeString(strings.Replace(guid, "-", "", -1))
+	if err != nil || len(raw) != 16 {
+		return make([]byte, 16)
+	}
+	// Data1, Data2 and Data3 are little endian
+	raw[0], raw[1], raw[2], raw[3] = raw[3], raw[2], raw[1], raw[0]
+	raw[4], raw[5] = raw[5], raw[4]
+	raw[6], raw[7] = raw[7], raw[6]
+	return raw
+}
+
+func stringValue(v GenericValue) string {
+	switch value := v.(type) {
+	case string:
+		return "s:" + value
+	case int:
+		return "i:" + hex.EncodeToString(MapiEncodeInt32(value))
+	}
+	return ""
+}
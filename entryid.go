@@ -0,0 +1,437 @@
+/**
+ * entry IDs of the recipients and of the distribution list members ([MS-OXCDATA] section 2.2.5, [MS-OXOCNTC] section 2.2.2.2.1)
+ */
+
+package tnefdecoder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+var ErrInvalidEntryId = errors.New("invalid entry ID")
+
+/**
+ * the provider UIDs identifying the entry ID structures (as decoded by LittleEndianDecoder.Guid)
+ */
+const (
+	ProviderUidOneOff      = "A41F2B81-A3BE-1910-9D6E-00DD010F5402" // One-Off EntryID: the address is in the entry ID
+	ProviderUidAddressBook = "C840A7DC-42C0-1A10-B4B9-08002B2FE182" // Address Book EntryID: the X500 DN of an Exchange user or list
+	ProviderUidWrapped     = "D3AD91C0-9D51-11CF-A4A9-00AA0047FAA4" // WrappedEntryId: a member of a personal distribution list
+	ProviderUidContact     = "0AAA42FE-C718-101A-E885-0B651C240000" // Contact Address EntryID: an address of a contact
+	ProviderUidStore       = "10BBA138-E505-1A10-A1BB-08002B2A56C2" // Store Object EntryID: a mailbox or a public folder store
+)
+
+/**
+ * the kinds of the decoded entry IDs
+ */
+const (
+	EntryIdUnknown        = "unknown"
+	EntryIdOneOff         = "one-off"
+	EntryIdAddressBook    = "address-book"
+	EntryIdContactAddress = "contact-address"
+	EntryIdWrapped        = "wrapped"
+	EntryIdFolder         = "folder"
+	EntryIdMessage        = "message"
+	EntryIdStore          = "store"
+)
+
+/**
+ * the types of the objects of the Address Book EntryIDs
+ */
+const (
+	AddressBookLocalUser        = 0x00000000
+	AddressBookDistList         = 0x00000001
+	AddressBookPublicFolder     = 0x00000002
+	AddressBookAutomatedMailbox = 0x00000003
+	AddressBookOrgMailbox       = 0x00000004
+	AddressBookPrivateDistList  = 0x00000005
+	AddressBookRemoteUser       = 0x00000006
+	AddressBookContainer        = 0x00000100
+	AddressBookTemplate         = 0x00000101
+	AddressBookOneOffUser       = 0x00000102
+	AddressBookSearch           = 0x00000200
+)
+
+/**
+ * the addresses of a contact referenced by the Contact Address EntryIDs (Index field)
+ */
+const (
+	ContactAddressEmail1      = 0x00
+	ContactAddressEmail2      = 0x01
+	ContactAddressEmail3      = 0x02
+	ContactAddressBusinessFax = 0x03
+	ContactAddressHomeFax     = 0x04
+	ContactAddressPrimaryFax  = 0x05
+)
+
+/**
+ * the types of the Folder and Message EntryIDs ([MS-OXCDATA] section 2.2.4.1)
+ */
+const (
+	StoreEntryIdPrivateFolder  = 0x0001
+	StoreEntryIdPublicFolder   = 0x0003
+	StoreEntryIdMappedFolder   = 0x0005 // eitLTWackyFolder: a public folder mapped into the private store
+	StoreEntryIdPrivateMessage = 0x0007
+	StoreEntryIdPublicMessage  = 0x0009
+	StoreEntryIdMappedMessage  = 0x000B
+)
+
+// the length of the Folder and Message EntryIDs
+const (
+	folderEntryIdLength  = 46
+	messageEntryIdLength = 70
+)
+
+// the One-Off EntryID strings are UTF-16 (MAPI_UNICODE)
+const oneOffUnicodeFlag = 0x8000
+
+// the flags of the encoded One-Off EntryIDs: Unicode strings, no rich text (MAPI_SEND_NO_RICH_INFO)
+const oneOffDefaultFlags = 0x9001
+
+/**
+ * WrappedEntryId types (the low 4 bits of the Type field)
+ */
+const (
+	WrappedEntryIdOneOff       = 0x00
+	WrappedEntryIdContact      = 0x03
+	WrappedEntryIdPersonalList = 0x04
+	WrappedEntryIdGalUser      = 0x05
+	WrappedEntryIdGalList      = 0x06
+)
+
+/**
+ * the address given by an entry ID
+ */
+type EntryIdAddress struct {
+	DisplayName  string
+	AddressType  string // SMTP, EX, ...
+	EmailAddress string // the address in the format of the address type (the X500 DN for EX)
+}
+
+/**
+ * return the SMTP address; "" if the address is not an SMTP address
+ */
+func (a *EntryIdAddress) GetSmtpAddress() string {
+	if a.AddressType == "" || strings.EqualFold(a.AddressType, "SMTP") {
+		return a.EmailAddress
+	}
+	return ""
+}
+
+/**
+ * return the provider UID of an entry ID
+ */
+func entryIdProviderUid(b []byte) string {
+	if len(b) < 20 {
+		return ""
+	}
+	return new(LittleEndianDecoder).Guid(b[4:20])
+}
+
+/**
+ * decode a One-Off EntryID: Flags, ProviderUID, Version, Flags, DisplayName, AddressType, EmailAddress
+ * the 8-bit strings are returned unchanged, see DecodeOneOffEntryIdCodepage
+ */
+func DecodeOneOffEntryId(b []byte) (*EntryIdAddress, error) {
+	return DecodeOneOffEntryIdCodepage(b, 0)
+}
+
+/**
+ * decode a One-Off EntryID; the 8-bit strings (entry IDs without the Unicode flag) are in the code page of the message
+ */
+func DecodeOneOffEntryIdCodepage(b []byte, codepage int) (*EntryIdAddress, error) {
+	if len(b) < 24 || entryIdProviderUid(b) != ProviderUidOneOff {
+		return nil, ErrInvalidEntryId
+	}
+
+	leDecoder := new(LittleEndianDecoder)
+	unicode := int(leDecoder.Uint16(b[22:24]))&oneOffUnicodeFlag != 0
+	data := b[24:]
+
+	// the null terminated strings
+	next := func() string {
+		if unicode {
+			for i := 0; i+1 < len(data); i += 2 {
+				if data[i] == 0 && data[i+1] == 0 {
+					v := leDecoder.Utf16(data[:i])
+					data = data[i+2:]
+					return v
+				}
+			}
+		} else if i := strings.IndexByte(string(data), 0); i >= 0 {
+			v := DecodeCodepage(data[:i], codepage)
+			data = data[i+1:]
+			return v
+		}
+		data = nil
+		return ""
+	}
+
+	a := &EntryIdAddress{}
+	a.DisplayName = next()
+	a.AddressType = next()
+	a.EmailAddress = next()
+	if a.EmailAddress == "" {
+		return nil, ErrInvalidEntryId
+	}
+	return a, nil
+}
+
+/**
+ * decode an Address Book EntryID: Flags, ProviderUID, Version, Type, X500DN; the address type is EX
+ */
+func DecodeAddressBookEntryId(b []byte) (*EntryIdAddress, error) {
+	if len(b) < 29 || entryIdProviderUid(b) != ProviderUidAddressBook {
+		return nil, ErrInvalidEntryId
+	}
+	dn := string(b[28:])
+	if i := strings.IndexByte(dn, 0); i >= 0 {
+		dn = dn[:i]
+	}
+	if dn == "" {
+		return nil, ErrInvalidEntryId
+	}
+	return &EntryIdAddress{AddressType: "EX", EmailAddress: dn}, nil
+}
+
+/**
+ * unwrap a WrappedEntryId: return the type (WrappedEntryId*) and the embedded entry ID
+ */
+func UnwrapEntryId(b []byte) (int, []byte, error) {
+	if len(b) < 21 || entryIdProviderUid(b) != ProviderUidWrapped {
+		return 0, nil, ErrInvalidEntryId
+	}
+	return int(b[20]) & 0x0F, b[21:], nil
+}
+
+/**
+ * return the address of an entry ID: One-Off, Address Book or wrapped One-Off / Address Book entry IDs
+ * the entry IDs of the objects of a message store (ex: a contact of a personal distribution list) do not give an address
+ */
+func DecodeEntryIdAddress(b []byte) (*EntryIdAddress, error) {
+	return DecodeEntryIdAddressCodepage(b, 0)
+}
+
+/**
+ * return the address of an entry ID, the 8-bit strings of the One-Off entry IDs being in the given code page
+ */
+func DecodeEntryIdAddressCodepage(b []byte, codepage int) (*EntryIdAddress, error) {
+	e, err := DecodeEntryIdCodepage(b, codepage)
+	if err != nil {
+		return nil, err
+	}
+	if a := e.GetAddress(); a != nil {
+		return a, nil
+	}
+	return nil, ErrInvalidEntryId
+}
+
+/**
+ * a decoded entry ID ([MS-OXCDATA] sections 2.2.4 and 2.2.5); the fields set depend on the kind
+ */
+type EntryId struct {
+	Kind        string // EntryIdOneOff, EntryIdAddressBook, ...
+	Flags       int
+	ProviderUid string
+
+	// one-off, address book: the address; the address type of the address-book entries is EX
+	Address *EntryIdAddress
+
+	// one-off: the strings are Unicode (else 8-bit)
+	Unicode bool
+
+	// address book: the type of the object (AddressBook*)
+	AddressBookType int
+
+	// contact address: the address of the contact (ContactAddress*) and the message entry ID of the contact
+	// wrapped: the type (WrappedEntryId*) and the wrapped entry ID
+	AddressIndex int
+	WrappedType  int
+	Embedded     *EntryId
+
+	// folder and message: the type (StoreEntryId*), the GUIDs of the databases and the global counters of the folder and
+	// of the message
+	StoreType            int
+	FolderDatabaseGuid   string
+	FolderGlobalCounter  uint64
+	MessageDatabaseGuid  string
+	MessageGlobalCounter uint64
+
+	// store: the server and the DN of the mailbox (empty for the public folder stores)
+	ServerName string
+	MailboxDn  string
+}
+
+/**
+ * decode an entry ID; the entry IDs of unknown structure are EntryIdUnknown (the error is for the truncated entry IDs)
+ * the 8-bit strings of the One-Off entry IDs are returned unchanged, see DecodeEntryIdCodepage
+ */
+func DecodeEntryId(b []byte) (*EntryId, error) {
+	return DecodeEntryIdCodepage(b, 0)
+}
+
+/**
+ * decode an entry ID, the 8-bit strings of the One-Off entry IDs being in the given code page (see DecodeCodepage)
+ */
+func DecodeEntryIdCodepage(b []byte, codepage int) (*EntryId, error) {
+	if len(b) < 20 {
+		return nil, ErrInvalidEntryId
+	}
+	leDecoder := new(LittleEndianDecoder)
+	e := &EntryId{Kind: EntryIdUnknown, Flags: int(leDecoder.Uint32(b[0:4])), ProviderUid: entryIdProviderUid(b)}
+
+	var err error
+	switch e.ProviderUid {
+	case ProviderUidOneOff:
+		e.Kind = EntryIdOneOff
+		e.Unicode = len(b) >= 24 && int(leDecoder.Uint16(b[22:24]))&oneOffUnicodeFlag != 0
+		e.Address, err = DecodeOneOffEntryIdCodepage(b, codepage)
+	case ProviderUidAddressBook:
+		e.Kind = EntryIdAddressBook
+		if len(b) >= 28 {
+			e.AddressBookType = int(leDecoder.Uint32(b[24:28]))
+		}
+		e.Address, err = DecodeAddressBookEntryId(b)
+	case ProviderUidWrapped:
+		e.Kind = EntryIdWrapped
+		var embedded []byte
+		if e.WrappedType, embedded, err = UnwrapEntryId(b); err == nil {
+			e.Embedded, err = DecodeEntryIdCodepage(embedded, codepage)
+		}
+	case ProviderUidContact:
+		// Version, Type, Index, EntryIdCount, EntryIdBytes
+		e.Kind = EntryIdContactAddress
+		if len(b) < 40 {
+			return nil, ErrInvalidEntryId
+		}
+		e.AddressIndex = int(leDecoder.Uint32(b[28:32]))
+		count := int(leDecoder.Uint32(b[32:36]))
+		if count > len(b)-36 {
+			return nil, ErrInvalidEntryId
+		}
+		e.Embedded, err = DecodeEntryIdCodepage(b[36:36+count], codepage)
+	case ProviderUidStore:
+		e.Kind = EntryIdStore
+		err = e.decodeStore(b)
+	default:
+		e.decodeFolderOrMessage(b)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+/**
+ * Folder EntryID: Flags, ProviderUID (the store), FolderType, DatabaseGuid, GlobalCounter, Pad
+ * Message EntryID: Flags, ProviderUID, MessageType, FolderDatabaseGuid, FolderGlobalCounter, Pad, MessageDatabaseGuid,
+ * MessageGlobalCounter, Pad
+ */
+func (e *EntryId) decodeFolderOrMessage(b []byte) {
+	if len(b) != folderEntryIdLength && len(b) != messageEntryIdLength {
+		return
+	}
+	leDecoder := new(LittleEndianDecoder)
+	storeType := int(leDecoder.Uint16(b[20:22]))
+	switch {
+	case len(b) == folderEntryIdLength && (storeType == StoreEntryIdPrivateFolder || storeType == StoreEntryIdPublicFolder || storeType == StoreEntryIdMappedFolder):
+		e.Kind = EntryIdFolder
+	case len(b) == messageEntryIdLength && (storeType == StoreEntryIdPrivateMessage || storeType == StoreEntryIdPublicMessage || storeType == StoreEntryIdMappedMessage):
+		e.Kind = EntryIdMessage
+		e.MessageDatabaseGuid = leDecoder.Guid(b[46:62])
+		e.MessageGlobalCounter = globalCounter(b[62:68])
+	default:
+		return
+	}
+	e.StoreType = storeType
+	e.FolderDatabaseGuid = leDecoder.Guid(b[22:38])
+	e.FolderGlobalCounter = globalCounter(b[38:44])
+}
+
+/**
+ * a GLOBCNT: 6 bytes, big endian
+ */
+func globalCounter(b []byte) uint64 {
+	v := uint64(0)
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+/**
+ * Store Object EntryID: Flags, ProviderUID, Version, Flag, DLLFileName (14 bytes), WrappedFlags, WrappedProviderUID,
+ * WrappedType, ServerShortname, MailboxDN (the strings are null terminated)
+ */
+func (e *EntryId) decodeStore(b []byte) error {
+	if len(b) < 60 {
+		return ErrInvalidEntryId
+	}
+	fields := strings.SplitN(string(b[60:]), "\x00", 3)
+	e.ServerName = fields[0]
+	if len(fields) > 1 {
+		e.MailboxDn = fields[1]
+	}
+	return nil
+}
+
+/**
+ * return the address of the entry ID: the address of the one-off and address-book entry IDs, wrapped or not; nil for
+ * the other entry IDs
+ */
+func (e *EntryId) GetAddress() *EntryIdAddress {
+	if e == nil {
+		return nil
+	}
+	if e.Kind == EntryIdWrapped {
+		return e.Embedded.GetAddress()
+	}
+	return e.Address
+}
+
+/**
+ * a description of the entry ID (ex: "one-off: John <SMTP:john@example.com>")
+ */
+func (e *EntryId) String() string {
+	if e == nil {
+		return ""
+	}
+	switch e.Kind {
+	case EntryIdOneOff, EntryIdAddressBook:
+		s := e.Kind + ": "
+		if e.Address.DisplayName != "" {
+			s += e.Address.DisplayName + " "
+		}
+		return s + "<" + e.Address.AddressType + ":" + e.Address.EmailAddress + ">"
+	case EntryIdWrapped:
+		return e.Kind + " (" + e.Embedded.String() + ")"
+	case EntryIdContactAddress:
+		return fmt.Sprintf("%s %d (%s)", e.Kind, e.AddressIndex, e.Embedded.String())
+	case EntryIdFolder:
+		return fmt.Sprintf("%s: %s-%012X", e.Kind, e.FolderDatabaseGuid, e.FolderGlobalCounter)
+	case EntryIdMessage:
+		return fmt.Sprintf("%s: %s-%012X / %s-%012X", e.Kind, e.FolderDatabaseGuid, e.FolderGlobalCounter, e.MessageDatabaseGuid, e.MessageGlobalCounter)
+	case EntryIdStore:
+		return e.Kind + ": " + e.ServerName + " " + e.MailboxDn
+	}
+	return e.Kind + ": " + e.ProviderUid
+}
+
+/**
+ * encode a One-Off EntryID with Unicode strings
+ */
+func EncodeOneOffEntryId(displayName, addressType, emailAddress string) []byte {
+	b := make([]byte, 4, 64)
+	b = append(b, encodeGuid(ProviderUidOneOff)...)
+	b = append(b, 0, 0, byte(oneOffDefaultFlags&0xFF), byte(oneOffDefaultFlags>>8))
+	for _, v := range []string{displayName, addressType, emailAddress} {
+		for _, u := range utf16.Encode([]rune(v + "\x00")) {
+			b = append(b, byte(u), byte(u>>8))
+		}
+	}
+	return b
+}
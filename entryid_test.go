@@ -0,0 +1,161 @@
+package tnefdecoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+/**
+ * an entry ID: Flags (0), the provider UID and the data
+ */
+func entryIdTestBytes(providerUid string, data ...[]byte) []byte {
+	return bytes.Join(append([][]byte{make([]byte, 4), encodeGuid(providerUid)}, data...), nil)
+}
+
+func entryIdTestUint32(values ...uint32) []byte {
+	b := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(b[4*i:], v)
+	}
+	return b
+}
+
+func entryIdTestAddressBook(dn string) []byte {
+	return entryIdTestBytes(ProviderUidAddressBook, entryIdTestUint32(1, AddressBookLocalUser), []byte(dn+"\x00"))
+}
+
+/**
+ * the entry IDs of the messages and of the folders: the type, then the database GUID, the global counter (6 bytes)
+ * and the padding (2 bytes) of the folder and of the message
+ */
+func entryIdTestMessage() []byte {
+	return entryIdTestBytes("11111111-2222-3333-4444-555555555555", []byte{StoreEntryIdPrivateMessage, 0},
+		encodeGuid("AAAAAAAA-0000-0000-0000-000000000001"), []byte{0, 0, 0, 0, 0x12, 0x34, 0, 0},
+		encodeGuid("AAAAAAAA-0000-0000-0000-000000000002"), []byte{0, 0, 0, 0, 0x56, 0x78, 0, 0})
+}
+
+func entryIdTestFolder() []byte {
+	return entryIdTestBytes("11111111-2222-3333-4444-555555555555", []byte{StoreEntryIdPublicFolder, 0},
+		encodeGuid("AAAAAAAA-0000-0000-0000-000000000001"), []byte{0, 0, 0, 0, 0x12, 0x34, 0, 0})
+}
+
+func TestDecodeEntryId(t *testing.T) {
+	const dn = "/O=EXAMPLE/OU=EXCHANGE ADMINISTRATIVE GROUP/CN=RECIPIENTS/CN=JDOE"
+	oneOff := EncodeOneOffEntryId("Jane Doe", "SMTP", "jane@example.com")
+	ansiOneOff := entryIdTestBytes(ProviderUidOneOff, []byte{0, 0, 0x01, 0x10}, []byte("Ren\xe9\x00SMTP\x00rene@example.com\x00"))
+
+	tests := []struct {
+		name    string
+		data    []byte
+		kind    string
+		address *EntryIdAddress
+		check   func(e *EntryId) bool
+	}{
+		{"one-off", oneOff, EntryIdOneOff, &EntryIdAddress{"Jane Doe", "SMTP", "jane@example.com"},
+			func(e *EntryId) bool { return e.Unicode }},
+		{"8-bit one-off", ansiOneOff, EntryIdOneOff, &EntryIdAddress{"René", "SMTP", "rene@example.com"},
+			func(e *EntryId) bool { return !e.Unicode }},
+		{"address book", entryIdTestAddressBook(dn), EntryIdAddressBook, &EntryIdAddress{"", "EX", dn},
+			func(e *EntryId) bool { return e.AddressBookType == AddressBookLocalUser }},
+		{"wrapped one-off", entryIdTestBytes(ProviderUidWrapped, []byte{WrappedEntryIdOneOff}, oneOff), EntryIdWrapped,
+			&EntryIdAddress{"Jane Doe", "SMTP", "jane@example.com"},
+			func(e *EntryId) bool {
+				return e.WrappedType == WrappedEntryIdOneOff && e.Embedded.Kind == EntryIdOneOff
+			}},
+		{"wrapped contact", entryIdTestBytes(ProviderUidWrapped, []byte{0x80 | WrappedEntryIdContact}, entryIdTestMessage()), EntryIdWrapped, nil,
+			func(e *EntryId) bool {
+				return e.WrappedType == WrappedEntryIdContact && e.Embedded.Kind == EntryIdMessage
+			}},
+		{"contact address", entryIdTestBytes(ProviderUidContact, entryIdTestUint32(3, 4, ContactAddressEmail2, 70), entryIdTestMessage()), EntryIdContactAddress, nil,
+			func(e *EntryId) bool {
+				return e.AddressIndex == ContactAddressEmail2 && e.Embedded.Kind == EntryIdMessage
+			}},
+		{"message", entryIdTestMessage(), EntryIdMessage, nil,
+			func(e *EntryId) bool {
+				return e.StoreType == StoreEntryIdPrivateMessage && e.FolderGlobalCounter == 0x1234 && e.MessageGlobalCounter == 0x5678
+			}},
+		{"folder", entryIdTestFolder(), EntryIdFolder, nil,
+			func(e *EntryId) bool {
+				return e.StoreType == StoreEntryIdPublicFolder && e.FolderGlobalCounter == 0x1234
+			}},
+		{"store", entryIdTestBytes(ProviderUidStore, make([]byte, 40), []byte("SERVER\x00/O=EXAMPLE/CN=JDOE\x00")), EntryIdStore, nil,
+			func(e *EntryId) bool { return e.ServerName == "SERVER" && e.MailboxDn == "/O=EXAMPLE/CN=JDOE" }},
+		{"unknown", entryIdTestBytes("11111111-2222-3333-4444-555555555555", []byte{1, 2, 3}), EntryIdUnknown, nil, nil},
+	}
+	for _, tt := range tests {
+		e, err := DecodeEntryIdCodepage(tt.data, 1252)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if e.Kind != tt.kind {
+			t.Errorf("%s: kind %q, want %q", tt.name, e.Kind, tt.kind)
+		}
+		if address := e.GetAddress(); !reflect.DeepEqual(address, tt.address) {
+			t.Errorf("%s: address %+v, want %+v", tt.name, address, tt.address)
+		}
+		if tt.check != nil && !tt.check(e) {
+			t.Errorf("%s: %+v", tt.name, e)
+		}
+		if e.String() == "" {
+			t.Errorf("%s: no description", tt.name)
+		}
+	}
+}
+
+func TestDecodeEntryIdInvalid(t *testing.T) {
+	oneOff := EncodeOneOffEntryId("Jane Doe", "SMTP", "jane@example.com")
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"shorter than a provider UID", oneOff[:19]},
+		{"one-off without the flags", oneOff[:22]},
+		{"one-off without address", EncodeOneOffEntryId("Jane Doe", "SMTP", "")},
+		{"address book without DN", entryIdTestAddressBook("")},
+		{"wrapped without entry ID", entryIdTestBytes(ProviderUidWrapped)},
+		{"wrapped invalid entry ID", entryIdTestBytes(ProviderUidWrapped, []byte{0}, oneOff[:30])},
+		{"contact address count larger than the data", entryIdTestBytes(ProviderUidContact, entryIdTestUint32(3, 4, 0, 0xFFFFFFFF), entryIdTestMessage())},
+		{"truncated store", entryIdTestBytes(ProviderUidStore, make([]byte, 10))},
+	}
+	for _, tt := range tests {
+		if e, err := DecodeEntryId(tt.data); err != ErrInvalidEntryId {
+			t.Errorf("%s: %+v, error %v, want %v", tt.name, e, err, ErrInvalidEntryId)
+		}
+	}
+
+	if _, err := DecodeEntryIdAddress(entryIdTestMessage()); err != ErrInvalidEntryId {
+		t.Errorf("address of a message entry ID: error %v", err)
+	}
+}
+
+func TestDecodeEntryIdMalformed(t *testing.T) {
+	checkMalformed(t, decodeEntryId, entryIdTestSeeds()...)
+}
+
+func FuzzDecodeEntryId(f *testing.F) {
+	fuzzDecoder(f, decodeEntryId, entryIdTestSeeds()...)
+}
+
+func entryIdTestSeeds() [][]byte {
+	oneOff := EncodeOneOffEntryId("Jane Doe", "SMTP", "jane@example.com")
+	return [][]byte{
+		oneOff,
+		entryIdTestBytes(ProviderUidOneOff, []byte{0, 0, 0x01, 0x10}, []byte("Ren\xe9\x00SMTP\x00rene@example.com\x00")),
+		entryIdTestAddressBook("/O=EXAMPLE/CN=RECIPIENTS/CN=JDOE"),
+		entryIdTestBytes(ProviderUidWrapped, []byte{WrappedEntryIdOneOff}, oneOff),
+		entryIdTestBytes(ProviderUidContact, entryIdTestUint32(3, 4, ContactAddressEmail1, 70), entryIdTestMessage()),
+		entryIdTestBytes(ProviderUidStore, make([]byte, 40), []byte("SERVER\x00/O=EXAMPLE/CN=JDOE\x00")),
+		entryIdTestFolder(),
+	}
+}
+
+func decodeEntryId(data []byte) {
+	if e, err := DecodeEntryIdCodepage(data, 932); err == nil {
+		_ = e.String()
+		e.GetAddress()
+	}
+}
@@ -0,0 +1,172 @@
+/**
+ * export the reports as multipart/report (RFC 6522): message disposition notifications for the read receipts (RFC 8098)
+ * and delivery status notifications for the delivery and non-delivery reports (RFC 3464)
+ */
+
+package tnefdecoder
+
+import (
+	"bytes"
+	"mime"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// the name of the user agent in the Reporting-UA field of the disposition notifications
+const ReportingUserAgent = "tnefdecoder"
+
+// the address of the Final-Recipient field when the report has no address for the recipient (the field is required)
+const UnknownReportRecipient = "unknown@unknown.invalid"
+
+/**
+ * build the multipart/report entity: the human readable part (the message body), the machine readable status and the
+ * headers of the original message
+ */
+func newMimeReportPart(r *Report, human *mimePart, t *TnefObject) *mimePart {
+	p := &mimePart{Header: textproto.MIMEHeader{}, MediaType: "multipart/report", Params: map[string]string{}}
+	p.Parts = append(p.Parts, human)
+
+	if r.IsDispositionNotification() {
+		p.Params["report-type"] = "disposition-notification"
+		p.Parts = append(p.Parts, newMimeStatusPart("message/disposition-notification", mdnFields(r, t)))
+	} else {
+		p.Params["report-type"] = "delivery-status"
+		p.Parts = append(p.Parts, newMimeStatusPart("message/delivery-status", dsnFields(r, t)...))
+	}
+
+	if headers := originalMessageHeaders(r); len(headers) > 0 {
+		p.Parts = append(p.Parts, newMimeStatusPart("text/rfc822-headers", headers))
+	}
+
+	return p
+}
+
+/**
+ * a part made of groups of fields ("Name: value" lines), the groups being separated by empty lines; the fields are
+ * folded like the headers
+ */
+func newMimeStatusPart(contentType string, groups ...[]string) *mimePart {
+	p := &mimePart{Header: textproto.MIMEHeader{}}
+	p.Header.Set("Content-Type", contentType)
+	p.Header.Set("Content-Transfer-Encoding", "7bit")
+
+	buf := &bytes.Buffer{}
+	for i, fields := range groups {
+		if i > 0 {
+			buf.WriteString("\r\n")
+		}
+		for _, field := range fields {
+			buf.WriteString(foldMimeHeader(field) + "\r\n")
+		}
+	}
+	p.Body = buf.Bytes()
+
+	return p
+}
+
+/**
+ * the fields of the disposition notification (RFC 8098 section 3.2)
+ */
+func mdnFields(r *Report, t *TnefObject) []string {
+	fields := []string{"Reporting-UA: " + ReportingUserAgent}
+
+	// the reader is the sender of the receipt
+	_, address := messageSender(t)
+	fields = append(fields, "Final-Recipient: rfc822; "+reportAddress(address))
+	if messageId := mimeHeaderValue(r.OriginalMessageId); messageId != "" {
+		fields = append(fields, "Original-Message-ID: "+messageId)
+	}
+
+	if r.Type == ReportTypeRead {
+		fields = append(fields, "Disposition: manual-action/MDN-sent-manually; displayed")
+	} else {
+		fields = append(fields, "Disposition: automatic-action/MDN-sent-automatically; deleted")
+	}
+
+	return fields
+}
+
+/**
+ * the per-message fields and the per-recipient fields of the delivery status notification (RFC 3464 section 2.2 - 2.3)
+ */
+func dsnFields(r *Report, t *TnefObject) [][]string {
+	mta := reportToken(r.ReportingMta)
+	if mta == "" {
+		// the domain of the sender of the report (the postmaster)
+		if _, address := messageSender(t); strings.Contains(address, "@") {
+			mta = reportToken(address[strings.LastIndex(address, "@")+1:])
+		}
+	}
+	if mta == "" {
+		mta = "localhost"
+	}
+
+	// Arrival-Date (the arrival at the reporting MTA) is not in the report properties: the submit time of the original
+	// message is in the Date of its headers
+	groups := [][]string{{"Reporting-MTA: dns; " + mta}}
+
+	for _, recipient := range r.Recipients {
+		address := recipient.GetSmtpAddress()
+		if address == "" {
+			address = recipient.GetEmailAddress()
+		}
+
+		fields := []string{"Final-Recipient: rfc822; " + reportAddress(address)}
+		if r.Type == ReportTypeDelivery {
+			fields = append(fields, "Action: delivered")
+		} else {
+			fields = append(fields, "Action: failed")
+		}
+		fields = append(fields, "Status: "+r.RecipientStatus(recipient))
+
+		if info := strings.TrimSpace(recipient.GetSupplementaryInfo()); info != "" && r.Type == ReportTypeNonDelivery {
+			// the text of the remote server, not an SMTP reply
+			fields = append(fields, "Diagnostic-Code: X-Exchange; "+mimeHeaderValue(info))
+		}
+		if deliverTime := recipient.GetDeliverTime(); !deliverTime.IsZero() {
+			fields = append(fields, "Last-Attempt-Date: "+deliverTime.Format(time.RFC1123Z))
+		} else if !r.ReportTime.IsZero() {
+			fields = append(fields, "Last-Attempt-Date: "+r.ReportTime.Format(time.RFC1123Z))
+		}
+
+		groups = append(groups, fields)
+	}
+
+	return groups
+}
+
+/**
+ * the known headers of the original message (text/rfc822-headers)
+ */
+func originalMessageHeaders(r *Report) []string {
+	headers := []string{}
+	if !r.OriginalSubmitTime.IsZero() {
+		headers = append(headers, "Date: "+r.OriginalSubmitTime.Format(time.RFC1123Z))
+	}
+	if messageId := mimeHeaderValue(r.OriginalMessageId); messageId != "" {
+		headers = append(headers, "Message-ID: "+messageId)
+	}
+	if subject := SanitizeHeaderValue(r.OriginalSubject); subject != "" {
+		headers = append(headers, "Subject: "+mime.QEncoding.Encode("utf-8", subject))
+	}
+	return headers
+}
+
+/**
+ * the address of a Final-Recipient field: without white spaces and control characters, UnknownReportRecipient if it is
+ * empty; the non ASCII addresses are RFC 2047 encoded (the status parts are 7bit)
+ */
+func reportAddress(address string) string {
+	if address = reportToken(address); address == "" {
+		return UnknownReportRecipient
+	}
+	return mimeHeaderValue(address)
+}
+
+/**
+ * remove the white spaces and the control characters of a value that is a single token (address, host name)
+ */
+func reportToken(v string) string {
+	return strings.Join(strings.Fields(SanitizeHeaderValue(v)), "")
+}
@@ -0,0 +1,534 @@
+/**
+ * export a decoded TNEF object as a MIME message (RFC 5322 / RFC 2045)
+ */
+
+package tnefdecoder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+/**
+ * a MIME entity; multipart entities have Parts, the others have the (already encoded) Body
+ */
+type mimePart struct {
+	Header    textproto.MIMEHeader
+	MediaType string
+	Params    map[string]string // the parameters of a multipart Content-Type, besides the boundary
+	Body      []byte
+	Parts     []*mimePart
+}
+
+/**
+ * return the encoded content of the entity; for multipart entities the boundary is added to the Content-Type header
+ */
+func (p *mimePart) content() []byte {
+	if len(p.Parts) == 0 {
+		return p.Body
+	}
+
+	// the parts are written like multipart.Writer does, with the folded headers of writeMimeHeader
+	buf := &bytes.Buffer{}
+	boundary := multipart.NewWriter(buf).Boundary()
+	for i, child := range p.Parts {
+		content := child.content()
+		if i > 0 {
+			buf.WriteString("\r\n")
+		}
+		buf.WriteString("--" + boundary + "\r\n")
+		writeMimeHeader(buf, child.Header)
+		buf.WriteString("\r\n")
+		buf.Write(content)
+	}
+	buf.WriteString("\r\n--" + boundary + "--\r\n")
+
+	params := map[string]string{"boundary": boundary}
+	for k, v := range p.Params {
+		params[k] = v
+	}
+	p.Header.Set("Content-Type", mime.FormatMediaType(p.MediaType, params))
+	return buf.Bytes()
+}
+
+/**
+ * export the TNEF object as a MIME message
+ * the message structure is: multipart/mixed( multipart/related( multipart/alternative( text, html ), inline attachments ), attachments )
+ * a multipart container is generated only if it has more than one part; the reports are wrapped in a multipart/report
+ */
+func ExportMime(t *TnefObject) ([]byte, error) {
+	root := mimeMessageBody(t)
+	if report := t.GetReport(); report != nil {
+		root = newMimeReportPart(report, root, t)
+	}
+
+	header := GetMimeHeader(t)
+	content := root.content()
+	for k, v := range root.Header {
+		header[k] = v
+	}
+
+	buf := &bytes.Buffer{}
+	writeMimeHeader(buf, header)
+	buf.WriteString("\r\n")
+	buf.Write(content)
+
+	return buf.Bytes(), nil
+}
+
+/**
+ * return the message headers (without the content headers)
+ */
+func GetMimeHeader(t *TnefObject) textproto.MIMEHeader {
+	header := textproto.MIMEHeader{}
+	header.Set("MIME-Version", "1.0")
+
+	// PidTagSubject may be Unicode, attSubject is always 8-bit
+	subject := t.GetAttributeStringValue(MapiPidTagSubject, "mapi")
+	if subject == "" {
+		subject = t.GetAttributeStringValue(AttSubject, "mapped")
+	}
+	if subject != "" {
+		header.Set("Subject", mime.QEncoding.Encode("utf-8", subject))
+	}
+
+	attr := t.GetAttribute(AttDateSent, "mapped")
+	if attr == nil {
+		attr = t.GetAttribute(MapiPidTagClientSubmitTime, "mapi")
+	}
+	if attr != nil && !attr.GetTimeValue().IsZero() {
+		header.Set("Date", attr.GetTimeValue().Format(time.RFC1123Z))
+	}
+
+	setMimeSenderHeaders(t, header)
+	setMimeRecipientHeaders(t, header)
+	setMimeFollowUpHeaders(t, header)
+	setMimeInternetHeaders(t, header)
+
+	return header
+}
+
+/**
+ * From and Sender (RFC 5322 section 3.6.2): the From is the user on whose behalf the message was sent, the Sender is
+ * the user who sent it if it is another user
+ */
+func setMimeSenderHeaders(t *TnefObject, header textproto.MIMEHeader) {
+	sender := t.Sender()
+	from := t.SentRepresenting()
+	if from.GetSmtpAddress() == "" {
+		from = sender
+	}
+	if v := from.MimeAddress(); v != "" {
+		header.Set("From", v)
+	}
+	if from != sender && !sender.sameAs(from) {
+		if v := sender.MimeAddress(); v != "" {
+			header.Set("Sender", v)
+		}
+	}
+}
+
+/**
+ * To and Cc from the recipient table; the Bcc recipients and the recipients without SMTP address are not exported
+ */
+func setMimeRecipientHeaders(t *TnefObject, header textproto.MIMEHeader) {
+	addresses := map[int][]string{}
+	for _, r := range t.Recipients {
+		a := &MessageAddress{DisplayName: r.GetDisplayName(), SmtpAddress: r.GetSmtpAddress()}
+		if v := a.MimeAddress(); v != "" {
+			addresses[r.GetRecipientType()] = append(addresses[r.GetRecipientType()], v)
+		}
+	}
+	if len(addresses[RecipientTypeTo]) > 0 {
+		header.Set("To", strings.Join(addresses[RecipientTypeTo], ", "))
+	}
+	if len(addresses[RecipientTypeCc]) > 0 {
+		header.Set("Cc", strings.Join(addresses[RecipientTypeCc], ", "))
+	}
+}
+
+/**
+ * the categories and the follow-up flag: Keywords, X-Message-Flag and Reply-By (RFC 2156); the importers map them to
+ * IMAP keywords and to the \Flagged flag
+ */
+func setMimeFollowUpHeaders(t *TnefObject, header textproto.MIMEHeader) {
+	if categories := t.GetCategories(); len(categories) > 0 {
+		keywords := make([]string, len(categories))
+		for i, c := range categories {
+			keywords[i] = mime.QEncoding.Encode("utf-8", strings.ReplaceAll(c, ",", " "))
+		}
+		header.Set("Keywords", strings.Join(keywords, ", "))
+	}
+
+	if t.IsFlagged() {
+		header.Set("X-Message-Flag", mime.QEncoding.Encode("utf-8", t.GetFlagRequest()))
+	}
+
+	if replyTime := t.GetReplyTime(); !replyTime.IsZero() {
+		header.Set("Reply-By", replyTime.Format(time.RFC1123Z))
+	}
+}
+
+/**
+ * the headers recovered from the internet headers of the message (see GetInternetHeaders): the threading headers
+ * (Message-ID, In-Reply-To, References, Thread-Topic, Thread-Index), the mailing list headers (List-*) and the extension
+ * headers (X-*) but the filter verdicts and server stamps (see internetHeaderDenylist); the headers generated by the
+ * export are kept
+ */
+func setMimeInternetHeaders(t *TnefObject, header textproto.MIMEHeader) {
+	for key, values := range t.GetInternetHeaders() {
+		if _, found := header[key]; found || !isExportedInternetHeader(key) {
+			continue
+		}
+		for _, v := range values {
+			header.Add(key, mimeHeaderValue(v))
+		}
+	}
+}
+
+/**
+ * the verdicts of the filters and the stamps of the servers which received the message: the importing server would
+ * trust them as if it had set them
+ */
+var internetHeaderDenylist = []string{
+	"x-ms-exchange-",
+	"x-forefront-antispam",
+	"x-microsoft-antispam",
+	"x-spam",
+	"x-virus",
+}
+
+func isExportedInternetHeader(key string) bool {
+	switch key {
+	case "Message-Id", "In-Reply-To", "References", "Thread-Topic", "Thread-Index":
+		return true
+	case "X-Ms-Tnef-Correlator":
+		// references the TNEF attachment, which is not in the exported message
+		return false
+	}
+	lower := strings.ToLower(key)
+	if strings.Contains(lower, "authentication-results") {
+		// X-Original-Authentication-Results, X-MS-Exchange-Authentication-Results, ...
+		return false
+	}
+	for _, prefix := range internetHeaderDenylist {
+		if strings.HasPrefix(lower, prefix) {
+			return false
+		}
+	}
+	return strings.HasPrefix(key, "List-") || strings.HasPrefix(key, "X-")
+}
+
+/**
+ * RFC 2047 encode the values having non ASCII characters (the values of the properties are decoded); the value is
+ * unfolded and its control characters are removed
+ */
+func mimeHeaderValue(v string) string {
+	v = SanitizeHeaderValue(v)
+	for i := 0; i < len(v); i++ {
+		if v[i] >= 0x80 {
+			return mime.QEncoding.Encode("utf-8", v)
+		}
+	}
+	return v
+}
+
+/**
+ * build the MIME tree of the message content
+ */
+func mimeMessageBody(t *TnefObject) *mimePart {
+	alternative := &mimePart{Header: textproto.MIMEHeader{}, MediaType: "multipart/alternative"}
+	if text := t.GetTextBody(); len(text) > 0 {
+		alternative.Parts = append(alternative.Parts, newMimeTextPart("text/plain", text))
+	}
+	if html := t.GetHtmlBody(); len(html) > 0 {
+		alternative.Parts = append(alternative.Parts, newMimeTextPart("text/html", html))
+	}
+	if len(alternative.Parts) == 0 {
+		// the reports without body get their description
+		alternative.Parts = append(alternative.Parts, newMimeTextPart("text/plain", []byte(t.GetReportDescription())))
+	}
+
+	related := &mimePart{Header: textproto.MIMEHeader{}, MediaType: "multipart/related"}
+	related.Parts = append(related.Parts, mimeSinglePart(alternative))
+
+	mixed := &mimePart{Header: textproto.MIMEHeader{}, MediaType: "multipart/mixed"}
+
+	for _, a := range t.Attachments {
+		if ref := a.GetReference(); ref != nil {
+			mixed.Parts = append(mixed.Parts, newMimeExternalBodyPart(a, ref))
+			continue
+		}
+
+		if len(a.GetData()) == 0 {
+			continue
+		}
+
+		if a.HasCID() && len(t.GetHtmlBody()) > 0 {
+			related.Parts = append(related.Parts, newMimeAttachmentPart(a, "inline"))
+		} else {
+			mixed.Parts = append(mixed.Parts, newMimeAttachmentPart(a, "attachment"))
+		}
+	}
+
+	mixed.Parts = append([]*mimePart{mimeSinglePart(related)}, mixed.Parts...)
+
+	return mimeSinglePart(mixed)
+}
+
+/**
+ * replace a multipart container having a single part with the part itself
+ */
+func mimeSinglePart(p *mimePart) *mimePart {
+	if len(p.Parts) == 1 {
+		return p.Parts[0]
+	}
+	return p
+}
+
+/**
+ * text part, utf-8 quoted-printable; the bodies are converted to UTF-8 by the accessors, the remaining 8-bit text
+ * (unknown code page) is read as windows-1252
+ */
+func newMimeTextPart(contentType string, data []byte) *mimePart {
+	if !utf8.Valid(data) {
+		data = []byte(DecodeCodepage(data, 1252))
+	}
+	p := &mimePart{Header: textproto.MIMEHeader{}}
+	p.Header.Set("Content-Type", mime.FormatMediaType(contentType, map[string]string{"charset": "utf-8"}))
+	p.Header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	buf := &bytes.Buffer{}
+	qp := quotedprintable.NewWriter(buf)
+	qp.Write(data)
+	qp.Close()
+	p.Body = buf.Bytes()
+
+	return p
+}
+
+/**
+ * attachment part, base64
+ */
+func newMimeAttachmentPart(a *Attachment, disposition string) *mimePart {
+	filename := a.GetFilename()
+
+	// keep the parameters of the declared type (ex: text/calendar; method=REQUEST)
+	contentType := a.ContentType()
+	params := map[string]string{}
+	if mediaType, declared, err := mime.ParseMediaType(a.GetAttributeStringValue(MapiPidTagAttachMimeTag, "mapi")); err == nil && NormalizeContentType(mediaType) == contentType {
+		params = declared
+	}
+	params["name"] = filename
+
+	p := &mimePart{Header: textproto.MIMEHeader{}}
+	p.Header.Set("Content-Type", formatMimeMediaType(contentType, params))
+	p.Header.Set("Content-Disposition", formatMimeMediaType(disposition, map[string]string{"filename": filename}))
+	p.Header.Set("Content-Transfer-Encoding", "base64")
+	if cid := a.GetCID(); cid != "" {
+		p.Header.Set("Content-ID", "<"+cid+">")
+	}
+	p.Body = mimeBase64(a.GetData())
+
+	return p
+}
+
+/**
+ * attachment by reference as message/external-body (RFC 2046 section 5.2.3, RFC 4483 for URLs)
+ * the phantom headers describe the referenced file
+ */
+func newMimeExternalBodyPart(a *Attachment, ref *ReferenceAttachment) *mimePart {
+	params := map[string]string{}
+	if ref.Url != "" {
+		params["access-type"] = "URL"
+		params["url"] = ref.Url
+	} else {
+		params["access-type"] = "local-file"
+		params["name"] = ref.Path
+	}
+
+	p := &mimePart{Header: textproto.MIMEHeader{}}
+	p.Header.Set("Content-Type", formatMimeMediaType("message/external-body", params))
+
+	filename := a.GetFilename()
+	phantom := textproto.MIMEHeader{}
+	phantom.Set("Content-Type", formatMimeMediaType(a.ContentType(), map[string]string{"name": filename}))
+	phantom.Set("Content-Disposition", formatMimeMediaType("attachment", map[string]string{"filename": filename}))
+
+	description := []string{}
+	if ref.ProviderType != "" {
+		description = append(description, ref.ProviderType)
+	}
+	if permission := ref.GetPermissionName(); permission != "" {
+		description = append(description, permission)
+	}
+	if len(description) > 0 {
+		phantom.Set("Content-Description", mime.QEncoding.Encode("utf-8", strings.Join(description, ", ")))
+	}
+
+	buf := &bytes.Buffer{}
+	writeMimeHeader(buf, phantom)
+	buf.WriteString("\r\n")
+	p.Body = buf.Bytes()
+
+	return p
+}
+
+/**
+ * base64 encoding, lines of 76 characters
+ */
+func mimeBase64(data []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	buf := &bytes.Buffer{}
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76])
+		buf.WriteString("\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded)
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+// the recommended length of the header lines (RFC 5322 section 2.1.1)
+const mimeLineLength = 78
+
+// the parameter values longer than this length are split into RFC 2231 continuations
+const mimeParameterLength = 60
+
+/**
+ * write the headers sorted by name; the long fields are folded
+ * the fields having an invalid name or a value with line breaks or control characters are not written: they could
+ * inject fields or end the header
+ */
+func writeMimeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		if isInternetHeaderName(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		for _, v := range header[k] {
+			if !isHeaderValueSafe(v) {
+				continue
+			}
+			buf.WriteString(foldMimeHeader(k+": "+v) + "\r\n")
+		}
+	}
+}
+
+/**
+ * fold a header field at the white spaces (RFC 5322 section 2.2.3): a line break is inserted before the white space
+ * ending a line longer than mimeLineLength
+ */
+func foldMimeHeader(field string) string {
+	if len(field) <= mimeLineLength {
+		return field
+	}
+
+	// the words with their leading white space
+	words := []string{}
+	start := 0
+	for i := 1; i < len(field); i++ {
+		if (field[i] == ' ' || field[i] == '\t') && field[i-1] != ' ' && field[i-1] != '\t' {
+			words = append(words, field[start:i])
+			start = i
+		}
+	}
+	words = append(words, field[start:])
+
+	var b strings.Builder
+	line := ""
+	for _, w := range words {
+		if line != "" && len(line)+len(w) > mimeLineLength && strings.TrimSpace(w) != "" {
+			b.WriteString(line + "\r\n")
+			line = ""
+		}
+		line += w
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+/**
+ * format a Content-Type / Content-Disposition value like mime.FormatMediaType; the long parameter values (URLs, long
+ * filenames) are split into RFC 2231 continuations (name*0, name*1, ...) so that the field can be folded
+ */
+func formatMimeMediaType(mediaType string, params map[string]string) string {
+	short := map[string]string{}
+	long := []string{}
+	for k, v := range params {
+		if len(v) > mimeParameterLength {
+			long = append(long, k)
+		} else {
+			short[k] = v
+		}
+	}
+	s := mime.FormatMediaType(mediaType, short)
+	if s == "" || len(long) == 0 {
+		return s
+	}
+	sort.Strings(long)
+
+	for _, k := range long {
+		v := params[k]
+		if isAsciiText(v) {
+			for i := 0; len(v) > 0; i++ {
+				n := mimeParameterLength
+				if n > len(v) {
+					n = len(v)
+				}
+				// the quoting of mime.FormatMediaType
+				section := mime.FormatMediaType("x/x", map[string]string{k + "*" + strconv.Itoa(i): v[:n]})
+				if section == "" {
+					return mime.FormatMediaType(mediaType, params)
+				}
+				s += "; " + strings.TrimPrefix(section, "x/x; ")
+				v = v[n:]
+			}
+			continue
+		}
+
+		// extended values: charset'language'percent-encoded value (RFC 2231 section 4.1)
+		encoded := []string{}
+		for i := 0; i < len(v); i++ {
+			if c := v[i]; c < 0x80 && (c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("!#$&+-.^_`|~", c) >= 0) {
+				encoded = append(encoded, string(c))
+			} else {
+				encoded = append(encoded, fmt.Sprintf("%%%02X", c))
+			}
+		}
+		section, count := "utf-8''", 0
+		for i, e := range encoded {
+			section += e
+			if len(section) >= mimeParameterLength || i == len(encoded)-1 {
+				s += "; " + k + "*" + strconv.Itoa(count) + "*=" + section
+				section = ""
+				count++
+			}
+		}
+	}
+	return s
+}
+
+func isAsciiText(v string) bool {
+	for i := 0; i < len(v); i++ {
+		if v[i] < 0x20 || v[i] >= 0x7F {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,474 @@
+package tnefdecoder
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+/**
+ * a message whose subject, internet headers and attachment filename try to inject header fields
+ */
+func mimeTestMessage(subject string, inReplyTo string, transportHeaders string, filename string) *TnefObject {
+	t := &TnefObject{}
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagSubject, subject))
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagBody, "Hello"))
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagInReplyToId, inReplyTo))
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagTransportMessageHeaders, transportHeaders))
+	t.SetAttribute(NewMapiStringAttribute(0, "value\r\nBcc: named@example.com").Named(PsInternetHeaders, "X-Named"))
+
+	a := NewAttachment()
+	a.SetFilename(filename)
+	a.SetData([]byte("data"))
+	t.Attachments = append(t.Attachments, a)
+	return t
+}
+
+func TestExportMimeHeaderInjection(t *testing.T) {
+	const filename = "report\r\nBcc: file@example.com\r\n\r\n<html>.txt"
+	tObj := mimeTestMessage("Hello\r\nBcc: subject@example.com", "<a@example.com>\r\nBcc: reply@example.com",
+		"X-Folded: no\r\n\tBcc: folded@example.com\r\nX-Raw: a\rBcc: cr@example.com\r\nBcc: transport@example.com\r\n\r\nX-Body: b\r\n",
+		filename)
+	data, err := ExportMime(tObj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkMimeHeader(t, data, textproto.MIMEHeader(msg.Header))
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"In-Reply-To", "<a@example.com>  Bcc: reply@example.com"},
+		{"X-Folded", "no Bcc: folded@example.com"},
+		{"X-Raw", "a Bcc: cr@example.com"},
+		{"X-Named", "value  Bcc: named@example.com"},
+		{"X-Body", ""},
+	}
+	for _, tt := range tests {
+		if v := msg.Header.Get(tt.key); v != tt.want {
+			t.Errorf("%s: %q, want %q", tt.key, v, tt.want)
+		}
+	}
+	if subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); err != nil || subject != "Hello\r\nBcc: subject@example.com" {
+		t.Errorf("subject %q %v", subject, err)
+	}
+
+	// the filename is percent-encoded in the parameters of the attachment part
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	found := false
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if part.Header.Get("Bcc") != "" {
+			t.Errorf("injected part header %v", part.Header)
+		}
+		if _, params, err := mime.ParseMediaType(part.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+			found = true
+			if params["filename"] != filename {
+				t.Errorf("filename %q", params["filename"])
+			}
+		}
+	}
+	if !found {
+		t.Error("no attachment part")
+	}
+}
+
+func TestWriteMimeHeaderUnsafe(t *testing.T) {
+	header := textproto.MIMEHeader{
+		"Subject":       {"ok"},
+		"X-Cr":          {"a\rb"},
+		"X-Lf":          {"a\nBcc: b"},
+		"X-Nul":         {"a\x00b"},
+		"X-Tab":         {"a\tb"},
+		"Bcc: b\r\nX-A": {"c"},
+		"":              {"empty"},
+		"X-Space Name":  {"d"},
+	}
+	buf := &bytes.Buffer{}
+	writeMimeHeader(buf, header)
+	if want := "Subject: ok\r\nX-Tab: a\tb\r\n"; buf.String() != want {
+		t.Errorf("%q, want %q", buf.String(), want)
+	}
+}
+
+func TestFoldMimeHeader(t *testing.T) {
+	long := "Thread-Topic: " + strings.Repeat("word ", 30)
+	unbreakable := "X-Id: " + strings.Repeat("x", 100) + " end"
+
+	tests := []struct {
+		name  string
+		field string
+		lines int
+	}{
+		{"short", "Subject: hello world", 1},
+		{"long", long, 3},
+		{"unbreakable word", unbreakable, 3},
+	}
+	for _, tt := range tests {
+		folded := foldMimeHeader(tt.field)
+		lines := strings.Split(folded, "\r\n")
+		if len(lines) != tt.lines {
+			t.Errorf("%s: %d lines, want %d: %q", tt.name, len(lines), tt.lines, folded)
+		}
+		for i, line := range lines {
+			if i > 0 && line[0] != ' ' && line[0] != '\t' {
+				t.Errorf("%s: continuation line without white space %q", tt.name, line)
+			}
+			if len(line) > mimeLineLength && !strings.Contains(line, strings.Repeat("x", 100)) {
+				t.Errorf("%s: line of %d characters", tt.name, len(line))
+			}
+		}
+		if unfolded := strings.ReplaceAll(folded, "\r\n", ""); unfolded != tt.field {
+			t.Errorf("%s: unfolded %q", tt.name, unfolded)
+		}
+	}
+}
+
+func TestFormatMimeMediaType(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]string
+	}{
+		{"short", map[string]string{"name": "report.pdf"}},
+		{"long URL", map[string]string{"access-type": "URL", "url": "https://example.com/sites/team/Shared%20Documents/" + strings.Repeat("folder/", 30) + "report.docx?web=1"}},
+		{"long quoted", map[string]string{"name": strings.Repeat(`a "quoted" name; `, 8) + ".txt"}},
+		{"long non-ASCII", map[string]string{"filename": strings.Repeat("Übersicht ", 10) + ".xlsx"}},
+		{"line breaks", map[string]string{"filename": strings.Repeat("x", 70) + "\r\nBcc: a@example.com\r\n.txt"}},
+		{"short non-ASCII", map[string]string{"name": "Überblick.txt"}},
+	}
+	for _, tt := range tests {
+		v := formatMimeMediaType("application/octet-stream", tt.params)
+		if strings.ContainsAny(v, "\r\n") {
+			t.Errorf("%s: line break in %q", tt.name, v)
+		}
+		mediaType, params, err := mime.ParseMediaType(v)
+		if err != nil {
+			t.Errorf("%s: %q: %v", tt.name, v, err)
+			continue
+		}
+		if mediaType != "application/octet-stream" {
+			t.Errorf("%s: media type %q", tt.name, mediaType)
+		}
+		for k, want := range tt.params {
+			if params[k] != want {
+				t.Errorf("%s: %s = %q, want %q", tt.name, k, params[k], want)
+			}
+		}
+		for _, line := range strings.Split(foldMimeHeader("Content-Type: "+v), "\r\n") {
+			if len(line) > mimeLineLength {
+				t.Errorf("%s: line of %d characters: %q", tt.name, len(line), line)
+			}
+		}
+	}
+}
+
+func TestSanitizeHeaderValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"plain", "plain"},
+		{" trimmed\r\n", "trimmed"},
+		{"a\r\n b", "a   b"},
+		{"a\rb\nc\td", "a b c d"},
+		{"a\x00b\x1bc\x7fd", "abcd"},
+		{"Grüße", "Grüße"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if v := SanitizeHeaderValue(tt.value); v != tt.want {
+			t.Errorf("%q: %q, want %q", tt.value, v, tt.want)
+		}
+		if !isHeaderValueSafe(SanitizeHeaderValue(tt.value)) {
+			t.Errorf("%q: unsafe value", tt.value)
+		}
+	}
+}
+
+func TestParseInternetHeaders(t *testing.T) {
+	header := ParseInternetHeaders("Microsoft Mail Internet Headers Version 2.0\r\n" +
+		"Message-ID: <a@example.com>\r\n" +
+		"Subject: a\r\n folded\r\n\tsubject\r\n" +
+		"X-Cr: a\rBcc: b\r\n" +
+		"Bad Name: c\r\n" +
+		"X-Empty: \r\n" +
+		"\r\n" +
+		"X-Body: d\r\n")
+
+	want := textproto.MIMEHeader{
+		"Message-Id": {"<a@example.com>"},
+		"Subject":    {"a folded subject"},
+		"X-Cr":       {"a Bcc: b"},
+	}
+	if len(header) != len(want) {
+		t.Errorf("%v, want %v", header, want)
+	}
+	for k, v := range want {
+		if header.Get(k) != v[0] {
+			t.Errorf("%s: %q, want %q", k, header.Get(k), v[0])
+		}
+	}
+}
+
+func TestExportMimeHeaderMalformed(t *testing.T) {
+	seed := []byte("Subject \xe9\r\nBcc: a@example.com\r\n\tX-Folded: b\r\n\r\n")
+	checkMalformed(t, func(b []byte) { exportMimeHeaders(t, string(b)) }, seed)
+}
+
+func FuzzExportMimeHeaders(f *testing.F) {
+	f.Add("X-A: b\r\nBcc: a@example.com\r\n\r\n")
+	f.Add("subject\r\nBcc: a@example.com")
+	f.Fuzz(func(t *testing.T, value string) {
+		exportMimeHeaders(t, value)
+	})
+}
+
+/**
+ * export a message having the value as subject, In-Reply-To, transport headers and filename; the header must parse
+ * and have only the exported fields
+ */
+func exportMimeHeaders(t *testing.T, value string) {
+	data, err := ExportMime(mimeTestMessage(value, value, value, value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("%q: %v", value, err)
+	}
+	checkMimeHeader(t, data, textproto.MIMEHeader(msg.Header))
+}
+
+/**
+ * the message header has only the fields of the export and the exported internet headers; the fields are folded
+ */
+func checkMimeHeader(t *testing.T, data []byte, header textproto.MIMEHeader) {
+	t.Helper()
+	for key := range header {
+		switch key {
+		case "Mime-Version", "Subject", "To", "Cc", "Content-Type", "Content-Transfer-Encoding":
+		default:
+			if !isExportedInternetHeader(key) {
+				t.Fatalf("injected field %s in %q", key, data)
+			}
+		}
+	}
+
+	end := bytes.Index(data, []byte("\r\n\r\n"))
+	for _, line := range strings.Split(string(data[:end]), "\r\n") {
+		if len(line) > 998 {
+			t.Fatalf("line of %d characters", len(line))
+		}
+	}
+}
+
+/**
+ * a string property in a code page (PtypString8)
+ */
+func mimeTestString8(id int, value []byte) *Attribute {
+	return NewMapiAttribute(id, MapiTypeString8, MapiEncodeVariableValues([][]byte{append(value, 0)}))
+}
+
+/**
+ * the decoded leaf parts of a message by media type
+ */
+func mimeTestParts(t *testing.T, data []byte) (textproto.MIMEHeader, map[string]string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := map[string]string{}
+	var read func(header textproto.MIMEHeader, body io.Reader)
+	read = func(header textproto.MIMEHeader, body io.Reader) {
+		mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(mediaType, "multipart/") {
+			reader := multipart.NewReader(body, params["boundary"])
+			for {
+				part, err := reader.NextPart()
+				if err == io.EOF {
+					return
+				} else if err != nil {
+					t.Fatal(err)
+				}
+				// the reader decodes the quoted-printable parts
+				read(part.Header, part)
+			}
+		}
+		if params["charset"] != "" && params["charset"] != "utf-8" {
+			t.Errorf("%s: charset %q", mediaType, params["charset"])
+		}
+		b, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Get("Content-Transfer-Encoding") == "quoted-printable" {
+			b, _ = io.ReadAll(quotedprintable.NewReader(bytes.NewReader(b)))
+		}
+		parts[mediaType] = string(b)
+	}
+	read(textproto.MIMEHeader(msg.Header), msg.Body)
+	return textproto.MIMEHeader(msg.Header), parts
+}
+
+func TestExportMimeRecipients(t *testing.T) {
+	tObj := &TnefObject{}
+	tObj.SetAttribute(NewMapiStringAttribute(MapiPidTagBody, "Hello"))
+	ex := NewRecipient()
+	ex.SetAttribute(NewMapiStringAttribute(MapiPidTagDisplayName, "Exchange User"))
+	ex.SetAttribute(NewMapiStringAttribute(MapiPidTagAddressType, "EX"))
+	ex.SetAttribute(NewMapiStringAttribute(MapiPidTagEmailAddress, "/o=Example/cn=Recipients/cn=user"))
+	tObj.Recipients = append(tObj.Recipients,
+		NewSmtpRecipient("Jane Doe", "jane@example.com", RecipientTypeTo),
+		NewSmtpRecipient("Müller, Hans", "hans@example.com", RecipientTypeTo),
+		NewSmtpRecipient("", "copy@example.com", RecipientTypeCc),
+		NewSmtpRecipient("Blind", "bcc@example.com", RecipientTypeBcc),
+		ex)
+
+	data, err := ExportMime(tObj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _ := mimeTestParts(t, data)
+	checkMimeHeader(t, data, header)
+
+	tests := []struct {
+		key  string
+		want []*mail.Address
+	}{
+		{"To", []*mail.Address{{Name: "Jane Doe", Address: "jane@example.com"}, {Name: "Müller, Hans", Address: "hans@example.com"}}},
+		{"Cc", []*mail.Address{{Name: "copy@example.com", Address: "copy@example.com"}}},
+	}
+	for _, tt := range tests {
+		list, err := mail.ParseAddressList(header.Get(tt.key))
+		if err != nil {
+			t.Errorf("%s: %q: %v", tt.key, header.Get(tt.key), err)
+			continue
+		}
+		if !reflect.DeepEqual(list, tt.want) {
+			t.Errorf("%s: %q", tt.key, header.Get(tt.key))
+		}
+	}
+	if v := header.Get("Bcc"); v != "" {
+		t.Errorf("Bcc: %q", v)
+	}
+}
+
+func TestExportMimeCodepage(t *testing.T) {
+	tObj := &TnefObject{Codepage: 1252}
+	tObj.SetAttribute(mimeTestString8(MapiPidTagSubject, []byte("R\xe9sum\xe9")))
+	tObj.SetAttribute(NewMappedStringAttribute(AttrLevelMessage, AttSubject, "ignored"))
+	tObj.SetAttribute(mimeTestString8(MapiPidTagBody, []byte("Gr\xfc\xdfe \x80")))
+	tObj.SetAttribute(NewMapiBinaryAttribute(MapiPidTagBodyHtml, []byte("<p>\xa4</p>")))
+	tObj.SetAttribute(NewMapiIntAttribute(MapiPidTagInternetCodepage, 28605))
+
+	data, err := ExportMime(tObj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, parts := mimeTestParts(t, data)
+	if subject, err := new(mime.WordDecoder).DecodeHeader(header.Get("Subject")); err != nil || subject != "Résumé" {
+		t.Errorf("subject %q %v", subject, err)
+	}
+	if parts["text/plain"] != "Grüße €" {
+		t.Errorf("text %q", parts["text/plain"])
+	}
+	if parts["text/html"] != "<p>€</p>" {
+		t.Errorf("HTML %q", parts["text/html"])
+	}
+
+	// the mapped attSubject is used if there is no PidTagSubject
+	tObj = &TnefObject{Codepage: 1252}
+	tObj.SetAttribute(NewMappedStringAttribute(AttrLevelMessage, AttSubject, "Caf\xe9"))
+	tObj.SetAttribute(NewMapiBinaryAttribute(MapiPidTagBodyHtml, []byte("<p>\xe9</p>")))
+	data, err = ExportMime(tObj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, parts = mimeTestParts(t, data)
+	if subject, err := new(mime.WordDecoder).DecodeHeader(header.Get("Subject")); err != nil || subject != "Café" {
+		t.Errorf("attSubject %q %v", subject, err)
+	}
+	if parts["text/html"] != "<p>é</p>" {
+		t.Errorf("HTML in the code page of the message %q", parts["text/html"])
+	}
+}
+
+func TestExportMimeUnknownCodepage(t *testing.T) {
+	tObj := &TnefObject{}
+	tObj.SetTextBody([]byte("caf\xe9"))
+	data, err := ExportMime(tObj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, parts := mimeTestParts(t, data); parts["text/plain"] != "café" {
+		t.Errorf("text %q", parts["text/plain"])
+	}
+}
+
+func TestIsExportedInternetHeader(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"Message-ID", true},
+		{"References", true},
+		{"List-Unsubscribe", true},
+		{"X-Mailer", true},
+		{"X-Custom-Header", true},
+		{"Received", false},
+		{"Authentication-Results", false},
+		{"X-Original-Authentication-Results", false},
+		{"X-MS-Exchange-Organization-SCL", false},
+		{"X-MS-Exchange-Organization-AuthAs", false},
+		{"X-MS-Exchange-Authentication-Results", false},
+		{"X-Forefront-Antispam-Report", false},
+		{"X-Microsoft-Antispam", false},
+		{"X-Spam-Status", false},
+		{"X-Spam-Flag", false},
+		{"X-Virus-Scanned", false},
+		{"X-MS-TNEF-Correlator", false},
+	}
+	for _, tt := range tests {
+		if v := isExportedInternetHeader(textproto.CanonicalMIMEHeaderKey(tt.key)); v != tt.want {
+			t.Errorf("%s: %v, want %v", tt.key, v, tt.want)
+		}
+	}
+
+	tObj := &TnefObject{}
+	tObj.SetAttribute(NewMapiStringAttribute(MapiPidTagTransportMessageHeaders,
+		"X-Spam-Flag: NO\r\nX-MS-Exchange-Organization-SCL: -1\r\nX-Mailer: client\r\n"))
+	data, err := ExportMime(tObj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _ := mimeTestParts(t, data)
+	if header.Get("X-Spam-Flag") != "" || header.Get("X-Ms-Exchange-Organization-Scl") != "" || header.Get("X-Mailer") != "client" {
+		t.Errorf("%v", header)
+	}
+}
@@ -0,0 +1,240 @@
+/**
+ * recurrence of the calendar objects in iCalendar: RRULE, EXDATE and the overrides of the modified occurrences (RECURRENCE-ID)
+ * ([MS-OXCICAL] sections 2.1.3.1.1.20.19, 2.1.3.2.2)
+ */
+
+package tnefdecoder
+
+import (
+	"strings"
+	"time"
+)
+
+/**
+ * the properties of the series that are not inherited by the overrides of the modified occurrences
+ */
+var icalOverrideExcluded = map[string]bool{
+	"DTSTART":                     true,
+	"DTEND":                       true,
+	"RRULE":                       true,
+	"EXDATE":                      true,
+	"RECURRENCE-ID":               true,
+	"SUMMARY":                     true,
+	"LOCATION":                    true,
+	"TRANSP":                      true,
+	"X-MICROSOFT-CDO-BUSYSTATUS":  true,
+	"X-MICROSOFT-CDO-ALLDAYEVENT": true,
+}
+
+/**
+ * the time zone of a recurrence pattern without time zone definition: the offset between the start of the series (UTC) and
+ * the start of the first instance in the pattern (local time); the daylight saving time is not known
+ */
+func recurrenceTimeZone(p *AppointmentRecurrencePattern, start time.Time) *TimeZoneDefinition {
+	if start.IsZero() {
+		return nil
+	}
+	local := p.StartDate.Add(time.Duration(p.StartTimeOffset) * time.Minute)
+	offset := local.Sub(start.UTC()).Round(15 * time.Minute)
+	if offset == 0 || offset > 14*time.Hour || offset < -14*time.Hour {
+		return nil
+	}
+	return NewFixedTimeZone(offset)
+}
+
+/**
+ * check if the attachment is a modified occurrence of a recurring appointment ([MS-OXOCAL] section 2.2.10.1)
+ * the attachment is an embedded message with the properties of the occurrence
+ */
+func (a *Attachment) IsCalendarException() bool {
+	return a.GetAttribute(MapiPidTagExceptionStartTime, "mapi") != nil
+}
+
+/**
+ * return the attachments that are modified occurrences of the recurrence
+ */
+func (t *TnefObject) GetCalendarExceptionAttachments() []*Attachment {
+	result := []*Attachment{}
+	for _, a := range t.Attachments {
+		if a.IsCalendarException() {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+/**
+ * remove the attachments that are modified occurrences; they are exported as overrides of the iCalendar object
+ */
+func (t *TnefObject) RemoveCalendarExceptionAttachments() {
+	attachments := []*Attachment{}
+	for _, a := range t.Attachments {
+		if !a.IsCalendarException() {
+			attachments = append(attachments, a)
+		}
+	}
+	t.Attachments = attachments
+}
+
+/**
+ * find the exception attachment of a modified occurrence: by the start of the exception (local time, PidTagExceptionStartTime)
+ * or by the start of the replaced occurrence (UTC, PidTagExceptionReplaceTime or PidLidExceptionReplaceTime of the embedded message)
+ */
+func findExceptionAttachment(attachments []*Attachment, e *RecurrenceException, originalStart time.Time) *Attachment {
+	for _, a := range attachments {
+		if attr := a.GetAttribute(MapiPidTagExceptionStartTime, "mapi"); attr != nil && attr.GetTimeValue().Equal(e.StartDateTime) {
+			return a
+		}
+	}
+	for _, a := range attachments {
+		if exceptionReplaceTime(a).Equal(originalStart) {
+			return a
+		}
+	}
+	return nil
+}
+
+/**
+ * the original start (UTC) of the occurrence replaced by the exception attachment
+ */
+func exceptionReplaceTime(a *Attachment) time.Time {
+	if attr := a.GetAttribute(MapiPidTagExceptionReplaceTime, "mapi"); attr != nil {
+		return attr.GetTimeValue()
+	}
+	if a.Embedded != nil {
+		return a.Embedded.GetNamedTimeValue(PsetidAppointment, MapiPidLidExceptionReplaceTime)
+	}
+	return time.Time{}
+}
+
+/**
+ * add RRULE and EXDATE to the event and a VEVENT for each modified occurrence
+ * the exception attachments give the body and the attendees of the modified occurrences
+ */
+func addICalendarRecurrence(calendar *ICalComponent, event *ICalComponent, p *AppointmentRecurrencePattern, zone *TimeZoneDefinition, allDay bool, exceptionAttachments []*Attachment) {
+	startOffset := time.Duration(p.StartTimeOffset) * time.Minute
+
+	// UNTIL is a DATE for the all day events, else a UTC DATE-TIME
+	until := ""
+	if p.EndType == RecurEndAfterDate {
+		if allDay {
+			until = p.EndDate.Format(ICalDateFormat)
+		} else {
+			until = ICalFormatDateTime(zone.ToUtc(p.EndDate.Add(startOffset)))
+		}
+	}
+	event.AddProperty("RRULE", p.RRule(until))
+
+	exdates := []string{}
+	params := []string{}
+	for _, d := range p.GetCancelledInstanceDates() {
+		var value string
+		value, params = icalendarTimeValue(d.Add(startOffset), zone, allDay)
+		exdates = append(exdates, value)
+	}
+	if len(exdates) > 0 {
+		event.AddProperty("EXDATE", strings.Join(exdates, ","), params...)
+	}
+
+	method := ""
+	if p := calendar.GetProperty("METHOD"); p != nil {
+		method = p.Value
+	}
+
+	for _, e := range p.Exceptions {
+		override := newICalendarOverride(event, e, zone, allDay)
+		if a := findExceptionAttachment(exceptionAttachments, e, zone.ToUtc(e.OriginalStartDate)); a != nil && a.Embedded != nil {
+			applyICalendarExceptionMessage(override, a.Embedded, method)
+		}
+		calendar.AddComponent(override)
+	}
+}
+
+/**
+ * add the content of the embedded message of an exception to the override: the body, the attendees and the subject and location
+ * (if they are not already set by the recurrence)
+ */
+func applyICalendarExceptionMessage(override *ICalComponent, message *TnefObject, method string) {
+	if body := strings.TrimSpace(string(message.GetTextBody())); body != "" {
+		override.RemoveProperty("DESCRIPTION")
+		override.AddText("DESCRIPTION", body)
+	}
+
+	if len(message.Recipients) > 0 {
+		override.RemoveProperty("ATTENDEE")
+		addICalendarAttendees(message, override, method)
+	}
+
+	if override.GetProperty("SUMMARY") == nil {
+		override.AddText("SUMMARY", message.GetAttributeStringValue(MapiPidTagSubject, "mapi"))
+	}
+	if override.GetProperty("LOCATION") == nil {
+		override.AddText("LOCATION", message.GetNamedStringValue(PsetidAppointment, MapiPidLidLocation))
+	}
+}
+
+/**
+ * create the VEVENT of a modified occurrence: a copy of the properties of the series with the changes of the exception
+ */
+func newICalendarOverride(master *ICalComponent, e *RecurrenceException, zone *TimeZoneDefinition, allDay bool) *ICalComponent {
+	override := NewICalComponent("VEVENT")
+	for _, p := range master.Properties {
+		if !icalOverrideExcluded[p.Name] {
+			override.Properties = append(override.Properties, p.Clone())
+		}
+	}
+
+	addICalendarLocalTime(override, "RECURRENCE-ID", e.OriginalStartDate, zone, allDay)
+
+	exceptionAllDay := allDay
+	if e.OverrideFlags&AroSubType != 0 {
+		exceptionAllDay = e.SubType
+	}
+	addICalendarLocalTime(override, "DTSTART", e.StartDateTime, zone, exceptionAllDay)
+	addICalendarLocalTime(override, "DTEND", e.EndDateTime, zone, exceptionAllDay)
+
+	copyICalendarProperty(override, master, "SUMMARY", e.OverrideFlags&AroSubject != 0, e.Subject)
+	copyICalendarProperty(override, master, "LOCATION", e.OverrideFlags&AroLocation != 0, e.Location)
+
+	if e.OverrideFlags&AroBusyStatus != 0 {
+		addICalendarBusyStatus(override, e.BusyStatus)
+	} else {
+		for _, name := range []string{"TRANSP", "X-MICROSOFT-CDO-BUSYSTATUS"} {
+			if p := master.GetProperty(name); p != nil {
+				override.Properties = append(override.Properties, p.Clone())
+			}
+		}
+	}
+	addICalendarAllDay(override, exceptionAllDay)
+
+	// the reminder of the series, unless the exception changes it
+	alarms := master.GetComponents("VALARM")
+	reminderSet := len(alarms) > 0
+	if e.OverrideFlags&AroReminder != 0 {
+		reminderSet = e.ReminderSet
+	}
+	switch {
+	case !reminderSet:
+	case e.OverrideFlags&AroReminderDelta != 0:
+		override.AddComponent(NewICalendarAlarm(e.ReminderDelta, ICalAlarmDescription))
+	case len(alarms) > 0:
+		for _, alarm := range alarms {
+			override.AddComponent(alarm.Clone())
+		}
+	default:
+		override.AddComponent(NewICalendarAlarm(DefaultReminderDelta, ICalAlarmDescription))
+	}
+
+	return override
+}
+
+/**
+ * set a TEXT property of the override: the changed value, or the value of the series
+ */
+func copyICalendarProperty(override *ICalComponent, master *ICalComponent, name string, changed bool, value string) {
+	if changed {
+		override.AddText(name, value)
+	} else if p := master.GetProperty(name); p != nil {
+		override.Properties = append(override.Properties, p.Clone())
+	}
+}
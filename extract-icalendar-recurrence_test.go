@@ -0,0 +1,63 @@
+package tnefdecoder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewICalendarOverrideCopy(t *testing.T) {
+	master := NewICalComponent("VEVENT")
+	master.AddText("SUMMARY", "Weekly", "LANGUAGE", "en")
+	master.AddText("LOCATION", "Room 1")
+	master.AddProperty("ORGANIZER", "mailto:jane@example.com", "CN", "Jane")
+	master.AddProperty("TRANSP", "OPAQUE")
+	master.AddProperty("X-MICROSOFT-CDO-BUSYSTATUS", "BUSY")
+	master.AddComponent(NewICalendarAlarm(15, ICalAlarmDescription))
+	want := master.Build()
+
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	e := &RecurrenceException{StartDateTime: start, EndDateTime: start.Add(time.Hour), OriginalStartDate: start}
+	override := newICalendarOverride(master, e, nil, false)
+
+	// change everything copied from the series
+	for _, p := range override.Properties {
+		p.Value += "-changed"
+		for _, param := range p.Parameters {
+			param.Values[0] += "-changed"
+		}
+		p.AddParameter("X-ADDED", "1")
+	}
+	alarms := override.GetComponents("VALARM")
+	if len(alarms) != 1 {
+		t.Fatalf("%d alarms, want 1", len(alarms))
+	}
+	alarms[0].GetProperty("TRIGGER").Value = "-PT1M"
+	alarms[0].AddProperty("X-ADDED", "1")
+
+	if got := master.Build(); got != want {
+		t.Errorf("series changed by the override:\n%s\nwant\n%s", got, want)
+	}
+	for _, name := range []string{"SUMMARY", "LOCATION", "ORGANIZER", "TRANSP", "X-MICROSOFT-CDO-BUSYSTATUS"} {
+		if p := override.GetProperty(name); p == nil || p == master.GetProperty(name) {
+			t.Errorf("%s: not copied", name)
+		}
+	}
+}
+
+func TestICalComponentClone(t *testing.T) {
+	c := NewICalComponent("VEVENT")
+	c.AddProperty("ATTENDEE", "mailto:a@example.com", "CN", "A", "ROLE", "REQ-PARTICIPANT")
+	c.AddComponent(NewICalendarAlarm(10, ICalAlarmDescription))
+	want := c.Build()
+
+	clone := c.Clone()
+	if clone.Build() != want {
+		t.Errorf("clone:\n%s", clone.Build())
+	}
+	clone.Properties[0].Parameters[0].Values[0] = "B"
+	clone.Components[0].Properties[0].Value = "AUDIO"
+	clone.AddProperty("SUMMARY", "x")
+	if c.Build() != want {
+		t.Errorf("original changed:\n%s", c.Build())
+	}
+}
@@ -0,0 +1,219 @@
+/**
+ * convert the tasks (IPM.Task) and the task requests (IPM.TaskRequest.*) to iCalendar VTODO ([MS-OXOTASK])
+ */
+
+package tnefdecoder
+
+import (
+	"strconv"
+	"strings"
+)
+
+/**
+ * task message classes ([MS-OXOTASK] section 2.2.1 and 2.2.2)
+ */
+const (
+	MessageClassTask               = "IPM.Task"
+	MessageClassTaskRequest        = "IPM.TaskRequest"
+	MessageClassTaskRequestAccept  = "IPM.TaskRequest.Accept"
+	MessageClassTaskRequestDecline = "IPM.TaskRequest.Decline"
+	MessageClassTaskRequestUpdate  = "IPM.TaskRequest.Update"
+)
+
+/**
+ * PidLidTaskStatus values
+ */
+const (
+	TaskStatusNotStarted = 0
+	TaskStatusInProgress = 1
+	TaskStatusComplete   = 2
+	TaskStatusWaiting    = 3
+	TaskStatusDeferred   = 4
+)
+
+// the name of the iCalendar attachment generated for a task
+const ICalendarTaskFilename = "task.ics"
+
+/**
+ * check if the object is a task (not a task request)
+ */
+func (t *TnefObject) IsTask() bool {
+	messageClass := t.GetMessageClass()
+	return messageClass == MessageClassTask || strings.HasPrefix(messageClass, MessageClassTask+".")
+}
+
+/**
+ * check if the message is a task request, a response or an update
+ */
+func (t *TnefObject) IsTaskRequest() bool {
+	messageClass := t.GetMessageClass()
+	return messageClass == MessageClassTaskRequest || strings.HasPrefix(messageClass, MessageClassTaskRequest+".")
+}
+
+/**
+ * return the iTIP method for a task message class: REQUEST for the requests, REPLY for the responses and the updates
+ * sent by the assignee, PUBLISH for the tasks
+ */
+func TaskICalendarMethod(messageClass string) string {
+	switch {
+	case strings.HasPrefix(messageClass, MessageClassTaskRequestAccept),
+		strings.HasPrefix(messageClass, MessageClassTaskRequestDecline),
+		strings.HasPrefix(messageClass, MessageClassTaskRequestUpdate):
+		return ICalMethodReply
+	case strings.HasPrefix(messageClass, MessageClassTaskRequest):
+		return ICalMethodRequest
+	}
+	return ICalMethodPublish
+}
+
+/**
+ * PidLidTaskStatus -> STATUS
+ */
+func taskStatusName(status int) string {
+	switch status {
+	case TaskStatusInProgress:
+		return "IN-PROCESS"
+	case TaskStatusComplete:
+		return "COMPLETED"
+	case TaskStatusDeferred:
+		return "CANCELLED"
+	}
+	return "NEEDS-ACTION"
+}
+
+/**
+ * the participation status of the assignee in a task response or update
+ */
+func taskParticipationStatus(messageClass string, status int) string {
+	switch {
+	case strings.HasPrefix(messageClass, MessageClassTaskRequestAccept):
+		return "ACCEPTED"
+	case strings.HasPrefix(messageClass, MessageClassTaskRequestDecline):
+		return "DECLINED"
+	}
+	switch status {
+	case TaskStatusInProgress:
+		return "IN-PROCESS"
+	case TaskStatusComplete:
+		return "COMPLETED"
+	}
+	return "NEEDS-ACTION"
+}
+
+/**
+ * convert the task properties of the object to a VCALENDAR with one VTODO
+ * message is the task request carrying the task as an embedded message (it gives the iTIP method, the assigner and the assignee);
+ * it is the task itself for a task that is not assigned
+ */
+func ExtractICalendarTask(task *TnefObject, message *TnefObject) *ICalComponent {
+	if message == nil {
+		message = task
+	}
+	messageClass := message.GetMessageClass()
+	method := ICalMethodPublish
+	if message.IsTaskRequest() {
+		method = TaskICalendarMethod(messageClass)
+	}
+
+	calendar := NewICalendar(method)
+	todo := NewICalComponent("VTODO")
+	calendar.AddComponent(todo)
+
+	if uid := task.GetNamedBinaryValue(PsetidCommon, MapiPidLidTaskGlobalId); len(uid) == 16 {
+		todo.AddText("UID", new(LittleEndianDecoder).Guid(uid))
+	}
+
+	todo.AddDateTime("DTSTAMP", icalendarStamp(task.GetNamedTimeValue(PsetidTask, MapiPidLidTaskLastUpdate), message, task))
+
+	subject := task.GetAttributeStringValue(MapiPidTagSubject, "mapi")
+	if subject == "" {
+		subject = task.GetAttributeStringValue(AttSubject, "mapped")
+	}
+	todo.AddText("SUMMARY", subject)
+	todo.AddText("DESCRIPTION", strings.TrimSpace(string(task.GetTextBody())))
+
+	// the start and due dates are dates in the time zone of the user
+	todo.AddDate("DTSTART", task.GetNamedTimeValue(PsetidTask, MapiPidLidTaskStartDate))
+	todo.AddDate("DUE", task.GetNamedTimeValue(PsetidTask, MapiPidLidTaskDueDate))
+
+	status := task.GetNamedIntValue(PsetidTask, MapiPidLidTaskStatus)
+	if task.GetNamedBoolValue(PsetidTask, MapiPidLidTaskComplete) {
+		status = TaskStatusComplete
+	}
+	todo.AddProperty("STATUS", taskStatusName(status))
+
+	if attr := task.GetNamedAttribute(PsetidTask, MapiPidLidPercentComplete); attr != nil {
+		todo.AddProperty("PERCENT-COMPLETE", strconv.Itoa(int(MapiDecodeFloat64(attr.Data)*100+0.5)))
+	}
+	if status == TaskStatusComplete {
+		todo.AddDateTime("COMPLETED", task.GetNamedTimeValue(PsetidTask, MapiPidLidTaskDateCompleted))
+	}
+
+	addICalendarClassification(task, todo)
+
+	if task.GetNamedBoolValue(PsetidTask, MapiPidLidTaskFRecurring) {
+		if recurrence, err := DecodeRecurrencePattern(task.GetNamedBinaryValue(PsetidTask, MapiPidLidTaskRecurrence)); err == nil {
+			until := ""
+			if recurrence.EndType == RecurEndAfterDate {
+				until = recurrence.EndDate.Format(ICalDateFormat)
+			}
+			todo.AddProperty("RRULE", recurrence.RRule(until))
+		}
+	}
+
+	addICalendarTaskParticipants(task, message, todo, method)
+	addICalendarTaskAlarm(task, todo, ICalAlarmDescription)
+
+	return calendar
+}
+
+/**
+ * ORGANIZER (the assigner) and ATTENDEE (the owner of the task)
+ * for a request the assigner is the sender and the owners are the recipients; for the responses and the updates
+ * the owner is the sender and the assigner is the recipient
+ */
+func addICalendarTaskParticipants(task *TnefObject, message *TnefObject, todo *ICalComponent, method string) {
+	senderName, senderAddress := messageSender(message)
+	owner := task.GetNamedStringValue(PsetidTask, MapiPidLidTaskOwner)
+
+	switch method {
+	case ICalMethodRequest:
+		addICalendarAddress(todo, "ORGANIZER", senderName, senderAddress)
+		for _, r := range message.Recipients {
+			if r.GetRecipientType() != RecipientTypeTo {
+				continue
+			}
+			if attendee := addICalendarAddress(todo, "ATTENDEE", r.GetDisplayName(), r.GetSmtpAddress()); attendee != nil {
+				attendee.AddParameter("PARTSTAT", "NEEDS-ACTION")
+				attendee.AddParameter("RSVP", "TRUE")
+			}
+		}
+	case ICalMethodReply:
+		for _, r := range message.Recipients {
+			if r.GetRecipientType() == RecipientTypeTo {
+				addICalendarAddress(todo, "ORGANIZER", r.GetDisplayName(), r.GetSmtpAddress())
+				break
+			}
+		}
+		if senderName == "" {
+			senderName = owner
+		}
+		if attendee := addICalendarAddress(todo, "ATTENDEE", senderName, senderAddress); attendee != nil {
+			attendee.AddParameter("PARTSTAT", taskParticipationStatus(message.GetMessageClass(), task.GetNamedIntValue(PsetidTask, MapiPidLidTaskStatus)))
+		}
+	}
+}
+
+/**
+ * create the iCalendar attachment of a task; nil if the object is not a task
+ */
+func NewICalendarTaskAttachment(t *TnefObject) *Attachment {
+	if !t.IsTask() {
+		return nil
+	}
+
+	attachment := NewAttachment()
+	attachment.SetICalendar(ExtractICalendarTask(t, nil))
+	attachment.SetFilename(ICalendarTaskFilename)
+	return attachment
+}
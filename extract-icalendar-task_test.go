@@ -0,0 +1,148 @@
+package tnefdecoder
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+/**
+ * a task in progress, 25% complete, owned by John
+ */
+func icalendarTestTask() *TnefObject {
+	percent := make([]byte, 8)
+	binary.LittleEndian.PutUint64(percent, math.Float64bits(0.25))
+
+	task := &TnefObject{}
+	task.SetAttribute(NewMappedStringAttribute(AttrLevelMessage, AttMessageClass, MessageClassTask))
+	task.SetAttribute(NewMapiStringAttribute(MapiPidTagSubject, "Write the report"))
+	task.SetAttribute(NewMapiIntAttribute(0, TaskStatusInProgress).Named(PsetidTask, MapiPidLidTaskStatus))
+	task.SetAttribute(NewMapiAttribute(0, MapiTypeFlt64, percent).Named(PsetidTask, MapiPidLidPercentComplete))
+	task.SetAttribute(NewMapiTimeAttribute(0, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)).Named(PsetidTask, MapiPidLidTaskStartDate))
+	task.SetAttribute(NewMapiTimeAttribute(0, time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)).Named(PsetidTask, MapiPidLidTaskDueDate))
+	task.SetAttribute(NewMapiStringAttribute(0, "John").Named(PsetidTask, MapiPidLidTaskOwner))
+	return task
+}
+
+func TestExtractICalendarTask(t *testing.T) {
+	task := icalendarTestTask()
+	calendar := ExtractICalendarTask(task, nil)
+	if method := calendar.GetProperty("METHOD").Value; method != ICalMethodPublish {
+		t.Errorf("METHOD %s", method)
+	}
+	todo := calendar.GetComponents("VTODO")[0]
+	for name, want := range map[string]string{
+		"SUMMARY":          "Write the report",
+		"STATUS":           "IN-PROCESS",
+		"PERCENT-COMPLETE": "25",
+		"DTSTART":          "20240304",
+		"DUE":              "20240308",
+	} {
+		if p := todo.GetProperty(name); p == nil || p.Value != want {
+			t.Errorf("%s: %+v, want %s", name, p, want)
+		}
+	}
+	if todo.GetProperty("COMPLETED") != nil || todo.GetProperty("ORGANIZER") != nil {
+		t.Errorf("%s", calendar.Build())
+	}
+
+	// completed
+	completed := time.Date(2024, 3, 7, 15, 0, 0, 0, time.UTC)
+	task.SetAttribute(NewMapiBoolAttribute(0, true).Named(PsetidTask, MapiPidLidTaskComplete))
+	task.SetAttribute(NewMapiTimeAttribute(0, completed).Named(PsetidTask, MapiPidLidTaskDateCompleted))
+	todo = ExtractICalendarTask(task, nil).GetComponents("VTODO")[0]
+	if todo.GetProperty("STATUS").Value != "COMPLETED" || todo.GetProperty("COMPLETED").Value != ICalFormatDateTime(completed) {
+		t.Errorf("completed: %s %+v", todo.GetProperty("STATUS").Value, todo.GetProperty("COMPLETED"))
+	}
+}
+
+func TestExtractICalendarTaskRequest(t *testing.T) {
+	tests := []struct {
+		messageClass string
+		method       string
+		organizer    string
+		attendee     string
+		partstat     string
+	}{
+		{MessageClassTaskRequest, ICalMethodRequest, "mailto:jane@example.com", "mailto:john@example.com", "NEEDS-ACTION"},
+		{MessageClassTaskRequestAccept, ICalMethodReply, "mailto:john@example.com", "mailto:jane@example.com", "ACCEPTED"},
+		{MessageClassTaskRequestDecline, ICalMethodReply, "mailto:john@example.com", "mailto:jane@example.com", "DECLINED"},
+		{MessageClassTaskRequestUpdate, ICalMethodReply, "mailto:john@example.com", "mailto:jane@example.com", "IN-PROCESS"},
+	}
+	for _, tt := range tests {
+		message := &TnefObject{}
+		message.SetAttribute(NewMappedStringAttribute(AttrLevelMessage, AttMessageClass, tt.messageClass))
+		setMessageSender(message, "Jane", "jane@example.com")
+		message.Recipients = append(message.Recipients, NewSmtpRecipient("John", "john@example.com", RecipientTypeTo),
+			NewSmtpRecipient("Copy", "copy@example.com", RecipientTypeCc))
+
+		calendar := ExtractICalendarTask(icalendarTestTask(), message)
+		todo := calendar.GetComponents("VTODO")[0]
+		if method := calendar.GetProperty("METHOD").Value; method != tt.method {
+			t.Errorf("%s: METHOD %s, want %s", tt.messageClass, method, tt.method)
+		}
+		organizer, attendee := todo.GetProperty("ORGANIZER"), todo.GetProperty("ATTENDEE")
+		if organizer == nil || organizer.Value != tt.organizer {
+			t.Errorf("%s: ORGANIZER %+v, want %s", tt.messageClass, organizer, tt.organizer)
+		}
+		if attendee == nil || attendee.Value != tt.attendee || attendee.GetParameter("PARTSTAT")[0] != tt.partstat {
+			t.Errorf("%s: ATTENDEE %+v, want %s %s", tt.messageClass, attendee, tt.attendee, tt.partstat)
+		}
+		attendees := 0
+		for _, p := range todo.Properties {
+			if p.Name == "ATTENDEE" {
+				attendees++
+			}
+		}
+		if attendees != 1 {
+			t.Errorf("%s: %d attendees, want 1 (the Cc recipient is not the owner)", tt.messageClass, attendees)
+		}
+	}
+}
+
+func TestExtractICalendarTaskStamp(t *testing.T) {
+	lastUpdate := time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)
+	submit := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	modified := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		task    []*Attribute
+		message []*Attribute
+		want    time.Time
+	}{
+		{"last update", []*Attribute{NewMapiTimeAttribute(0, lastUpdate).Named(PsetidTask, MapiPidLidTaskLastUpdate)},
+			[]*Attribute{NewMapiTimeAttribute(MapiPidTagClientSubmitTime, submit)}, lastUpdate},
+		{"submit time of the request", []*Attribute{NewMapiTimeAttribute(MapiPidTagLastModificationTime, modified)},
+			[]*Attribute{NewMapiTimeAttribute(MapiPidTagClientSubmitTime, submit)}, submit},
+		{"modification time of the task", []*Attribute{NewMapiTimeAttribute(MapiPidTagLastModificationTime, modified)}, nil, modified},
+	}
+	for _, tt := range tests {
+		task, message := icalendarTestTask(), &TnefObject{}
+		message.SetAttribute(NewMappedStringAttribute(AttrLevelMessage, AttMessageClass, MessageClassTaskRequest))
+		for _, attr := range tt.task {
+			task.SetAttribute(attr)
+		}
+		for _, attr := range tt.message {
+			message.SetAttribute(attr)
+		}
+		todo := ExtractICalendarTask(task, message).GetComponents("VTODO")[0]
+		if stamp := todo.GetProperty("DTSTAMP").Value; stamp != ICalFormatDateTime(tt.want) {
+			t.Errorf("%s: DTSTAMP %s, want %s", tt.name, stamp, ICalFormatDateTime(tt.want))
+		}
+	}
+}
+
+func TestNewICalendarTaskAttachment(t *testing.T) {
+	if a := NewICalendarTaskAttachment(&TnefObject{}); a != nil {
+		t.Errorf("not a task: %+v", a)
+	}
+	a := NewICalendarTaskAttachment(icalendarTestTask())
+	if a == nil || a.GetFilename() != ICalendarTaskFilename {
+		t.Fatalf("%+v", a)
+	}
+	if _, err := ParseICalendar(string(a.GetData())); err != nil {
+		t.Errorf("%q: %v", a.GetData(), err)
+	}
+}
@@ -0,0 +1,595 @@
+/**
+ * convert the calendar objects (meeting requests, responses and cancellations) to iCalendar (RFC 5545, iTIP RFC 5546)
+ * the mapping follows [MS-OXCICAL] section 2.1.3
+ */
+
+package tnefdecoder
+
+import (
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ * meeting message classes ([MS-OXOCAL] section 2.2.6.1)
+ */
+const (
+	MessageClassAppointment     = "IPM.Appointment"
+	MessageClassMeeting         = "IPM.Schedule.Meeting"
+	MessageClassMeetingRequest  = "IPM.Schedule.Meeting.Request"
+	MessageClassMeetingCanceled = "IPM.Schedule.Meeting.Canceled"
+	MessageClassMeetingRespPos  = "IPM.Schedule.Meeting.Resp.Pos"
+	MessageClassMeetingRespNeg  = "IPM.Schedule.Meeting.Resp.Neg"
+	MessageClassMeetingRespTent = "IPM.Schedule.Meeting.Resp.Tent"
+)
+
+/**
+ * iTIP methods
+ */
+const (
+	ICalMethodPublish = "PUBLISH"
+	ICalMethodRequest = "REQUEST"
+	ICalMethodReply   = "REPLY"
+	ICalMethodCancel  = "CANCEL"
+)
+
+/**
+ * PidTagRecipientTrackStatus / PidLidResponseStatus values
+ */
+const (
+	ResponseNone         = 0
+	ResponseOrganized    = 1
+	ResponseTentative    = 2
+	ResponseAccepted     = 3
+	ResponseDeclined     = 4
+	ResponseNotResponded = 5
+)
+
+// the name of the iCalendar attachment generated for a meeting message
+const ICalendarFilename = "invite.ics"
+
+// the fixed prefix of PidLidGlobalObjectId ([MS-OXOCAL] section 2.2.1.27)
+var globalObjectIdPrefix = []byte{0x04, 0x00, 0x00, 0x00, 0x82, 0x00, 0xE0, 0x00, 0x74, 0xC5, 0xB7, 0x10, 0x1A, 0x82, 0xE0, 0x08}
+
+// the marker of a GOID created from an iCalendar UID that is not a GOID
+var globalObjectIdVCalMarker = []byte("vCal-Uid\x01\x00\x00\x00")
+
+var ErrInvalidGlobalObjectId = errors.New("invalid global object id")
+
+/**
+ * PidLidGlobalObjectId / PidLidCleanGlobalObjectId
+ *
+ * GlobalObjectId = ByteArrayID(16) YH YL M D CreationTime(8) X(8) Size(4) Data(Size)
+ * the instance date (YH YL M D) identifies an occurrence of a recurring meeting; it is 0 for the whole series
+ */
+type GlobalObjectId struct {
+	Year         int
+	Month        int
+	Day          int
+	CreationTime time.Time
+	Data         []byte
+	Raw          []byte
+}
+
+func DecodeGlobalObjectId(b []byte) (*GlobalObjectId, error) {
+	if len(b) < 40 || !strings.HasPrefix(string(b), string(globalObjectIdPrefix)) {
+		return nil, ErrInvalidGlobalObjectId
+	}
+
+	leReader := new(LittleEndianDecoder)
+	g := &GlobalObjectId{Raw: b}
+	g.Year = int(b[16])<<8 | int(b[17])
+	g.Month = int(b[18])
+	g.Day = int(b[19])
+	g.CreationTime = MapiDecodeTime(b[20:28])
+
+	size := int(leReader.Uint32(b[36:40]))
+	if size > len(b)-40 {
+		return nil, ErrInvalidGlobalObjectId
+	}
+	g.Data = b[40 : 40+size]
+
+	return g, nil
+}
+
+/**
+ * check if the id identifies an occurrence of a recurring meeting
+ */
+func (g *GlobalObjectId) HasInstanceDate() bool {
+	return g.Year != 0 || g.Month != 0 || g.Day != 0
+}
+
+/**
+ * the instance date (UTC midnight); zero time for the whole series
+ */
+func (g *GlobalObjectId) InstanceDate() time.Time {
+	if !g.HasInstanceDate() {
+		return time.Time{}
+	}
+	return time.Date(g.Year, time.Month(g.Month), g.Day, 0, 0, 0, 0, time.UTC)
+}
+
+/**
+ * the iCalendar UID ([MS-OXCICAL] section 2.1.3.1.1.20.26):
+ * the UID stored in the id if it was created from an iCalendar object (vCal-Uid), else the id without instance date as hex
+ */
+func (g *GlobalObjectId) Uid() string {
+	if strings.HasPrefix(string(g.Data), string(globalObjectIdVCalMarker)) {
+		return strings.TrimRight(string(g.Data[len(globalObjectIdVCalMarker):]), "\x00")
+	}
+
+	clean := make([]byte, len(g.Raw))
+	copy(clean, g.Raw)
+	clean[16], clean[17], clean[18], clean[19] = 0, 0, 0, 0
+
+	return strings.ToUpper(hex.EncodeToString(clean))
+}
+
+/**
+ * check if the message is a meeting message (request, response or cancellation)
+ */
+func (t *TnefObject) IsMeetingMessage() bool {
+	return strings.HasPrefix(t.GetMessageClass(), MessageClassMeeting+".")
+}
+
+/**
+ * return the iTIP method for the message class: REQUEST, CANCEL, REPLY or PUBLISH (appointments)
+ */
+func ICalendarMethod(messageClass string) string {
+	switch {
+	case strings.HasPrefix(messageClass, MessageClassMeetingRequest):
+		return ICalMethodRequest
+	case strings.HasPrefix(messageClass, MessageClassMeetingCanceled):
+		return ICalMethodCancel
+	case strings.HasPrefix(messageClass, MessageClassMeeting+".Resp."):
+		return ICalMethodReply
+	case strings.HasPrefix(messageClass, MessageClassAppointment):
+		return ICalMethodPublish
+	}
+	return ""
+}
+
+/**
+ * the participation status of the sender of a meeting response
+ */
+func replyParticipationStatus(messageClass string) string {
+	switch {
+	case strings.HasPrefix(messageClass, MessageClassMeetingRespPos):
+		return "ACCEPTED"
+	case strings.HasPrefix(messageClass, MessageClassMeetingRespNeg):
+		return "DECLINED"
+	case strings.HasPrefix(messageClass, MessageClassMeetingRespTent):
+		return "TENTATIVE"
+	}
+	return "NEEDS-ACTION"
+}
+
+/**
+ * PidTagRecipientTrackStatus -> PARTSTAT
+ */
+func trackStatusParticipationStatus(status int) string {
+	switch status {
+	case ResponseTentative:
+		return "TENTATIVE"
+	case ResponseAccepted, ResponseOrganized:
+		return "ACCEPTED"
+	case ResponseDeclined:
+		return "DECLINED"
+	}
+	return "NEEDS-ACTION"
+}
+
+/**
+ * PidLidBusyStatus -> X-MICROSOFT-CDO-BUSYSTATUS
+ */
+func busyStatusName(status int) string {
+	switch status {
+	case BusyStatusFree:
+		return "FREE"
+	case BusyStatusTentative:
+		return "TENTATIVE"
+	case BusyStatusOutOfOffice:
+		return "OOF"
+	case BusyStatusWorkingElsewhere:
+		return "WORKINGELSEWHERE"
+	}
+	return "BUSY"
+}
+
+/**
+ * convert the calendar properties of the object to a VCALENDAR with one VEVENT
+ * return nil if the object is not a calendar object
+ */
+func ExtractICalendar(t *TnefObject) *ICalComponent {
+	messageClass := t.GetMessageClass()
+	method := ICalendarMethod(messageClass)
+	if method == "" {
+		return nil
+	}
+
+	calendar := NewICalendar(method)
+	event := NewICalComponent("VEVENT")
+	calendar.AddComponent(event)
+
+	// UID, RECURRENCE-ID
+	goid, _ := DecodeGlobalObjectId(t.GetNamedBinaryValue(PsetidMeeting, MapiPidLidGlobalObjectId))
+	if goid == nil {
+		goid, _ = DecodeGlobalObjectId(t.GetNamedBinaryValue(PsetidMeeting, MapiPidLidCleanGlobalObjectId))
+	}
+	if goid != nil {
+		event.AddText("UID", goid.Uid())
+	}
+
+	start, end := t.GetNamedTimeValue(PsetidAppointment, MapiPidLidAppointmentStartWhole), t.GetNamedTimeValue(PsetidAppointment, MapiPidLidAppointmentEndWhole)
+	if start.IsZero() {
+		if attr := t.GetAttribute(AttDateStart, "mapped"); attr != nil {
+			start = attr.GetTimeValue()
+		}
+	}
+	if end.IsZero() {
+		if attr := t.GetAttribute(AttDateEnd, "mapped"); attr != nil {
+			end = attr.GetTimeValue()
+		}
+	}
+	allDay := t.GetNamedBoolValue(PsetidAppointment, MapiPidLidAppointmentSubType)
+
+	// the recurrence of the series (not of a single occurrence)
+	var recurrence *AppointmentRecurrencePattern
+	if goid == nil || !goid.HasInstanceDate() {
+		recurrence, _ = DecodeAppointmentRecurrence(t.GetNamedBinaryValue(PsetidAppointment, MapiPidLidAppointmentRecur))
+	}
+
+	// the times are rendered in the time zone of the organizer
+	startZone := t.GetAppointmentTimeZone(recurrence != nil, false)
+	endZone := t.GetAppointmentTimeZone(recurrence != nil, true)
+	if startZone == nil && recurrence != nil {
+		startZone = recurrenceTimeZone(recurrence, start)
+	}
+	if endZone == nil {
+		endZone = startZone
+	}
+
+	if goid != nil && goid.HasInstanceDate() {
+		recurrenceId := t.GetNamedTimeValue(PsetidAppointment, MapiPidLidExceptionReplaceTime)
+		if recurrenceId.IsZero() {
+			// the instance date with the time of the occurrence
+			recurrenceId = startZone.ToUtc(goid.InstanceDate().Add(startZone.ToLocal(start).Sub(startZone.ToLocal(start).Truncate(24 * time.Hour))))
+		}
+		addICalendarTime(event, "RECURRENCE-ID", recurrenceId, startZone, allDay)
+	}
+
+	// DTSTAMP
+	event.AddDateTime("DTSTAMP", icalendarStamp(t.GetNamedTimeValue(PsetidMeeting, MapiPidLidAttendeeCriticalChange), t))
+
+	// DTSTART, DTEND; the all day events start and end at midnight in the time zone of the organizer
+	addICalendarTime(event, "DTSTART", start, startZone, allDay)
+	addICalendarTime(event, "DTEND", end, endZone, allDay)
+
+	if attr := t.GetNamedAttribute(PsetidAppointment, MapiPidLidAppointmentSequence); attr != nil {
+		event.AddProperty("SEQUENCE", strconv.Itoa(attr.GetIntValue()))
+	}
+
+	subject := t.GetAttributeStringValue(MapiPidTagSubject, "mapi")
+	if subject == "" {
+		subject = t.GetAttributeStringValue(AttSubject, "mapped")
+	}
+	event.AddText("SUMMARY", subject)
+	event.AddText("LOCATION", t.GetNamedStringValue(PsetidAppointment, MapiPidLidLocation))
+	event.AddText("DESCRIPTION", strings.TrimSpace(string(t.GetTextBody())))
+
+	addICalendarParticipants(t, event, method, replyParticipationStatus(messageClass))
+
+	switch method {
+	case ICalMethodCancel:
+		event.AddProperty("STATUS", "CANCELLED")
+	case ICalMethodRequest, ICalMethodPublish:
+		event.AddProperty("STATUS", "CONFIRMED")
+	}
+
+	addICalendarClassification(t, event)
+
+	// busy status; a meeting request carries the status intended by the organizer
+	busyStatus := BusyStatusBusy
+	if attr := t.GetNamedAttribute(PsetidAppointment, MapiPidLidIntendedBusyStatus); attr != nil && method == ICalMethodRequest {
+		busyStatus = attr.GetIntValue()
+	} else if attr := t.GetNamedAttribute(PsetidAppointment, MapiPidLidBusyStatus); attr != nil {
+		busyStatus = attr.GetIntValue()
+	}
+	addICalendarBusyStatus(event, busyStatus)
+	if method == ICalMethodRequest {
+		event.AddProperty("X-MICROSOFT-CDO-INTENDEDSTATUS", busyStatusName(busyStatus))
+	}
+	addICalendarAllDay(event, allDay)
+
+	// the reminder of the organizer (the replies and the cancellations do not carry it)
+	if method == ICalMethodRequest || method == ICalMethodPublish {
+		addICalendarEventAlarm(t, event, ICalAlarmDescription)
+	}
+
+	// RRULE, EXDATE and the modified occurrences
+	if recurrence != nil {
+		addICalendarRecurrence(calendar, event, recurrence, startZone, allDay, t.GetCalendarExceptionAttachments())
+	}
+
+	// the definitions of the time zones used by the event
+	for _, zone := range []*TimeZoneDefinition{endZone, startZone} {
+		if zone != nil && zone.Tzid() != "" && !hasICalendarTimeZone(calendar, zone.Tzid()) {
+			calendar.Components = append([]*ICalComponent{zone.VTimeZone()}, calendar.Components...)
+		}
+	}
+
+	return calendar
+}
+
+/**
+ * CLASS (PidTagSensitivity) and PRIORITY (PidTagImportance)
+ */
+func addICalendarClassification(t *TnefObject, c *ICalComponent) {
+	if attr := t.GetAttribute(MapiPidTagSensitivity, "mapi"); attr != nil {
+		switch attr.GetIntValue() {
+		case 1, 2:
+			c.AddProperty("CLASS", "PRIVATE")
+		case 3:
+			c.AddProperty("CLASS", "CONFIDENTIAL")
+		default:
+			c.AddProperty("CLASS", "PUBLIC")
+		}
+	}
+
+	if attr := t.GetAttribute(MapiPidTagImportance, "mapi"); attr != nil {
+		switch attr.GetIntValue() {
+		case 0:
+			c.AddProperty("PRIORITY", "9")
+		case 2:
+			c.AddProperty("PRIORITY", "1")
+		default:
+			c.AddProperty("PRIORITY", "5")
+		}
+	}
+}
+
+/**
+ * TRANSP and X-MICROSOFT-CDO-BUSYSTATUS
+ */
+func addICalendarBusyStatus(event *ICalComponent, busyStatus int) {
+	if busyStatus == BusyStatusFree {
+		event.AddProperty("TRANSP", "TRANSPARENT")
+	} else {
+		event.AddProperty("TRANSP", "OPAQUE")
+	}
+	event.AddProperty("X-MICROSOFT-CDO-BUSYSTATUS", busyStatusName(busyStatus))
+}
+
+/**
+ * X-MICROSOFT-CDO-ALLDAYEVENT
+ */
+func addICalendarAllDay(event *ICalComponent, allDay bool) {
+	if allDay {
+		event.AddProperty("X-MICROSOFT-CDO-ALLDAYEVENT", "TRUE")
+	} else {
+		event.AddProperty("X-MICROSOFT-CDO-ALLDAYEVENT", "FALSE")
+	}
+}
+
+/**
+ * ORGANIZER and ATTENDEE properties
+ * for requests and cancellations the organizer is the sender and the attendees are the recipients;
+ * for replies the only attendee is the sender (with the status of the response) and the organizer is the recipient
+ */
+func addICalendarParticipants(t *TnefObject, event *ICalComponent, method string, replyStatus string) {
+	senderName, senderAddress := messageSender(t)
+
+	if method == ICalMethodReply {
+		for _, r := range t.Recipients {
+			if r.GetRecipientType() == RecipientTypeTo {
+				addICalendarAddress(event, "ORGANIZER", r.GetDisplayName(), r.GetSmtpAddress())
+				break
+			}
+		}
+		if attendee := addICalendarAddress(event, "ATTENDEE", senderName, senderAddress); attendee != nil {
+			attendee.AddParameter("PARTSTAT", replyStatus)
+		}
+		return
+	}
+
+	if organizer := addICalendarAddress(event, "ORGANIZER", senderName, senderAddress); organizer == nil {
+		for _, r := range t.Recipients {
+			if r.IsOrganizer() || r.GetRecipientType() == RecipientTypeOriginator {
+				addICalendarAddress(event, "ORGANIZER", r.GetDisplayName(), r.GetSmtpAddress())
+				break
+			}
+		}
+	}
+
+	addICalendarAttendees(t, event, method)
+}
+
+/**
+ * ATTENDEE properties from the recipient table (the organizer is skipped)
+ */
+func addICalendarAttendees(t *TnefObject, event *ICalComponent, method string) {
+	for _, r := range t.Recipients {
+		if r.IsOrganizer() || r.GetRecipientType() == RecipientTypeOriginator {
+			continue
+		}
+
+		attendee := addICalendarAddress(event, "ATTENDEE", r.GetDisplayName(), r.GetSmtpAddress())
+		if attendee == nil {
+			continue
+		}
+
+		switch r.GetRecipientType() {
+		case RecipientTypeCc:
+			attendee.AddParameter("ROLE", "OPT-PARTICIPANT")
+		case RecipientTypeBcc:
+			// the resources (rooms, equipment) are sent as bcc recipients
+			attendee.AddParameter("CUTYPE", "RESOURCE")
+			attendee.AddParameter("ROLE", "NON-PARTICIPANT")
+		default:
+			attendee.AddParameter("ROLE", "REQ-PARTICIPANT")
+		}
+
+		attendee.AddParameter("PARTSTAT", trackStatusParticipationStatus(r.GetTrackStatus()))
+		if method == ICalMethodRequest {
+			if attr := t.GetAttribute(MapiPidTagResponseRequested, "mapi"); attr == nil || attr.GetBoolValue() {
+				attendee.AddParameter("RSVP", "TRUE")
+			}
+		}
+	}
+}
+
+/**
+ * add a CAL-ADDRESS property (mailto URI) with the common name; nothing is added if the address is missing
+ */
+func addICalendarAddress(event *ICalComponent, name string, displayName string, address string) *ICalProperty {
+	if address == "" {
+		return nil
+	}
+	if displayName != "" {
+		return event.AddProperty(name, "mailto:"+address, "CN", displayName)
+	}
+	return event.AddProperty(name, "mailto:"+address)
+}
+
+/**
+ * the name and the SMTP address of the sender: the represented user (on behalf of) or the sender
+ */
+func messageSender(t *TnefObject) (string, string) {
+	for _, a := range []*MessageAddress{t.SentRepresenting(), t.Sender()} {
+		if address := a.GetSmtpAddress(); address != "" {
+			return a.DisplayName, address
+		}
+	}
+	return "", ""
+}
+
+/**
+ * the DTSTAMP: the given time, else the sent, submit or modification time of the first object having one, else now
+ * (DTSTAMP is required)
+ */
+func icalendarStamp(stamp time.Time, objects ...*TnefObject) time.Time {
+	for _, t := range objects {
+		if attr := t.GetAttribute(AttDateSent, "mapped"); stamp.IsZero() && attr != nil {
+			stamp = attr.GetTimeValue()
+		}
+		for _, id := range []int{MapiPidTagClientSubmitTime, MapiPidTagLastModificationTime} {
+			if attr := t.GetAttribute(id, "mapi"); stamp.IsZero() && attr != nil {
+				stamp = attr.GetTimeValue()
+			}
+		}
+	}
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+	return stamp
+}
+
+/**
+ * check if the calendar has the VTIMEZONE with the given TZID
+ */
+func hasICalendarTimeZone(calendar *ICalComponent, tzid string) bool {
+	for _, vtimezone := range calendar.GetComponents("VTIMEZONE") {
+		if p := vtimezone.GetProperty("TZID"); p != nil && p.Value == tzid {
+			return true
+		}
+	}
+	return false
+}
+
+/**
+ * add a DATE-TIME (or DATE for the all day events) property for a UTC time, rendered in the given time zone
+ * (local time with TZID); without time zone the value is in UTC
+ */
+func addICalendarTime(c *ICalComponent, name string, utc time.Time, zone *TimeZoneDefinition, allDay bool) *ICalProperty {
+	if utc.IsZero() {
+		return nil
+	}
+	if allDay && zone == nil {
+		return c.AddDate(name, truncateToDay(utc, midnightTimeZone(utc)))
+	}
+	return addICalendarLocalTime(c, name, zone.ToLocal(utc), zone, allDay)
+}
+
+/**
+ * add a DATE-TIME (or DATE) property for a local time of the given time zone
+ */
+func addICalendarLocalTime(c *ICalComponent, name string, local time.Time, zone *TimeZoneDefinition, allDay bool) *ICalProperty {
+	value, params := icalendarTimeValue(local, zone, allDay)
+	return c.AddProperty(name, value, params...)
+}
+
+/**
+ * the value and the parameters of a local time: DATE, local DATE-TIME with TZID or UTC DATE-TIME (zones without name)
+ */
+func icalendarTimeValue(local time.Time, zone *TimeZoneDefinition, allDay bool) (string, []string) {
+	if allDay {
+		return local.Format(ICalDateFormat), []string{"VALUE", "DATE"}
+	}
+	if tzid := zone.Tzid(); tzid != "" {
+		return local.Format(ICalDateTimeFormat), []string{"TZID", tzid}
+	}
+	return ICalFormatDateTime(zone.ToUtc(local)), nil
+}
+
+/**
+ * the date of the time in the time zone (UTC if nil)
+ */
+func truncateToDay(v time.Time, zone *TimeZoneDefinition) time.Time {
+	if v.IsZero() {
+		return v
+	}
+	local := zone.ToLocal(v)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+/**
+ * the time zone of the organizer of an all day event without time zone properties: the all day events are stored as
+ * the midnight of the organizer converted to UTC, the offset is the one making the time a midnight (from -11:45 to
+ * +12:00); nil if the time is a midnight UTC
+ */
+func midnightTimeZone(v time.Time) *TimeZoneDefinition {
+	v = v.UTC()
+	timeOfDay := v.Sub(v.Truncate(24 * time.Hour)).Round(15 * time.Minute)
+	if timeOfDay == 0 || timeOfDay == 24*time.Hour {
+		return nil
+	}
+	offset := -timeOfDay
+	if offset <= -12*time.Hour {
+		offset += 24 * time.Hour
+	}
+	return NewFixedTimeZone(offset)
+}
+
+/**
+ * create the iCalendar attachment of a meeting message; nil if the object is not a meeting message
+ */
+func NewICalendarAttachment(t *TnefObject) *Attachment {
+	if !t.IsMeetingMessage() {
+		return nil
+	}
+	calendar := ExtractICalendar(t)
+	if calendar == nil {
+		return nil
+	}
+
+	attachment := NewAttachment()
+	attachment.SetICalendar(calendar)
+	attachment.SetFilename(ICalendarFilename)
+	return attachment
+}
+
+/**
+ * set the iCalendar object as the content of the attachment (text/calendar with the iTIP method)
+ */
+func (a *Attachment) SetICalendar(calendar *ICalComponent) {
+	a.SetData([]byte(calendar.Build()))
+
+	contentType := "text/calendar; charset=utf-8"
+	if method := calendar.GetProperty("METHOD"); method != nil {
+		contentType += "; method=" + method.Value
+	}
+	a.SetAttribute(NewMapiStringAttribute(MapiPidTagAttachMimeTag, contentType))
+}
@@ -0,0 +1,152 @@
+/**
+ * convert the personal distribution lists (IPM.DistList) to vCard groups: KIND:group and MEMBER (vCard 4.0, RFC 6350),
+ * X-ADDRESSBOOKSERVER-KIND and X-ADDRESSBOOKSERVER-MEMBER for the vCard 3.0 consumers
+ */
+
+package tnefdecoder
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"strings"
+	"vcard"
+)
+
+// the message class of the personal distribution lists (contact groups)
+const MessageClassDistList = "IPM.DistList"
+
+/**
+ * check if the object is a personal distribution list
+ */
+func (t *TnefObject) IsDistributionList() bool {
+	messageClass := t.GetMessageClass()
+	return messageClass == MessageClassDistList || strings.HasPrefix(messageClass, MessageClassDistList+".")
+}
+
+/**
+ * return the members of the distribution list: the address of the member entry ID, else the address of the one-off entry ID
+ * at the same position (the members that are contacts are referenced by the entry ID of the contact); the EX addresses
+ * are replaced by the SMTP addresses given by the address resolver
+ */
+func (t *TnefObject) GetDistributionListMembers() []*EntryIdAddress {
+	var members, oneOffMembers [][]byte
+	if attr := t.GetNamedAttribute(PsetidAddress, MapiPidLidDistributionListMembers); attr != nil {
+		members = attr.GetBinaryValueArray()
+	}
+	if attr := t.GetNamedAttribute(PsetidAddress, MapiPidLidDistributionListOneOffMembers); attr != nil {
+		oneOffMembers = attr.GetBinaryValueArray()
+	}
+
+	count := len(members)
+	if len(oneOffMembers) > count {
+		count = len(oneOffMembers)
+	}
+
+	result := []*EntryIdAddress{}
+	for i := 0; i < count; i++ {
+		var member, oneOff *EntryIdAddress
+		if i < len(members) {
+			member, _ = DecodeEntryIdAddressCodepage(members[i], t.Codepage)
+		}
+		if i < len(oneOffMembers) {
+			oneOff, _ = DecodeEntryIdAddressCodepage(oneOffMembers[i], t.Codepage)
+		}
+		member, oneOff = t.resolveEntryIdAddress(member), t.resolveEntryIdAddress(oneOff)
+
+		switch {
+		case member != nil && (member.GetSmtpAddress() != "" || oneOff == nil):
+			if member.DisplayName == "" && oneOff != nil {
+				member.DisplayName = oneOff.DisplayName
+			}
+			result = append(result, member)
+		case oneOff != nil:
+			result = append(result, oneOff)
+		}
+	}
+	return result
+}
+
+/**
+ * return the name of the distribution list: PidTagDisplayName, PidLidDistributionListName or the subject
+ */
+func (t *TnefObject) GetDistributionListName() string {
+	if name := t.GetAttributeStringValue(MapiPidTagDisplayName, "mapi"); name != "" {
+		return name
+	}
+	if name := t.GetNamedStringValue(PsetidAddress, MapiPidLidDistributionListName); name != "" {
+		return name
+	}
+	return t.GetAttributeStringValue(MapiPidTagSubject, "mapi")
+}
+
+// the namespace of the UUIDs of the members without SMTP address (RFC 4122 section 4.3)
+var vcardMemberNamespace = []byte{0x3f, 0x0b, 0x6c, 0x52, 0x8e, 0x1d, 0x4a, 0x27, 0x9c, 0x45, 0x61, 0xd0, 0x2b, 0x7e, 0x93, 0xa8}
+
+/**
+ * the URI of a member: mailto: for an SMTP address, else a name-based UUID (version 5) of the address (or of the
+ * display name) so the member and its name are kept and the same member gets the same URI; "" for an empty member
+ */
+func vcardMemberUri(member *EntryIdAddress) string {
+	if address := member.GetSmtpAddress(); address != "" {
+		return "mailto:" + address
+	}
+	name := strings.ToUpper(member.AddressType + ":" + member.EmailAddress)
+	if member.EmailAddress == "" {
+		if member.DisplayName == "" {
+			return ""
+		}
+		name = "NAME:" + member.DisplayName
+	}
+
+	h := sha1.New()
+	h.Write(vcardMemberNamespace)
+	h.Write([]byte(name))
+	u := h.Sum(nil)[:16]
+	u[6] = u[6]&0x0F | 0x50
+	u[8] = u[8]&0x3F | 0x80
+	return fmt.Sprintf("urn:uuid:%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+/**
+ * fill the vCard with the distribution list: FN, the kind and the members (mailto: URIs, see vcardMemberUri) with their
+ * display name (X-CN)
+ * version is the version of the vCard builder (VcardVersion3 or VcardVersion4)
+ */
+func ExtractVCardGroup(t *TnefObject, vc vcard.IVCard, version string) {
+	kindName, memberName := "X-ADDRESSBOOKSERVER-KIND", "X-ADDRESSBOOKSERVER-MEMBER"
+	if version == VcardVersion4 {
+		kindName, memberName = "kind", "member"
+	}
+
+	if name := t.GetDistributionListName(); name != "" {
+		fnProp := vc.CreateProperty("fn")
+		fnProp.AddValue(vcard.NewText(name))
+		vc.AddProperty(fnProp)
+	}
+
+	if kindProp := vc.CreateProperty(kindName); kindProp != nil {
+		kindProp.AddValue(vcard.NewText("group"))
+		vc.AddProperty(kindProp)
+	}
+
+	for _, member := range t.GetDistributionListMembers() {
+		uri := vcardMemberUri(member)
+		if uri == "" {
+			continue
+		}
+		if memberProp := vc.CreateProperty(memberName); memberProp != nil {
+			memberProp.AddValue(vcard.NewText(uri))
+			if member.DisplayName != "" && member.DisplayName != member.GetSmtpAddress() {
+				vc.AddPropertyParameter(memberProp, "X-CN", []string{vcardNameParameter(member.DisplayName)})
+			}
+			vc.AddProperty(memberProp)
+		}
+	}
+
+	// NOTE - PidTagBody
+	if body := strings.TrimSpace(t.GetAttributeStringValue(MapiPidTagBody, "mapi")); body != "" {
+		noteProp := vc.CreateProperty("note")
+		noteProp.AddValue(vcard.NewText(body))
+		vc.AddProperty(noteProp)
+	}
+}
@@ -0,0 +1,73 @@
+package tnefdecoder
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+/**
+ * a distribution list with an SMTP member, an EX member without SMTP address and a fax member
+ */
+func vcardGroupTestList() *TnefObject {
+	t := &TnefObject{}
+	t.SetAttribute(NewMappedStringAttribute(AttrLevelMessage, AttMessageClass, MessageClassDistList))
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagDisplayName, "Team"))
+	members := [][]byte{
+		EncodeOneOffEntryId("Jane Doe", "SMTP", "jane@example.com"),
+		EncodeOneOffEntryId("Doe, John", "EX", "/o=Example/cn=Recipients/cn=jdoe"),
+		EncodeOneOffEntryId("Office Fax", "FAX", "+49 30 1234"),
+	}
+	t.SetAttribute(NewMapiAttribute(0, MapiTypeMVBinary, MapiEncodeVariableValues(members)).Named(PsetidAddress, MapiPidLidDistributionListOneOffMembers))
+	return t
+}
+
+func TestGetDistributionListMembers(t *testing.T) {
+	tObj := vcardGroupTestList()
+	if !tObj.IsDistributionList() || tObj.GetDistributionListName() != "Team" {
+		t.Fatalf("list %q", tObj.GetDistributionListName())
+	}
+	want := []*EntryIdAddress{
+		{"Jane Doe", "SMTP", "jane@example.com"},
+		{"Doe, John", "EX", "/o=Example/cn=Recipients/cn=jdoe"},
+		{"Office Fax", "FAX", "+49 30 1234"},
+	}
+	if members := tObj.GetDistributionListMembers(); !reflect.DeepEqual(members, want) {
+		t.Errorf("%+v", members)
+	}
+
+	// the members without SMTP address get a UUID URI, their names are kept in X-CN
+	uuid := regexp.MustCompile(`^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	for _, member := range want[1:] {
+		if uri := vcardMemberUri(member); !uuid.MatchString(uri) {
+			t.Errorf("%s: URI %q", member.DisplayName, uri)
+		}
+	}
+	if v := vcardNameParameter("Doe, John"); v != `"Doe, John"` {
+		t.Errorf("name parameter %q", v)
+	}
+}
+
+func TestVCardMemberUri(t *testing.T) {
+	ex := &EntryIdAddress{DisplayName: "John", AddressType: "EX", EmailAddress: "/o=Example/cn=jdoe"}
+	tests := []struct {
+		name   string
+		member *EntryIdAddress
+		same   bool
+	}{
+		{"same DN in another case", &EntryIdAddress{AddressType: "ex", EmailAddress: "/O=EXAMPLE/CN=JDOE"}, true},
+		{"other DN", &EntryIdAddress{DisplayName: "John", AddressType: "EX", EmailAddress: "/o=Example/cn=jsmith"}, false},
+		{"name only", &EntryIdAddress{DisplayName: "John"}, false},
+	}
+	for _, tt := range tests {
+		if same := vcardMemberUri(tt.member) == vcardMemberUri(ex); same != tt.same {
+			t.Errorf("%s: same URI %v", tt.name, same)
+		}
+	}
+	if uri := vcardMemberUri(&EntryIdAddress{}); uri != "" {
+		t.Errorf("empty member: %q", uri)
+	}
+	if uri := vcardMemberUri(&EntryIdAddress{AddressType: "SMTP", EmailAddress: "jane@example.com"}); uri != "mailto:jane@example.com" {
+		t.Errorf("SMTP member: %q", uri)
+	}
+}
@@ -2,14 +2,26 @@ package tnefdecoder
 
 import (
 	"vcard"
-//	"strings"
+	"strings"
+	"time"
 //	"bytes"
 	//"fmt"
 	"strconv"
 	b64 "encoding/base64"
 )
 
-func ExtractVCard(t *TnefObject, vc vcard.IVCard) {
+/**
+ * the supported vCard versions
+ */
+const (
+	VcardVersion3 = "3.0" // RFC 2426
+	VcardVersion4 = "4.0" // RFC 6350
+)
+
+/**
+ * fill the vCard with the contact properties; version is the version of the vCard builder (VcardVersion3 or VcardVersion4)
+ */
+func ExtractVCard(t *TnefObject, vc vcard.IVCard, version string) {
 	var (
 		attr *Attribute
 		attrValue string
@@ -52,6 +64,14 @@ func ExtractVCard(t *TnefObject, vc vcard.IVCard) {
 		vc.AddProperty(propFn)
 	}
 
+	// KIND - 4.0 only; the Outlook contacts are individuals
+	if version == VcardVersion4 {
+		if kindProp := vc.CreateProperty("kind"); kindProp != nil {
+			kindProp.AddValue(vcard.NewText("individual"))
+			vc.AddProperty(kindProp)
+		}
+	}
+
 	/*
 	 N -> PidTagSurname, PidTagGivenName, PidTagMiddleName, PidTagDisplayNamePrefix (Honorific Prefixes), PidTagGeneration (Honorific Postfixes)
 	*/
@@ -122,6 +142,18 @@ func ExtractVCard(t *TnefObject, vc vcard.IVCard) {
 	for _, att := range t.Attachments {
 		hasPhotoAttr := att.GetAttribute(MapiPidTagAttachmentContactPhoto, "mapi");
 
+		if hasPhotoAttr!= nil && hasPhotoAttr.GetBoolValue() && version == VcardVersion4 {
+			// the contact has picture; 4.0 embeds it as a data URI
+			contentType := att.ContentType()
+			if contentType == "" || contentType == "application/octet-stream" {
+				contentType = "image/jpeg"
+			}
+			photoProp := vc.CreateProperty("photo")
+			photoProp.AddValue(vcard.NewPhoto("data:" + contentType + ";base64," + b64.StdEncoding.EncodeToString(att.Data)))
+			vc.AddProperty(photoProp)
+			break
+		}
+
 		if hasPhotoAttr!= nil && hasPhotoAttr.GetBoolValue() {
 			// the contact has picture
 			photoValue := vcard.NewPhoto(b64.StdEncoding.EncodeToString(att.Data))
@@ -143,8 +175,8 @@ func ExtractVCard(t *TnefObject, vc vcard.IVCard) {
 	// vCard data format: BDAY:<date or date-time value>
 	attr = t.GetAttribute(MapiPidTagBirthday, "mapi");
 	attrValue = ""
-	if attr != nil {
-		attrValue = strconv.FormatInt(int64(attr.GetIntValue()),10)
+	if attr != nil && !attr.GetTimeValue().IsZero() {
+		attrValue = vcardDate(attr.GetTimeValue())
 	}
 	propBdayValue := vcard.NewText(attrValue)
 
@@ -155,11 +187,36 @@ func ExtractVCard(t *TnefObject, vc vcard.IVCard) {
 		vc.AddProperty(propBday)
 	}
 
+	// ANNIVERSARY - PidTagWeddingAnniversary (X-MS-ANNIVERSARY before 4.0)
+	if attr = t.GetAttribute(MapiPidTagWeddingAnniversary, "mapi"); attr != nil && !attr.GetTimeValue().IsZero() {
+		anniversaryName := "X-MS-ANNIVERSARY"
+		if version == VcardVersion4 {
+			anniversaryName = "anniversary"
+		}
+		if anniversaryProp := vc.CreateProperty(anniversaryName); anniversaryProp != nil {
+			anniversaryProp.AddValue(vcard.NewText(vcardDate(attr.GetTimeValue())))
+			vc.AddProperty(anniversaryProp)
+		}
+	}
+
+	// GENDER - PidTagGender, 4.0 only
+	if attr = t.GetAttribute(MapiPidTagGender, "mapi"); attr != nil && version == VcardVersion4 {
+		if gender := vcardGender(attr.GetIntValue()); gender != "" {
+			if genderProp := vc.CreateProperty("gender"); genderProp != nil {
+				genderProp.AddValue(vcard.NewText(gender))
+				vc.AddProperty(genderProp)
+			}
+		}
+	}
+
+	// the address selected as mailing address (PidLidPostalAddressId) is the preferred address
+	postalAddressId := t.GetNamedIntValue(PsetidAddress, MapiPidLidPostalAddressId)
+
 	// ADR - WORK
 	adrWork := vcard.NewAddress()
 
 	// PO BOX
-	attr = t.GetAttribute(MapiPidLidWorkAddressPostOfficeBox, "mapi")
+	attr = t.GetNamedAttribute(PsetidAddress, MapiPidLidWorkAddressPostOfficeBox)
 	attrValue = ""
 	if attr != nil {
 		attrValue = attr.GetStringValue()
@@ -167,7 +224,7 @@ func ExtractVCard(t *TnefObject, vc vcard.IVCard) {
 	adrWork.Pobox = attrValue
 
 	// street
-	attr = t.GetAttribute(MapiPidLidWorkAddressStreet, "mapi")
+	attr = t.GetNamedAttribute(PsetidAddress, MapiPidLidWorkAddressStreet)
 	attrValue = ""
 	if attr != nil {
 		attrValue = attr.GetStringValue()
@@ -175,7 +232,7 @@ func ExtractVCard(t *TnefObject, vc vcard.IVCard) {
 	adrWork.Street = attrValue
 	//fmt.Println("PidLidWorkAddressStreet: ", attrValue)
 
-	attr = t.GetAttribute(MapiPidLidWorkAddressCity, "mapi")
+	attr = t.GetNamedAttribute(PsetidAddress, MapiPidLidWorkAddressCity)
 	attrValue = ""
 	if attr != nil {
 		attrValue = attr.GetStringValue()
@@ -183,7 +240,7 @@ func ExtractVCard(t *TnefObject, vc vcard.IVCard) {
 	adrWork.Locality = attrValue
 	//fmt.Println("PidLidWorkAddressCity: ", attrValue)
 
-	attr = t.GetAttribute(MapiPidLidWorkAddressState, "mapi")
+	attr = t.GetNamedAttribute(PsetidAddress, MapiPidLidWorkAddressState)
 	attrValue = ""
 	if attr != nil {
 		attrValue = attr.GetStringValue()
@@ -192,15 +249,15 @@ func ExtractVCard(t *TnefObject, vc vcard.IVCard) {
 	adrWork.Region = attrValue
 	//fmt.Println("PidLidWorkAddressState: ", attrValue)
 
-	attr = t.GetAttribute(MapiPidLidWorkAddressPostalCode, "mapi")
+	attr = t.GetNamedAttribute(PsetidAddress, MapiPidLidWorkAddressPostalCode)
 	attrValue = ""
 	if attr != nil {
 		attrValue = attr.GetStringValue()
 	}
-	adrWork.Region = attrValue
+	adrWork.PostalCode = attrValue
 	//fmt.Println("PidLidWorkAddressPostalCode: ", attrValue)
 
-	attr = t.GetAttribute(MapiPidLidWorkAddressCountry, "mapi")
+	attr = t.GetNamedAttribute(PsetidAddress, MapiPidLidWorkAddressCountry)
 	attrValue = ""
 	if attr != nil {
 		attrValue = attr.GetStringValue()
@@ -215,6 +272,10 @@ func ExtractVCard(t *TnefObject, vc vcard.IVCard) {
 		propAdrWorkParamType.AddValue("work")
 		propAdrWork.AddParameter(propAdrWorkParamType)
 
+		if postalAddressId == PostalAddressWork {
+			addVCardPref(vc, propAdrWork, version)
+		}
+
 		vc.AddProperty(propAdrWork)
 	}
 
@@ -285,6 +346,10 @@ func ExtractVCard(t *TnefObject, vc vcard.IVCard) {
 		propAdrHomeParamType.AddValue("home")
 		propAdrHome.AddParameter(propAdrHomeParamType)
 
+		if postalAddressId == PostalAddressHome {
+			addVCardPref(vc, propAdrHome, version)
+		}
+
 		vc.AddProperty(propAdrHome)
 	}
 
@@ -349,6 +414,10 @@ func ExtractVCard(t *TnefObject, vc vcard.IVCard) {
 		propAdrOtherParamType.AddValue("postal")
 		propAdrOther.AddParameter(propAdrOtherParamType)
 
+		if postalAddressId == PostalAddressOther {
+			addVCardPref(vc, propAdrOther, version)
+		}
+
 		vc.AddProperty(propAdrOther)
 	}
 
@@ -361,12 +430,6 @@ When exporting: The address that is selected as the mailing address by the PidLi
 property gets the value "pref" included in its TYPE parameter.
 	*/
 
-	attr = t.GetAttribute(MapiPidLidPostalAddressId, "mapi")
-	attrValue = ""
-	if attr != nil {
-		attrValue = attr.GetStringValue()
-	}
-	//fmt.Println("PidLidPostalAddressId: ", attrValue)
 
 
 	//TEL -> : TEL; TYPE=[Type]:[Phone Number]
@@ -377,7 +440,7 @@ property gets the value "pref" included in its TYPE parameter.
 	if attr != nil {
 		attrValue = attr.GetStringValue()
 	}
-	pTelHomeValue := vcard.NewText(attrValue)
+	pTelHomeValue := vcard.NewText(vcardTelValue(attrValue, version))
 
 	if !pTelHomeValue.IsEmpty() {
 		propTelHome := vc.CreateProperty("tel")
@@ -387,6 +450,10 @@ property gets the value "pref" included in its TYPE parameter.
 		propTelParamType.AddValue("home")
 		propTelHome.AddParameter(propTelParamType)
 
+		if version == VcardVersion4 {
+			vc.AddPropertyParameter(propTelHome, "VALUE", []string{"uri"})
+		}
+
 		vc.AddProperty(propTelHome)
 	}
 	//fmt.Println("PidTagHomeTelephoneNumber: ", attrValue)
@@ -396,7 +463,7 @@ property gets the value "pref" included in its TYPE parameter.
 	if attr != nil {
 		attrValue = attr.GetStringValue()
 	}
-	pTelHome2Value := vcard.NewText(attrValue)
+	pTelHome2Value := vcard.NewText(vcardTelValue(attrValue, version))
 
 	if !pTelHome2Value.IsEmpty() {
 		propTelHome2 := vc.CreateProperty("tel")
@@ -406,6 +473,10 @@ property gets the value "pref" included in its TYPE parameter.
 		propTelHome2ParamType.AddValue("home")
 		propTelHome2.AddParameter(propTelHome2ParamType)
 
+		if version == VcardVersion4 {
+			vc.AddPropertyParameter(propTelHome2, "VALUE", []string{"uri"})
+		}
+
 		vc.AddProperty(propTelHome2)
 	}
 
@@ -422,7 +493,7 @@ Additional TEL types with the TYPE parameter set to "msg", "voice", "video", "bb
 	if attr != nil {
 		attrValue = attr.GetStringValue()
 	}
-	pTelMsgValue := vcard.NewText(attrValue)
+	pTelMsgValue := vcard.NewText(vcardTelValue(attrValue, version))
 
 	if !pTelMsgValue.IsEmpty() {
 		propTelMsg := vc.CreateProperty("tel")
@@ -432,6 +503,10 @@ Additional TEL types with the TYPE parameter set to "msg", "voice", "video", "bb
 		propTelMsgParamType.AddValue("msg")
 		propTelMsg.AddParameter(propTelMsgParamType)
 
+		if version == VcardVersion4 {
+			vc.AddPropertyParameter(propTelMsg, "VALUE", []string{"uri"})
+		}
+
 		vc.AddProperty(propTelMsg)
 	}
 	//fmt.Println("PidTagOtherTelephoneNumber: ", attrValue)
@@ -443,7 +518,7 @@ Additional TEL types with the TYPE parameter set to "msg", "voice", "video", "bb
 	if attr != nil {
 		attrValue = attr.GetStringValue()
 	}
-	pTelWorkValue := vcard.NewText(attrValue)
+	pTelWorkValue := vcard.NewText(vcardTelValue(attrValue, version))
 
 	if !pTelWorkValue.IsEmpty() {
 		propTelWork := vc.CreateProperty("tel")
@@ -453,6 +528,10 @@ Additional TEL types with the TYPE parameter set to "msg", "voice", "video", "bb
 		propTelWorkParamType.AddValue("work")
 		propTelWork.AddParameter(propTelWorkParamType)
 
+		if version == VcardVersion4 {
+			vc.AddPropertyParameter(propTelWork, "VALUE", []string{"uri"})
+		}
+
 		vc.AddProperty(propTelWork)
 	}
 	//fmt.Println("PidTagBusinessTelephoneNumber: ", attrValue)
@@ -462,7 +541,7 @@ Additional TEL types with the TYPE parameter set to "msg", "voice", "video", "bb
 	if attr != nil {
 		attrValue = attr.GetStringValue()
 	}
-	pTelWork2Value := vcard.NewText(attrValue)
+	pTelWork2Value := vcard.NewText(vcardTelValue(attrValue, version))
 
 	if !pTelWork2Value.IsEmpty() {
 		propTelWork2 := vc.CreateProperty("tel")
@@ -472,57 +551,63 @@ Additional TEL types with the TYPE parameter set to "msg", "voice", "video", "bb
 		propTelWork2ParamType.AddValue("work")
 		propTelWork2.AddParameter(propTelWork2ParamType)
 
+		if version == VcardVersion4 {
+			vc.AddPropertyParameter(propTelWork2, "VALUE", []string{"uri"})
+		}
+
 		vc.AddProperty(propTelWork2)
 	}
 	//fmt.Println("PidTagBusiness2TelephoneNumber: ", attrValue)
 
-
-	// EMAIL;TYPE=[Type]:[Email]
-	attr = t.GetAttribute(MapiPidLidEmail1EmailAddress, "mapi")
-	attrValue = ""
-	if attr != nil {
-		attrValue = attr.GetStringValue()
-	}
-	pEmailValue := vcard.NewText(attrValue)
-	if !pEmailValue.IsEmpty() {
-		propEmail := vc.CreateProperty("email")
-		propEmail.AddValue(pEmailValue)
-		vc.AddProperty(propEmail)
+	// TEL - the other numbers of the contact ([MS-OXVCARD] section 2.1.3.2.x)
+	for _, tel := range vcardTelephoneNumbers {
+		attrValue = t.GetAttributeStringValue(tel.Id, "mapi")
+		if attrValue == "" {
+			continue
+		}
+		if tel.Property == "X-MS-TEL" && version != VcardVersion4 {
+			// Outlook specific numbers: X-MS-TEL;TYPE=ASSISTANT
+			telProp := vc.CreateProperty("X-MS-TEL")
+			telProp.AddValue(vcard.NewText(attrValue))
+			vc.AddPropertyParameter(telProp, "TYPE", tel.Types)
+			vc.AddProperty(telProp)
+			continue
+		}
+		telProp := vc.CreateProperty("tel")
+		telProp.AddValue(vcard.NewText(vcardTelValue(attrValue, version)))
+		vc.AddPropertyParameter(telProp, "TYPE", tel.Types)
+		if version == VcardVersion4 {
+			vc.AddPropertyParameter(telProp, "VALUE", []string{"uri"})
+		}
+		vc.AddProperty(telProp)
 	}
-	//fmt.Println("PidLidEmail1EmailAddress: ", attrValue)
 
-	attr = t.GetAttribute(MapiPidLidEmail2EmailAddress, "mapi");
-	attrValue = ""
-	if attr != nil {
-		attrValue = attr.GetStringValue()
-	}
-	pEmail2Value := vcard.NewText(attrValue)
-	if !pEmail2Value.IsEmpty() {
-		propEmail2 := vc.CreateProperty("email")
-		propEmail2.AddValue(pEmail2Value)
-		vc.AddProperty(propEmail2)
-	}
-	//fmt.Println("PidLidEmail2EmailAddress: ", attrValue)
 
-	attr = t.GetAttribute(MapiPidLidEmail3EmailAddress, "mapi");  // (0x80A3 in docs, but 0x803A in other implementations and samples)
-	attrValue = ""
-	if attr != nil {
-		attrValue = attr.GetStringValue()
+	// EMAIL;TYPE=[Type]:[Email] - PidLidEmail1EmailAddress .. PidLidEmail3EmailAddress with the address type and the display name
+	addVCardEmail(t, vc, version, MapiPidLidEmail1EmailAddress, MapiPidLidEmail1AddressType, MapiPidLidEmail1DisplayName, true)
+	addVCardEmail(t, vc, version, MapiPidLidEmail2EmailAddress, MapiPidLidEmail2AddressType, MapiPidLidEmail2DisplayName, false)
+	// (0x80A3 in docs, but 0x803A in other implementations and samples)
+	if t.GetNamedStringValue(PsetidAddress, MapiPidLidEmail3EmailAddress) != "" {
+		addVCardEmail(t, vc, version, MapiPidLidEmail3EmailAddress, MapiPidLidEmail3AddressType, MapiPidLidEmail3DisplayName, false)
+	} else {
+		addVCardEmail(t, vc, version, MapiPidLidEmail3EmailAddress_1, MapiPidLidEmail3AddressType, MapiPidLidEmail3DisplayName, false)
 	}
-	if attrValue == "" {
-		attr = t.GetAttribute(MapiPidLidEmail3EmailAddress_1, "mapi");
-		attrValue = ""
-		if attr != nil {
-			attrValue = attr.GetStringValue()
+
+	// IMPP - PidLidInstantMessagingAddress (X-MS-IMADDRESS before 4.0)
+	if imAddress := t.GetNamedStringValue(PsetidAddress, MapiPidLidInstantMessagingAddress); imAddress != "" {
+		if version == VcardVersion4 {
+			if !strings.Contains(imAddress, ":") {
+				imAddress = "sip:" + imAddress
+			}
+			if imppProp := vc.CreateProperty("impp"); imppProp != nil {
+				imppProp.AddValue(vcard.NewText(imAddress))
+				vc.AddProperty(imppProp)
+			}
+		} else if imProp := vc.CreateProperty("X-MS-IMADDRESS"); imProp != nil {
+			imProp.AddValue(vcard.NewText(imAddress))
+			vc.AddProperty(imProp)
 		}
 	}
-	pEmail3Value := vcard.NewText(attrValue)
-	if !pEmail3Value.IsEmpty() {
-		propEmail3 := vc.CreateProperty("email")
-		propEmail3.AddValue(pEmail3Value)
-		vc.AddProperty(propEmail3)
-	}
-	//fmt.Println("PidLidEmail3EmailAddress: ", attrValue)
 
 	//PidTagProfession - ROLE
 	attr = t.GetAttribute(MapiPidTagProfession, "mapi");
@@ -537,6 +622,31 @@ Additional TEL types with the TYPE parameter set to "msg", "voice", "video", "bb
 		vc.AddProperty(roleProp)
 	}
 
+	// TITLE - PidTagTitle
+	if title := t.GetAttributeStringValue(MapiPidTagTitle, "mapi"); title != "" {
+		titleProp := vc.CreateProperty("title")
+		titleProp.AddValue(vcard.NewText(title))
+		vc.AddProperty(titleProp)
+	}
+
+	// X-MS-OFFICELOCATION - PidTagOfficeLocation
+	if office := t.GetAttributeStringValue(MapiPidTagOfficeLocation, "mapi"); office != "" {
+		if officeProp := vc.CreateProperty("X-MS-OFFICELOCATION"); officeProp != nil {
+			officeProp.AddValue(vcard.NewText(office))
+			vc.AddProperty(officeProp)
+		}
+	}
+
+	// the related persons: X-MS-SPOUSE, X-MS-CHILD, X-MS-ASSISTANT, X-MS-MANAGER; RELATED in 4.0 (the manager has no RELATED type)
+	addVCardRelated(vc, version, "X-MS-SPOUSE", "spouse", t.GetAttributeStringValue(MapiPidTagSpouseName, "mapi"))
+	if attr = t.GetAttribute(MapiPidTagChildrensNames, "mapi"); attr != nil {
+		for _, child := range attr.GetStringValueArray() {
+			addVCardRelated(vc, version, "X-MS-CHILD", "child", strings.TrimRight(child, "\x00"))
+		}
+	}
+	addVCardRelated(vc, version, "X-MS-ASSISTANT", "agent", t.GetAttributeStringValue(MapiPidTagAssistant, "mapi"))
+	addVCardRelated(vc, version, "X-MS-MANAGER", "", t.GetAttributeStringValue(MapiPidTagManagerName, "mapi"))
+
 	//PidTagProfession - ORG
 
 	 // ORG - company (PidTagCompanyName)
@@ -567,16 +677,10 @@ Additional TEL types with the TYPE parameter set to "msg", "voice", "video", "bb
 	}
 
 
-	 // CATEGORIES -PidLidCategories
+	 // CATEGORIES - Keywords / PidLidCategories
 	 categProp := vcard.NewProperty("categories")
-	 attr = t.GetAttribute(0x00009000, "mapi");
-	 attrValue = ""
-	 if attr != nil {
-		for _, attrValue = range attr.GetStringValueArray() {
-			if attrValue != "" {
-				categProp.AddValue(vcard.NewText(attrValue))
-			}
-		 }
+	 for _, category := range t.GetCategories() {
+		categProp.AddValue(vcard.NewText(category))
 	 }
 
 	 if len(categProp.GetValue()) > 0  {
@@ -597,11 +701,14 @@ Additional TEL types with the TYPE parameter set to "msg", "voice", "video", "bb
 		 vc.AddProperty(noteProp)
 	 }
 
-	 // REV - PidTagLastModificationTime
-	 attr = t.GetAttribute(AttDateModified, "mapped")
+	 // REV - PidTagLastModificationTime, else attDateModified
+	 attr = t.GetAttribute(MapiPidTagLastModificationTime, "mapi")
+	 if attr == nil {
+		 attr = t.GetAttribute(AttDateModified, "mapped")
+	 }
 	 attrValue = ""
-	 if (attr != nil) {
-		attrValue = strconv.FormatInt(int64(attr.GetIntValue()),10)
+	 if attr != nil && !attr.GetTimeValue().IsZero() {
+		attrValue = attr.GetTimeValue().UTC().Format("20060102T150405Z")
 	 }
 
 
@@ -676,44 +783,59 @@ Additional TEL types with the TYPE parameter set to "msg", "voice", "video", "bb
 		}
 
 	 // KEY - PidTagUserX509Certificate
-	 /*
-	 attr = t.GetMapiAttribute(0x3A70);
-	 attrValue = ""
-	 if attr != nil {
-		 //@TODO: extract correct certificat value (PidTagUserX509Certificate [MS-OXOABK] - remove first 4 bytes - 2 bytes for tag & 2 byte for length)
-		 attrValue = strings.TrimRight(string(attr.Data), "\x00")
+	 if attr = t.GetAttribute(MapiPidTagUserX509Certificate, "mapi"); attr != nil {
+		 for _, certificate := range attr.GetBinaryValueArray() {
+			 if der := decodeContactCertificate(certificate); der != nil {
+				 addVCardKey(vc, version, der)
+			 }
+		 }
 	 }
 
-	 keyValue := vcard.NewText()
-	 keyValue.SetValue(attrValue)
-	 if !keyValue.IsEmpty() {
-		keyProp := vcard.NewProperty("key")
-		keyProp.AddValue(keyValue)
-
-		keyParamType := vcard.NewParameter("encoding")
-		keyParamType.AddValue("b")
-		keyProp.AddParameter(keyParamType)
-
-		 vc.AddProperty(keyProp)
-	 }*/
+	 // GEO - the coordinates of the mailing address, else of the first address having coordinates
+	 if geo := contactGeo(t, postalAddressId); geo != "" {
+		 geoProp := vc.CreateProperty("geo")
+		 if version == VcardVersion4 {
+			 geoProp.AddValue(vcard.NewText("geo:" + strings.Replace(geo, ";", ",", 1)))
+		 } else {
+			 geoProp.AddValue(vcard.NewText(geo))
+		 }
+		 vc.AddProperty(geoProp)
+	 }
 
-	 //X-MS-OL-DESIGN
-	 attr = t.GetAttribute(MapiPidLidBusinessCardDisplayDefinition, "mapi")
+	 //X-MS-OL-DESIGN - PidLidBusinessCardDisplayDefinition decoded into the electronic business card XML
 	 attrValue = ""
-	 if attr != nil {
-		 // @ToDO - decode binary [MS-OXOCNTC] 2.2.1.7.1
+	 if definition := t.GetBusinessCardDisplayDefinition(); definition != nil {
+		 attrValue = definition.DesignXml()
 	 }
 
 	 designValue := vcard.NewText(attrValue)
 	 if !designValue.IsEmpty() {
 		designProp := vc.CreateProperty("X-MS-OL-DESIGN")
 		designProp.AddValue(designValue)
+		vc.AddPropertyParameter(designProp, "CHARSET", []string{"utf-8"})
 		vc.AddProperty(designProp)
 	 }
 
+	 // X-MS-CARDPICTURE - PidLidBusinessCardCardPicture, the image of the card (a data URI in 4.0)
+	 if picture := t.GetNamedBinaryValue(PsetidAddress, MapiPidLidBusinessCardCardPicture); len(picture) > 0 {
+		 pictureType := strings.ToUpper(strings.TrimPrefix(SniffContentType(picture), "image/"))
+		 if pictureType == "" {
+			 pictureType = "PNG"
+		 }
+		 pictureProp := vc.CreateProperty("X-MS-CARDPICTURE")
+		 if version == VcardVersion4 {
+			 pictureProp.AddValue(vcard.NewText("data:image/" + strings.ToLower(pictureType) + ";base64," + b64.StdEncoding.EncodeToString(picture)))
+		 } else {
+			 pictureProp.AddValue(vcard.NewText(b64.StdEncoding.EncodeToString(picture)))
+			 vc.AddPropertyParameter(pictureProp, "TYPE", []string{pictureType})
+			 vc.AddPropertyParameter(pictureProp, "ENCODING", []string{"BASE64"})
+		 }
+		 vc.AddProperty(pictureProp)
+	 }
+
 
 	//FBURL - available only on 4.0
-	attr = t.GetAttribute(MapiPidLidFreeBusyLocation, "mapi");
+	attr = t.GetNamedAttribute(PsetidAddress, MapiPidLidFreeBusyLocation);
 	attrValue = ""
 	if attr != nil {
 		attrValue = attr.GetStringValue()
@@ -728,3 +850,230 @@ Additional TEL types with the TYPE parameter set to "msg", "voice", "video", "bb
 		}
 	}
 }
+
+/**
+ * PidLidPostalAddressId values: the address used as the mailing address
+ */
+const (
+	PostalAddressNone = 0
+	PostalAddressHome = 1
+	PostalAddressWork = 2
+	PostalAddressOther = 3
+)
+
+/**
+ * mark the preferred property: TYPE=pref in 3.0, PREF=1 in 4.0
+ */
+func addVCardPref(vc vcard.IVCard, prop *vcard.Property, version string) {
+	if version == VcardVersion4 {
+		vc.AddPropertyParameter(prop, "PREF", []string{"1"})
+	} else {
+		vc.AddPropertyParameter(prop, "TYPE", []string{"pref"})
+	}
+}
+
+/**
+ * the value of a TEL property: the number in 3.0, a tel: URI in 4.0 (the visual separators are kept, the spaces removed)
+ */
+func vcardTelValue(number string, version string) string {
+	if version != VcardVersion4 || number == "" {
+		return number
+	}
+	return "tel:" + strings.Join(strings.Fields(number), "-")
+}
+
+/**
+ * a vCard DATE (birthday, anniversary); Outlook stores the dates as the local midnight converted to UTC
+ */
+func vcardDate(v time.Time) string {
+	return truncateToDay(v, midnightTimeZone(v)).Format("20060102")
+}
+
+/**
+ * PidTagGender -> GENDER: 1 = female, 2 = male
+ */
+func vcardGender(gender int) string {
+	switch gender {
+	case 1:
+		return "F"
+	case 2:
+		return "M"
+	}
+	return ""
+}
+
+/**
+ * the telephone numbers exported in addition to the home, work and other numbers: TEL with the TYPE values, or X-MS-TEL
+ * for the numbers specific to Outlook (TEL in 4.0)
+ */
+var vcardTelephoneNumbers = []struct {
+	Id       int
+	Property string
+	Types    []string
+}{
+	{MapiPidTagPrimaryTelephoneNumber, "tel", []string{"pref"}},
+	{MapiPidTagMobileTelephoneNumber, "tel", []string{"cell"}},
+	{MapiPidTagPagerTelephoneNumber, "tel", []string{"pager"}},
+	{MapiPidTagCarTelephoneNumber, "tel", []string{"car"}},
+	{MapiPidTagIsdnNumber, "tel", []string{"isdn"}},
+	{MapiPidTagBusinessFaxNumber, "tel", []string{"work", "fax"}},
+	{MapiPidTagHomeFaxNumber, "tel", []string{"home", "fax"}},
+	{MapiPidTagPrimaryFaxNumber, "tel", []string{"fax"}},
+	{MapiPidTagAssistantTelephoneNumber, "X-MS-TEL", []string{"ASSISTANT"}},
+	{MapiPidTagCallbackTelephoneNumber, "X-MS-TEL", []string{"CALLBACK"}},
+	{MapiPidTagCompanyMainTelephoneNumber, "X-MS-TEL", []string{"COMPANY"}},
+	{MapiPidTagRadioTelephoneNumber, "X-MS-TEL", []string{"RADIO"}},
+	{MapiPidTagTelexNumber, "X-MS-TEL", []string{"TELEX"}},
+	{MapiPidTagTelecommunicationsDeviceForDeafTelephoneNumber, "X-MS-TEL", []string{"TTYTDD"}},
+}
+
+/**
+ * add an EMAIL property: TYPE=internet for the SMTP addresses (x400 for the X.400 addresses), the display name of
+ * the address as X-CN when it is not the address itself; the first address is the preferred address in 4.0
+ */
+func addVCardEmail(t *TnefObject, vc vcard.IVCard, version string, addressLid, typeLid, displayNameLid int, first bool) {
+	address := t.GetNamedStringValue(PsetidAddress, addressLid)
+	if address == "" {
+		return
+	}
+	emailProp := vc.CreateProperty("email")
+	emailProp.AddValue(vcard.NewText(address))
+
+	addressType := strings.ToUpper(t.GetNamedStringValue(PsetidAddress, typeLid))
+	if version != VcardVersion4 {
+		switch addressType {
+		case "", "SMTP":
+			vc.AddPropertyParameter(emailProp, "TYPE", []string{"internet"})
+		case "X400":
+			vc.AddPropertyParameter(emailProp, "TYPE", []string{"x400"})
+		}
+	}
+
+	if displayName := t.GetNamedStringValue(PsetidAddress, displayNameLid); displayName != "" && displayName != address {
+		vc.AddPropertyParameter(emailProp, "X-CN", []string{vcardNameParameter(displayName)})
+	}
+
+	if first && version == VcardVersion4 {
+		addVCardPref(vc, emailProp, version)
+	}
+	vc.AddProperty(emailProp)
+}
+
+/**
+ * a display name as parameter value: quoted if it has a separator, the double quotes are not allowed
+ */
+func vcardNameParameter(displayName string) string {
+	if strings.ContainsAny(displayName, ",;:") {
+		return "\"" + strings.Replace(displayName, "\"", "'", -1) + "\""
+	}
+	return displayName
+}
+
+/**
+ * add a related person: the Outlook property (X-MS-SPOUSE, ...) before 4.0, RELATED;VALUE=text;TYPE=relation in 4.0
+ * (the Outlook property is kept in 4.0 when there is no matching relation type)
+ */
+func addVCardRelated(vc vcard.IVCard, version string, outlookName string, relation string, name string) {
+	if name == "" {
+		return
+	}
+	if version == VcardVersion4 && relation != "" {
+		relatedProp := vc.CreateProperty("related")
+		relatedProp.AddValue(vcard.NewText(name))
+		vc.AddPropertyParameter(relatedProp, "TYPE", []string{relation})
+		vc.AddPropertyParameter(relatedProp, "VALUE", []string{"text"})
+		vc.AddProperty(relatedProp)
+		return
+	}
+	if relatedProp := vc.CreateProperty(outlookName); relatedProp != nil {
+		relatedProp.AddValue(vcard.NewText(name))
+		vc.AddProperty(relatedProp)
+	}
+}
+
+// the tag of the certificate in the certificate blobs of PidTagUserX509Certificate
+const contactCertificateTag = 0x0003
+
+/**
+ * return the DER certificate of a PidTagUserX509Certificate value: a DER certificate, or the Outlook blob made of
+ * TLV entries (tag and length including the 4 bytes of the header, both uint16) with the certificate in tag 0x0003
+ */
+func decodeContactCertificate(b []byte) []byte {
+	if len(b) > 0 && b[0] == 0x30 {
+		// ASN.1 SEQUENCE
+		return b
+	}
+	leDecoder := new(LittleEndianDecoder)
+	for len(b) >= 4 {
+		tag := int(leDecoder.Uint16(b[0:2]))
+		length := int(leDecoder.Uint16(b[2:4]))
+		if length < 4 || length > len(b) {
+			return nil
+		}
+		if tag == contactCertificateTag {
+			return b[4:length]
+		}
+		b = b[length:]
+	}
+	return nil
+}
+
+/**
+ * add a KEY property: ENCODING=b;TYPE=X509 before 4.0, a data URI in 4.0
+ */
+func addVCardKey(vc vcard.IVCard, version string, der []byte) {
+	keyProp := vc.CreateProperty("key")
+	if version == VcardVersion4 {
+		keyProp.AddValue(vcard.NewText("data:application/pkix-cert;base64," + b64.StdEncoding.EncodeToString(der)))
+		vc.AddProperty(keyProp)
+		return
+	}
+	keyProp.AddValue(vcard.NewText(b64.StdEncoding.EncodeToString(der)))
+	vc.AddPropertyParameter(keyProp, "ENCODING", []string{"b"})
+	vc.AddPropertyParameter(keyProp, "TYPE", []string{"X509"})
+	vc.AddProperty(keyProp)
+}
+
+/**
+ * the coordinates of the contact addresses set by Exchange: string named properties of PSETID_Address
+ */
+var contactGeoProperties = map[int][2]string{
+	PostalAddressHome:  {"HomeLatitude", "HomeLongitude"},
+	PostalAddressWork:  {"WorkLatitude", "WorkLongitude"},
+	PostalAddressOther: {"OtherLatitude", "OtherLongitude"},
+}
+
+/**
+ * return "latitude;longitude" for the mailing address, else for the first address having coordinates; "" if none
+ */
+func contactGeo(t *TnefObject, postalAddressId int) string {
+	for _, id := range []int{postalAddressId, PostalAddressWork, PostalAddressHome, PostalAddressOther} {
+		names, ok := contactGeoProperties[id]
+		if !ok {
+			continue
+		}
+		latitude := vcardCoordinate(t.GetNamedAttribute(PsetidAddress, names[0]))
+		longitude := vcardCoordinate(t.GetNamedAttribute(PsetidAddress, names[1]))
+		if latitude != "" && longitude != "" {
+			return latitude + ";" + longitude
+		}
+	}
+	return ""
+}
+
+/**
+ * a coordinate stored as a double or as a string
+ */
+func vcardCoordinate(attr *Attribute) string {
+	if attr == nil {
+		return ""
+	}
+	if attr.DataType == MapiTypeFlt64 {
+		return strconv.FormatFloat(MapiDecodeFloat64(attr.Data), 'f', -1, 64)
+	}
+	v := strings.TrimSpace(attr.GetStringValue())
+	if _, err := strconv.ParseFloat(v, 64); err != nil {
+		return ""
+	}
+	return v
+}
@@ -0,0 +1,102 @@
+package tnefdecoder
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVCardTelValue(t *testing.T) {
+	tests := []struct {
+		number  string
+		version string
+		want    string
+	}{
+		{"+49 30 1234-56", VcardVersion3, "+49 30 1234-56"},
+		{"+49 30 1234-56", VcardVersion4, "tel:+49-30-1234-56"},
+		{"", VcardVersion4, ""},
+	}
+	for _, tt := range tests {
+		if v := vcardTelValue(tt.number, tt.version); v != tt.want {
+			t.Errorf("%s %q: %q, want %q", tt.version, tt.number, v, tt.want)
+		}
+	}
+}
+
+func TestVCardGender(t *testing.T) {
+	for gender, want := range map[int]string{0: "", 1: "F", 2: "M", 3: ""} {
+		if v := vcardGender(gender); v != want {
+			t.Errorf("%d: %q, want %q", gender, v, want)
+		}
+	}
+}
+
+func TestVCardDate(t *testing.T) {
+	tests := []struct {
+		name string
+		v    time.Time
+		want string
+	}{
+		{"UTC midnight", time.Date(1980, 5, 17, 0, 0, 0, 0, time.UTC), "19800517"},
+		{"midnight in UTC+2", time.Date(1980, 5, 16, 22, 0, 0, 0, time.UTC), "19800517"},
+		{"midnight in UTC-5", time.Date(1980, 5, 17, 5, 0, 0, 0, time.UTC), "19800517"},
+		{"midnight in UTC+12", time.Date(1980, 5, 16, 12, 0, 0, 0, time.UTC), "19800517"},
+	}
+	for _, tt := range tests {
+		if v := vcardDate(tt.v); v != tt.want {
+			t.Errorf("%s: %s, want %s", tt.name, v, tt.want)
+		}
+	}
+}
+
+func TestDecodeContactCertificate(t *testing.T) {
+	der := []byte{0x30, 0x03, 0x02, 0x01, 0x05}
+	tlv := func(tag int, value []byte) []byte {
+		n := len(value) + 4
+		return append([]byte{byte(tag), byte(tag >> 8), byte(n), byte(n >> 8)}, value...)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{"DER", der, der},
+		{"Outlook blob", append(append(tlv(0x0001, []byte{1, 0, 0, 0}), tlv(contactCertificateTag, der)...), tlv(0x0004, nil)...), der},
+		{"no certificate", tlv(0x0001, []byte{1, 0, 0, 0}), nil},
+		{"length larger than the data", append(tlv(contactCertificateTag, der)[:4], 0x30), nil},
+		{"length smaller than the header", []byte{0x01, 0x00, 0x02, 0x00, 0x03, 0x00, 0x09, 0x00}, nil},
+		{"empty", nil, nil},
+	}
+	for _, tt := range tests {
+		if v := decodeContactCertificate(tt.data); string(v) != string(tt.want) {
+			t.Errorf("%s: %x, want %x", tt.name, v, tt.want)
+		}
+	}
+}
+
+func TestDecodeContactCertificateMalformed(t *testing.T) {
+	seed := []byte{0x01, 0x00, 0x08, 0x00, 1, 0, 0, 0, 0x03, 0x00, 0x09, 0x00, 0x30, 0x03, 0x02, 0x01, 0x05}
+	checkMalformed(t, func(b []byte) { decodeContactCertificate(b) }, seed)
+}
+
+func TestContactGeo(t *testing.T) {
+	tObj := &TnefObject{}
+	if geo := contactGeo(tObj, PostalAddressHome); geo != "" {
+		t.Errorf("no coordinates: %q", geo)
+	}
+
+	tObj.SetAttribute(NewMapiStringAttribute(0, "52.52").Named(PsetidAddress, "WorkLatitude"))
+	tObj.SetAttribute(NewMapiStringAttribute(0, "13.405").Named(PsetidAddress, "WorkLongitude"))
+	tObj.SetAttribute(NewMapiStringAttribute(0, "not a number").Named(PsetidAddress, "OtherLatitude"))
+	tObj.SetAttribute(NewMapiStringAttribute(0, "2.35").Named(PsetidAddress, "OtherLongitude"))
+	if geo := contactGeo(tObj, PostalAddressOther); geo != "52.52;13.405" {
+		t.Errorf("invalid mailing address coordinates: %q", geo)
+	}
+
+	latitude := NewMapiAttribute(0, MapiTypeFlt64, []byte{0, 0, 0, 0, 0, 0x6C, 0x48, 0x40}).Named(PsetidAddress, "HomeLatitude")
+	tObj.SetAttribute(latitude)
+	tObj.SetAttribute(NewMapiStringAttribute(0, "-2.5").Named(PsetidAddress, "HomeLongitude"))
+	if geo := contactGeo(tObj, PostalAddressHome); geo != "48.84375;-2.5" {
+		t.Errorf("home coordinates: %q", geo)
+	}
+}
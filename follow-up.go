@@ -0,0 +1,92 @@
+/**
+ * the follow-up flags and the categories of the messages ([MS-OXOFLAG])
+ */
+
+package tnefdecoder
+
+import (
+	"strings"
+	"time"
+)
+
+// the action shown by Outlook for a flag without PidLidFlagRequest
+const DefaultFlagRequest = "Follow up"
+
+/**
+ * return PidTagFlagStatus: FlagStatusNone, FlagStatusComplete or FlagStatusFlagged
+ */
+func (t *TnefObject) GetFlagStatus() int {
+	if attr := t.GetAttribute(MapiPidTagFlagStatus, "mapi"); attr != nil {
+		return attr.GetIntValue()
+	}
+	return FlagStatusNone
+}
+
+/**
+ * check if the message is flagged for follow-up (and not completed)
+ */
+func (t *TnefObject) IsFlagged() bool {
+	return t.GetFlagStatus() == FlagStatusFlagged
+}
+
+/**
+ * return the requested follow-up action (PidLidFlagRequest); DefaultFlagRequest for a flagged message without it
+ */
+func (t *TnefObject) GetFlagRequest() string {
+	request := t.GetNamedStringValue(PsetidCommon, MapiPidLidFlagRequest)
+	if request == "" && t.IsFlagged() {
+		request = DefaultFlagRequest
+	}
+	return request
+}
+
+/**
+ * return when the flag was marked complete (PidTagFlagCompleteTime); zero time if it is not completed
+ */
+func (t *TnefObject) GetFlagCompleteTime() time.Time {
+	if attr := t.GetAttribute(MapiPidTagFlagCompleteTime, "mapi"); attr != nil {
+		return attr.GetTimeValue()
+	}
+	return time.Time{}
+}
+
+/**
+ * return the due date of the follow-up (PidLidTaskDueDate); zero time if the flag has no due date
+ */
+func (t *TnefObject) GetFollowUpDueDate() time.Time {
+	return t.GetNamedTimeValue(PsetidTask, MapiPidLidTaskDueDate)
+}
+
+/**
+ * return the date by which a reply is expected: PidTagReplyTime, else the due date of the follow-up flag
+ */
+func (t *TnefObject) GetReplyTime() time.Time {
+	if attr := t.GetAttribute(MapiPidTagReplyTime, "mapi"); attr != nil && !attr.GetTimeValue().IsZero() {
+		return attr.GetTimeValue()
+	}
+	if t.IsFlagged() {
+		return t.GetFollowUpDueDate()
+	}
+	return time.Time{}
+}
+
+/**
+ * return the categories of the object: the Keywords named property (PS_PUBLIC_STRINGS), else PidLidCategories
+ */
+func (t *TnefObject) GetCategories() []string {
+	attr := t.GetNamedAttribute(PsPublicStrings, NamedKeywords)
+	if attr == nil {
+		attr = t.GetNamedAttribute(PsetidCommon, MapiPidLidCategories)
+	}
+	if attr == nil {
+		return nil
+	}
+
+	categories := []string{}
+	for _, v := range attr.GetStringValueArray() {
+		if v = strings.TrimRight(v, "\x00"); v != "" {
+			categories = append(categories, v)
+		}
+	}
+	return categories
+}
@@ -0,0 +1,88 @@
+package tnefdecoder
+
+import (
+	"mime"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFollowUpFlag(t *testing.T) {
+	due := time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)
+	reply := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	completed := time.Date(2024, 3, 7, 15, 0, 0, 0, time.UTC)
+
+	tObj := &TnefObject{}
+	if tObj.IsFlagged() || tObj.GetFlagRequest() != "" || !tObj.GetReplyTime().IsZero() {
+		t.Errorf("not flagged: %v %q %v", tObj.IsFlagged(), tObj.GetFlagRequest(), tObj.GetReplyTime())
+	}
+
+	tObj.SetAttribute(NewMapiIntAttribute(MapiPidTagFlagStatus, FlagStatusFlagged))
+	tObj.SetAttribute(NewMapiTimeAttribute(0, due).Named(PsetidTask, MapiPidLidTaskDueDate))
+	if !tObj.IsFlagged() || tObj.GetFlagRequest() != DefaultFlagRequest || tObj.GetReplyTime() != due {
+		t.Errorf("flagged: %v %q %v", tObj.IsFlagged(), tObj.GetFlagRequest(), tObj.GetReplyTime())
+	}
+
+	tObj.SetAttribute(NewMapiStringAttribute(0, "Call").Named(PsetidCommon, MapiPidLidFlagRequest))
+	tObj.SetAttribute(NewMapiTimeAttribute(MapiPidTagReplyTime, reply))
+	if tObj.GetFlagRequest() != "Call" || tObj.GetReplyTime() != reply {
+		t.Errorf("request and reply time: %q %v", tObj.GetFlagRequest(), tObj.GetReplyTime())
+	}
+
+	tObj.SetAttribute(NewMapiIntAttribute(MapiPidTagFlagStatus, FlagStatusComplete))
+	tObj.SetAttribute(NewMapiTimeAttribute(MapiPidTagFlagCompleteTime, completed))
+	if tObj.IsFlagged() || tObj.GetFlagCompleteTime() != completed {
+		t.Errorf("completed: %v %v", tObj.IsFlagged(), tObj.GetFlagCompleteTime())
+	}
+}
+
+func TestGetCategories(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes []*Attribute
+		want       []string
+	}{
+		{"none", nil, nil},
+		{"Keywords", []*Attribute{NewMapiStringArrayAttribute(0, []string{"Red", "", "Project X"}).Named(PsPublicStrings, NamedKeywords)}, []string{"Red", "Project X"}},
+		{"PidLidCategories", []*Attribute{NewMapiStringArrayAttribute(0, []string{"Blue"}).Named(PsetidCommon, MapiPidLidCategories)}, []string{"Blue"}},
+		{"Keywords first", []*Attribute{
+			NewMapiStringArrayAttribute(0, []string{"Blue"}).Named(PsetidCommon, MapiPidLidCategories),
+			NewMapiStringArrayAttribute(0, []string{"Red"}).Named(PsPublicStrings, NamedKeywords),
+		}, []string{"Red"}},
+	}
+	for _, tt := range tests {
+		tObj := &TnefObject{}
+		for _, attr := range tt.attributes {
+			tObj.SetAttribute(attr)
+		}
+		if categories := tObj.GetCategories(); !reflect.DeepEqual(categories, tt.want) {
+			t.Errorf("%s: %q, want %q", tt.name, categories, tt.want)
+		}
+	}
+}
+
+func TestExportMimeFollowUpHeaders(t *testing.T) {
+	due := time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)
+	tObj := &TnefObject{}
+	tObj.SetAttribute(NewMapiIntAttribute(MapiPidTagFlagStatus, FlagStatusFlagged))
+	tObj.SetAttribute(NewMapiStringAttribute(0, "Rückruf").Named(PsetidCommon, MapiPidLidFlagRequest))
+	tObj.SetAttribute(NewMapiTimeAttribute(0, due).Named(PsetidTask, MapiPidLidTaskDueDate))
+	tObj.SetAttribute(NewMapiStringArrayAttribute(0, []string{"Red", "a, b", "Grün"}).Named(PsPublicStrings, NamedKeywords))
+
+	header := GetMimeHeader(tObj)
+	decoder := new(mime.WordDecoder)
+	if keywords, err := decoder.DecodeHeader(header.Get("Keywords")); err != nil || keywords != "Red, a  b, Grün" {
+		t.Errorf("Keywords %q %v", keywords, err)
+	}
+	if flag, err := decoder.DecodeHeader(header.Get("X-Message-Flag")); err != nil || flag != "Rückruf" {
+		t.Errorf("X-Message-Flag %q %v", flag, err)
+	}
+	if v := header.Get("Reply-By"); v != due.Format(time.RFC1123Z) {
+		t.Errorf("Reply-By %q", v)
+	}
+
+	tObj.SetAttribute(NewMapiIntAttribute(MapiPidTagFlagStatus, FlagStatusComplete))
+	if header = GetMimeHeader(tObj); header.Get("X-Message-Flag") != "" || header.Get("Reply-By") != "" {
+		t.Errorf("completed flag: %v", header)
+	}
+}
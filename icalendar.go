@@ -0,0 +1,441 @@
+/**
+ * minimal iCalendar (RFC 5545) writer used to export the calendar items
+ */
+
+package tnefdecoder
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// the product identifier of the generated iCalendar objects
+const ICalProdId = "-//tnefdecoder//TNEF to iCalendar//EN"
+
+/**
+ * iCalendar date-time formats
+ */
+const (
+	ICalDateFormat     = "20060102"
+	ICalDateTimeFormat = "20060102T150405"
+)
+
+var ErrInvalidICalendar = errors.New("invalid iCalendar object")
+
+/**
+ * a property parameter; the values are quoted when required
+ */
+type ICalParameter struct {
+	Name   string
+	Values []string
+}
+
+/**
+ * a content line: NAME;PARAM=VALUE:value
+ * the value is written as it is, without its control characters; use AddText for TEXT values (escaped)
+ */
+type ICalProperty struct {
+	Name       string
+	Parameters []*ICalParameter
+	Value      string
+}
+
+/**
+ * an iCalendar component (VCALENDAR, VEVENT, VTODO, VALARM, VTIMEZONE, ...)
+ */
+type ICalComponent struct {
+	Name       string
+	Properties []*ICalProperty
+	Components []*ICalComponent
+}
+
+func NewICalComponent(name string) *ICalComponent {
+	return &ICalComponent{Name: strings.ToUpper(name)}
+}
+
+/**
+ * create a VCALENDAR object with VERSION, PRODID and METHOD (if not empty)
+ */
+func NewICalendar(method string) *ICalComponent {
+	c := NewICalComponent("VCALENDAR")
+	c.AddProperty("PRODID", ICalProdId)
+	c.AddProperty("VERSION", "2.0")
+	if method != "" {
+		c.AddProperty("METHOD", method)
+	}
+	return c
+}
+
+/**
+ * add a property with a raw value; parameters are given as name, value pairs
+ */
+func (c *ICalComponent) AddProperty(name string, value string, params ...string) *ICalProperty {
+	p := &ICalProperty{Name: strings.ToUpper(name), Value: value}
+	for i := 0; i+1 < len(params); i += 2 {
+		p.AddParameter(params[i], params[i+1])
+	}
+	c.Properties = append(c.Properties, p)
+	return p
+}
+
+/**
+ * add a TEXT property (the value is escaped); empty values are ignored
+ */
+func (c *ICalComponent) AddText(name string, value string, params ...string) *ICalProperty {
+	if value == "" {
+		return nil
+	}
+	return c.AddProperty(name, ICalEscapeText(value), params...)
+}
+
+/**
+ * add a DATE-TIME property in UTC; zero values are ignored
+ */
+func (c *ICalComponent) AddDateTime(name string, value time.Time, params ...string) *ICalProperty {
+	if value.IsZero() {
+		return nil
+	}
+	return c.AddProperty(name, ICalFormatDateTime(value), params...)
+}
+
+/**
+ * add a DATE property (VALUE=DATE); zero values are ignored
+ */
+func (c *ICalComponent) AddDate(name string, value time.Time, params ...string) *ICalProperty {
+	if value.IsZero() {
+		return nil
+	}
+	return c.AddProperty(name, value.Format(ICalDateFormat), append([]string{"VALUE", "DATE"}, params...)...)
+}
+
+func (c *ICalComponent) AddComponent(component *ICalComponent) {
+	c.Components = append(c.Components, component)
+}
+
+/**
+ * return the first property with the given name
+ */
+func (c *ICalComponent) GetProperty(name string) *ICalProperty {
+	name = strings.ToUpper(name)
+	for _, p := range c.Properties {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+/**
+ * remove all the properties with the given name
+ */
+func (c *ICalComponent) RemoveProperty(name string) {
+	name = strings.ToUpper(name)
+	properties := []*ICalProperty{}
+	for _, p := range c.Properties {
+		if p.Name != name {
+			properties = append(properties, p)
+		}
+	}
+	c.Properties = properties
+}
+
+/**
+ * return the sub components with the given name
+ */
+func (c *ICalComponent) GetComponents(name string) []*ICalComponent {
+	result := []*ICalComponent{}
+	name = strings.ToUpper(name)
+	for _, component := range c.Components {
+		if component.Name == name {
+			result = append(result, component)
+		}
+	}
+	return result
+}
+
+/**
+ * a deep copy of the component: the copy can be changed without changing the original
+ */
+func (c *ICalComponent) Clone() *ICalComponent {
+	clone := &ICalComponent{Name: c.Name}
+	for _, p := range c.Properties {
+		clone.Properties = append(clone.Properties, p.Clone())
+	}
+	for _, component := range c.Components {
+		clone.Components = append(clone.Components, component.Clone())
+	}
+	return clone
+}
+
+/**
+ * a deep copy of the property (the parameters are copied)
+ */
+func (p *ICalProperty) Clone() *ICalProperty {
+	clone := &ICalProperty{Name: p.Name, Value: p.Value}
+	for _, param := range p.Parameters {
+		clone.AddParameter(param.Name, append([]string{}, param.Values...)...)
+	}
+	return clone
+}
+
+func (p *ICalProperty) AddParameter(name string, values ...string) {
+	p.Parameters = append(p.Parameters, &ICalParameter{Name: strings.ToUpper(name), Values: values})
+}
+
+/**
+ * return the values of a parameter (nil if missing)
+ */
+func (p *ICalProperty) GetParameter(name string) []string {
+	name = strings.ToUpper(name)
+	for _, param := range p.Parameters {
+		if param.Name == name {
+			return param.Values
+		}
+	}
+	return nil
+}
+
+/**
+ * serialize the component (CRLF line endings, lines folded at 75 octets)
+ */
+func (c *ICalComponent) Build() string {
+	buf := &bytes.Buffer{}
+	c.write(buf)
+	return buf.String()
+}
+
+func (c *ICalComponent) write(buf *bytes.Buffer) {
+	writeICalLine(buf, "BEGIN:"+c.Name)
+	for _, p := range c.Properties {
+		writeICalLine(buf, p.String())
+	}
+	for _, component := range c.Components {
+		component.write(buf)
+	}
+	writeICalLine(buf, "END:"+c.Name)
+}
+
+/**
+ * the content line (unfolded)
+ */
+func (p *ICalProperty) String() string {
+	line := p.Name
+	for _, param := range p.Parameters {
+		values := make([]string, len(param.Values))
+		for i, v := range param.Values {
+			values[i] = ICalQuoteParameter(v)
+		}
+		line += ";" + param.Name + "=" + strings.Join(values, ",")
+	}
+	return line + ":" + icalRawValue(p.Value)
+}
+
+/**
+ * remove the control characters (except the tab) from a raw value: a line break in an address or a URI would start a
+ * new content line
+ */
+func icalRawValue(value string) string {
+	return strings.Map(func(c rune) rune {
+		if (c < 0x20 && c != '\t') || c == 0x7F {
+			return -1
+		}
+		return c
+	}, value)
+}
+
+/**
+ * write a content line folded at 75 octets, without splitting UTF-8 characters
+ */
+func writeICalLine(buf *bytes.Buffer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		// the continuation lines start with a space
+		limit = 74
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+/**
+ * escape a TEXT value: backslash, semicolon, comma and new lines
+ */
+func ICalEscapeText(value string) string {
+	value = strings.Replace(value, "\r\n", "\n", -1)
+	value = strings.Replace(value, "\r", "\n", -1)
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(value)
+}
+
+/**
+ * quote a parameter value if it contains ":", ";" or ","; double quotes and control characters are not allowed
+ * (RFC 5545 section 3.1): the line breaks are replaced with a space, the other characters are removed
+ */
+func ICalQuoteParameter(value string) string {
+	value = strings.Map(func(c rune) rune {
+		switch {
+		case c == '\r' || c == '\n':
+			return ' '
+		case c == '"' || (c < 0x20 && c != '\t') || c == 0x7F:
+			return -1
+		}
+		return c
+	}, value)
+	if strings.ContainsAny(value, ":;,") {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+/**
+ * UTC DATE-TIME value
+ */
+func ICalFormatDateTime(t time.Time) string {
+	return t.UTC().Format(ICalDateTimeFormat) + "Z"
+}
+
+/**
+ * parse an iCalendar object: the first component of the text with its sub components
+ * the property values are kept as they are (see GetText for the TEXT values)
+ */
+func ParseICalendar(text string) (*ICalComponent, error) {
+	// unfold the lines
+	text = strings.NewReplacer("\r\n ", "", "\r\n\t", "", "\n ", "", "\n\t", "").Replace(text)
+
+	stack := []*ICalComponent{}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		// the content lines have the same syntax as in vCard
+		p := parseVCardLine(line)
+		if p == nil {
+			continue
+		}
+
+		switch p.Name {
+		case "begin":
+			component := NewICalComponent(p.Value)
+			if len(stack) > 0 {
+				stack[len(stack)-1].AddComponent(component)
+			}
+			stack = append(stack, component)
+		case "end":
+			if len(stack) == 0 || !strings.EqualFold(stack[len(stack)-1].Name, p.Value) {
+				return nil, ErrInvalidICalendar
+			}
+			if len(stack) == 1 {
+				return stack[0], nil
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				continue
+			}
+			property := &ICalProperty{Name: strings.ToUpper(p.Name), Value: p.Value}
+			for _, param := range p.Params {
+				property.AddParameter(param.Name, param.Values...)
+			}
+			component := stack[len(stack)-1]
+			component.Properties = append(component.Properties, property)
+		}
+	}
+	return nil, ErrInvalidICalendar
+}
+
+/**
+ * the value of a TEXT property (unescaped)
+ */
+func (p *ICalProperty) GetText() string {
+	if p == nil {
+		return ""
+	}
+	return ICalUnescapeText(p.Value)
+}
+
+/**
+ * unescape a TEXT value
+ */
+func ICalUnescapeText(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
+
+/**
+ * parse a DATE or DATE-TIME value; utc is set for the UTC DATE-TIME values (Z), date for the DATE values
+ * the local times are returned as wall clock in UTC
+ */
+func ParseICalDateTime(value string) (v time.Time, utc bool, date bool, err error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case len(value) == len(ICalDateFormat):
+		v, err = time.Parse(ICalDateFormat, value)
+		return v, false, true, err
+	case strings.HasSuffix(value, "Z"):
+		v, err = time.Parse(ICalDateTimeFormat, strings.TrimSuffix(value, "Z"))
+		return v, true, false, err
+	}
+	v, err = time.Parse(ICalDateTimeFormat, value)
+	return v, false, false, err
+}
+
+/**
+ * parse a DURATION value (RFC 5545 section 3.3.6) as a number of minutes; the seconds are truncated
+ */
+func ParseICalDuration(value string) (int, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	sign := 1
+	if strings.HasPrefix(value, "-") {
+		sign = -1
+	}
+	value = strings.TrimLeft(value, "+-")
+	if !strings.HasPrefix(value, "P") || len(value) < 3 {
+		return 0, ErrInvalidICalendar
+	}
+
+	seconds := 0
+	number := ""
+	inTime := false
+	for _, c := range value[1:] {
+		if c >= '0' && c <= '9' {
+			number += string(c)
+			continue
+		}
+		if c == 'T' {
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, ErrInvalidICalendar
+		}
+		number = ""
+		switch {
+		case c == 'W' && !inTime:
+			seconds += n * 7 * 86400
+		case c == 'D' && !inTime:
+			seconds += n * 86400
+		case c == 'H' && inTime:
+			seconds += n * 3600
+		case c == 'M' && inTime:
+			seconds += n * 60
+		case c == 'S' && inTime:
+			seconds += n
+		default:
+			return 0, ErrInvalidICalendar
+		}
+	}
+	if number != "" {
+		return 0, ErrInvalidICalendar
+	}
+	return sign * seconds / 60, nil
+}
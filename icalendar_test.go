@@ -0,0 +1,123 @@
+package tnefdecoder
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestICalQuoteParameter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Jane Doe", "Jane Doe"},
+		{"Doe, Jane", `"Doe, Jane"`},
+		{"mailto:jane@example.com", `"mailto:jane@example.com"`},
+		{`Jane "JD" Doe`, "Jane JD Doe"},
+		{"Jane\r\nATTENDEE:mailto:evil@example.com", `"Jane  ATTENDEE:mailto:evil@example.com"`},
+		{"a\x00b\x1bc\x7fd\te", "abcd\te"},
+		{`";CN=x:`, `";CN=x:"`},
+	}
+	for _, tt := range tests {
+		if v := ICalQuoteParameter(tt.value); v != tt.want {
+			t.Errorf("%q: %q, want %q", tt.value, v, tt.want)
+		}
+	}
+}
+
+func TestICalPropertyInjection(t *testing.T) {
+	event := NewICalComponent("VEVENT")
+	event.AddText("SUMMARY", "Meeting\r\nATTENDEE:mailto:summary@example.com")
+	event.AddProperty("ORGANIZER", "mailto:jane@example.com", "CN", "Jane\"\r\nATTENDEE:mailto:cn@example.com\r\n")
+
+	calendar := NewICalendar("REQUEST")
+	calendar.AddComponent(event)
+	calendar, err := ParseICalendar(calendar.Build())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed := calendar.GetComponents("VEVENT")[0]
+	if parsed.GetProperty("ATTENDEE") != nil {
+		t.Errorf("injected ATTENDEE in %+v", parsed)
+	}
+	if summary := parsed.GetProperty("SUMMARY").GetText(); summary != "Meeting\nATTENDEE:mailto:summary@example.com" {
+		t.Errorf("SUMMARY %q", summary)
+	}
+	if cn := parsed.GetProperty("ORGANIZER").GetParameter("CN"); len(cn) != 1 || !strings.HasPrefix(cn[0], "Jane") {
+		t.Errorf("CN %q", cn)
+	}
+}
+
+func TestICalRawValueLineBreaks(t *testing.T) {
+	event := NewICalComponent("VEVENT")
+	addICalendarAddress(event, "ORGANIZER", "Jane", "jane@example.com\r\nATTENDEE:mailto:evil@example.com")
+	addICalendarAddress(event, "ATTENDEE", "", "john@example.com\nX-INJECTED:1\x00")
+
+	calendar := NewICalendar("REQUEST")
+	calendar.AddComponent(event)
+	calendar, err := ParseICalendar(calendar.Build())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed := calendar.GetComponents("VEVENT")[0]
+	if len(parsed.Properties) != 2 || parsed.GetProperty("X-INJECTED") != nil {
+		t.Fatalf("injected properties in %+v", parsed.Properties)
+	}
+	if v := parsed.GetProperty("ORGANIZER").Value; v != "mailto:jane@example.comATTENDEE:mailto:evil@example.com" {
+		t.Errorf("ORGANIZER %q", v)
+	}
+	if v := parsed.GetProperty("ATTENDEE").Value; v != "mailto:john@example.comX-INJECTED:1" {
+		t.Errorf("ATTENDEE %q", v)
+	}
+}
+
+func TestTruncateToDay(t *testing.T) {
+	tests := []struct {
+		v    time.Time
+		zone *TimeZoneDefinition
+		want string
+	}{
+		// the local midnight of the organizer converted to UTC
+		{time.Date(2024, 3, 3, 23, 0, 0, 0, time.UTC), nil, "20240304"},
+		{time.Date(2024, 3, 4, 5, 0, 0, 0, time.UTC), nil, "20240304"},
+		{time.Date(2024, 3, 3, 12, 0, 0, 0, time.UTC), nil, "20240304"},
+		{time.Date(2024, 3, 3, 18, 30, 0, 0, time.UTC), nil, "20240304"},
+		{time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), nil, "20240304"},
+		// a known time zone: the date is truncated, not rounded
+		{time.Date(2024, 3, 4, 22, 59, 0, 0, time.UTC), NewFixedTimeZone(time.Hour), "20240304"},
+		{time.Date(2024, 3, 4, 23, 0, 0, 0, time.UTC), NewFixedTimeZone(time.Hour), "20240305"},
+		{time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC), NewFixedTimeZone(14 * time.Hour), "20240305"},
+	}
+	for _, test := range tests {
+		zone := test.zone
+		if zone == nil {
+			zone = midnightTimeZone(test.v)
+		}
+		if got := truncateToDay(test.v, zone).Format(ICalDateFormat); got != test.want {
+			t.Errorf("%v: %s, want %s", test.v, got, test.want)
+		}
+	}
+}
+
+func TestExtractICalendarStamp(t *testing.T) {
+	submit := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	modified := time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		attributes []*Attribute
+		want       time.Time
+	}{
+		{[]*Attribute{NewMapiTimeAttribute(MapiPidTagClientSubmitTime, submit), NewMapiTimeAttribute(MapiPidTagLastModificationTime, modified)}, submit},
+		{[]*Attribute{NewMapiTimeAttribute(MapiPidTagLastModificationTime, modified)}, modified},
+	} {
+		tObj := &TnefObject{}
+		tObj.SetAttribute(NewMappedStringAttribute(AttrLevelMessage, AttMessageClass, MessageClassMeetingRequest))
+		for _, attr := range test.attributes {
+			tObj.SetAttribute(attr)
+		}
+		calendar := ExtractICalendar(tObj)
+		if stamp := calendar.GetComponents("VEVENT")[0].GetProperty("DTSTAMP").Value; stamp != ICalFormatDateTime(test.want) {
+			t.Errorf("DTSTAMP %s, want %s", stamp, ICalFormatDateTime(test.want))
+		}
+	}
+}
@@ -0,0 +1,541 @@
+/**
+ * recurrence of the imported calendar objects: RRULE to RecurrencePattern, and the encoding of PidLidAppointmentRecur
+ * ([MS-OXOCAL] section 2.2.1.44, [MS-OXCICAL] section 2.1.3.2.2)
+ */
+
+package tnefdecoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+/**
+ * the versions written in the recurrence blobs
+ */
+const (
+	recurrenceVersion       = 0x3004
+	recurrenceReaderVersion = 0x3006
+	recurrenceWriterVersion = 0x3009
+)
+
+// the EndDate of the recurrences without end (December 31, 4500) and their OccurrenceCount
+const (
+	recurNeverEndDate         = 0x5AE980DF
+	recurNeverOccurrenceCount = 10
+)
+
+// the last date of the expansion of the recurrences
+var recurrenceExpansionLimit = MinutesToTime(recurNeverEndDate)
+
+// the maximum Period of the patterns ([MS-OXOCAL] section 2.2.1.44.1): 999 days, 99 weeks or months
+const (
+	recurMaxDailyPeriod = 999
+	recurMaxPeriod      = 99
+)
+
+var ErrUnsupportedRecurrence = errors.New("unsupported recurrence rule")
+
+/**
+ * PidLidRecurrenceType values
+ */
+const (
+	RecurrenceTypeNone    = 0
+	RecurrenceTypeDaily   = 1
+	RecurrenceTypeWeekly  = 2
+	RecurrenceTypeMonthly = 3
+	RecurrenceTypeYearly  = 4
+)
+
+/**
+ * convert a date to minutes since January 1, 1601 (the time zone is not changed)
+ */
+func TimeToMinutes(t time.Time) int {
+	return int((t.Unix() + 11644473600) / 60)
+}
+
+/**
+ * split an RRULE value into its parts (the names in upper case)
+ */
+func parseRRuleParts(rule string) map[string]string {
+	parts := map[string]string{}
+	for _, part := range strings.Split(rule, ";") {
+		if eq := strings.Index(part, "="); eq > 0 {
+			parts[strings.ToUpper(strings.TrimSpace(part[:eq]))] = strings.ToUpper(strings.TrimSpace(part[eq+1:]))
+		}
+	}
+	return parts
+}
+
+/**
+ * parse a BYDAY value: the occurrence in the month (0 if missing) and the day of the week (0 = Sunday, -1 if invalid)
+ */
+func parseRRuleWeekDay(v string) (int, int) {
+	v = strings.TrimSpace(v)
+	if len(v) < 2 {
+		return 0, -1
+	}
+	nth := 0
+	if prefix := v[:len(v)-2]; prefix != "" {
+		n, err := strconv.Atoi(strings.TrimPrefix(prefix, "+"))
+		if err != nil {
+			return 0, -1
+		}
+		nth = n
+	}
+	for i, day := range icalWeekDays {
+		if day == v[len(v)-2:] {
+			return nth, i
+		}
+	}
+	return 0, -1
+}
+
+/**
+ * create the recurrence pattern of an RRULE ([MS-OXCICAL] section 2.1.3.2.2)
+ * start is the start of the series and until the end of an UNTIL rule, both local times; StartDate, EndDate and
+ * OccurrenceCount are computed from the instances of the recurrence
+ */
+func NewRecurrencePatternFromRRule(rule string, start time.Time, until time.Time) (*RecurrencePattern, error) {
+	parts := parseRRuleParts(rule)
+
+	interval := 1
+	if v, ok := parts["INTERVAL"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, ErrUnsupportedRecurrence
+		}
+		interval = n
+	}
+	for _, unsupported := range []string{"BYSECOND", "BYMINUTE", "BYHOUR", "BYYEARDAY", "BYWEEKNO", "RSCALE"} {
+		if _, ok := parts[unsupported]; ok {
+			return nil, ErrUnsupportedRecurrence
+		}
+	}
+
+	weekDays, nth := 0, 0
+	if byDay := parts["BYDAY"]; byDay != "" {
+		for _, v := range strings.Split(byDay, ",") {
+			n, day := parseRRuleWeekDay(v)
+			if day < 0 || (nth != 0 && n != nth) {
+				return nil, ErrUnsupportedRecurrence
+			}
+			weekDays |= 1 << uint(day)
+			nth = n
+		}
+	}
+	if v, ok := parts["BYSETPOS"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || nth != 0 {
+			return nil, ErrUnsupportedRecurrence
+		}
+		nth = n
+	}
+	if nth < 0 {
+		if nth != -1 {
+			return nil, ErrUnsupportedRecurrence
+		}
+		nth = RecurNthLast
+	}
+
+	p := &RecurrencePattern{ReaderVersion: recurrenceVersion, WriterVersion: recurrenceVersion, Period: interval}
+	if wkst := parts["WKST"]; wkst != "" {
+		if _, day := parseRRuleWeekDay(wkst); day >= 0 {
+			p.FirstDOW = day
+		}
+	}
+
+	switch parts["FREQ"] {
+	case "DAILY":
+		p.RecurFrequency = RecurFrequencyDaily
+		p.PatternType = PatternTypeDay
+		if interval > recurMaxDailyPeriod {
+			return nil, ErrUnsupportedRecurrence
+		}
+		p.Period = interval * 1440
+		if weekDays != 0 {
+			// every weekday: a daily recurrence stored as a weekly pattern
+			if interval != 1 || nth != 0 {
+				return nil, ErrUnsupportedRecurrence
+			}
+			p.PatternType = PatternTypeWeek
+			p.Period = 1
+			p.WeekDays = weekDays
+		}
+	case "WEEKLY":
+		p.RecurFrequency = RecurFrequencyWeekly
+		p.PatternType = PatternTypeWeek
+		if interval > recurMaxPeriod {
+			return nil, ErrUnsupportedRecurrence
+		}
+		p.WeekDays = weekDays
+		if p.WeekDays == 0 {
+			p.WeekDays = 1 << uint(start.Weekday())
+		}
+	case "MONTHLY", "YEARLY":
+		p.RecurFrequency = RecurFrequencyMonthly
+		if parts["FREQ"] == "YEARLY" {
+			p.RecurFrequency = RecurFrequencyYearly
+			p.Period = interval * 12
+			if month := parts["BYMONTH"]; month != "" && month != strconv.Itoa(int(start.Month())) {
+				return nil, ErrUnsupportedRecurrence
+			}
+		}
+		if p.Period > recurMaxPeriod {
+			return nil, ErrUnsupportedRecurrence
+		}
+		monthDay := parts["BYMONTHDAY"]
+		switch {
+		case weekDays != 0:
+			if nth == 0 || monthDay != "" {
+				return nil, ErrUnsupportedRecurrence
+			}
+			p.PatternType = PatternTypeMonthNth
+			p.WeekDays = weekDays
+			p.Nth = nth
+		case monthDay == "-1":
+			p.PatternType = PatternTypeMonthEnd
+		case monthDay != "":
+			day, err := strconv.Atoi(monthDay)
+			if err != nil || day < 1 || day > 31 {
+				return nil, ErrUnsupportedRecurrence
+			}
+			p.PatternType = PatternTypeMonth
+			p.DayOfMonth = day
+		default:
+			p.PatternType = PatternTypeMonth
+			p.DayOfMonth = start.Day()
+		}
+	default:
+		return nil, ErrUnsupportedRecurrence
+	}
+
+	p.EndType = RecurEndNever
+	if v, ok := parts["COUNT"]; ok {
+		count, err := strconv.Atoi(v)
+		if err != nil || count < 1 {
+			return nil, ErrUnsupportedRecurrence
+		}
+		p.EndType = RecurEndAfterCount
+		p.OccurrenceCount = count
+	} else if _, ok := parts["UNTIL"]; ok {
+		if until.IsZero() {
+			return nil, ErrUnsupportedRecurrence
+		}
+		p.EndType = RecurEndAfterDate
+	}
+
+	anchor := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	first, last, count := p.instanceRange(anchor, until)
+	if count == 0 {
+		return nil, ErrUnsupportedRecurrence
+	}
+	p.StartDate = first
+	p.FirstDateTime = p.firstDateTime(anchor)
+
+	switch p.EndType {
+	case RecurEndNever:
+		p.OccurrenceCount = recurNeverOccurrenceCount
+		p.EndDate = recurrenceExpansionLimit
+	default:
+		p.OccurrenceCount = count
+		p.EndDate = last
+	}
+	return p, nil
+}
+
+/**
+ * the dates (midnight) of the first and the last instances from the anchor (the date of the start of the series) and
+ * their number: all the instances for the patterns with an end, the first one for the others; the instances are
+ * enumerated by period of the pattern (day, week or month) until the expansion limit
+ */
+func (p *RecurrencePattern) instanceRange(anchor time.Time, until time.Time) (time.Time, time.Time, int) {
+	var first, last time.Time
+	count := 0
+	for n := 0; ; n++ {
+		periodStart, dates := p.periodDates(anchor, n)
+		if !periodStart.Before(recurrenceExpansionLimit) {
+			return first, last, count
+		}
+		for _, d := range dates {
+			if d.Before(anchor) {
+				continue
+			}
+			if !d.Before(recurrenceExpansionLimit) || (p.EndType == RecurEndAfterDate && d.After(until)) {
+				return first, last, count
+			}
+			if count == 0 {
+				first = d
+			}
+			last = d
+			count++
+			if p.EndType == RecurEndNever || (p.EndType == RecurEndAfterCount && count == p.OccurrenceCount) {
+				return first, last, count
+			}
+		}
+	}
+}
+
+/**
+ * the start of the nth period of the pattern from the anchor and the dates of its instances
+ */
+func (p *RecurrencePattern) periodDates(anchor time.Time, n int) (time.Time, []time.Time) {
+	switch p.PatternType {
+	case PatternTypeDay:
+		d := anchor.AddDate(0, 0, n*(p.Period/1440))
+		return d, []time.Time{d}
+	case PatternTypeWeek:
+		weekStart := p.weekStart(anchor).AddDate(0, 0, 7*n*p.Period)
+		dates := []time.Time{}
+		for i := 0; i < 7; i++ {
+			if d := weekStart.AddDate(0, 0, i); p.WeekDays&(1<<uint(d.Weekday())) != 0 {
+				dates = append(dates, d)
+			}
+		}
+		return weekStart, dates
+	}
+
+	monthStart := time.Date(anchor.Year(), anchor.Month()+time.Month(n*p.Period), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := monthStart.AddDate(0, 1, -1).Day()
+	day := 0
+	switch p.PatternType {
+	case PatternTypeMonth:
+		day = p.DayOfMonth
+		if day > lastDay {
+			day = lastDay
+		}
+	case PatternTypeMonthEnd:
+		day = lastDay
+	case PatternTypeMonthNth:
+		days := []int{}
+		for i := 1; i <= lastDay; i++ {
+			if p.WeekDays&(1<<uint(monthStart.AddDate(0, 0, i-1).Weekday())) != 0 {
+				days = append(days, i)
+			}
+		}
+		index := p.Nth - 1
+		if p.Nth == RecurNthLast || index >= len(days) {
+			index = len(days) - 1
+		}
+		if index >= 0 {
+			day = days[index]
+		}
+	}
+	if day == 0 {
+		return monthStart, nil
+	}
+	return monthStart, []time.Time{monthStart.AddDate(0, 0, day-1)}
+}
+
+/**
+ * the first day of the week of the date (FirstDOW)
+ */
+func (p *RecurrencePattern) weekStart(d time.Time) time.Time {
+	return d.AddDate(0, 0, -((int(d.Weekday()) - p.FirstDOW + 7) % 7))
+}
+
+/**
+ * FirstDateTime ([MS-OXOCAL] section 2.2.1.44.1.2): the offset of the first period of the pattern
+ */
+func (p *RecurrencePattern) firstDateTime(anchor time.Time) int {
+	switch p.PatternType {
+	case PatternTypeDay:
+		return TimeToMinutes(anchor) % p.Period
+	case PatternTypeWeek:
+		return TimeToMinutes(p.weekStart(anchor)) % (p.Period * 7 * 1440)
+	}
+	months := ((anchor.Year()-1601)*12 + int(anchor.Month()) - 1) % p.Period
+	return TimeToMinutes(time.Date(1601, time.Month(1+months), 1, 0, 0, 0, 0, time.UTC))
+}
+
+/**
+ * PidLidRecurrenceType of the pattern
+ */
+func (p *RecurrencePattern) RecurrenceType() int {
+	switch p.RecurFrequency {
+	case RecurFrequencyDaily:
+		return RecurrenceTypeDaily
+	case RecurFrequencyWeekly:
+		return RecurrenceTypeWeekly
+	case RecurFrequencyMonthly:
+		return RecurrenceTypeMonthly
+	case RecurFrequencyYearly:
+		return RecurrenceTypeYearly
+	}
+	return RecurrenceTypeNone
+}
+
+/**
+ * little endian writer of the recurrence blobs
+ */
+type recurrenceWriter struct {
+	bytes.Buffer
+}
+
+func (w *recurrenceWriter) uint16(v int) {
+	binary.Write(w, binary.LittleEndian, uint16(v))
+}
+
+func (w *recurrenceWriter) uint32(v int) {
+	binary.Write(w, binary.LittleEndian, uint32(v))
+}
+
+func (w *recurrenceWriter) minutes(t time.Time) {
+	w.uint32(TimeToMinutes(t))
+}
+
+func (w *recurrenceWriter) dates(dates []time.Time) {
+	w.uint32(len(dates))
+	for _, d := range dates {
+		w.minutes(d)
+	}
+}
+
+/**
+ * the 8-bit strings of ExceptionInfo: the characters that are not in Latin-1 are replaced with "?"
+ */
+func (w *recurrenceWriter) ansiString(s string) {
+	b := []byte{}
+	for _, r := range s {
+		if r > 0xFF {
+			r = '?'
+		}
+		b = append(b, byte(r))
+	}
+	w.uint16(len(b) + 1)
+	w.uint16(len(b))
+	w.Write(b)
+}
+
+func (w *recurrenceWriter) unicodeString(s string) {
+	u := utf16.Encode([]rune(s))
+	w.uint16(len(u))
+	binary.Write(w, binary.LittleEndian, u)
+}
+
+/**
+ * encode the RecurrencePattern structure (see DecodeRecurrencePattern)
+ */
+func (p *RecurrencePattern) Encode() []byte {
+	w := &recurrenceWriter{}
+	p.encode(w)
+	return w.Bytes()
+}
+
+func (p *RecurrencePattern) encode(w *recurrenceWriter) {
+	w.uint16(p.ReaderVersion)
+	w.uint16(p.WriterVersion)
+	w.uint16(p.RecurFrequency)
+	w.uint16(p.PatternType)
+	w.uint16(p.CalendarType)
+	w.uint32(p.FirstDateTime)
+	w.uint32(p.Period)
+	w.uint32(p.SlidingFlag)
+
+	switch p.PatternType {
+	case PatternTypeWeek:
+		w.uint32(p.WeekDays)
+	case PatternTypeMonth, PatternTypeMonthEnd, PatternTypeHjMonth, PatternTypeHjMonthEnd:
+		w.uint32(p.DayOfMonth)
+	case PatternTypeMonthNth, PatternTypeHjMonthNth:
+		w.uint32(p.WeekDays)
+		w.uint32(p.Nth)
+	}
+
+	w.uint32(p.EndType)
+	w.uint32(p.OccurrenceCount)
+	w.uint32(p.FirstDOW)
+	w.dates(p.DeletedInstanceDates)
+	w.dates(p.ModifiedInstanceDates)
+	w.minutes(p.StartDate)
+	w.minutes(p.EndDate)
+}
+
+/**
+ * encode PidLidAppointmentRecur (see DecodeAppointmentRecurrence); the exceptions are written with their extended exceptions
+ */
+func (p *AppointmentRecurrencePattern) Encode() []byte {
+	w := &recurrenceWriter{}
+	p.RecurrencePattern.encode(w)
+	w.uint32(recurrenceReaderVersion)
+	w.uint32(recurrenceWriterVersion)
+	w.uint32(p.StartTimeOffset)
+	w.uint32(p.EndTimeOffset)
+
+	// ExceptionInfo
+	w.uint16(len(p.Exceptions))
+	for _, e := range p.Exceptions {
+		w.minutes(e.StartDateTime)
+		w.minutes(e.EndDateTime)
+		w.minutes(e.OriginalStartDate)
+		w.uint16(e.OverrideFlags)
+		if e.OverrideFlags&AroSubject != 0 {
+			w.ansiString(e.Subject)
+		}
+		if e.OverrideFlags&AroMeetingType != 0 {
+			w.uint32(e.MeetingType)
+		}
+		if e.OverrideFlags&AroReminderDelta != 0 {
+			w.uint32(e.ReminderDelta)
+		}
+		if e.OverrideFlags&AroReminder != 0 {
+			w.uint32(boolToInt(e.ReminderSet))
+		}
+		if e.OverrideFlags&AroLocation != 0 {
+			w.ansiString(e.Location)
+		}
+		if e.OverrideFlags&AroBusyStatus != 0 {
+			w.uint32(e.BusyStatus)
+		}
+		if e.OverrideFlags&AroAttachment != 0 {
+			w.uint32(boolToInt(e.Attachment))
+		}
+		if e.OverrideFlags&AroSubType != 0 {
+			w.uint32(boolToInt(e.SubType))
+		}
+		if e.OverrideFlags&AroAppointmentColor != 0 {
+			w.uint32(e.AppointmentColor)
+		}
+	}
+
+	// ReservedBlock1
+	w.uint32(0)
+
+	// ExtendedException
+	for _, e := range p.Exceptions {
+		// ChangeHighlight
+		w.uint32(4)
+		w.uint32(e.ChangeHighlight)
+		// ReservedBlockEE1
+		w.uint32(0)
+		if e.OverrideFlags&(AroSubject|AroLocation) != 0 {
+			w.minutes(e.StartDateTime)
+			w.minutes(e.EndDateTime)
+			w.minutes(e.OriginalStartDate)
+			if e.OverrideFlags&AroSubject != 0 {
+				w.unicodeString(e.Subject)
+			}
+			if e.OverrideFlags&AroLocation != 0 {
+				w.unicodeString(e.Location)
+			}
+			// ReservedBlockEE2
+			w.uint32(0)
+		}
+	}
+
+	// ReservedBlock2
+	w.uint32(0)
+	return w.Bytes()
+}
+
+func boolToInt(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,754 @@
+/**
+ * convert the iCalendar meeting messages (iTIP REQUEST, CANCEL and REPLY) to meeting messages: the inverse of
+ * ExtractICalendar ([MS-OXCICAL] section 2.1.3.1, [MS-OXOCAL])
+ */
+
+package tnefdecoder
+
+import (
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the message class of the embedded messages holding the modified occurrences of a recurring meeting
+const MessageClassAppointmentException = "IPM.OLE.CLASS.{00061055-0000-0000-C000-000000000046}"
+
+// PidTagAttachmentFlags: the attachment is an exception of a recurring appointment (afException)
+const AttachmentFlagException = 0x00000002
+
+var ErrUnsupportedICalendar = errors.New("unsupported iCalendar object")
+
+// the time zone of the recurring events whose times are in UTC
+var utcTimeZone = &TimeZoneDefinition{KeyName: "UTC", Rules: []*TimeZoneRule{{Flags: TimeZoneRuleFlagEffective}}}
+
+/**
+ * the subject prefixes of the meeting messages
+ */
+var meetingSubjectPrefixes = map[string]string{
+	MessageClassMeetingCanceled: "Canceled: ",
+	MessageClassMeetingRespPos:  "Accepted: ",
+	MessageClassMeetingRespNeg:  "Declined: ",
+	MessageClassMeetingRespTent: "Tentative: ",
+}
+
+/**
+ * create the meeting message of an iCalendar REQUEST, CANCEL or REPLY: the first VEVENT with the overrides of its
+ * modified occurrences
+ */
+func ImportICalendar(data []byte) (*TnefObject, error) {
+	calendar, err := ParseICalendar(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(calendar.Name, "VCALENDAR") {
+		return nil, ErrInvalidICalendar
+	}
+
+	i := &icalendarImport{calendar: calendar, zones: map[string]*TimeZoneDefinition{}}
+	for _, vtimezone := range calendar.GetComponents("VTIMEZONE") {
+		if zone, err := TimeZoneFromVTimeZone(vtimezone); err == nil {
+			i.zones[vtimezone.GetProperty("TZID").GetText()] = zone
+		}
+	}
+	return i.meetingMessage()
+}
+
+/**
+ * encode an iCalendar meeting message as a TNEF stream (winmail.dat)
+ */
+func EncodeICalendar(data []byte) ([]byte, error) {
+	t, err := ImportICalendar(data)
+	if err != nil {
+		return nil, err
+	}
+	e := NewEncoder()
+	return e.Encode(t)
+}
+
+/**
+ * create a PidLidGlobalObjectId from an iCalendar UID: the UIDs created from a GOID are decoded, the others are stored
+ * in a vCal-Uid id; instance is the date of an occurrence (zero for the whole series)
+ */
+func NewGlobalObjectId(uid string, instance time.Time) []byte {
+	var b []byte
+	if raw, err := hex.DecodeString(uid); err == nil {
+		if _, err := DecodeGlobalObjectId(raw); err == nil {
+			b = raw
+		}
+	}
+	if b == nil {
+		data := append(append([]byte{}, globalObjectIdVCalMarker...), uid...)
+		data = append(data, 0)
+		b = append([]byte{}, globalObjectIdPrefix...)
+		// instance date, CreationTime, X
+		b = append(b, make([]byte, 20)...)
+		b = append(b, MapiEncodeInt32(len(data))...)
+		b = append(b, data...)
+	}
+	if !instance.IsZero() {
+		b[16], b[17], b[18], b[19] = byte(instance.Year()>>8), byte(instance.Year()), byte(instance.Month()), byte(instance.Day())
+	}
+	return b
+}
+
+type icalendarImport struct {
+	calendar *ICalComponent
+	zones    map[string]*TimeZoneDefinition
+}
+
+/**
+ * a DATE or DATE-TIME of the event: the UTC time, the local time, the time zone (nil for UTC and floating times) and
+ * the DATE flag
+ */
+type icalendarTime struct {
+	Utc    time.Time
+	Local  time.Time
+	Zone   *TimeZoneDefinition
+	AllDay bool
+}
+
+/**
+ * the time zone of a TZID: the VTIMEZONE of the calendar, else the time zone database
+ */
+func (i *icalendarImport) timeZone(tzid string, year int) *TimeZoneDefinition {
+	if tzid == "" {
+		return nil
+	}
+	if zone, ok := i.zones[tzid]; ok {
+		return zone
+	}
+	name := strings.TrimPrefix(tzid, "/")
+	if iana := WindowsToIana(tzid); iana != "" {
+		name = iana
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil
+	}
+	zone := TimeZoneFromLocation(tzid, location, year)
+	i.zones[tzid] = zone
+	return zone
+}
+
+/**
+ * parse a DATE or DATE-TIME property; nil if the property is missing or invalid
+ */
+func (i *icalendarImport) time(p *ICalProperty) *icalendarTime {
+	if p == nil {
+		return nil
+	}
+	value, utc, date, err := ParseICalDateTime(strings.Split(p.Value, ",")[0])
+	if err != nil {
+		return nil
+	}
+	if utc {
+		return &icalendarTime{Utc: value, Local: value}
+	}
+	tzid := ""
+	if v := p.GetParameter("TZID"); len(v) > 0 {
+		tzid = v[0]
+	}
+	zone := i.timeZone(tzid, value.Year())
+	return &icalendarTime{Utc: zone.ToUtc(value), Local: value, Zone: zone, AllDay: date}
+}
+
+/**
+ * the local time of a property in the given zone (RECURRENCE-ID, EXDATE, UNTIL)
+ */
+func (i *icalendarImport) localTimes(p *ICalProperty, zone *TimeZoneDefinition) []time.Time {
+	result := []time.Time{}
+	if p == nil {
+		return result
+	}
+	for _, v := range strings.Split(p.Value, ",") {
+		value, utc, _, err := ParseICalDateTime(v)
+		if err != nil {
+			continue
+		}
+		if utc {
+			value = zone.ToLocal(value)
+		} else if tzid := p.GetParameter("TZID"); len(tzid) > 0 {
+			if valueZone := i.timeZone(tzid[0], value.Year()); valueZone != zone {
+				value = zone.ToLocal(valueZone.ToUtc(value))
+			}
+		}
+		result = append(result, value)
+	}
+	return result
+}
+
+/**
+ * the message of the first VEVENT
+ */
+func (i *icalendarImport) meetingMessage() (*TnefObject, error) {
+	method := ""
+	if p := i.calendar.GetProperty("METHOD"); p != nil {
+		method = strings.ToUpper(p.GetText())
+	}
+
+	events := i.calendar.GetComponents("VEVENT")
+	if len(events) == 0 {
+		return nil, ErrInvalidICalendar
+	}
+	// the series (or the single occurrence) and the overrides of the modified occurrences
+	event := events[0]
+	for _, e := range events {
+		if e.GetProperty("RECURRENCE-ID") == nil {
+			event = e
+			break
+		}
+	}
+	overrides := []*ICalComponent{}
+	if event.GetProperty("RECURRENCE-ID") == nil {
+		for _, e := range events {
+			if e != event && e.GetProperty("RECURRENCE-ID") != nil && e.GetProperty("UID").GetText() == event.GetProperty("UID").GetText() {
+				overrides = append(overrides, e)
+			}
+		}
+	}
+
+	var messageClass string
+	var replyAttendee *ICalProperty
+	switch method {
+	case ICalMethodRequest:
+		messageClass = MessageClassMeetingRequest
+	case ICalMethodCancel:
+		messageClass = MessageClassMeetingCanceled
+	case ICalMethodReply:
+		for _, attendee := range event.Properties {
+			if attendee.Name != "ATTENDEE" {
+				continue
+			}
+			if status := attendee.GetParameter("PARTSTAT"); len(status) > 0 {
+				switch strings.ToUpper(status[0]) {
+				case "ACCEPTED":
+					messageClass = MessageClassMeetingRespPos
+				case "DECLINED":
+					messageClass = MessageClassMeetingRespNeg
+				case "TENTATIVE":
+					messageClass = MessageClassMeetingRespTent
+				}
+			}
+			if messageClass != "" {
+				replyAttendee = attendee
+				break
+			}
+		}
+	}
+	if messageClass == "" {
+		return nil, ErrUnsupportedICalendar
+	}
+
+	start := i.time(event.GetProperty("DTSTART"))
+	if start == nil {
+		return nil, ErrInvalidICalendar
+	}
+	end := i.time(event.GetProperty("DTEND"))
+	if end == nil {
+		end = &icalendarTime{Utc: start.Utc, Local: start.Local, Zone: start.Zone, AllDay: start.AllDay}
+		if p := event.GetProperty("DURATION"); p != nil {
+			if minutes, err := ParseICalDuration(p.Value); err == nil {
+				end.Utc = end.Utc.Add(time.Duration(minutes) * time.Minute)
+				end.Local = end.Local.Add(time.Duration(minutes) * time.Minute)
+			}
+		} else if start.AllDay {
+			end.Utc, end.Local = end.Utc.AddDate(0, 0, 1), end.Local.AddDate(0, 0, 1)
+		}
+	}
+
+	t := &TnefObject{}
+	t.SetAttribute(NewMappedStringAttribute(AttrLevelMessage, AttMessageClass, messageClass))
+
+	summary := event.GetProperty("SUMMARY").GetText()
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagSubject, meetingSubjectPrefixes[messageClass]+summary))
+	if summary != "" {
+		t.SetAttribute(NewMapiStringAttribute(MapiPidTagNormalizedSubject, summary))
+		t.SetAttribute(NewMapiStringAttribute(MapiPidTagConversationTopic, summary))
+	}
+	if description := event.GetProperty("DESCRIPTION").GetText(); description != "" {
+		t.SetAttribute(NewMapiStringAttribute(MapiPidTagBody, description))
+	}
+
+	stamp := time.Now().UTC()
+	if p := i.time(event.GetProperty("DTSTAMP")); p != nil {
+		stamp = p.Utc
+	}
+	t.SetAttribute(NewMapiTimeAttribute(MapiPidTagClientSubmitTime, stamp))
+	t.SetAttribute(NewMapiTimeAttribute(0, stamp).Named(PsetidMeeting, MapiPidLidAttendeeCriticalChange))
+	t.SetAttribute(NewMapiTimeAttribute(0, stamp).Named(PsetidMeeting, MapiPidLidOwnerCriticalChange))
+
+	// UID, RECURRENCE-ID
+	uid := event.GetProperty("UID").GetText()
+	if uid == "" {
+		return nil, ErrInvalidICalendar
+	}
+	instance := time.Time{}
+	recurrenceId := event.GetProperty("RECURRENCE-ID")
+	if recurrenceId != nil {
+		if original := i.time(recurrenceId); original != nil {
+			instance = original.Local
+			if original.Zone == nil && start.Zone != nil {
+				instance = start.Zone.ToLocal(original.Utc)
+			}
+			t.SetAttribute(NewMapiTimeAttribute(0, original.Utc).Named(PsetidAppointment, MapiPidLidExceptionReplaceTime))
+			t.SetAttribute(NewMapiBoolAttribute(0, true).Named(PsetidMeeting, MapiPidLidIsException))
+		}
+	}
+	t.SetAttribute(NewMapiBinaryAttribute(0, NewGlobalObjectId(uid, instance)).Named(PsetidMeeting, MapiPidLidGlobalObjectId))
+	t.SetAttribute(NewMapiBinaryAttribute(0, NewGlobalObjectId(uid, time.Time{})).Named(PsetidMeeting, MapiPidLidCleanGlobalObjectId))
+
+	sequence := 0
+	if p := event.GetProperty("SEQUENCE"); p != nil {
+		sequence, _ = strconv.Atoi(strings.TrimSpace(p.Value))
+	}
+	t.SetAttribute(NewMapiIntAttribute(0, sequence).Named(PsetidAppointment, MapiPidLidAppointmentSequence))
+
+	setAppointmentTimes(t, start, end)
+	if location := event.GetProperty("LOCATION").GetText(); location != "" {
+		t.SetAttribute(NewMapiStringAttribute(0, location).Named(PsetidAppointment, MapiPidLidLocation))
+		t.SetAttribute(NewMapiStringAttribute(0, location).Named(PsetidMeeting, MapiPidLidWhere))
+	}
+	setICalendarClassification(t, event)
+
+	// the busy status intended by the organizer; the request is tentative until it is accepted
+	busyStatus := icalendarBusyStatus(event)
+	stateFlags := AppointmentStateMeeting | AppointmentStateReceived
+	switch messageClass {
+	case MessageClassMeetingRequest:
+		t.SetAttribute(NewMapiIntAttribute(0, busyStatus).Named(PsetidAppointment, MapiPidLidIntendedBusyStatus))
+		t.SetAttribute(NewMapiIntAttribute(0, BusyStatusTentative).Named(PsetidAppointment, MapiPidLidBusyStatus))
+		t.SetAttribute(NewMapiIntAttribute(0, ResponseNotResponded).Named(PsetidAppointment, MapiPidLidResponseStatus))
+		meetingType := MeetingTypeRequest
+		if sequence > 0 {
+			meetingType = MeetingTypeFull
+		}
+		t.SetAttribute(NewMapiIntAttribute(0, meetingType).Named(PsetidMeeting, MapiPidLidMeetingType))
+	case MessageClassMeetingCanceled:
+		stateFlags |= AppointmentStateCanceled
+		t.SetAttribute(NewMapiIntAttribute(0, busyStatus).Named(PsetidAppointment, MapiPidLidIntendedBusyStatus))
+		t.SetAttribute(NewMapiIntAttribute(0, BusyStatusFree).Named(PsetidAppointment, MapiPidLidBusyStatus))
+	default:
+		t.SetAttribute(NewMapiIntAttribute(0, busyStatus).Named(PsetidAppointment, MapiPidLidBusyStatus))
+		t.SetAttribute(NewMapiTimeAttribute(0, stamp).Named(PsetidAppointment, MapiPidLidAppointmentReplyTime))
+	}
+	t.SetAttribute(NewMapiIntAttribute(0, stateFlags).Named(PsetidAppointment, MapiPidLidAppointmentStateFlags))
+	t.SetAttribute(NewMapiBoolAttribute(0, true).Named(PsetidAppointment, MapiPidLidFInvited))
+
+	if method != ICalMethodReply {
+		setICalendarReminder(t, event, start.Utc)
+	}
+
+	if err := i.setParticipants(t, event, method, replyAttendee); err != nil {
+		return nil, err
+	}
+
+	// the time zone of the organizer and the recurrence
+	recurring := event.GetProperty("RRULE") != nil && recurrenceId == nil
+	zone := start.Zone
+	if zone == nil && recurring {
+		zone = utcTimeZone
+	}
+	if zone != nil {
+		t.SetAttribute(NewMapiBinaryAttribute(0, zone.Encode(false)).Named(PsetidAppointment, MapiPidLidAppointmentTimeZoneDefinitionStartDisplay))
+		endZone := end.Zone
+		if endZone == nil {
+			endZone = zone
+		}
+		t.SetAttribute(NewMapiBinaryAttribute(0, endZone.Encode(false)).Named(PsetidAppointment, MapiPidLidAppointmentTimeZoneDefinitionEndDisplay))
+	}
+
+	t.SetAttribute(NewMapiBoolAttribute(0, recurring).Named(PsetidAppointment, MapiPidLidRecurring))
+	t.SetAttribute(NewMapiBoolAttribute(0, recurring).Named(PsetidMeeting, MapiPidLidIsRecurring))
+	if !recurring {
+		t.SetAttribute(NewMapiIntAttribute(0, RecurrenceTypeNone).Named(PsetidAppointment, MapiPidLidRecurrenceType))
+		t.SetAttribute(NewMapiTimeAttribute(0, start.Utc).Named(PsetidAppointment, MapiPidLidClipStart))
+		t.SetAttribute(NewMapiTimeAttribute(0, end.Utc).Named(PsetidAppointment, MapiPidLidClipEnd))
+		return t, nil
+	}
+
+	local := start.Local
+	if start.Zone == nil {
+		local = zone.ToLocal(start.Utc)
+	}
+	recurrence, err := i.recurrence(event, overrides, local, end.Utc.Sub(start.Utc), zone, start.AllDay)
+	if err != nil {
+		return nil, err
+	}
+	t.SetAttribute(NewMapiBinaryAttribute(0, recurrence.Encode()).Named(PsetidAppointment, MapiPidLidAppointmentRecur))
+	t.SetAttribute(NewMapiIntAttribute(0, recurrence.RecurrenceType()).Named(PsetidAppointment, MapiPidLidRecurrenceType))
+	t.SetAttribute(NewMapiBinaryAttribute(0, zone.Encode(true)).Named(PsetidAppointment, MapiPidLidAppointmentTimeZoneDefinitionRecur))
+	t.SetAttribute(NewMapiBinaryAttribute(0, zone.EncodeStruct(local.Year())).Named(PsetidAppointment, MapiPidLidTimeZoneStruct))
+	t.SetAttribute(NewMapiStringAttribute(0, zone.KeyName).Named(PsetidAppointment, MapiPidLidTimeZoneDescription))
+	t.SetAttribute(NewMapiTimeAttribute(0, recurrence.StartDate).Named(PsetidAppointment, MapiPidLidClipStart))
+	t.SetAttribute(NewMapiTimeAttribute(0, recurrence.EndDate).Named(PsetidAppointment, MapiPidLidClipEnd))
+
+	for _, override := range overrides {
+		if a := i.exceptionAttachment(t, override, zone); a != nil {
+			t.Attachments = append(t.Attachments, a)
+		}
+	}
+	return t, nil
+}
+
+/**
+ * the start, the end and the duration of the appointment
+ */
+func setAppointmentTimes(t *TnefObject, start *icalendarTime, end *icalendarTime) {
+	t.SetAttribute(NewMapiTimeAttribute(0, start.Utc).Named(PsetidAppointment, MapiPidLidAppointmentStartWhole))
+	t.SetAttribute(NewMapiTimeAttribute(0, end.Utc).Named(PsetidAppointment, MapiPidLidAppointmentEndWhole))
+	t.SetAttribute(NewMapiTimeAttribute(MapiPidTagStartDate, start.Utc))
+	t.SetAttribute(NewMapiTimeAttribute(MapiPidTagEndDate, end.Utc))
+	t.SetAttribute(NewMapiIntAttribute(0, int(end.Utc.Sub(start.Utc).Minutes())).Named(PsetidAppointment, MapiPidLidAppointmentDuration))
+	t.SetAttribute(NewMapiBoolAttribute(0, start.AllDay).Named(PsetidAppointment, MapiPidLidAppointmentSubType))
+}
+
+/**
+ * X-MICROSOFT-CDO-BUSYSTATUS, else TRANSP -> PidLidBusyStatus
+ */
+func icalendarBusyStatus(event *ICalComponent) int {
+	names := map[string]int{
+		"FREE":             BusyStatusFree,
+		"TENTATIVE":        BusyStatusTentative,
+		"BUSY":             BusyStatusBusy,
+		"OOF":              BusyStatusOutOfOffice,
+		"WORKINGELSEWHERE": BusyStatusWorkingElsewhere,
+	}
+	for _, name := range []string{"X-MICROSOFT-CDO-INTENDEDSTATUS", "X-MICROSOFT-CDO-BUSYSTATUS"} {
+		if status, ok := names[strings.ToUpper(event.GetProperty(name).GetText())]; ok {
+			return status
+		}
+	}
+	if strings.EqualFold(event.GetProperty("TRANSP").GetText(), "TRANSPARENT") {
+		return BusyStatusFree
+	}
+	return BusyStatusBusy
+}
+
+/**
+ * CLASS -> PidTagSensitivity and PRIORITY -> PidTagImportance
+ */
+func setICalendarClassification(t *TnefObject, event *ICalComponent) {
+	switch strings.ToUpper(event.GetProperty("CLASS").GetText()) {
+	case "PUBLIC":
+		t.SetAttribute(NewMapiIntAttribute(MapiPidTagSensitivity, 0))
+	case "PRIVATE":
+		t.SetAttribute(NewMapiIntAttribute(MapiPidTagSensitivity, 2))
+	case "CONFIDENTIAL":
+		t.SetAttribute(NewMapiIntAttribute(MapiPidTagSensitivity, 3))
+	}
+
+	if priority, err := strconv.Atoi(strings.TrimSpace(event.GetProperty("PRIORITY").GetText())); err == nil && priority > 0 {
+		importance := 1
+		switch {
+		case priority < 5:
+			importance = 2
+		case priority > 5:
+			importance = 0
+		}
+		t.SetAttribute(NewMapiIntAttribute(MapiPidTagImportance, importance))
+	}
+}
+
+/**
+ * the reminder of the first VALARM relative to the start of the event
+ */
+func setICalendarReminder(t *TnefObject, event *ICalComponent, start time.Time) {
+	for _, alarm := range event.GetComponents("VALARM") {
+		trigger := alarm.GetProperty("TRIGGER")
+		if trigger == nil || len(trigger.GetParameter("VALUE")) > 0 {
+			continue
+		}
+		if related := trigger.GetParameter("RELATED"); len(related) > 0 && !strings.EqualFold(related[0], "START") {
+			continue
+		}
+		minutes, err := ParseICalDuration(trigger.Value)
+		if err != nil {
+			continue
+		}
+		t.SetAttribute(NewMapiBoolAttribute(0, true).Named(PsetidCommon, MapiPidLidReminderSet))
+		t.SetAttribute(NewMapiIntAttribute(0, -minutes).Named(PsetidCommon, MapiPidLidReminderDelta))
+		t.SetAttribute(NewMapiTimeAttribute(0, start).Named(PsetidCommon, MapiPidLidReminderTime))
+		t.SetAttribute(NewMapiTimeAttribute(0, start.Add(time.Duration(minutes)*time.Minute)).Named(PsetidCommon, MapiPidLidReminderSignalTime))
+		return
+	}
+	t.SetAttribute(NewMapiBoolAttribute(0, false).Named(PsetidCommon, MapiPidLidReminderSet))
+}
+
+/**
+ * the address and the name of a CAL-ADDRESS property
+ */
+func icalendarAddress(p *ICalProperty) (string, string) {
+	if p == nil {
+		return "", ""
+	}
+	address := strings.TrimSpace(p.Value)
+	if strings.HasPrefix(strings.ToLower(address), "mailto:") {
+		address = address[len("mailto:"):]
+	}
+	name := ""
+	if cn := p.GetParameter("CN"); len(cn) > 0 {
+		name = cn[0]
+	}
+	return address, name
+}
+
+/**
+ * the sender and the recipients: the organizer sends the requests and the cancellations to the attendees, the attendee
+ * sends the reply to the organizer
+ */
+func (i *icalendarImport) setParticipants(t *TnefObject, event *ICalComponent, method string, replyAttendee *ICalProperty) error {
+	organizerAddress, organizerName := icalendarAddress(event.GetProperty("ORGANIZER"))
+
+	if method == ICalMethodReply {
+		address, name := icalendarAddress(replyAttendee)
+		if address == "" || organizerAddress == "" {
+			return ErrInvalidICalendar
+		}
+		setMessageSender(t, name, address)
+		if name != "" {
+			t.SetAttribute(NewMapiStringAttribute(0, name).Named(PsetidAppointment, MapiPidLidAppointmentReplyName))
+		}
+		t.Recipients = append(t.Recipients, NewSmtpRecipient(organizerName, organizerAddress, RecipientTypeTo))
+		return nil
+	}
+
+	if organizerAddress == "" {
+		return ErrInvalidICalendar
+	}
+	setMessageSender(t, organizerName, organizerAddress)
+
+	t.Recipients = icalendarRecipients(event, organizerAddress)
+	names := map[int][]string{}
+	responseRequested := len(t.Recipients) == 0
+	for _, attendee := range event.Properties {
+		if attendee.Name != "ATTENDEE" {
+			continue
+		}
+		if rsvp := attendee.GetParameter("RSVP"); len(rsvp) == 0 || strings.EqualFold(rsvp[0], "TRUE") {
+			responseRequested = true
+		}
+	}
+	for _, r := range t.Recipients {
+		names[r.GetRecipientType()] = append(names[r.GetRecipientType()], r.GetDisplayName())
+	}
+	if len(names[RecipientTypeTo]) > 0 {
+		t.SetAttribute(NewMapiStringAttribute(0, strings.Join(names[RecipientTypeTo], "; ")).Named(PsetidAppointment, MapiPidLidToAttendeesString))
+	}
+	if len(names[RecipientTypeCc]) > 0 {
+		t.SetAttribute(NewMapiStringAttribute(0, strings.Join(names[RecipientTypeCc], "; ")).Named(PsetidAppointment, MapiPidLidCcAttendeesString))
+	}
+	if method == ICalMethodRequest {
+		t.SetAttribute(NewMapiBoolAttribute(MapiPidTagResponseRequested, responseRequested))
+		t.SetAttribute(NewMapiBoolAttribute(MapiPidTagReplyRequested, responseRequested))
+	}
+	return nil
+}
+
+/**
+ * the recipients of the attendees (the organizer is skipped): the required attendees are To recipients, the optional
+ * attendees Cc recipients and the resources Bcc recipients
+ */
+func icalendarRecipients(event *ICalComponent, organizerAddress string) []*Recipient {
+	recipients := []*Recipient{}
+	for _, attendee := range event.Properties {
+		if attendee.Name != "ATTENDEE" {
+			continue
+		}
+		address, name := icalendarAddress(attendee)
+		if address == "" || strings.EqualFold(address, organizerAddress) {
+			continue
+		}
+
+		recipientType := RecipientTypeTo
+		role, cutype := "", ""
+		if v := attendee.GetParameter("ROLE"); len(v) > 0 {
+			role = strings.ToUpper(v[0])
+		}
+		if v := attendee.GetParameter("CUTYPE"); len(v) > 0 {
+			cutype = strings.ToUpper(v[0])
+		}
+		switch {
+		case cutype == "RESOURCE" || cutype == "ROOM" || role == "NON-PARTICIPANT":
+			recipientType = RecipientTypeBcc
+		case role == "OPT-PARTICIPANT":
+			recipientType = RecipientTypeCc
+		}
+		recipients = append(recipients, NewSmtpRecipient(name, address, recipientType))
+	}
+	return recipients
+}
+
+/**
+ * set the sender of the message (PidTagSender* and PidTagSentRepresenting*)
+ */
+func setMessageSender(t *TnefObject, name string, address string) {
+	if name == "" {
+		name = address
+	}
+	entryId := EncodeOneOffEntryId(name, "SMTP", address)
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagSenderName, name))
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagSenderAddressType, "SMTP"))
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagSenderEmailAddress, address))
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagSenderSmtpAddress, address))
+	t.SetAttribute(NewMapiBinaryAttribute(MapiPidTagSenderEntryId, entryId))
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagSentRepresentingName, name))
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagSentRepresentingAddressType, "SMTP"))
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagSentRepresentingEmailAddress, address))
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagSentRepresentingSmtpAddress, address))
+	t.SetAttribute(NewMapiBinaryAttribute(MapiPidTagSentRepresentingEntryId, entryId))
+}
+
+/**
+ * the recurrence of the series: RRULE, EXDATE and the modified occurrences
+ * start is the local start of the series
+ */
+func (i *icalendarImport) recurrence(event *ICalComponent, overrides []*ICalComponent, start time.Time, duration time.Duration, zone *TimeZoneDefinition, allDay bool) (*AppointmentRecurrencePattern, error) {
+	rule := event.GetProperty("RRULE").GetText()
+	until := time.Time{}
+	if v, ok := parseRRuleParts(rule)["UNTIL"]; ok {
+		if dates := i.localTimes(&ICalProperty{Value: v}, zone); len(dates) > 0 {
+			until = dates[0]
+		}
+	}
+	pattern, err := NewRecurrencePatternFromRRule(rule, start, until)
+	if err != nil {
+		return nil, err
+	}
+
+	midnight := func(v time.Time) time.Time {
+		return time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	startOffset := start.Hour()*60 + start.Minute()
+	p := &AppointmentRecurrencePattern{
+		RecurrencePattern: pattern,
+		StartTimeOffset:   startOffset,
+		EndTimeOffset:     startOffset + int(duration.Minutes()),
+	}
+
+	deleted := map[time.Time]bool{}
+	modified := map[time.Time]bool{}
+	for _, exdate := range event.Properties {
+		if exdate.Name == "EXDATE" {
+			for _, d := range i.localTimes(exdate, zone) {
+				deleted[midnight(d)] = true
+			}
+		}
+	}
+
+	summary := event.GetProperty("SUMMARY").GetText()
+	location := event.GetProperty("LOCATION").GetText()
+	busyStatus := icalendarBusyStatus(event)
+	for _, override := range overrides {
+		originals := i.localTimes(override.GetProperty("RECURRENCE-ID"), zone)
+		if len(originals) == 0 {
+			continue
+		}
+		original := originals[0]
+		deleted[midnight(original)] = true
+		if strings.EqualFold(override.GetProperty("STATUS").GetText(), "CANCELLED") {
+			continue
+		}
+
+		overrideStart := i.time(override.GetProperty("DTSTART"))
+		if overrideStart == nil {
+			continue
+		}
+		overrideEnd := i.time(override.GetProperty("DTEND"))
+		e := &RecurrenceException{
+			StartDateTime:     zone.ToLocal(overrideStart.Utc),
+			OriginalStartDate: original,
+		}
+		if overrideStart.AllDay {
+			e.StartDateTime = overrideStart.Local
+		}
+		e.EndDateTime = e.StartDateTime.Add(duration)
+		if overrideEnd != nil {
+			e.EndDateTime = e.StartDateTime.Add(overrideEnd.Utc.Sub(overrideStart.Utc))
+		}
+		modified[midnight(e.StartDateTime)] = true
+
+		if v := override.GetProperty("SUMMARY").GetText(); v != summary {
+			e.OverrideFlags |= AroSubject
+			e.Subject = v
+		}
+		if v := override.GetProperty("LOCATION").GetText(); v != location {
+			e.OverrideFlags |= AroLocation
+			e.Location = v
+		}
+		if v := icalendarBusyStatus(override); v != busyStatus {
+			e.OverrideFlags |= AroBusyStatus
+			e.BusyStatus = v
+		}
+		if overrideStart.AllDay != allDay {
+			e.OverrideFlags |= AroSubType
+			e.SubType = overrideStart.AllDay
+		}
+		p.Exceptions = append(p.Exceptions, e)
+	}
+
+	p.DeletedInstanceDates = sortedDates(deleted)
+	p.ModifiedInstanceDates = sortedDates(modified)
+	sort.Slice(p.Exceptions, func(a, b int) bool {
+		return p.Exceptions[a].OriginalStartDate.Before(p.Exceptions[b].OriginalStartDate)
+	})
+	return p, nil
+}
+
+func sortedDates(dates map[time.Time]bool) []time.Time {
+	result := []time.Time{}
+	for d := range dates {
+		result = append(result, d)
+	}
+	sort.Slice(result, func(a, b int) bool { return result[a].Before(result[b]) })
+	return result
+}
+
+/**
+ * the attachment of a modified occurrence ([MS-OXOCAL] section 2.2.10.1): an embedded message with the properties of the
+ * occurrence; nil for the cancelled occurrences
+ */
+func (i *icalendarImport) exceptionAttachment(series *TnefObject, override *ICalComponent, zone *TimeZoneDefinition) *Attachment {
+	if strings.EqualFold(override.GetProperty("STATUS").GetText(), "CANCELLED") {
+		return nil
+	}
+	originals := i.localTimes(override.GetProperty("RECURRENCE-ID"), zone)
+	start := i.time(override.GetProperty("DTSTART"))
+	if len(originals) == 0 || start == nil {
+		return nil
+	}
+	end := i.time(override.GetProperty("DTEND"))
+	if end == nil {
+		duration := series.GetNamedTimeValue(PsetidAppointment, MapiPidLidAppointmentEndWhole).Sub(series.GetNamedTimeValue(PsetidAppointment, MapiPidLidAppointmentStartWhole))
+		end = &icalendarTime{Utc: start.Utc.Add(duration), AllDay: start.AllDay}
+	}
+	originalUtc := zone.ToUtc(originals[0])
+
+	e := &TnefObject{}
+	e.SetAttribute(NewMappedStringAttribute(AttrLevelMessage, AttMessageClass, MessageClassAppointmentException))
+	summary := override.GetProperty("SUMMARY").GetText()
+	e.SetAttribute(NewMapiStringAttribute(MapiPidTagSubject, summary))
+	if description := override.GetProperty("DESCRIPTION").GetText(); description != "" {
+		e.SetAttribute(NewMapiStringAttribute(MapiPidTagBody, description))
+	}
+	setAppointmentTimes(e, start, end)
+	if location := override.GetProperty("LOCATION").GetText(); location != "" {
+		e.SetAttribute(NewMapiStringAttribute(0, location).Named(PsetidAppointment, MapiPidLidLocation))
+	}
+	e.SetAttribute(NewMapiIntAttribute(0, icalendarBusyStatus(override)).Named(PsetidAppointment, MapiPidLidBusyStatus))
+	e.SetAttribute(NewMapiTimeAttribute(0, originalUtc).Named(PsetidAppointment, MapiPidLidExceptionReplaceTime))
+	e.SetAttribute(NewMapiBoolAttribute(0, true).Named(PsetidMeeting, MapiPidLidIsException))
+	organizerAddress, _ := icalendarAddress(override.GetProperty("ORGANIZER"))
+	e.Recipients = icalendarRecipients(override, organizerAddress)
+
+	a := NewEmbeddedMessageAttachment(e, summary)
+	a.SetAttribute(NewMapiBoolAttribute(MapiPidTagAttachmentHidden, true))
+	a.SetAttribute(NewMapiIntAttribute(MapiPidTagAttachmentFlags, AttachmentFlagException))
+	a.SetAttribute(NewMapiTimeAttribute(MapiPidTagExceptionStartTime, zone.ToLocal(start.Utc)))
+	a.SetAttribute(NewMapiTimeAttribute(MapiPidTagExceptionEndTime, zone.ToLocal(end.Utc)))
+	a.SetAttribute(NewMapiTimeAttribute(MapiPidTagExceptionReplaceTime, originalUtc))
+	return a
+}
@@ -0,0 +1,159 @@
+package tnefdecoder
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const importTestRequest = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"PRODID:-//Example//Test//EN\r\n" +
+	"METHOD:REQUEST\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:import-test-1\r\n" +
+	"DTSTAMP:20240301T080000Z\r\n" +
+	"DTSTART:20240304T090000Z\r\n" +
+	"DTEND:20240304T100000Z\r\n" +
+	"RRULE:FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10\r\n" +
+	"EXDATE:20240306T090000Z\r\n" +
+	"SUMMARY:Weekly meeting\r\n" +
+	"LOCATION:Room 1\r\n" +
+	"ORGANIZER;CN=Organizer:mailto:organizer@example.com\r\n" +
+	"ATTENDEE;CN=Attendee;ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE:mailto:attendee@example.com\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:import-test-1\r\n" +
+	"DTSTAMP:20240301T080000Z\r\n" +
+	"RECURRENCE-ID:20240311T090000Z\r\n" +
+	"DTSTART:20240311T140000Z\r\n" +
+	"DTEND:20240311T150000Z\r\n" +
+	"SUMMARY:Moved meeting\r\n" +
+	"LOCATION:Room 1\r\n" +
+	"ORGANIZER;CN=Organizer:mailto:organizer@example.com\r\n" +
+	"ATTENDEE;CN=Attendee;ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION;RSVP=TRUE:mailto:attendee@example.com\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func importTestDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestNewRecurrencePatternFromRRule(t *testing.T) {
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC) // a Monday
+	tests := []struct {
+		rule        string
+		until       time.Time
+		patternType int
+		period      int
+		startDate   time.Time
+		endDate     time.Time
+		count       int
+	}{
+		{"FREQ=DAILY;INTERVAL=2;COUNT=3", time.Time{}, PatternTypeDay, 2 * 1440, importTestDate(2024, 3, 4), importTestDate(2024, 3, 8), 3},
+		{"FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;COUNT=6", time.Time{}, PatternTypeWeek, 1, importTestDate(2024, 3, 4), importTestDate(2024, 3, 11), 6},
+		{"FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10", time.Time{}, PatternTypeWeek, 1, importTestDate(2024, 3, 4), importTestDate(2024, 4, 3), 10},
+		{"FREQ=WEEKLY;INTERVAL=2;UNTIL=20240401T090000Z", time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC), PatternTypeWeek, 2, importTestDate(2024, 3, 4), importTestDate(2024, 4, 1), 3},
+		{"FREQ=MONTHLY;BYMONTHDAY=31;COUNT=3", time.Time{}, PatternTypeMonth, 1, importTestDate(2024, 3, 31), importTestDate(2024, 5, 31), 3},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=2", time.Time{}, PatternTypeMonthEnd, 1, importTestDate(2024, 3, 31), importTestDate(2024, 4, 30), 2},
+		{"FREQ=MONTHLY;BYDAY=-1FR;COUNT=2", time.Time{}, PatternTypeMonthNth, 1, importTestDate(2024, 3, 29), importTestDate(2024, 4, 26), 2},
+		{"FREQ=MONTHLY;BYDAY=MO;BYSETPOS=1;INTERVAL=2;COUNT=2", time.Time{}, PatternTypeMonthNth, 2, importTestDate(2024, 3, 4), importTestDate(2024, 5, 6), 2},
+		{"FREQ=YEARLY;COUNT=2", time.Time{}, PatternTypeMonth, 12, importTestDate(2024, 3, 4), importTestDate(2025, 3, 4), 2},
+		{"FREQ=WEEKLY", time.Time{}, PatternTypeWeek, 1, importTestDate(2024, 3, 4), recurrenceExpansionLimit, recurNeverOccurrenceCount},
+	}
+
+	for _, test := range tests {
+		p, err := NewRecurrencePatternFromRRule(test.rule, start, test.until)
+		if err != nil {
+			t.Errorf("%s: %v", test.rule, err)
+			continue
+		}
+		if p.PatternType != test.patternType || p.Period != test.period {
+			t.Errorf("%s: pattern type %d, period %d, want %d, %d", test.rule, p.PatternType, p.Period, test.patternType, test.period)
+		}
+		if !p.StartDate.Equal(test.startDate) || !p.EndDate.Equal(test.endDate) || p.OccurrenceCount != test.count {
+			t.Errorf("%s: %v - %v, %d occurrences, want %v - %v, %d", test.rule, p.StartDate, p.EndDate, p.OccurrenceCount, test.startDate, test.endDate, test.count)
+		}
+	}
+}
+
+func TestNewRecurrencePatternFromRRuleLimits(t *testing.T) {
+	start := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	for _, rule := range []string{
+		"FREQ=DAILY;INTERVAL=576460752303423488",
+		"FREQ=DAILY;INTERVAL=1000",
+		"FREQ=WEEKLY;INTERVAL=100",
+		"FREQ=MONTHLY;INTERVAL=100",
+		"FREQ=YEARLY;INTERVAL=9",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=HOURLY",
+		"FREQ=MONTHLY;BYDAY=1MO,2TU",
+	} {
+		if _, err := NewRecurrencePatternFromRRule(rule, start, time.Time{}); err != ErrUnsupportedRecurrence {
+			t.Errorf("%s: error %v, want ErrUnsupportedRecurrence", rule, err)
+		}
+	}
+
+	for _, rule := range []string{"FREQ=DAILY;INTERVAL=999", "FREQ=WEEKLY;INTERVAL=99", "FREQ=MONTHLY;INTERVAL=99", "FREQ=YEARLY;INTERVAL=8"} {
+		if _, err := NewRecurrencePatternFromRRule(rule, start, time.Time{}); err != nil {
+			t.Errorf("%s: %v", rule, err)
+		}
+	}
+
+	// the instances are enumerated by period until the expansion limit (December 31, 4500)
+	begin := time.Now()
+	p, err := NewRecurrencePatternFromRRule("FREQ=MONTHLY;BYDAY=1MO;COUNT=2000000000", start, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Second {
+		t.Errorf("expansion in %v", elapsed)
+	}
+	if p.EndDate.After(recurrenceExpansionLimit) || p.EndDate.Year() != 4500 {
+		t.Errorf("end date %v", p.EndDate)
+	}
+}
+
+func TestEncodeICalendarRoundTrip(t *testing.T) {
+	data, err := EncodeICalendar([]byte(importTestRequest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDecoder()
+	tObj, err := d.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if class := tObj.GetMessageClass(); class != MessageClassMeetingRequest {
+		t.Errorf("message class %q", class)
+	}
+	if len(tObj.Recipients) != 1 || tObj.Recipients[0].GetSmtpAddress() != "attendee@example.com" {
+		t.Errorf("recipients %v", tObj.Recipients)
+	}
+
+	recurrence, err := DecodeAppointmentRecurrence(tObj.GetNamedBinaryValue(PsetidAppointment, MapiPidLidAppointmentRecur))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recurrence.OccurrenceCount != 10 || !recurrence.EndDate.Equal(importTestDate(2024, 4, 3)) {
+		t.Errorf("recurrence %+v", recurrence.RecurrencePattern)
+	}
+	if len(recurrence.DeletedInstanceDates) != 2 || len(recurrence.ModifiedInstanceDates) != 1 || len(recurrence.Exceptions) != 1 {
+		t.Fatalf("%d deleted, %d modified instances, %d exceptions", len(recurrence.DeletedInstanceDates), len(recurrence.ModifiedInstanceDates), len(recurrence.Exceptions))
+	}
+	if e := recurrence.Exceptions[0]; e.Subject != "Moved meeting" || e.StartDateTime.Hour() != 14 {
+		t.Errorf("exception %+v", e)
+	}
+
+	calendar := ExtractICalendar(tObj)
+	if calendar == nil {
+		t.Fatal("no iCalendar object")
+	}
+	// unfold the lines
+	text := strings.ReplaceAll(calendar.Build(), "\r\n ", "")
+	for _, want := range []string{"METHOD:REQUEST", "RRULE:FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10", "SUMMARY:Weekly meeting", "SUMMARY:Moved meeting", "UID:import-test-1", "mailto:attendee@example.com"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("%q not found in\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+/**
+ * the internet headers of the message ([MS-OXCMAIL] section 2.2.3.2): the header of the message as received by the
+ * transport (PidTagTransportMessageHeaders), the headers kept as named properties of PS_INTERNET_HEADERS and the MAPI
+ * properties mapped to headers
+ */
+
+package tnefdecoder
+
+import (
+	"encoding/base64"
+	"net/textproto"
+	"strings"
+)
+
+/**
+ * the headers mapped to MAPI properties
+ */
+var internetHeaderProperties = []struct {
+	Name string
+	Id   int
+}{
+	{"Message-Id", MapiPidTagInternetMessageId},
+	{"In-Reply-To", MapiPidTagInReplyToId},
+	{"References", MapiPidTagInternetReferences},
+	{"List-Help", MapiPidTagListHelp},
+	{"List-Subscribe", MapiPidTagListSubscribe},
+	{"List-Unsubscribe", MapiPidTagListUnsubscribe},
+	{"Thread-Topic", MapiPidTagConversationTopic},
+}
+
+/**
+ * return the internet headers of the message: the transport headers, completed with the PS_INTERNET_HEADERS properties
+ * and the MAPI properties (Message-ID, In-Reply-To, References, List-*, Thread-Topic, Thread-Index); the values are
+ * as stored (the transport headers are RFC 2047 encoded, the properties are not) but unfolded and without control
+ * characters (see SanitizeHeaderValue), so that they cannot inject header fields
+ */
+func (t *TnefObject) GetInternetHeaders() textproto.MIMEHeader {
+	header := ParseInternetHeaders(t.GetAttributeStringValue(MapiPidTagTransportMessageHeaders, "mapi"))
+
+	for _, attr := range t.Attributes {
+		name, ok := attr.PropMapValue.(string)
+		if !ok || attr.Type != "mapi" || !strings.EqualFold(attr.GUID, PsInternetHeaders) || !isInternetHeaderName(name) {
+			continue
+		}
+		key := textproto.CanonicalMIMEHeaderKey(name)
+		if value := SanitizeHeaderValue(attr.GetStringValueCodepage(t.Codepage)); value != "" && header.Get(key) == "" {
+			header.Set(key, value)
+		}
+	}
+
+	for _, p := range internetHeaderProperties {
+		if value := SanitizeHeaderValue(t.GetAttributeStringValue(p.Id, "mapi")); value != "" && header.Get(p.Name) == "" {
+			header.Set(p.Name, value)
+		}
+	}
+	if attr := t.GetAttribute(MapiPidTagConversationIndex, "mapi"); attr != nil && header.Get("Thread-Index") == "" {
+		if index := attr.GetBinaryValue(); len(index) > 0 {
+			header.Set("Thread-Index", base64.StdEncoding.EncodeToString(index))
+		}
+	}
+
+	return header
+}
+
+/**
+ * parse a header block (RFC 5322 section 2.2): the folded fields are unfolded and the lines that are not fields are
+ * ignored (ex: the "Microsoft Mail Internet Headers Version 2.0" line of Exchange); the header ends at the first empty
+ * line
+ */
+func ParseInternetHeaders(text string) textproto.MIMEHeader {
+	header := textproto.MIMEHeader{}
+
+	name, value := "", ""
+	flush := func() {
+		if v := SanitizeHeaderValue(value); name != "" && v != "" {
+			header.Add(name, v)
+		}
+		name, value = "", ""
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case line == "":
+			if len(header) > 0 || name != "" {
+				flush()
+				return header
+			}
+		case line[0] == ' ' || line[0] == '\t':
+			// folded field: the line break is removed
+			if name != "" {
+				value += line
+			}
+		default:
+			flush()
+			if i := strings.IndexByte(line, ':'); i > 0 && isInternetHeaderName(strings.TrimRight(line[:i], " \t")) {
+				name, value = textproto.CanonicalMIMEHeaderKey(strings.TrimRight(line[:i], " \t")), line[i+1:]
+			}
+		}
+	}
+	flush()
+	return header
+}
+
+/**
+ * unfold a header value and remove its control characters: the line breaks (CR, LF) and the tabs are replaced with a
+ * space, the other control characters (including DEL) are removed; the value is trimmed
+ */
+func SanitizeHeaderValue(v string) string {
+	var b strings.Builder
+	for _, c := range v {
+		switch {
+		case c == '\r' || c == '\n' || c == '\t':
+			b.WriteByte(' ')
+		case c < 0x20 || c == 0x7F:
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+/**
+ * check if a header value can be written as is: no control characters except the tab
+ */
+func isHeaderValueSafe(v string) bool {
+	for i := 0; i < len(v); i++ {
+		if c := v[i]; (c < 0x20 && c != '\t') || c == 0x7F {
+			return false
+		}
+	}
+	return true
+}
+
+/**
+ * a field name: printable US-ASCII characters except ":" (RFC 5322 section 3.6.8)
+ */
+func isInternetHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if name[i] < 33 || name[i] > 126 || name[i] == ':' {
+			return false
+		}
+	}
+	return true
+}
@@ -7,6 +7,7 @@ package tnefdecoder
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"unicode/utf16"
 )
 
@@ -37,6 +38,17 @@ func (c *LittleEndianDecoder) String(b []byte) (string) {
 }
 
 
+/**
+ * read a GUID: Data1 (UINT32) Data2 (UINT16) Data3 (UINT16) Data4 (8 bytes)
+ * the result has the canonical form XXXXXXXX-XXXX-XXXX-XXXX-XXXXXXXXXXXX
+ */
+func (c *LittleEndianDecoder) Guid(b []byte) string {
+	if len(b) < 16 {
+		return ""
+	}
+	return fmt.Sprintf("%08X-%04X-%04X-%X-%X", c.Uint32(b[0:4]), c.Uint16(b[4:6]), c.Uint16(b[6:8]), b[8:10], b[10:16])
+}
+
 // Int = Int32
 func (c *LittleEndianDecoder) Int32(b []byte) (int32) {
 	var v int32
@@ -88,20 +100,14 @@ func (c *LittleEndianDecoder) Boolean(b []byte) (bool) {
 	return v
 }
 
-// read utf16 little endian
+// read utf16 little endian; an empty content is an empty string and a trailing odd byte is ignored
 func (c *LittleEndianDecoder) Utf16(content []byte) (convertedStringToUnicode string) {
-	tmp := []uint16{}
+	tmp := make([]uint16, 0, len(content)/2)
 
-	bytesRead := 0
-	for {
+	for bytesRead := 0; bytesRead+2 <= len(content); bytesRead += 2 {
 		tmp = append(tmp, binary.LittleEndian.Uint16(content[bytesRead:]))
-		bytesRead += 2
-
-		convertedStringToUnicode = string(utf16.Decode(tmp));
-
-		if (len(content) <= bytesRead) {
-			break
-		}
 	}
+
+	convertedStringToUnicode = string(utf16.Decode(tmp))
 	return
 }
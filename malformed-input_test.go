@@ -0,0 +1,64 @@
+package tnefdecoder
+
+import (
+	"math/rand"
+	"runtime/debug"
+	"testing"
+)
+
+/**
+ * the helpers of the tests of the decoders of untrusted input (metafiles, recurrences, time zones, entry IDs...): the
+ * decoders must return an error or a partial result for any input, never panic or loop
+ */
+
+/**
+ * decode malformed variants of the seeds: every prefix, each byte replaced by the extreme values, then random bytes
+ * replaced (deterministic)
+ */
+func checkMalformed(t *testing.T, decode func([]byte), seeds ...[]byte) {
+	t.Helper()
+	r := rand.New(rand.NewSource(1))
+	for _, data := range seeds {
+		for i := 0; i < len(data); i++ {
+			checkNoPanic(t, data[:i], decode)
+		}
+
+		for i := range data {
+			for _, v := range []byte{0x00, 0x01, 0x7F, 0x80, 0xFF} {
+				mutated := append([]byte{}, data...)
+				mutated[i] = v
+				checkNoPanic(t, mutated, decode)
+			}
+		}
+
+		for n := 0; n < 1000 && len(data) > 0; n++ {
+			mutated := append([]byte{}, data...)
+			for i := r.Intn(8); i >= 0; i-- {
+				mutated[r.Intn(len(mutated))] = byte(r.Intn(256))
+			}
+			checkNoPanic(t, mutated, decode)
+		}
+	}
+}
+
+/**
+ * fuzz a decoder from the seeds
+ */
+func fuzzDecoder(f *testing.F, decode func([]byte), seeds ...[]byte) {
+	for _, data := range seeds {
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		decode(data)
+	})
+}
+
+func checkNoPanic(t *testing.T, data []byte, decode func([]byte)) {
+	t.Helper()
+	defer func() {
+		if err := recover(); err != nil {
+			t.Fatalf("panic decoding %x: %v\n%s", data, err, debug.Stack())
+		}
+	}()
+	decode(data)
+}
@@ -3,6 +3,11 @@
  */
 package tnefdecoder
 
+import (
+	"encoding/binary"
+	"time"
+	"unicode/utf16"
+)
 
 
 /**
@@ -309,3 +314,116 @@ func MapiDecodeBinary(b []byte) []byte {
 func MapiDecodeBinaryArray(b []byte) [][]byte {
 	return GetPropertyMultiVariableValues(b)
 }
+
+/**
+ * MapiTypeSystime - FILETIME: the number of 100-nanosecond intervals since January 1, 1601 (UTC)
+ */
+func MapiDecodeTime(b []byte) time.Time {
+	if len(b) < 8 {
+		return time.Time{}
+	}
+	leReader := new(LittleEndianDecoder)
+	ft := int64(leReader.Uint64(b[0:8]))
+	if ft <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(ft/10000000-11644473600, (ft%10000000)*100).UTC()
+}
+
+// MapiTypeMVSystime
+func MapiDecodeTimeArray(b []byte) []time.Time {
+	items  := GetPropertyMultiScalarValues(b, MapiTypeMVSystime)
+	result := make([]time.Time, len(items))
+	for i, item := range items {
+		result[i] = MapiDecodeTime(item)
+	}
+	return result
+}
+
+/**
+ * encode a MAPI PtypUnicode value (PropertyMultiVariableContent with one value, null terminated, padded)
+ */
+func MapiEncodeUnicode(v string) []byte {
+	units := utf16.Encode([]rune(v + "\x00"))
+	data := make([]byte, 8, 8+len(units)*2+2)
+	binary.LittleEndian.PutUint32(data[0:4], 1)
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(units)*2))
+	for _, u := range units {
+		data = append(data, byte(u), byte(u>>8))
+	}
+	if padd := 4 - len(data)%4; padd < 4 {
+		data = append(data, make([]byte, padd)...)
+	}
+	return data
+}
+
+/**
+ * encode the values of a variable length property (PropertyMultiVariableContent): the count, then each value with its
+ * length, padded to 4 bytes
+ */
+func MapiEncodeVariableValues(values [][]byte) []byte {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data[0:4], uint32(len(values)))
+	for _, v := range values {
+		length := make([]byte, 4)
+		binary.LittleEndian.PutUint32(length, uint32(len(v)))
+		data = append(data, length...)
+		data = append(data, v...)
+		if padd := 4 - len(v)%4; padd < 4 {
+			data = append(data, make([]byte, padd)...)
+		}
+	}
+	return data
+}
+
+/**
+ * encode a MAPI PtypMultipleString value (null terminated UTF-16 strings)
+ */
+func MapiEncodeUnicodeArray(values []string) []byte {
+	items := make([][]byte, len(values))
+	for i, v := range values {
+		for _, u := range utf16.Encode([]rune(v + "\x00")) {
+			items[i] = append(items[i], byte(u), byte(u>>8))
+		}
+	}
+	return MapiEncodeVariableValues(items)
+}
+
+/**
+ * encode a MAPI PtypBinary value
+ */
+func MapiEncodeBinary(v []byte) []byte {
+	return MapiEncodeVariableValues([][]byte{v})
+}
+
+/**
+ * encode a MAPI PtypInteger32 value (PtypInteger16 and PtypBoolean values are padded to 4 bytes too)
+ */
+func MapiEncodeInt32(v int) []byte {
+	data := make([]byte, 4)
+	binary.LittleEndian.PutUint32(data, uint32(int32(v)))
+	return data
+}
+
+/**
+ * encode a MAPI PtypMultipleInteger32 value
+ */
+func MapiEncodeInt32Array(values []int) []byte {
+	data := make([]byte, 4, 4+len(values)*4)
+	binary.LittleEndian.PutUint32(data, uint32(len(values)))
+	for _, v := range values {
+		data = append(data, MapiEncodeInt32(v)...)
+	}
+	return data
+}
+
+/**
+ * encode a MAPI PtypTime value (FILETIME)
+ */
+func MapiEncodeTime(v time.Time) []byte {
+	data := make([]byte, 8)
+	if !v.IsZero() {
+		binary.LittleEndian.PutUint64(data, uint64((v.Unix()+11644473600)*10000000+int64(v.Nanosecond()/100)))
+	}
+	return data
+}
@@ -0,0 +1,178 @@
+/**
+ * recipients decoded from attRecipTable
+ */
+
+package tnefdecoder
+
+import (
+	"strings"
+	"time"
+)
+
+func NewRecipient() *Recipient {
+	return &Recipient{
+		TnefObject: &TnefObject{},
+	}
+}
+
+/**
+ * a row of the recipient table; the recipient properties are the MAPI attributes of the row
+ */
+type Recipient struct {
+	*TnefObject
+}
+
+/**
+ * PidTagDisplayName
+ */
+func (r *Recipient) GetDisplayName() string {
+	return r.GetAttributeStringValue(MapiPidTagDisplayName, "mapi")
+}
+
+/**
+ * PidTagAddressType (SMTP, EX, ...)
+ */
+func (r *Recipient) GetAddressType() string {
+	return r.GetAttributeStringValue(MapiPidTagAddressType, "mapi")
+}
+
+/**
+ * PidTagEmailAddress; the format of the address depends on the address type
+ */
+func (r *Recipient) GetEmailAddress() string {
+	return r.GetAttributeStringValue(MapiPidTagEmailAddress, "mapi")
+}
+
+/**
+ * return the SMTP address of the recipient: PidTagSmtpAddress, the email address if the address type is SMTP, else
+ * the address given by the address resolver for an EX address or an address-book entry ID
+ */
+func (r *Recipient) GetSmtpAddress() string {
+	if address := r.GetAttributeStringValue(MapiPidTagSmtpAddress, "mapi"); address != "" {
+		return address
+	}
+	addressType := r.GetAddressType()
+	if address := r.GetEmailAddress(); address != "" && (addressType == "" || strings.EqualFold(addressType, "SMTP")) {
+		return address
+	}
+	var entryId []byte
+	if attr := r.GetAttribute(MapiPidTagEntryId, "mapi"); attr != nil {
+		entryId = attr.GetBinaryValue()
+	}
+	if resolved := r.resolveAddress(addressType, r.GetEmailAddress(), entryId); resolved != nil {
+		return resolved.SmtpAddress
+	}
+	return ""
+}
+
+/**
+ * the entry ID of the recipient: PidTagEntryId, else PidTagRecipientEntryId; nil if the recipient has no entry ID
+ */
+func (r *Recipient) GetEntryId() *EntryId {
+	for _, id := range []int{MapiPidTagEntryId, MapiPidTagRecipientEntryId} {
+		if attr := r.GetAttribute(id, "mapi"); attr != nil {
+			if e, err := DecodeEntryIdCodepage(attr.GetBinaryValue(), r.Codepage); err == nil {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+/**
+ * PidTagRecipientType: RecipientTypeTo, RecipientTypeCc, RecipientTypeBcc (the flags from the high bits are removed)
+ */
+func (r *Recipient) GetRecipientType() int {
+	attr := r.GetAttribute(MapiPidTagRecipientType, "mapi")
+	if attr == nil {
+		return RecipientTypeTo
+	}
+	return attr.GetIntValue() & 0x0000000F
+}
+
+/**
+ * PidTagRecipientFlags
+ */
+func (r *Recipient) GetRecipientFlags() int {
+	attr := r.GetAttribute(MapiPidTagRecipientFlags, "mapi")
+	if attr == nil {
+		return 0
+	}
+	return attr.GetIntValue()
+}
+
+/**
+ * check if the recipient is the organizer of the meeting
+ */
+func (r *Recipient) IsOrganizer() bool {
+	return r.GetRecipientFlags()&RecipientFlagOrganizer != 0
+}
+
+/**
+ * PidTagRecipientTrackStatus: the response of a meeting attendee
+ */
+func (r *Recipient) GetTrackStatus() int {
+	attr := r.GetAttribute(MapiPidTagRecipientTrackStatus, "mapi")
+	if attr == nil {
+		return 0
+	}
+	return attr.GetIntValue()
+}
+
+/**
+ * PidTagNonDeliveryReportDiagCode: the diagnostic of a non-delivery report; NdrDiagNoDiagnostic if missing
+ */
+func (r *Recipient) GetNonDeliveryDiagCode() int {
+	attr := r.GetAttribute(MapiPidTagNonDeliveryReportDiagCode, "mapi")
+	if attr == nil {
+		return NdrDiagNoDiagnostic
+	}
+	return attr.GetIntValue()
+}
+
+/**
+ * PidTagNonDeliveryReportReasonCode of the recipient; -1 if missing
+ */
+func (r *Recipient) GetNonDeliveryReasonCode() int {
+	attr := r.GetAttribute(MapiPidTagNonDeliveryReportReasonCode, "mapi")
+	if attr == nil {
+		return -1
+	}
+	return attr.GetIntValue()
+}
+
+/**
+ * PidTagSupplementaryInfo: the diagnostic text of the server that rejected the message
+ */
+func (r *Recipient) GetSupplementaryInfo() string {
+	return r.GetAttributeStringValue(MapiPidTagSupplementaryInfo, "mapi")
+}
+
+/**
+ * PidTagDeliverTime: when the message was delivered to the recipient (delivery reports)
+ */
+func (r *Recipient) GetDeliverTime() time.Time {
+	attr := r.GetAttribute(MapiPidTagDeliverTime, "mapi")
+	if attr == nil {
+		return time.Time{}
+	}
+	return attr.GetTimeValue()
+}
+
+/**
+ * create a recipient with an SMTP address: the address properties and a One-Off EntryID
+ */
+func NewSmtpRecipient(displayName string, address string, recipientType int) *Recipient {
+	if displayName == "" {
+		displayName = address
+	}
+	r := NewRecipient()
+	r.SetAttribute(NewMapiStringAttribute(MapiPidTagDisplayName, displayName))
+	r.SetAttribute(NewMapiStringAttribute(MapiPidTagAddressType, "SMTP"))
+	r.SetAttribute(NewMapiStringAttribute(MapiPidTagEmailAddress, address))
+	r.SetAttribute(NewMapiStringAttribute(MapiPidTagSmtpAddress, address))
+	r.SetAttribute(NewMapiIntAttribute(MapiPidTagRecipientType, recipientType))
+	r.SetAttribute(NewMapiBinaryAttribute(MapiPidTagEntryId, EncodeOneOffEntryId(displayName, "SMTP", address)))
+	r.SetAttribute(NewMapiIntAttribute(MapiPidTagRecipientFlags, RecipientFlagSendable))
+	return r
+}
@@ -0,0 +1,473 @@
+/**
+ * decoder for the recurrence of the calendar objects: PidLidAppointmentRecur (AppointmentRecurrencePattern, [MS-OXOCAL] section 2.2.1.44)
+ * the dates of the pattern are in the time zone of the appointment, as minutes since January 1, 1601
+ */
+
+package tnefdecoder
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ * RecurFrequency
+ */
+const (
+	RecurFrequencyDaily   = 0x200A
+	RecurFrequencyWeekly  = 0x200B
+	RecurFrequencyMonthly = 0x200C
+	RecurFrequencyYearly  = 0x200D
+)
+
+/**
+ * PatternType
+ */
+const (
+	PatternTypeDay        = 0x0000
+	PatternTypeWeek       = 0x0001
+	PatternTypeMonth      = 0x0002
+	PatternTypeMonthEnd   = 0x0003
+	PatternTypeMonthNth   = 0x0004
+	PatternTypeHjMonth    = 0x000A
+	PatternTypeHjMonthNth = 0x000B
+	PatternTypeHjMonthEnd = 0x000C
+)
+
+/**
+ * EndType
+ */
+const (
+	RecurEndAfterDate  = 0x2021
+	RecurEndAfterCount = 0x2022
+	RecurEndNever      = 0x2023
+	RecurEndNeverOld   = 0xFFFFFFFF
+)
+
+/**
+ * OverrideFlags of an exception: the properties changed by the exception
+ */
+const (
+	AroSubject          = 0x0001
+	AroMeetingType      = 0x0002
+	AroReminderDelta    = 0x0004
+	AroReminder         = 0x0008
+	AroLocation         = 0x0010
+	AroBusyStatus       = 0x0020
+	AroAttachment       = 0x0040
+	AroSubType          = 0x0080
+	AroAppointmentColor = 0x0100
+	AroExceptionalBody  = 0x0200
+)
+
+// the value of the N in the month-nth patterns meaning "the last"
+const RecurNthLast = 5
+
+var ErrInvalidRecurrence = errors.New("invalid recurrence pattern")
+
+// the iCalendar names of the days of the week, Sunday first (the order of the day bits)
+var icalWeekDays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+/**
+ * RecurrencePattern ([MS-OXOCAL] section 2.2.1.44.1)
+ */
+type RecurrencePattern struct {
+	ReaderVersion  int
+	WriterVersion  int
+	RecurFrequency int
+	PatternType    int
+	CalendarType   int
+	FirstDateTime  int
+	Period         int
+	SlidingFlag    int
+
+	// PatternTypeSpecific: the days of the week (bit 0 = Sunday) for the week and month-nth patterns
+	WeekDays int
+	// PatternTypeSpecific: the day of the month for the month patterns
+	DayOfMonth int
+	// PatternTypeSpecific: the occurrence of the week days in the month (1 - 4, 5 = last) for the month-nth patterns
+	Nth int
+
+	EndType         int
+	OccurrenceCount int
+	FirstDOW        int
+
+	// the original dates of the deleted and modified instances (midnight, local time)
+	DeletedInstanceDates  []time.Time
+	ModifiedInstanceDates []time.Time
+
+	// the date of the first and last instance (midnight, local time); the last date is not used if the pattern has no end
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+/**
+ * a modified instance of the recurrence (ExceptionInfo and ExtendedException, [MS-OXOCAL] sections 2.2.1.44.2 - 2.2.1.44.3)
+ * the times are in local time; only the properties flagged in OverrideFlags are set
+ */
+type RecurrenceException struct {
+	StartDateTime     time.Time
+	EndDateTime       time.Time
+	OriginalStartDate time.Time
+	OverrideFlags     int
+
+	Subject          string
+	MeetingType      int
+	ReminderDelta    int
+	ReminderSet      bool
+	Location         string
+	BusyStatus       int
+	Attachment       bool
+	SubType          bool
+	AppointmentColor int
+	ChangeHighlight  int
+}
+
+/**
+ * AppointmentRecurrencePattern
+ */
+type AppointmentRecurrencePattern struct {
+	*RecurrencePattern
+
+	ReaderVersion2 int
+	WriterVersion2 int
+
+	// the start and the end of the instances, in minutes since midnight (local time)
+	StartTimeOffset int
+	EndTimeOffset   int
+
+	Exceptions []*RecurrenceException
+}
+
+/**
+ * bounds checked little endian reader; after the first error all the reads return 0
+ */
+type recurrenceReader struct {
+	b         []byte
+	offset    int
+	err       error
+	leDecoder *LittleEndianDecoder
+}
+
+func (r *recurrenceReader) next(n int) []byte {
+	if r.err != nil || n < 0 || r.offset+n > len(r.b) {
+		r.err = ErrInvalidRecurrence
+		return nil
+	}
+	v := r.b[r.offset : r.offset+n]
+	r.offset += n
+	return v
+}
+
+func (r *recurrenceReader) byte() int {
+	if v := r.next(1); v != nil {
+		return int(v[0])
+	}
+	return 0
+}
+
+func (r *recurrenceReader) uint16() int {
+	if v := r.next(2); v != nil {
+		return int(r.leDecoder.Uint16(v))
+	}
+	return 0
+}
+
+func (r *recurrenceReader) uint32() int {
+	if v := r.next(4); v != nil {
+		return int(r.leDecoder.Uint32(v))
+	}
+	return 0
+}
+
+func (r *recurrenceReader) minutes() time.Time {
+	return MinutesToTime(r.uint32())
+}
+
+/**
+ * convert a date stored as minutes since January 1, 1601 (the time zone is not changed)
+ */
+func MinutesToTime(minutes int) time.Time {
+	// 11644473600 = the seconds between 1601-01-01 and 1970-01-01 (time.Duration cannot hold 400 years)
+	return time.Unix(int64(minutes)*60-11644473600, 0).UTC()
+}
+
+/**
+ * decode the RecurrencePattern structure (PidLidAppointmentRecur starts with it; tasks use it in PidLidTaskRecurrence)
+ */
+func DecodeRecurrencePattern(b []byte) (*RecurrencePattern, error) {
+	r := &recurrenceReader{b: b, leDecoder: new(LittleEndianDecoder)}
+	p := decodeRecurrencePattern(r)
+	if r.err != nil {
+		return nil, r.err
+	}
+	return p, nil
+}
+
+func decodeRecurrencePattern(r *recurrenceReader) *RecurrencePattern {
+	p := &RecurrencePattern{}
+	p.ReaderVersion = r.uint16()
+	p.WriterVersion = r.uint16()
+	p.RecurFrequency = r.uint16()
+	p.PatternType = r.uint16()
+	p.CalendarType = r.uint16()
+	p.FirstDateTime = r.uint32()
+	p.Period = r.uint32()
+	p.SlidingFlag = r.uint32()
+
+	switch p.PatternType {
+	case PatternTypeWeek:
+		p.WeekDays = r.uint32()
+	case PatternTypeMonth, PatternTypeMonthEnd, PatternTypeHjMonth, PatternTypeHjMonthEnd:
+		p.DayOfMonth = r.uint32()
+	case PatternTypeMonthNth, PatternTypeHjMonthNth:
+		p.WeekDays = r.uint32()
+		p.Nth = r.uint32()
+	}
+
+	p.EndType = r.uint32()
+	p.OccurrenceCount = r.uint32()
+	p.FirstDOW = r.uint32()
+
+	count := r.uint32()
+	for i := 0; i < count && r.err == nil; i++ {
+		p.DeletedInstanceDates = append(p.DeletedInstanceDates, r.minutes())
+	}
+	count = r.uint32()
+	for i := 0; i < count && r.err == nil; i++ {
+		p.ModifiedInstanceDates = append(p.ModifiedInstanceDates, r.minutes())
+	}
+
+	p.StartDate = r.minutes()
+	p.EndDate = r.minutes()
+
+	return p
+}
+
+/**
+ * decode PidLidAppointmentRecur
+ */
+func DecodeAppointmentRecurrence(b []byte) (*AppointmentRecurrencePattern, error) {
+	r := &recurrenceReader{b: b, leDecoder: new(LittleEndianDecoder)}
+
+	p := &AppointmentRecurrencePattern{}
+	p.RecurrencePattern = decodeRecurrencePattern(r)
+	p.ReaderVersion2 = r.uint32()
+	p.WriterVersion2 = r.uint32()
+	p.StartTimeOffset = r.uint32()
+	p.EndTimeOffset = r.uint32()
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	// ExceptionInfo
+	count := r.uint16()
+	for i := 0; i < count && r.err == nil; i++ {
+		e := &RecurrenceException{}
+		e.StartDateTime = r.minutes()
+		e.EndDateTime = r.minutes()
+		e.OriginalStartDate = r.minutes()
+		e.OverrideFlags = r.uint16()
+
+		if e.OverrideFlags&AroSubject != 0 {
+			r.uint16() // SubjectLength = SubjectLength2 + 1
+			e.Subject = string(r.next(r.uint16()))
+		}
+		if e.OverrideFlags&AroMeetingType != 0 {
+			e.MeetingType = r.uint32()
+		}
+		if e.OverrideFlags&AroReminderDelta != 0 {
+			e.ReminderDelta = r.uint32()
+		}
+		if e.OverrideFlags&AroReminder != 0 {
+			e.ReminderSet = r.uint32() != 0
+		}
+		if e.OverrideFlags&AroLocation != 0 {
+			r.uint16() // LocationLength = LocationLength2 + 1
+			e.Location = string(r.next(r.uint16()))
+		}
+		if e.OverrideFlags&AroBusyStatus != 0 {
+			e.BusyStatus = r.uint32()
+		}
+		if e.OverrideFlags&AroAttachment != 0 {
+			e.Attachment = r.uint32() != 0
+		}
+		if e.OverrideFlags&AroSubType != 0 {
+			e.SubType = r.uint32() != 0
+		}
+		if e.OverrideFlags&AroAppointmentColor != 0 {
+			e.AppointmentColor = r.uint32()
+		}
+
+		p.Exceptions = append(p.Exceptions, e)
+	}
+
+	// ReservedBlock1
+	r.next(r.uint32())
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	// ExtendedException: the Unicode subject and location; old writers may not write it
+	for _, e := range p.Exceptions {
+		if r.offset >= len(r.b) {
+			break
+		}
+
+		if p.WriterVersion2 >= 0x3009 {
+			size := r.uint32()
+			if size >= 4 {
+				e.ChangeHighlight = r.uint32()
+				r.next(size - 4)
+			} else {
+				r.next(size)
+			}
+		}
+
+		// ReservedBlockEE1
+		r.next(r.uint32())
+
+		if e.OverrideFlags&(AroSubject|AroLocation) != 0 {
+			// StartDateTime, EndDateTime, OriginalStartDate; the same as in ExceptionInfo
+			r.next(12)
+			if e.OverrideFlags&AroSubject != 0 {
+				if subject := r.next(r.uint16() * 2); len(subject) > 0 {
+					e.Subject = r.leDecoder.Utf16(subject)
+				}
+			}
+			if e.OverrideFlags&AroLocation != 0 {
+				if location := r.next(r.uint16() * 2); len(location) > 0 {
+					e.Location = r.leDecoder.Utf16(location)
+				}
+			}
+			// ReservedBlockEE2
+			r.next(r.uint32())
+		}
+	}
+
+	// the extended exceptions are optional; keep the decoded exceptions
+	return p, nil
+}
+
+/**
+ * check if the pattern has an end date or a number of occurrences
+ */
+func (p *RecurrencePattern) HasEnd() bool {
+	return p.EndType == RecurEndAfterDate || p.EndType == RecurEndAfterCount
+}
+
+/**
+ * the deleted instances that are not modified (cancelled occurrences)
+ */
+func (p *RecurrencePattern) GetCancelledInstanceDates() []time.Time {
+	result := []time.Time{}
+	for _, d := range p.DeletedInstanceDates {
+		modified := false
+		for _, m := range p.ModifiedInstanceDates {
+			if m.Equal(d) {
+				modified = true
+				break
+			}
+		}
+		if !modified {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+/**
+ * the iCalendar names of the days of the week from a day mask (bit 0 = Sunday)
+ */
+func recurrenceWeekDays(mask int) []string {
+	days := []string{}
+	for i, day := range icalWeekDays {
+		if mask&(1<<uint(i)) != 0 {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+/**
+ * return the RRULE value of the pattern ([MS-OXCICAL] section 2.1.3.2.2)
+ * until is the end of the recurrence (the start of the last instance) as iCalendar value (DATE or UTC DATE-TIME)
+ */
+func (p *RecurrencePattern) RRule(until string) string {
+	rule := []string{}
+	interval := p.Period
+	yearly := p.RecurFrequency == RecurFrequencyYearly
+
+	switch p.PatternType {
+	case PatternTypeDay:
+		rule = append(rule, "FREQ=DAILY")
+		// the period of the daily patterns is in minutes
+		interval = p.Period / 1440
+	case PatternTypeWeek:
+		rule = append(rule, "FREQ=WEEKLY")
+		if p.RecurFrequency == RecurFrequencyDaily {
+			// every weekday: a daily recurrence stored as a weekly pattern
+			interval = 1
+		}
+	case PatternTypeMonth, PatternTypeMonthEnd, PatternTypeMonthNth, PatternTypeHjMonth, PatternTypeHjMonthEnd, PatternTypeHjMonthNth:
+		if p.PatternType >= PatternTypeHjMonth {
+			// RFC 7529 non-Gregorian recurrence rules
+			rule = append(rule, "RSCALE=ISLAMIC-CIVIL")
+		}
+		if yearly {
+			rule = append(rule, "FREQ=YEARLY")
+			// the period of the yearly patterns is in months
+			interval = p.Period / 12
+		} else {
+			rule = append(rule, "FREQ=MONTHLY")
+		}
+	}
+
+	if interval > 1 {
+		rule = append(rule, "INTERVAL="+strconv.Itoa(interval))
+	}
+
+	switch p.PatternType {
+	case PatternTypeWeek:
+		rule = append(rule, "BYDAY="+strings.Join(recurrenceWeekDays(p.WeekDays), ","))
+	case PatternTypeMonth, PatternTypeHjMonth:
+		if yearly {
+			rule = append(rule, "BYMONTH="+strconv.Itoa(int(p.StartDate.Month())))
+		}
+		rule = append(rule, "BYMONTHDAY="+strconv.Itoa(p.DayOfMonth))
+	case PatternTypeMonthEnd, PatternTypeHjMonthEnd:
+		if yearly {
+			rule = append(rule, "BYMONTH="+strconv.Itoa(int(p.StartDate.Month())))
+		}
+		rule = append(rule, "BYMONTHDAY=-1")
+	case PatternTypeMonthNth, PatternTypeHjMonthNth:
+		if yearly {
+			rule = append(rule, "BYMONTH="+strconv.Itoa(int(p.StartDate.Month())))
+		}
+		rule = append(rule, "BYDAY="+strings.Join(recurrenceWeekDays(p.WeekDays), ","))
+		if p.Nth == RecurNthLast {
+			rule = append(rule, "BYSETPOS=-1")
+		} else {
+			rule = append(rule, "BYSETPOS="+strconv.Itoa(p.Nth))
+		}
+	}
+
+	switch p.EndType {
+	case RecurEndAfterCount:
+		rule = append(rule, "COUNT="+strconv.Itoa(p.OccurrenceCount))
+	case RecurEndAfterDate:
+		if until != "" {
+			rule = append(rule, "UNTIL="+until)
+		}
+	}
+
+	if p.PatternType == PatternTypeWeek && p.FirstDOW >= 0 && p.FirstDOW < len(icalWeekDays) {
+		rule = append(rule, "WKST="+icalWeekDays[p.FirstDOW])
+	}
+
+	return strings.Join(rule, ";")
+}
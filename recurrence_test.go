@@ -0,0 +1,175 @@
+package tnefdecoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func recurrenceTestDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+/**
+ * a weekly recurrence on Monday and Wednesday, 10 occurrences, two deleted instances, the second one being modified
+ */
+func recurrenceTestPattern() *AppointmentRecurrencePattern {
+	return &AppointmentRecurrencePattern{
+		RecurrencePattern: &RecurrencePattern{
+			ReaderVersion:         0x3004,
+			WriterVersion:         0x3004,
+			RecurFrequency:        0x200B,
+			PatternType:           PatternTypeWeek,
+			Period:                1,
+			WeekDays:              0x02 | 0x08,
+			EndType:               RecurEndAfterCount,
+			OccurrenceCount:       10,
+			FirstDOW:              1,
+			DeletedInstanceDates:  []time.Time{recurrenceTestDate(2024, 3, 6), recurrenceTestDate(2024, 3, 11)},
+			ModifiedInstanceDates: []time.Time{recurrenceTestDate(2024, 3, 11)},
+			StartDate:             recurrenceTestDate(2024, 3, 4),
+			EndDate:               recurrenceTestDate(2024, 4, 3),
+		},
+		StartTimeOffset: 9 * 60,
+		EndTimeOffset:   10 * 60,
+		Exceptions: []*RecurrenceException{{
+			StartDateTime:     time.Date(2024, 3, 11, 14, 0, 0, 0, time.UTC),
+			EndDateTime:       time.Date(2024, 3, 11, 15, 0, 0, 0, time.UTC),
+			OriginalStartDate: time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC),
+			OverrideFlags:     AroSubject | AroLocation | AroBusyStatus,
+			Subject:           "Réunion déplacée",
+			Location:          "Salle 2",
+			BusyStatus:        2,
+		}},
+	}
+}
+
+func TestDecodeAppointmentRecurrence(t *testing.T) {
+	want := recurrenceTestPattern()
+	got, err := DecodeAppointmentRecurrence(want.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got.RecurrencePattern, want.RecurrencePattern) {
+		t.Errorf("pattern %+v, want %+v", got.RecurrencePattern, want.RecurrencePattern)
+	}
+	if got.StartTimeOffset != want.StartTimeOffset || got.EndTimeOffset != want.EndTimeOffset {
+		t.Errorf("offsets %d - %d, want %d - %d", got.StartTimeOffset, got.EndTimeOffset, want.StartTimeOffset, want.EndTimeOffset)
+	}
+	if len(got.Exceptions) != 1 {
+		t.Fatalf("%d exceptions, want 1", len(got.Exceptions))
+	}
+	e := got.Exceptions[0]
+	// the Unicode subject and location of the extended exception replace the 8-bit strings
+	if e.Subject != "Réunion déplacée" || e.Location != "Salle 2" || e.BusyStatus != 2 || !e.StartDateTime.Equal(want.Exceptions[0].StartDateTime) {
+		t.Errorf("exception %+v", e)
+	}
+	if rule := got.RRule(""); rule != "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10;WKST=MO" {
+		t.Errorf("RRULE %q", rule)
+	}
+}
+
+func TestDecodeRecurrencePatternTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern *RecurrencePattern
+	}{
+		{"daily", &RecurrencePattern{PatternType: PatternTypeDay, Period: 1440, EndType: RecurEndNever}},
+		{"monthly", &RecurrencePattern{PatternType: PatternTypeMonth, Period: 1, DayOfMonth: 15, EndType: RecurEndAfterDate}},
+		{"month end", &RecurrencePattern{PatternType: PatternTypeMonthEnd, Period: 1, DayOfMonth: 31, EndType: RecurEndNever}},
+		{"month nth", &RecurrencePattern{PatternType: PatternTypeMonthNth, Period: 12, WeekDays: 0x3E, Nth: RecurNthLast, EndType: RecurEndNever}},
+		{"hijri month nth", &RecurrencePattern{PatternType: PatternTypeHjMonthNth, Period: 1, WeekDays: 0x01, Nth: 2, EndType: RecurEndNever}},
+	}
+	for _, tt := range tests {
+		tt.pattern.StartDate = recurrenceTestDate(2024, 1, 1)
+		tt.pattern.EndDate = recurrenceTestDate(2025, 1, 1)
+		got, err := DecodeRecurrencePattern(tt.pattern.Encode())
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.pattern) {
+			t.Errorf("%s: %+v, want %+v", tt.name, got, tt.pattern)
+		}
+	}
+}
+
+func TestDecodeRecurrenceInvalid(t *testing.T) {
+	pattern := recurrenceTestPattern().RecurrencePattern.Encode()
+
+	// DeletedInstanceCount larger than the data (the count is after the 38 bytes of the weekly pattern)
+	tooManyDeleted := append([]byte{}, pattern...)
+	binary.LittleEndian.PutUint32(tooManyDeleted[38:42], 0xFFFFFFFF)
+
+	// an exception whose subject is longer than the data
+	appointment := recurrenceTestPattern()
+	appointment.Exceptions[0].OverrideFlags = AroSubject
+	appointment.Exceptions[0].Subject = "subject"
+	longSubject := appointment.Encode()
+	i := bytes.Index(longSubject, []byte("subject"))
+	binary.LittleEndian.PutUint16(longSubject[i-2:i], 0xFFFF)
+
+	tests := []struct {
+		name   string
+		data   []byte
+		decode func([]byte) error
+	}{
+		{"empty pattern", nil, decodeRecurrencePatternError},
+		{"truncated pattern", pattern[:len(pattern)-1], decodeRecurrencePatternError},
+		{"too many deleted instances", tooManyDeleted, decodeRecurrencePatternError},
+		{"pattern without the appointment fields", pattern, decodeAppointmentRecurrenceError},
+		{"subject longer than the data", longSubject, decodeAppointmentRecurrenceError},
+	}
+	for _, tt := range tests {
+		if err := tt.decode(tt.data); err != ErrInvalidRecurrence {
+			t.Errorf("%s: error %v, want %v", tt.name, err, ErrInvalidRecurrence)
+		}
+	}
+}
+
+func TestDecodeRecurrenceEmptyStrings(t *testing.T) {
+	// the extended exception with empty Unicode strings
+	p := recurrenceTestPattern()
+	p.Exceptions[0].Subject = ""
+	p.Exceptions[0].Location = ""
+	got, err := DecodeAppointmentRecurrence(p.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := got.Exceptions[0]; e.Subject != "" || e.Location != "" {
+		t.Errorf("exception %+v", e)
+	}
+}
+
+func TestDecodeRecurrenceMalformed(t *testing.T) {
+	data := recurrenceTestPattern().Encode()
+	checkMalformed(t, decodeRecurrence, data, recurrenceTestPattern().RecurrencePattern.Encode())
+	checkMalformed(t, func(b []byte) { DecodeRecurrencePattern(b) }, data)
+}
+
+func FuzzDecodeAppointmentRecurrence(f *testing.F) {
+	fuzzDecoder(f, decodeRecurrence, recurrenceTestPattern().Encode(), recurrenceTestPattern().RecurrencePattern.Encode())
+}
+
+/**
+ * decode the appointment recurrence and use the decoded pattern
+ */
+func decodeRecurrence(data []byte) {
+	if p, err := DecodeAppointmentRecurrence(data); err == nil {
+		p.RRule("")
+		p.GetCancelledInstanceDates()
+	}
+}
+
+func decodeRecurrencePatternError(data []byte) error {
+	_, err := DecodeRecurrencePattern(data)
+	return err
+}
+
+func decodeAppointmentRecurrenceError(data []byte) error {
+	_, err := DecodeAppointmentRecurrence(data)
+	return err
+}
@@ -0,0 +1,123 @@
+/**
+ * the reminders of the Outlook items ([MS-OXORMDR]) and their VALARM representation ([MS-OXCICAL] section 2.1.3.1.1.20.61)
+ */
+
+package tnefdecoder
+
+import (
+	"strconv"
+	"time"
+)
+
+// the reminder delta used by Outlook when PidLidReminderDelta is missing
+const DefaultReminderDelta = 15
+
+// the DESCRIPTION of the generated VALARM components
+const ICalAlarmDescription = "Reminder"
+
+/**
+ * the reminder of an appointment, a task or a message flagged for follow-up
+ */
+type Reminder struct {
+	Set        bool      // PidLidReminderSet
+	Delta      int       // PidLidReminderDelta: minutes before the start of the appointment
+	Time       time.Time // PidLidReminderTime: the start of the appointment, or the first signal time of the task / message (UTC)
+	SignalTime time.Time // PidLidReminderSignalTime: when the reminder is signaled (UTC); computed from Time if missing
+}
+
+/**
+ * return the reminder of the object; nil if no reminder property is set
+ */
+func (t *TnefObject) GetReminder() *Reminder {
+	set := t.GetNamedAttribute(PsetidCommon, MapiPidLidReminderSet)
+	delta := t.GetNamedAttribute(PsetidCommon, MapiPidLidReminderDelta)
+	reminderTime := t.GetNamedTimeValue(PsetidCommon, MapiPidLidReminderTime)
+	signalTime := t.GetNamedTimeValue(PsetidCommon, MapiPidLidReminderSignalTime)
+	if set == nil && delta == nil && reminderTime.IsZero() && signalTime.IsZero() {
+		return nil
+	}
+
+	r := &Reminder{Delta: DefaultReminderDelta, Time: reminderTime, SignalTime: signalTime}
+	if set != nil {
+		r.Set = set.GetBoolValue()
+	}
+	if delta != nil {
+		r.Delta = delta.GetIntValue()
+	}
+
+	// the delta applies only to the calendar objects; for the other objects PidLidReminderTime is the signal time
+	if r.SignalTime.IsZero() && !r.Time.IsZero() {
+		r.SignalTime = r.Time
+		if ICalendarMethod(t.GetMessageClass()) != "" {
+			r.SignalTime = r.Time.Add(-time.Duration(r.Delta) * time.Minute)
+		}
+	}
+	return r
+}
+
+/**
+ * create a VALARM signaled delta minutes before the start of the component (after the start if delta is negative)
+ */
+func NewICalendarAlarm(delta int, description string) *ICalComponent {
+	alarm := NewICalComponent("VALARM")
+	alarm.AddProperty("ACTION", "DISPLAY")
+	alarm.AddText("DESCRIPTION", description)
+	// a single sign: -PT15M before the start, PT15M after
+	alarm.AddProperty("TRIGGER", ICalFormatDuration(-delta), "RELATED", "START")
+	return alarm
+}
+
+/**
+ * create a VALARM signaled at a fixed time (tasks and messages, whose reminders are not relative to a start)
+ */
+func NewICalendarAbsoluteAlarm(signal time.Time, description string) *ICalComponent {
+	alarm := NewICalComponent("VALARM")
+	alarm.AddProperty("ACTION", "DISPLAY")
+	alarm.AddText("DESCRIPTION", description)
+	alarm.AddDateTime("TRIGGER", signal, "VALUE", "DATE-TIME")
+	return alarm
+}
+
+/**
+ * format a number of minutes as an iCalendar DURATION: weeks, days, hours or minutes
+ */
+func ICalFormatDuration(minutes int) string {
+	if minutes < 0 {
+		return "-" + ICalFormatDuration(-minutes)
+	}
+	switch {
+	case minutes == 0:
+		return "PT0S"
+	case minutes%(7*24*60) == 0:
+		return "P" + strconv.Itoa(minutes/(7*24*60)) + "W"
+	case minutes%(24*60) == 0:
+		return "P" + strconv.Itoa(minutes/(24*60)) + "D"
+	case minutes%60 == 0:
+		return "PT" + strconv.Itoa(minutes/60) + "H"
+	}
+	return "PT" + strconv.Itoa(minutes) + "M"
+}
+
+/**
+ * add the VALARM of the reminder of an appointment to the event
+ */
+func addICalendarEventAlarm(t *TnefObject, event *ICalComponent, description string) {
+	if r := t.GetReminder(); r != nil && r.Set {
+		event.AddComponent(NewICalendarAlarm(r.Delta, description))
+	}
+}
+
+/**
+ * add the VALARM of the reminder of a task to the todo: at the signal time, else relative to the start of the task
+ */
+func addICalendarTaskAlarm(t *TnefObject, todo *ICalComponent, description string) {
+	r := t.GetReminder()
+	if r == nil || !r.Set {
+		return
+	}
+	if !r.SignalTime.IsZero() {
+		todo.AddComponent(NewICalendarAbsoluteAlarm(r.SignalTime, description))
+	} else if todo.GetProperty("DTSTART") != nil {
+		todo.AddComponent(NewICalendarAlarm(r.Delta, description))
+	}
+}
@@ -0,0 +1,103 @@
+package tnefdecoder
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestICalFormatDuration(t *testing.T) {
+	tests := []struct {
+		minutes int
+		want    string
+	}{
+		{0, "PT0S"},
+		{15, "PT15M"},
+		{-15, "-PT15M"},
+		{90, "PT90M"},
+		{120, "PT2H"},
+		{24 * 60, "P1D"},
+		{-2 * 24 * 60, "-P2D"},
+		{14 * 24 * 60, "P2W"},
+	}
+	for _, tt := range tests {
+		if v := ICalFormatDuration(tt.minutes); v != tt.want {
+			t.Errorf("%d: %s, want %s", tt.minutes, v, tt.want)
+		}
+		if minutes, err := ParseICalDuration(ICalFormatDuration(tt.minutes)); err != nil || minutes != tt.minutes {
+			t.Errorf("%d: parsed %d %v", tt.minutes, minutes, err)
+		}
+	}
+}
+
+func TestNewICalendarAlarm(t *testing.T) {
+	for delta, want := range map[int]string{15: "-PT15M", -30: "PT30M", 0: "PT0S"} {
+		trigger := NewICalendarAlarm(delta, ICalAlarmDescription).GetProperty("TRIGGER")
+		if trigger.Value != want || !reflect.DeepEqual(trigger.GetParameter("RELATED"), []string{"START"}) {
+			t.Errorf("%d: TRIGGER %+v, want %s", delta, trigger, want)
+		}
+	}
+}
+
+func TestGetReminder(t *testing.T) {
+	start := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	signal := time.Date(2024, 3, 4, 9, 50, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		messageClass string
+		attributes   []*Attribute
+		want         *Reminder
+	}{
+		{"no reminder", "IPM.Note", nil, nil},
+		{"meeting: signaled delta minutes before the start", MessageClassMeetingRequest, []*Attribute{
+			NewMapiBoolAttribute(0, true).Named(PsetidCommon, MapiPidLidReminderSet),
+			NewMapiIntAttribute(0, 30).Named(PsetidCommon, MapiPidLidReminderDelta),
+			NewMapiTimeAttribute(0, start).Named(PsetidCommon, MapiPidLidReminderTime),
+		}, &Reminder{true, 30, start, start.Add(-30 * time.Minute)}},
+		{"message: the reminder time is the signal time", "IPM.Note", []*Attribute{
+			NewMapiBoolAttribute(0, true).Named(PsetidCommon, MapiPidLidReminderSet),
+			NewMapiTimeAttribute(0, start).Named(PsetidCommon, MapiPidLidReminderTime),
+		}, &Reminder{true, DefaultReminderDelta, start, start}},
+		{"signal time set", "IPM.Note", []*Attribute{
+			NewMapiBoolAttribute(0, false).Named(PsetidCommon, MapiPidLidReminderSet),
+			NewMapiTimeAttribute(0, start).Named(PsetidCommon, MapiPidLidReminderTime),
+			NewMapiTimeAttribute(0, signal).Named(PsetidCommon, MapiPidLidReminderSignalTime),
+		}, &Reminder{false, DefaultReminderDelta, start, signal}},
+	}
+	for _, tt := range tests {
+		tObj := &TnefObject{}
+		tObj.SetAttribute(NewMappedStringAttribute(AttrLevelMessage, AttMessageClass, tt.messageClass))
+		for _, attr := range tt.attributes {
+			tObj.SetAttribute(attr)
+		}
+		if r := tObj.GetReminder(); !reflect.DeepEqual(r, tt.want) {
+			t.Errorf("%s: %+v, want %+v", tt.name, r, tt.want)
+		}
+	}
+}
+
+func TestICalendarAlarms(t *testing.T) {
+	signal := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+
+	// the task reminders are signaled at a fixed time
+	task := icalendarTestTask()
+	task.SetAttribute(NewMapiBoolAttribute(0, true).Named(PsetidCommon, MapiPidLidReminderSet))
+	task.SetAttribute(NewMapiTimeAttribute(0, signal).Named(PsetidCommon, MapiPidLidReminderSignalTime))
+	alarms := ExtractICalendarTask(task, nil).GetComponents("VTODO")[0].GetComponents("VALARM")
+	if len(alarms) != 1 || alarms[0].GetProperty("TRIGGER").Value != ICalFormatDateTime(signal) {
+		t.Errorf("task alarms %+v", alarms)
+	}
+
+	// the event reminders are relative to the start; no alarm when the reminder is not set
+	for set, want := range map[bool]int{true: 1, false: 0} {
+		meeting := &TnefObject{}
+		meeting.SetAttribute(NewMappedStringAttribute(AttrLevelMessage, AttMessageClass, MessageClassMeetingRequest))
+		meeting.SetAttribute(NewMapiBoolAttribute(0, set).Named(PsetidCommon, MapiPidLidReminderSet))
+		meeting.SetAttribute(NewMapiIntAttribute(0, 10).Named(PsetidCommon, MapiPidLidReminderDelta))
+		alarms = ExtractICalendar(meeting).GetComponents("VEVENT")[0].GetComponents("VALARM")
+		if len(alarms) != want || (want == 1 && alarms[0].GetProperty("TRIGGER").Value != "-PT10M") {
+			t.Errorf("reminder set %v: alarms %+v", set, alarms)
+		}
+	}
+}
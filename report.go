@@ -0,0 +1,225 @@
+/**
+ * reports: read receipts (REPORT.*.IPNRN), not-read notices (REPORT.*.IPNNRN), delivery (REPORT.*.DR) and
+ * non-delivery reports (REPORT.*.NDR) ([MS-OXOMSG] section 2.2.2)
+ */
+
+package tnefdecoder
+
+import (
+	"strings"
+	"time"
+)
+
+/**
+ * report types: the suffix of the report message class
+ */
+const (
+	ReportTypeRead        = "IPNRN"
+	ReportTypeNotRead     = "IPNNRN"
+	ReportTypeDelivery    = "DR"
+	ReportTypeNonDelivery = "NDR"
+)
+
+// the prefix of the report message classes (REPORT.IPM.Note.NDR)
+const MessageClassReportPrefix = "REPORT."
+
+/**
+ * PidTagNonDeliveryReportDiagCode -> RFC 3463 enhanced status code; the reports are final (Action: failed): the
+ * transient conditions (congestion, delivery time expired) get the permanent class
+ */
+var ndrDiagStatusCodes = map[int]string{
+	NdrDiagOrNameUnrecognized:   "5.1.1",
+	NdrDiagOrNameAmbiguous:      "5.1.4",
+	NdrDiagMtsCongested:         "5.4.5",
+	NdrDiagLoopDetected:         "5.4.6",
+	NdrDiagRecipientUnavailable: "5.2.1",
+	NdrDiagMaximumTimeExpired:   "5.4.7",
+	6:                           "5.6.1", // encoded information types unsupported
+	NdrDiagContentTooLong:       "5.3.4",
+	8:                           "5.6.3", // impractical to convert
+	9:                           "5.6.3", // prohibited to convert
+	10:                          "5.6.3", // conversion unsubscribed
+	11:                          "5.5.4", // parameters invalid
+	12:                          "5.6.0", // content syntax in error
+	13:                          "5.3.4", // length constraint violated
+	14:                          "5.5.3", // number constraint violated
+	15:                          "5.6.1", // content type unsupported
+	NdrDiagTooManyRecipients:    "5.5.3",
+	17:                          "5.7.0", // no bilateral agreement
+	18:                          "5.3.3", // critical function unsupported
+	19:                          "5.6.2", // conversion loss prohibited
+	26:                          "5.7.1", // reassignment prohibited
+	27:                          "5.4.6", // redirection loop detected
+	28:                          "5.7.1", // expansion prohibited
+	29:                          "5.7.1", // submission prohibited
+	NdrDiagExpansionFailed:      "5.2.4",
+	31:                          "5.6.1", // rendition unsupported
+	NdrDiagMailAddressIncorrect: "5.1.3",
+	33:                          "5.1.2", // mail office incorrect or invalid
+	34:                          "5.1.3", // mail address incomplete
+	NdrDiagMailRecipientUnknown: "5.1.1",
+	36:                          "5.1.6", // mail recipient deceased
+}
+
+/**
+ * the decoded report properties
+ */
+type Report struct {
+	Type               string // ReportTypeRead, ReportTypeNotRead, ReportTypeDelivery or ReportTypeNonDelivery
+	Text               string // PidTagReportText
+	OriginalSubject    string // PidTagOriginalSubject
+	OriginalMessageId  string // PidTagOriginalMessageId
+	OriginalSubmitTime time.Time
+	ReportTime         time.Time
+	ReasonCode         int    // PidTagNonDeliveryReportReasonCode; -1 if missing
+	ReportingMta       string // PidTagReportingMessageTransferAgent
+	Recipients         []*Recipient
+}
+
+/**
+ * return the report type from the message class; "" if the message is not a report
+ */
+func (t *TnefObject) GetReportType() string {
+	messageClass := t.GetMessageClass()
+	if !strings.HasPrefix(strings.ToUpper(messageClass), MessageClassReportPrefix) {
+		return ""
+	}
+	suffix := strings.ToUpper(messageClass[strings.LastIndex(messageClass, ".")+1:])
+	switch suffix {
+	case ReportTypeRead, ReportTypeNotRead, ReportTypeDelivery, ReportTypeNonDelivery:
+		return suffix
+	}
+	return ""
+}
+
+/**
+ * check if the message is a read receipt or a delivery report
+ */
+func (t *TnefObject) IsReport() bool {
+	return t.GetReportType() != ""
+}
+
+/**
+ * return the report properties; nil if the message is not a report
+ */
+func (t *TnefObject) GetReport() *Report {
+	reportType := t.GetReportType()
+	if reportType == "" {
+		return nil
+	}
+
+	r := &Report{
+		Type:              reportType,
+		Text:              strings.TrimSpace(t.GetAttributeStringValue(MapiPidTagReportText, "mapi")),
+		OriginalSubject:   t.GetAttributeStringValue(MapiPidTagOriginalSubject, "mapi"),
+		OriginalMessageId: t.GetAttributeStringValue(MapiPidTagOriginalMessageId, "mapi"),
+		ReasonCode:        -1,
+		ReportingMta:      t.GetAttributeStringValue(MapiPidTagReportingMessageTransferAgent, "mapi"),
+	}
+	if attr := t.GetAttribute(MapiPidTagOriginalSubmitTime, "mapi"); attr != nil {
+		r.OriginalSubmitTime = attr.GetTimeValue()
+	}
+	if attr := t.GetAttribute(MapiPidTagReportTime, "mapi"); attr != nil {
+		r.ReportTime = attr.GetTimeValue()
+	}
+	if attr := t.GetAttribute(MapiPidTagNonDeliveryReportReasonCode, "mapi"); attr != nil {
+		r.ReasonCode = attr.GetIntValue()
+	}
+
+	// the recipients the report is about carry the report properties; else the report is about all the recipients
+	for _, recipient := range t.Recipients {
+		if recipient.GetAttribute(MapiPidTagNonDeliveryReportDiagCode, "mapi") != nil ||
+			recipient.GetAttribute(MapiPidTagNonDeliveryReportReasonCode, "mapi") != nil ||
+			recipient.GetAttribute(MapiPidTagDeliverTime, "mapi") != nil {
+			r.Recipients = append(r.Recipients, recipient)
+		}
+	}
+	if len(r.Recipients) == 0 {
+		r.Recipients = t.Recipients
+	}
+
+	return r
+}
+
+/**
+ * check if the report is a read receipt or a not-read notice (MDN), not a delivery status notification
+ */
+func (r *Report) IsDispositionNotification() bool {
+	return r.Type == ReportTypeRead || r.Type == ReportTypeNotRead
+}
+
+/**
+ * return the human readable description of the report: PidTagReportText, else a generated text
+ */
+func (r *Report) Description() string {
+	if r.Text != "" {
+		return r.Text
+	}
+
+	subject := ""
+	if r.OriginalSubject != "" {
+		subject = " \"" + r.OriginalSubject + "\""
+	}
+	switch r.Type {
+	case ReportTypeRead:
+		return "Your message" + subject + " was read."
+	case ReportTypeNotRead:
+		return "Your message" + subject + " was deleted without being read."
+	case ReportTypeDelivery:
+		return "Your message" + subject + " was delivered to the following recipients:\r\n" + r.recipientList()
+	}
+	return "Your message" + subject + " could not be delivered to the following recipients:\r\n" + r.recipientList()
+}
+
+func (r *Report) recipientList() string {
+	var list strings.Builder
+	for _, recipient := range r.Recipients {
+		list.WriteString("    " + recipientLabel(recipient))
+		if info := recipient.GetSupplementaryInfo(); info != "" && r.Type == ReportTypeNonDelivery {
+			list.WriteString(": " + info)
+		}
+		list.WriteString("\r\n")
+	}
+	return list.String()
+}
+
+/**
+ * "Name <address>", the name or the address of a recipient
+ */
+func recipientLabel(r *Recipient) string {
+	name, address := r.GetDisplayName(), r.GetSmtpAddress()
+	if address == "" {
+		address = r.GetEmailAddress()
+	}
+	switch {
+	case name != "" && address != "" && name != address:
+		return name + " <" + address + ">"
+	case address != "":
+		return address
+	}
+	return name
+}
+
+/**
+ * return the RFC 3463 status of a recipient of the report: 2.0.0 for the delivered messages, the status mapped from the
+ * diagnostic code for the failures (5.0.0 if unknown)
+ */
+func (r *Report) RecipientStatus(recipient *Recipient) string {
+	if r.Type == ReportTypeDelivery {
+		return "2.0.0"
+	}
+	if status, ok := ndrDiagStatusCodes[recipient.GetNonDeliveryDiagCode()]; ok {
+		return status
+	}
+	return "5.0.0"
+}
+
+/**
+ * return the description of the report of a message without body; "" if the message is not a report
+ */
+func (t *TnefObject) GetReportDescription() string {
+	if r := t.GetReport(); r != nil {
+		return r.Description()
+	}
+	return ""
+}
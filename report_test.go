@@ -0,0 +1,115 @@
+package tnefdecoder
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+/**
+ * a report of the given type about a message sent to two recipients
+ */
+func reportTestMessage(reportType string) *TnefObject {
+	t := &TnefObject{}
+	t.SetAttribute(NewMappedStringAttribute(AttrLevelMessage, AttMessageClass, "REPORT.IPM.Note."+reportType))
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagOriginalSubject, "Quarterly report"))
+	t.SetAttribute(NewMapiStringAttribute(MapiPidTagOriginalMessageId, "<original@example.com>"))
+	t.SetAttribute(NewMapiTimeAttribute(MapiPidTagOriginalSubmitTime, time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)))
+	t.SetAttribute(NewMapiTimeAttribute(MapiPidTagReportTime, time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)))
+	setMessageSender(t, "Postmaster", "postmaster@example.com")
+	return t
+}
+
+func reportTestRecipient(address string, diagCode int, info string) *Recipient {
+	r := NewSmtpRecipient(address, address, 1)
+	r.SetAttribute(NewMapiIntAttribute(MapiPidTagNonDeliveryReportDiagCode, diagCode))
+	if info != "" {
+		r.SetAttribute(NewMapiStringAttribute(MapiPidTagSupplementaryInfo, info))
+	}
+	return r
+}
+
+func reportTestStatus(t *TnefObject) string {
+	r := t.GetReport()
+	part := newMimeReportPart(r, newMimeTextPart("text/plain", []byte(r.Description())), t)
+	return string(part.Parts[1].Body)
+}
+
+func TestDsnNonDeliveryReport(t *testing.T) {
+	tObj := reportTestMessage(ReportTypeNonDelivery)
+	tObj.SetAttribute(NewMapiStringAttribute(MapiPidTagReportingMessageTransferAgent, "mx1.example.com"))
+	tObj.Recipients = append(tObj.Recipients,
+		reportTestRecipient("john@example.net", NdrDiagMailRecipientUnknown, "550 5.1.1 User unknown"),
+		reportTestRecipient("jane@example.net", NdrDiagMtsCongested, "452 4.3.1 No memory\r\nX-Injected: 1"),
+		reportTestRecipient("joe@example.net", NdrDiagMaximumTimeExpired, ""),
+	)
+
+	want := "Reporting-MTA: dns; mx1.example.com\r\n" +
+		"\r\n" +
+		"Final-Recipient: rfc822; john@example.net\r\n" +
+		"Action: failed\r\n" +
+		"Status: 5.1.1\r\n" +
+		"Diagnostic-Code: X-Exchange; 550 5.1.1 User unknown\r\n" +
+		"Last-Attempt-Date: Fri, 01 Mar 2024 09:30:00 +0000\r\n" +
+		"\r\n" +
+		"Final-Recipient: rfc822; jane@example.net\r\n" +
+		"Action: failed\r\n" +
+		"Status: 5.4.5\r\n" +
+		"Diagnostic-Code: X-Exchange; 452 4.3.1 No memory  X-Injected: 1\r\n" +
+		"Last-Attempt-Date: Fri, 01 Mar 2024 09:30:00 +0000\r\n" +
+		"\r\n" +
+		"Final-Recipient: rfc822; joe@example.net\r\n" +
+		"Action: failed\r\n" +
+		"Status: 5.4.7\r\n" +
+		"Last-Attempt-Date: Fri, 01 Mar 2024 09:30:00 +0000\r\n"
+	if got := reportTestStatus(tObj); got != want {
+		t.Errorf("delivery status\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDsnDeliveryReport(t *testing.T) {
+	tObj := reportTestMessage(ReportTypeDelivery)
+	r := NewSmtpRecipient("John", "john@example.net", 1)
+	r.SetAttribute(NewMapiTimeAttribute(MapiPidTagDeliverTime, time.Date(2024, 3, 1, 8, 1, 0, 0, time.UTC)))
+	tObj.Recipients = append(tObj.Recipients, r, NewSmtpRecipient("Other", "other@example.net", 1))
+
+	// the MTA is the domain of the sender of the report; only the recipient with a delivery time is reported
+	want := "Reporting-MTA: dns; example.com\r\n" +
+		"\r\n" +
+		"Final-Recipient: rfc822; john@example.net\r\n" +
+		"Action: delivered\r\n" +
+		"Status: 2.0.0\r\n" +
+		"Last-Attempt-Date: Fri, 01 Mar 2024 08:01:00 +0000\r\n"
+	if got := reportTestStatus(tObj); got != want {
+		t.Errorf("delivery status\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMdnReadReceipt(t *testing.T) {
+	for _, test := range []struct {
+		reportType  string
+		disposition string
+	}{
+		{ReportTypeRead, "manual-action/MDN-sent-manually; displayed"},
+		{ReportTypeNotRead, "automatic-action/MDN-sent-automatically; deleted"},
+	} {
+		tObj := reportTestMessage(test.reportType)
+		setMessageSender(tObj, "Reader", "reader@example.net")
+
+		want := "Reporting-UA: " + ReportingUserAgent + "\r\n" +
+			"Final-Recipient: rfc822; reader@example.net\r\n" +
+			"Original-Message-ID: <original@example.com>\r\n" +
+			"Disposition: " + test.disposition + "\r\n"
+		if got := reportTestStatus(tObj); got != want {
+			t.Errorf("%s: disposition notification\n%s\nwant\n%s", test.reportType, got, want)
+		}
+	}
+}
+
+func TestReportOriginalHeaders(t *testing.T) {
+	r := reportTestMessage(ReportTypeNonDelivery).GetReport()
+	want := "Date: Fri, 01 Mar 2024 08:00:00 +0000\r\nMessage-ID: <original@example.com>\r\nSubject: Quarterly report"
+	if got := strings.Join(originalMessageHeaders(r), "\r\n"); got != want {
+		t.Errorf("headers\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,280 @@
+/**
+ * the sender of the message and the users it was sent or received on behalf of ([MS-OXOMSG] section 2.2.1, [MS-OXTNEF]
+ * section 2.1.3.3): the PidTagSender*, PidTagSentRepresenting* and PidTagReceivedRepresenting* properties merged with
+ * the legacy attFrom, attSentFor, attOwner and attDelegate attributes
+ */
+
+package tnefdecoder
+
+import (
+	"bytes"
+	"net/mail"
+	"strings"
+)
+
+/**
+ * an address of the message (sender, represented sender, represented receiver)
+ */
+type MessageAddress struct {
+	DisplayName  string
+	AddressType  string // SMTP, EX, ...
+	EmailAddress string // the address in the format of the address type (the X500 DN for EX)
+	SmtpAddress  string // the SMTP address of the EX addresses
+	EntryId      []byte
+	SearchKey    []byte // ADDRTYPE:ADDRESS, upper case
+
+	// the code page of the 8-bit strings of the entry ID (the code page of the message)
+	codepage int
+}
+
+/**
+ * the properties of an address: name, address type, email address, SMTP address, entry ID, search key
+ */
+type messageAddressIds [6]int
+
+var (
+	senderAddressIds               = messageAddressIds{MapiPidTagSenderName, MapiPidTagSenderAddressType, MapiPidTagSenderEmailAddress, MapiPidTagSenderSmtpAddress, MapiPidTagSenderEntryId, MapiPidTagSenderSearchKey}
+	sentRepresentingAddressIds     = messageAddressIds{MapiPidTagSentRepresentingName, MapiPidTagSentRepresentingAddressType, MapiPidTagSentRepresentingEmailAddress, MapiPidTagSentRepresentingSmtpAddress, MapiPidTagSentRepresentingEntryId, MapiPidTagSentRepresentingSearchKey}
+	receivedRepresentingAddressIds = messageAddressIds{MapiPidTagReceivedRepresentingName, MapiPidTagReceivedRepresentingAddressType, MapiPidTagReceivedRepresentingEmailAddress, MapiPidTagReceivedRepresentingSmtpAddress, MapiPidTagReceivedRepresentingEntryId, MapiPidTagReceivedRepresentingSearchKey}
+)
+
+/**
+ * the sender of the message: PidTagSender* or attFrom; nil if the message has no sender
+ */
+func (t *TnefObject) Sender() *MessageAddress {
+	a := t.messageAddress(senderAddressIds)
+	if attr := t.GetAttribute(AttFrom, "mapped"); attr != nil {
+		a.merge(decodeTnefTriple(attr.Data, t.Codepage))
+	}
+	a.resolve(t.messageAddress(sentRepresentingAddressIds))
+	t.resolveMessageAddress(a)
+	return a.valid()
+}
+
+/**
+ * the user on whose behalf the message was sent: PidTagSentRepresenting*, attSentFor or (except for the meeting
+ * responses) attOwner; nil if the message has no represented sender
+ */
+func (t *TnefObject) SentRepresenting() *MessageAddress {
+	a := t.messageAddress(sentRepresentingAddressIds)
+	if attr := t.GetAttribute(AttSentFor, "mapped"); attr != nil {
+		a.merge(decodeTnefOwner(attr.Data, t.Codepage))
+	}
+	if attr := t.GetAttribute(AttOwner, "mapped"); attr != nil && !t.isMeetingResponse() {
+		a.merge(decodeTnefOwner(attr.Data, t.Codepage))
+	}
+	a.resolve(t.messageAddress(senderAddressIds))
+	t.resolveMessageAddress(a)
+	return a.valid()
+}
+
+/**
+ * the user on whose behalf the message was received (the delegator): PidTagReceivedRepresenting*, attOwner for the
+ * meeting responses and attDelegate; nil if the message was not received by a delegate
+ */
+func (t *TnefObject) ReceivedRepresenting() *MessageAddress {
+	a := t.messageAddress(receivedRepresentingAddressIds)
+	if attr := t.GetAttribute(AttOwner, "mapped"); attr != nil && t.isMeetingResponse() {
+		a.merge(decodeTnefOwner(attr.Data, t.Codepage))
+	}
+	if attr := t.GetAttribute(AttDelegate, "mapped"); attr != nil && len(a.EntryId) == 0 {
+		a.EntryId = attr.Data
+		a.resolve(nil)
+	}
+	t.resolveMessageAddress(a)
+	return a.valid()
+}
+
+/**
+ * attOwner holds the represented receiver of the meeting responses and the represented sender of the requests
+ */
+func (t *TnefObject) isMeetingResponse() bool {
+	return strings.HasPrefix(strings.ToUpper(t.GetMessageClass()), "IPM.SCHEDULE.MEETING.RESP")
+}
+
+func (t *TnefObject) messageAddress(ids messageAddressIds) *MessageAddress {
+	a := &MessageAddress{
+		DisplayName:  t.GetAttributeStringValue(ids[0], "mapi"),
+		AddressType:  t.GetAttributeStringValue(ids[1], "mapi"),
+		EmailAddress: t.GetAttributeStringValue(ids[2], "mapi"),
+		SmtpAddress:  t.GetAttributeStringValue(ids[3], "mapi"),
+		codepage:     t.Codepage,
+	}
+	if attr := t.GetAttribute(ids[4], "mapi"); attr != nil {
+		a.EntryId = attr.GetBinaryValue()
+	}
+	if attr := t.GetAttribute(ids[5], "mapi"); attr != nil {
+		a.SearchKey = attr.GetBinaryValue()
+	}
+	return a
+}
+
+/**
+ * fill the empty fields from another source of the same address
+ */
+func (a *MessageAddress) merge(other *MessageAddress) {
+	if other == nil {
+		return
+	}
+	if a.DisplayName == "" {
+		a.DisplayName = other.DisplayName
+	}
+	if a.EmailAddress == "" {
+		a.AddressType, a.EmailAddress = other.AddressType, other.EmailAddress
+	}
+	if a.SmtpAddress == "" {
+		a.SmtpAddress = other.SmtpAddress
+	}
+	if len(a.EntryId) == 0 {
+		a.EntryId = other.EntryId
+	}
+	if len(a.SearchKey) == 0 {
+		a.SearchKey = other.SearchKey
+	}
+}
+
+/**
+ * complete the address from the entry ID, and the SMTP address of an EX address from the other address of the
+ * message if it is the same user (the message was not sent on behalf of another user)
+ */
+func (a *MessageAddress) resolve(other *MessageAddress) {
+	if len(a.EntryId) > 0 {
+		if address, err := DecodeEntryIdAddressCodepage(a.EntryId, a.codepage); err == nil {
+			a.merge(&MessageAddress{DisplayName: address.DisplayName, AddressType: address.AddressType, EmailAddress: address.EmailAddress})
+		}
+	}
+	if a.GetSmtpAddress() == "" && a.sameAs(other) {
+		a.SmtpAddress = other.GetSmtpAddress()
+	}
+}
+
+/**
+ * the SMTP address (and the missing display name) of an EX address given by the address resolver
+ */
+func (t *TnefObject) resolveMessageAddress(a *MessageAddress) {
+	if a.GetSmtpAddress() != "" {
+		return
+	}
+	if resolved := t.resolveAddress(a.AddressType, a.EmailAddress, a.EntryId); resolved != nil {
+		a.SmtpAddress = resolved.SmtpAddress
+		if a.DisplayName == "" {
+			a.DisplayName = resolved.DisplayName
+		}
+	}
+}
+
+func (a *MessageAddress) valid() *MessageAddress {
+	if a.DisplayName == "" && a.EmailAddress == "" && a.SmtpAddress == "" {
+		return nil
+	}
+	return a
+}
+
+/**
+ * check if the addresses designate the same user: same search key, entry ID or email address
+ */
+func (a *MessageAddress) sameAs(other *MessageAddress) bool {
+	if a == nil || other == nil {
+		return false
+	}
+	if len(a.SearchKey) > 0 && bytes.Equal(a.SearchKey, other.SearchKey) {
+		return true
+	}
+	if len(a.EntryId) > 0 && bytes.Equal(a.EntryId, other.EntryId) {
+		return true
+	}
+	return a.EmailAddress != "" && strings.EqualFold(a.AddressType, other.AddressType) && strings.EqualFold(a.EmailAddress, other.EmailAddress)
+}
+
+/**
+ * return the SMTP address: the SMTP address property, or the email address if the address type is SMTP; "" for an
+ * EX address without SMTP address
+ */
+func (a *MessageAddress) GetSmtpAddress() string {
+	if a == nil {
+		return ""
+	}
+	if a.SmtpAddress != "" {
+		return a.SmtpAddress
+	}
+	if a.AddressType == "" || strings.EqualFold(a.AddressType, "SMTP") {
+		return a.EmailAddress
+	}
+	return ""
+}
+
+/**
+ * decode the entry ID of the address; nil if the address has no entry ID
+ */
+func (a *MessageAddress) GetEntryId() *EntryId {
+	if a == nil || len(a.EntryId) == 0 {
+		return nil
+	}
+	e, _ := DecodeEntryIdCodepage(a.EntryId, a.codepage)
+	return e
+}
+
+/**
+ * format the address for a MIME header (RFC 5322 mailbox, the display name is RFC 2047 encoded); "" if the address
+ * has no SMTP address
+ */
+func (a *MessageAddress) MimeAddress() string {
+	address := a.GetSmtpAddress()
+	if address == "" {
+		return ""
+	}
+	return (&mail.Address{Name: a.DisplayName, Address: address}).String()
+}
+
+/**
+ * split an address of the legacy attributes: ADDRTYPE:ADDRESS
+ */
+func splitTnefAddress(name string, address string) *MessageAddress {
+	a := &MessageAddress{DisplayName: name, EmailAddress: address}
+	if i := strings.IndexByte(address, ':'); i > 0 {
+		a.AddressType, a.EmailAddress = address[:i], address[i+1:]
+	}
+	return a.valid()
+}
+
+/**
+ * decode the TRP structure of attFrom: trpid, cbgrtrp, cch, cb (UINT16), the display name (cch bytes) and the address
+ * (cb bytes), both null terminated and padded
+ */
+func decodeTnefTriple(b []byte, codepage int) *MessageAddress {
+	if len(b) < 8 {
+		return nil
+	}
+	leDecoder := new(LittleEndianDecoder)
+	nameLength := int(leDecoder.Uint16(b[4:6]))
+	data := b[8:]
+	if nameLength > len(data) {
+		return nil
+	}
+	name := DecodeCodepage(bytes.TrimRight(data[:nameLength], "\x00"), codepage)
+	address := bytes.TrimLeft(data[nameLength:], "\x00")
+	if i := bytes.IndexByte(address, 0); i >= 0 {
+		address = address[:i]
+	}
+	return splitTnefAddress(name, DecodeCodepage(address, codepage))
+}
+
+/**
+ * decode attOwner and attSentFor: the length (UINT16) and the display name, the length (UINT16) and the address
+ */
+func decodeTnefOwner(b []byte, codepage int) *MessageAddress {
+	leDecoder := new(LittleEndianDecoder)
+	values := []string{}
+	for len(values) < 2 && len(b) >= 2 {
+		length := int(leDecoder.Uint16(b[:2]))
+		if length > len(b)-2 {
+			return nil
+		}
+		values = append(values, DecodeCodepage(bytes.TrimRight(b[2:2+length], "\x00"), codepage))
+		b = b[2+length:]
+	}
+	if len(values) < 2 {
+		return nil
+	}
+	return splitTnefAddress(values[0], values[1])
+}
@@ -0,0 +1,193 @@
+package tnefdecoder
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+/**
+ * encode the TRP structure of attFrom
+ */
+func senderTestTriple(name string, address string) *Attribute {
+	nameData := append([]byte(name), 0)
+	addressData := append([]byte(address), 0)
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint16(b[0:], 4) // trpidOneOff
+	binary.LittleEndian.PutUint16(b[2:], uint16(8+len(nameData)+len(addressData)))
+	binary.LittleEndian.PutUint16(b[4:], uint16(len(nameData)))
+	binary.LittleEndian.PutUint16(b[6:], uint16(len(addressData)))
+	b = append(append(b, nameData...), addressData...)
+	return &Attribute{Type: "mapped", Level: AttrLevelMessage, Id: AttFrom, Data: b}
+}
+
+/**
+ * encode attOwner / attSentFor: the length and the display name, the length and the address
+ */
+func senderTestOwner(id int, name string, address string) *Attribute {
+	b := []byte{}
+	for _, v := range []string{name, address} {
+		data := append([]byte(v), 0)
+		b = append(b, byte(len(data)), byte(len(data)>>8))
+		b = append(b, data...)
+	}
+	return &Attribute{Type: "mapped", Level: AttrLevelMessage, Id: id, Data: b}
+}
+
+func TestSender(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes []*Attribute
+		want       MessageAddress
+	}{
+		{"MAPI properties", []*Attribute{
+			NewMapiStringAttribute(MapiPidTagSenderName, "Jane Doe"),
+			NewMapiStringAttribute(MapiPidTagSenderAddressType, "SMTP"),
+			NewMapiStringAttribute(MapiPidTagSenderEmailAddress, "jane@example.com"),
+		}, MessageAddress{DisplayName: "Jane Doe", AddressType: "SMTP", EmailAddress: "jane@example.com"}},
+		{"attFrom", []*Attribute{senderTestTriple("Jane Doe", "SMTP:jane@example.com")},
+			MessageAddress{DisplayName: "Jane Doe", AddressType: "SMTP", EmailAddress: "jane@example.com"}},
+		{"name from the MAPI property, address from attFrom", []*Attribute{
+			NewMapiStringAttribute(MapiPidTagSenderName, "Doe, Jane"),
+			senderTestTriple("Jane Doe", "SMTP:jane@example.com"),
+		}, MessageAddress{DisplayName: "Doe, Jane", AddressType: "SMTP", EmailAddress: "jane@example.com"}},
+		{"address from the entry ID", []*Attribute{
+			NewMapiBinaryAttribute(MapiPidTagSenderEntryId, EncodeOneOffEntryId("Jane Doe", "SMTP", "jane@example.com")),
+		}, MessageAddress{DisplayName: "Jane Doe", AddressType: "SMTP", EmailAddress: "jane@example.com"}},
+		{"SMTP address of the represented sender", []*Attribute{
+			NewMapiStringAttribute(MapiPidTagSenderName, "Jane Doe"),
+			NewMapiStringAttribute(MapiPidTagSenderAddressType, "EX"),
+			NewMapiStringAttribute(MapiPidTagSenderEmailAddress, "/o=Example/cn=jane"),
+			NewMapiStringAttribute(MapiPidTagSentRepresentingAddressType, "EX"),
+			NewMapiStringAttribute(MapiPidTagSentRepresentingEmailAddress, "/O=EXAMPLE/CN=JANE"),
+			NewMapiStringAttribute(MapiPidTagSentRepresentingSmtpAddress, "jane@example.com"),
+		}, MessageAddress{DisplayName: "Jane Doe", AddressType: "EX", EmailAddress: "/o=Example/cn=jane", SmtpAddress: "jane@example.com"}},
+		{"SMTP address of another represented sender", []*Attribute{
+			NewMapiStringAttribute(MapiPidTagSenderAddressType, "EX"),
+			NewMapiStringAttribute(MapiPidTagSenderEmailAddress, "/o=Example/cn=jane"),
+			NewMapiStringAttribute(MapiPidTagSentRepresentingAddressType, "EX"),
+			NewMapiStringAttribute(MapiPidTagSentRepresentingEmailAddress, "/o=Example/cn=boss"),
+			NewMapiStringAttribute(MapiPidTagSentRepresentingSmtpAddress, "boss@example.com"),
+		}, MessageAddress{AddressType: "EX", EmailAddress: "/o=Example/cn=jane"}},
+	}
+	for _, tt := range tests {
+		tObj := &TnefObject{}
+		for _, attr := range tt.attributes {
+			tObj.SetAttribute(attr)
+		}
+		a := tObj.Sender()
+		if a == nil {
+			t.Errorf("%s: no sender", tt.name)
+			continue
+		}
+		if a.DisplayName != tt.want.DisplayName || a.AddressType != tt.want.AddressType || a.EmailAddress != tt.want.EmailAddress || a.SmtpAddress != tt.want.SmtpAddress {
+			t.Errorf("%s: %+v, want %+v", tt.name, a, tt.want)
+		}
+	}
+
+	if a := (&TnefObject{}).Sender(); a != nil {
+		t.Errorf("no sender: %+v", a)
+	}
+}
+
+func TestSenderAddressResolver(t *testing.T) {
+	resolver := MapAddressResolver{}
+	resolver.Add("/o=Example/cn=jane", "jane@example.com", "Jane Doe")
+
+	tObj := &TnefObject{AddressResolver: resolver}
+	tObj.SetAttribute(NewMapiBinaryAttribute(MapiPidTagSenderEntryId, EncodeOneOffEntryId("", "EX", "/o=Example/cn=jane")))
+	a := tObj.Sender()
+	if a.GetSmtpAddress() != "jane@example.com" || a.DisplayName != "Jane Doe" {
+		t.Errorf("%+v", a)
+	}
+
+	// an unknown EX address has no SMTP address and no MIME address
+	tObj = &TnefObject{AddressResolver: resolver}
+	tObj.SetAttribute(NewMapiStringAttribute(MapiPidTagSenderName, "John"))
+	tObj.SetAttribute(NewMapiStringAttribute(MapiPidTagSenderAddressType, "EX"))
+	tObj.SetAttribute(NewMapiStringAttribute(MapiPidTagSenderEmailAddress, "/o=Example/cn=john"))
+	if a := tObj.Sender(); a.GetSmtpAddress() != "" || a.MimeAddress() != "" {
+		t.Errorf("unknown: %+v %q", a, a.MimeAddress())
+	}
+}
+
+func TestSentRepresenting(t *testing.T) {
+	tests := []struct {
+		name         string
+		messageClass string
+		attributes   []*Attribute
+		sent         string
+		received     string
+	}{
+		{"attSentFor", "IPM.Note", []*Attribute{senderTestOwner(AttSentFor, "Boss", "SMTP:boss@example.com")}, "boss@example.com", ""},
+		{"attOwner of a request", "IPM.Schedule.Meeting.Request", []*Attribute{senderTestOwner(AttOwner, "Boss", "SMTP:boss@example.com")}, "boss@example.com", ""},
+		{"attOwner of a response", "IPM.Schedule.Meeting.Resp.Pos", []*Attribute{senderTestOwner(AttOwner, "Boss", "SMTP:boss@example.com")}, "", "boss@example.com"},
+		{"MAPI property first", "IPM.Note", []*Attribute{
+			NewMapiStringAttribute(MapiPidTagSentRepresentingEmailAddress, "team@example.com"),
+			senderTestOwner(AttSentFor, "Boss", "SMTP:boss@example.com"),
+		}, "team@example.com", ""},
+		{"attDelegate", "IPM.Note", []*Attribute{
+			{Type: "mapped", Level: AttrLevelMessage, Id: AttDelegate, Data: EncodeOneOffEntryId("Boss", "SMTP", "boss@example.com")},
+		}, "", "boss@example.com"},
+	}
+	for _, tt := range tests {
+		tObj := &TnefObject{}
+		tObj.SetAttribute(NewMappedStringAttribute(AttrLevelMessage, AttMessageClass, tt.messageClass))
+		for _, attr := range tt.attributes {
+			tObj.SetAttribute(attr)
+		}
+		if v := tObj.SentRepresenting().GetSmtpAddress(); v != tt.sent {
+			t.Errorf("%s: sent representing %q, want %q", tt.name, v, tt.sent)
+		}
+		if v := tObj.ReceivedRepresenting().GetSmtpAddress(); v != tt.received {
+			t.Errorf("%s: received representing %q, want %q", tt.name, v, tt.received)
+		}
+	}
+}
+
+func TestExportMimeSenderHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		attributes []*Attribute
+		from       string
+		sender     string
+	}{
+		{"sender only", []*Attribute{senderTestTriple("Jane Doe", "SMTP:jane@example.com")}, `"Jane Doe" <jane@example.com>`, ""},
+		{"sent by the represented user", []*Attribute{
+			senderTestTriple("Jane Doe", "SMTP:jane@example.com"),
+			senderTestOwner(AttSentFor, "Jane Doe", "SMTP:jane@example.com"),
+		}, `"Jane Doe" <jane@example.com>`, ""},
+		{"sent on behalf of another user", []*Attribute{
+			senderTestTriple("Jane Doe", "SMTP:jane@example.com"),
+			senderTestOwner(AttSentFor, "Boss", "SMTP:boss@example.com"),
+		}, `"Boss" <boss@example.com>`, `"Jane Doe" <jane@example.com>`},
+		{"represented user without SMTP address", []*Attribute{
+			senderTestTriple("Jane Doe", "SMTP:jane@example.com"),
+			senderTestOwner(AttSentFor, "Boss", "EX:/o=Example/cn=boss"),
+		}, `"Jane Doe" <jane@example.com>`, ""},
+	}
+	for _, tt := range tests {
+		tObj := &TnefObject{}
+		tObj.SetAttribute(NewMapiStringAttribute(MapiPidTagBody, "Hello"))
+		for _, attr := range tt.attributes {
+			tObj.SetAttribute(attr)
+		}
+		data, err := ExportMime(tObj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header, _ := mimeTestParts(t, data)
+		if v := header.Get("From"); v != tt.from {
+			t.Errorf("%s: From %q, want %q", tt.name, v, tt.from)
+		}
+		if v := header.Get("Sender"); v != tt.sender {
+			t.Errorf("%s: Sender %q, want %q", tt.name, v, tt.sender)
+		}
+	}
+}
+
+func TestDecodeTnefAddressMalformed(t *testing.T) {
+	checkMalformed(t, func(b []byte) {
+		decodeTnefTriple(b, 1252)
+		decodeTnefOwner(b, 1252)
+	}, senderTestTriple("Jane Doe", "SMTP:jane@example.com").Data, senderTestOwner(AttOwner, "Boss", "SMTP:boss@example.com").Data)
+}
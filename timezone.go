@@ -0,0 +1,664 @@
+/**
+ * time zones of the calendar objects ([MS-OXOCAL] sections 2.2.1.39 - 2.2.1.43):
+ * PidLidTimeZoneStruct (TZSTRUCT), PidLidTimeZoneDescription and the TZDEFINITION structures of
+ * PidLidAppointmentTimeZoneDefinitionStartDisplay, PidLidAppointmentTimeZoneDefinitionEndDisplay and PidLidAppointmentTimeZoneDefinitionRecur
+ */
+
+package tnefdecoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+/**
+ * TZRULE flags
+ */
+const (
+	TimeZoneRuleFlagRecurCurrent = 0x0001 // TZRULE_FLAG_RECUR_CURRENT_TZREG: the rule is the one used by the recurrence
+	TimeZoneRuleFlagEffective    = 0x0002 // TZRULE_FLAG_EFFECTIVE_TZREG: the rule is the current rule of the time zone
+)
+
+// the size of a TZRULE structure
+const timeZoneRuleSize = 66
+
+// TZDEFINITION_FLAG_VALID_KEYNAME: the TZDEFINITION has a key name
+const timeZoneDefinitionFlagKeyName = 0x0002
+
+var ErrInvalidTimeZone = errors.New("invalid time zone definition")
+
+/**
+ * SYSTEMTIME; for the time zone transitions the date is relative if Year is 0:
+ * Day is the occurrence of DayOfWeek in the month (1 - 4, 5 = the last)
+ */
+type SystemTime struct {
+	Year         int
+	Month        int
+	DayOfWeek    int
+	Day          int
+	Hour         int
+	Minute       int
+	Second       int
+	Milliseconds int
+}
+
+/**
+ * the standard and daylight rules of a time zone starting with a year; the biases are in minutes (UTC = local time + bias)
+ * the daylight saving time is not used if the month of the transition dates is 0
+ */
+type TimeZoneRule struct {
+	Flags        int
+	Year         int
+	Bias         int
+	StandardBias int
+	DaylightBias int
+	StandardDate SystemTime
+	DaylightDate SystemTime
+}
+
+/**
+ * a time zone: the Windows name (registry key) and the rules sorted by year
+ */
+type TimeZoneDefinition struct {
+	KeyName string
+	Rules   []*TimeZoneRule
+}
+
+func decodeSystemTime(b []byte) SystemTime {
+	leReader := new(LittleEndianDecoder)
+	v := make([]int, 8)
+	for i := range v {
+		v[i] = int(leReader.Uint16(b[i*2 : i*2+2]))
+	}
+	return SystemTime{v[0], v[1], v[2], v[3], v[4], v[5], v[6], v[7]}
+}
+
+/**
+ * decode a TZDEFINITION structure
+ *
+ * TZDEFINITION = bMajorVersion(1) bMinorVersion(1) cbHeader(2) wReserved(2) cchKeyName(2) KeyName(cchKeyName UTF-16 chars) cRules(2) *TZRULE
+ * TZRULE = bMajorVersion(1) bMinorVersion(1) wReserved(2) wTZRuleFlags(2) wYear(2) X(14) lBias(4) lStandardBias(4) lDaylightBias(4)
+ *          stStandardDate(16) stDaylightDate(16)
+ */
+func DecodeTimeZoneDefinition(b []byte) (*TimeZoneDefinition, error) {
+	leReader := new(LittleEndianDecoder)
+	if len(b) < 10 {
+		return nil, ErrInvalidTimeZone
+	}
+
+	headerSize := int(leReader.Uint16(b[2:4]))
+	keyLength := int(leReader.Uint16(b[6:8])) * 2
+	if 8+keyLength+2 > len(b) || 4+headerSize > len(b) {
+		return nil, ErrInvalidTimeZone
+	}
+
+	d := &TimeZoneDefinition{}
+	d.KeyName = leReader.Utf16(b[8 : 8+keyLength])
+
+	offset := 4 + headerSize
+	rulesCount := int(leReader.Uint16(b[offset-2 : offset]))
+	for i := 0; i < rulesCount; i++ {
+		if offset+timeZoneRuleSize > len(b) {
+			return nil, ErrInvalidTimeZone
+		}
+		rule := b[offset : offset+timeZoneRuleSize]
+		d.Rules = append(d.Rules, &TimeZoneRule{
+			Flags:        int(leReader.Uint16(rule[4:6])),
+			Year:         int(leReader.Uint16(rule[6:8])),
+			Bias:         int(leReader.Int32(rule[22:26])),
+			StandardBias: int(leReader.Int32(rule[26:30])),
+			DaylightBias: int(leReader.Int32(rule[30:34])),
+			StandardDate: decodeSystemTime(rule[34:50]),
+			DaylightDate: decodeSystemTime(rule[50:66]),
+		})
+		offset += timeZoneRuleSize
+	}
+
+	if len(d.Rules) == 0 {
+		return nil, ErrInvalidTimeZone
+	}
+	sort.SliceStable(d.Rules, func(i, j int) bool { return d.Rules[i].Year < d.Rules[j].Year })
+
+	return d, nil
+}
+
+/**
+ * decode a TZSTRUCT (PidLidTimeZoneStruct); the name of the time zone is given by PidLidTimeZoneDescription
+ *
+ * TZSTRUCT = lBias(4) lStandardBias(4) lDaylightBias(4) wStandardYear(2) stStandardDate(16) wDaylightYear(2) stDaylightDate(16)
+ */
+func DecodeTimeZoneStruct(b []byte, name string) (*TimeZoneDefinition, error) {
+	if len(b) < 48 {
+		return nil, ErrInvalidTimeZone
+	}
+	leReader := new(LittleEndianDecoder)
+	rule := &TimeZoneRule{
+		Flags:        TimeZoneRuleFlagEffective | TimeZoneRuleFlagRecurCurrent,
+		Bias:         int(leReader.Int32(b[0:4])),
+		StandardBias: int(leReader.Int32(b[4:8])),
+		DaylightBias: int(leReader.Int32(b[8:12])),
+		StandardDate: decodeSystemTime(b[14:30]),
+		DaylightDate: decodeSystemTime(b[32:48]),
+	}
+	return &TimeZoneDefinition{KeyName: name, Rules: []*TimeZoneRule{rule}}, nil
+}
+
+/**
+ * a time zone with a fixed offset from UTC and without name; the times in this zone are exported in UTC
+ */
+func NewFixedTimeZone(offset time.Duration) *TimeZoneDefinition {
+	return &TimeZoneDefinition{Rules: []*TimeZoneRule{{Bias: -int(offset.Minutes())}}}
+}
+
+/**
+ * the IANA name of the time zone; "" if the Windows name is not known
+ */
+func (d *TimeZoneDefinition) IanaName() string {
+	if d == nil {
+		return ""
+	}
+	return WindowsToIana(d.KeyName)
+}
+
+/**
+ * the TZID of the time zone: the IANA name if known, else the Windows name
+ */
+func (d *TimeZoneDefinition) Tzid() string {
+	if d == nil {
+		return ""
+	}
+	if iana := d.IanaName(); iana != "" {
+		return iana
+	}
+	return d.KeyName
+}
+
+/**
+ * the Go location of the time zone (requires the IANA time zone database)
+ */
+func (d *TimeZoneDefinition) Location() (*time.Location, error) {
+	iana := d.IanaName()
+	if iana == "" {
+		return nil, fmt.Errorf("unknown time zone: %s", d.KeyName)
+	}
+	return time.LoadLocation(iana)
+}
+
+/**
+ * the rule used for a year: the last rule starting before or in that year
+ */
+func (d *TimeZoneDefinition) RuleForYear(year int) *TimeZoneRule {
+	result := d.Rules[0]
+	for _, rule := range d.Rules {
+		if rule.Year <= year {
+			result = rule
+		}
+	}
+	return result
+}
+
+/**
+ * check if the rule has daylight saving time
+ */
+func (r *TimeZoneRule) HasDaylight() bool {
+	return r.StandardDate.Month != 0 && r.DaylightDate.Month != 0
+}
+
+/**
+ * the offsets from UTC of the standard and the daylight time
+ */
+func (r *TimeZoneRule) StandardOffset() time.Duration {
+	return -time.Duration(r.Bias+r.StandardBias) * time.Minute
+}
+
+func (r *TimeZoneRule) DaylightOffset() time.Duration {
+	return -time.Duration(r.Bias+r.DaylightBias) * time.Minute
+}
+
+/**
+ * the local time of the transition in the given year
+ */
+func (s SystemTime) TransitionTime(year int) time.Time {
+	if s.Year != 0 {
+		// absolute date
+		return time.Date(year, time.Month(s.Month), s.Day, s.Hour, s.Minute, s.Second, 0, time.UTC)
+	}
+
+	// the first DayOfWeek of the month, then the n-th occurrence (5 = the last in the month)
+	first := time.Date(year, time.Month(s.Month), 1, s.Hour, s.Minute, s.Second, 0, time.UTC)
+	day := first.AddDate(0, 0, (s.DayOfWeek-int(first.Weekday())+7)%7)
+	for i := 1; i < s.Day; i++ {
+		next := day.AddDate(0, 0, 7)
+		if next.Month() != day.Month() {
+			break
+		}
+		day = next
+	}
+	return day
+}
+
+/**
+ * the offset from UTC of the time zone at the given moment
+ */
+func (d *TimeZoneDefinition) Offset(utc time.Time) time.Duration {
+	utc = utc.UTC()
+	rule := d.RuleForYear(utc.Year())
+	if !rule.HasDaylight() {
+		return rule.StandardOffset()
+	}
+
+	// the daylight time starts at a local standard time and ends at a local daylight time
+	start := rule.DaylightDate.TransitionTime(utc.Year()).Add(-rule.StandardOffset())
+	end := rule.StandardDate.TransitionTime(utc.Year()).Add(-rule.DaylightOffset())
+
+	daylight := false
+	if start.Before(end) {
+		daylight = !utc.Before(start) && utc.Before(end)
+	} else {
+		// southern hemisphere
+		daylight = !utc.Before(start) || utc.Before(end)
+	}
+
+	if daylight {
+		return rule.DaylightOffset()
+	}
+	return rule.StandardOffset()
+}
+
+/**
+ * convert a UTC time to the local time of the zone; the result has the location UTC (wall clock)
+ * a nil time zone is UTC
+ */
+func (d *TimeZoneDefinition) ToLocal(utc time.Time) time.Time {
+	if d == nil || utc.IsZero() {
+		return utc.UTC()
+	}
+	return utc.UTC().Add(d.Offset(utc))
+}
+
+/**
+ * convert a local time (wall clock, any location) of the zone to UTC
+ * a nil time zone is UTC
+ */
+func (d *TimeZoneDefinition) ToUtc(local time.Time) time.Time {
+	local = time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), time.UTC)
+	if d == nil || local.IsZero() {
+		return local
+	}
+	rule := d.RuleForYear(local.Year())
+	utc := local.Add(-rule.StandardOffset())
+	if offset := d.Offset(utc); offset != rule.StandardOffset() {
+		utc = local.Add(-offset)
+	}
+	return utc
+}
+
+/**
+ * the VTIMEZONE component: a STANDARD (and DAYLIGHT) observance for each rule
+ */
+func (d *TimeZoneDefinition) VTimeZone() *ICalComponent {
+	vtimezone := NewICalComponent("VTIMEZONE")
+	vtimezone.AddProperty("TZID", d.Tzid())
+
+	for i, rule := range d.Rules {
+		year := rule.Year
+		if year < 1601 {
+			year = 1601
+		}
+		until := ""
+		if i+1 < len(d.Rules) && d.Rules[i+1].Year > year {
+			// the last moment of the year before the next rule
+			until = ICalFormatDateTime(time.Date(d.Rules[i+1].Year, 1, 1, 0, 0, 0, 0, time.UTC).Add(-rule.StandardOffset() - time.Second))
+		}
+
+		if !rule.HasDaylight() {
+			standard := NewICalComponent("STANDARD")
+			standard.AddProperty("DTSTART", time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Format(ICalDateTimeFormat))
+			standard.AddProperty("TZOFFSETFROM", formatUtcOffset(rule.StandardOffset()))
+			standard.AddProperty("TZOFFSETTO", formatUtcOffset(rule.StandardOffset()))
+			vtimezone.AddComponent(standard)
+			continue
+		}
+
+		vtimezone.AddComponent(timeZoneObservance("STANDARD", rule.StandardDate, year, rule.DaylightOffset(), rule.StandardOffset(), until))
+		vtimezone.AddComponent(timeZoneObservance("DAYLIGHT", rule.DaylightDate, year, rule.StandardOffset(), rule.DaylightOffset(), until))
+	}
+
+	return vtimezone
+}
+
+/**
+ * a STANDARD or DAYLIGHT observance with a yearly RRULE
+ */
+func timeZoneObservance(name string, transition SystemTime, year int, from time.Duration, to time.Duration, until string) *ICalComponent {
+	observance := NewICalComponent(name)
+	observance.AddProperty("DTSTART", transition.TransitionTime(year).Format(ICalDateTimeFormat))
+
+	rule := "FREQ=YEARLY;BYMONTH=" + strconv.Itoa(transition.Month)
+	if transition.Year != 0 {
+		rule += ";BYMONTHDAY=" + strconv.Itoa(transition.Day)
+	} else if transition.Day >= RecurNthLast {
+		rule += ";BYDAY=-1" + icalWeekDays[transition.DayOfWeek%7]
+	} else {
+		rule += ";BYDAY=" + strconv.Itoa(transition.Day) + icalWeekDays[transition.DayOfWeek%7]
+	}
+	if until != "" {
+		rule += ";UNTIL=" + until
+	}
+	observance.AddProperty("RRULE", rule)
+
+	observance.AddProperty("TZOFFSETFROM", formatUtcOffset(from))
+	observance.AddProperty("TZOFFSETTO", formatUtcOffset(to))
+	return observance
+}
+
+/**
+ * UTC-OFFSET value: +HHMM / -HHMM
+ */
+func formatUtcOffset(offset time.Duration) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	minutes := int(offset.Minutes())
+	return fmt.Sprintf("%s%02d%02d", sign, minutes/60, minutes%60)
+}
+
+/**
+ * the time zone of the appointment: PidLidAppointmentTimeZoneDefinitionRecur for the recurring appointments,
+ * PidLidAppointmentTimeZoneDefinitionStartDisplay (or EndDisplay for the end) for the others,
+ * then PidLidTimeZoneStruct with PidLidTimeZoneDescription; nil if the object has no time zone
+ */
+func (t *TnefObject) GetAppointmentTimeZone(recurring bool, end bool) *TimeZoneDefinition {
+	candidates := []int{MapiPidLidAppointmentTimeZoneDefinitionStartDisplay}
+	if end {
+		candidates = []int{MapiPidLidAppointmentTimeZoneDefinitionEndDisplay, MapiPidLidAppointmentTimeZoneDefinitionStartDisplay}
+	}
+	if recurring {
+		candidates = append([]int{MapiPidLidAppointmentTimeZoneDefinitionRecur}, candidates...)
+	}
+
+	for _, lid := range candidates {
+		if tz, err := DecodeTimeZoneDefinition(t.GetNamedBinaryValue(PsetidAppointment, lid)); err == nil {
+			return tz
+		}
+	}
+
+	tz, err := DecodeTimeZoneStruct(t.GetNamedBinaryValue(PsetidAppointment, MapiPidLidTimeZoneStruct), t.GetNamedStringValue(PsetidAppointment, MapiPidLidTimeZoneDescription))
+	if err == nil {
+		return tz
+	}
+	return nil
+}
+
+func encodeSystemTime(buf *bytes.Buffer, s SystemTime) {
+	for _, v := range []int{s.Year, s.Month, s.DayOfWeek, s.Day, s.Hour, s.Minute, s.Second, s.Milliseconds} {
+		binary.Write(buf, binary.LittleEndian, uint16(v))
+	}
+}
+
+/**
+ * encode the time zone as a TZDEFINITION structure (see DecodeTimeZoneDefinition); the last rule is the effective rule,
+ * and the rule of the recurrence if recurring is set (PidLidAppointmentTimeZoneDefinitionRecur)
+ */
+func (d *TimeZoneDefinition) Encode(recurring bool) []byte {
+	key := utf16.Encode([]rune(d.KeyName))
+
+	buf := &bytes.Buffer{}
+	buf.Write([]byte{0x02, 0x01})
+	binary.Write(buf, binary.LittleEndian, uint16(6+len(key)*2))
+	binary.Write(buf, binary.LittleEndian, uint16(timeZoneDefinitionFlagKeyName))
+	binary.Write(buf, binary.LittleEndian, uint16(len(key)))
+	binary.Write(buf, binary.LittleEndian, key)
+	binary.Write(buf, binary.LittleEndian, uint16(len(d.Rules)))
+
+	for i, rule := range d.Rules {
+		flags := 0
+		if i == len(d.Rules)-1 {
+			flags = TimeZoneRuleFlagEffective
+			if recurring {
+				flags |= TimeZoneRuleFlagRecurCurrent
+			}
+		}
+		year := rule.Year
+		if year < 1601 {
+			year = 1601
+		}
+		buf.Write([]byte{0x02, 0x01})
+		binary.Write(buf, binary.LittleEndian, uint16(timeZoneRuleSize-4))
+		binary.Write(buf, binary.LittleEndian, uint16(flags))
+		binary.Write(buf, binary.LittleEndian, uint16(year))
+		buf.Write(make([]byte, 14))
+		binary.Write(buf, binary.LittleEndian, int32(rule.Bias))
+		binary.Write(buf, binary.LittleEndian, int32(rule.StandardBias))
+		binary.Write(buf, binary.LittleEndian, int32(rule.DaylightBias))
+		encodeSystemTime(buf, rule.StandardDate)
+		encodeSystemTime(buf, rule.DaylightDate)
+	}
+	return buf.Bytes()
+}
+
+/**
+ * encode the rule used in the given year as a TZSTRUCT (PidLidTimeZoneStruct)
+ */
+func (d *TimeZoneDefinition) EncodeStruct(year int) []byte {
+	rule := d.RuleForYear(year)
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, int32(rule.Bias))
+	binary.Write(buf, binary.LittleEndian, int32(rule.StandardBias))
+	binary.Write(buf, binary.LittleEndian, int32(rule.DaylightBias))
+	binary.Write(buf, binary.LittleEndian, uint16(rule.StandardDate.Year))
+	encodeSystemTime(buf, rule.StandardDate)
+	binary.Write(buf, binary.LittleEndian, uint16(rule.DaylightDate.Year))
+	encodeSystemTime(buf, rule.DaylightDate)
+	return buf.Bytes()
+}
+
+/**
+ * the Windows name of an iCalendar TZID: the TZID if it is a Windows name, the Windows name of an IANA zone, else the TZID
+ */
+func windowsTimeZoneName(tzid string) string {
+	tzid = strings.TrimSpace(tzid)
+	if WindowsToIana(tzid) != "" {
+		return tzid
+	}
+	// the TZID of some clients has a prefix: /mozilla.org/20050126_1/Europe/Berlin, /Europe/Berlin
+	for name := tzid; name != ""; {
+		if windows := IanaToWindows(strings.TrimPrefix(name, "/")); windows != "" {
+			return windows
+		}
+		slash := strings.Index(strings.TrimPrefix(name, "/"), "/")
+		if slash < 0 {
+			break
+		}
+		name = strings.TrimPrefix(name, "/")[slash:]
+	}
+	return tzid
+}
+
+/**
+ * create a time zone from a VTIMEZONE component: the last STANDARD and DAYLIGHT observances give the rule
+ */
+func TimeZoneFromVTimeZone(vtimezone *ICalComponent) (*TimeZoneDefinition, error) {
+	latest := func(name string) *ICalComponent {
+		var result *ICalComponent
+		var resultStart time.Time
+		for _, observance := range vtimezone.GetComponents(name) {
+			start, _, _, err := ParseICalDateTime(observance.GetProperty("DTSTART").GetText())
+			if err == nil && (result == nil || !start.Before(resultStart)) {
+				result, resultStart = observance, start
+			}
+		}
+		return result
+	}
+
+	standard, daylight := latest("STANDARD"), latest("DAYLIGHT")
+	if standard == nil {
+		standard, daylight = daylight, nil
+	}
+	if standard == nil {
+		return nil, ErrInvalidTimeZone
+	}
+	standardOffset, err := parseUtcOffset(standard.GetProperty("TZOFFSETTO").GetText())
+	if err != nil {
+		return nil, err
+	}
+
+	rule := &TimeZoneRule{Flags: TimeZoneRuleFlagEffective, Bias: -int(standardOffset.Minutes())}
+	if daylight != nil && daylight.GetProperty("RRULE") != nil && standard.GetProperty("RRULE") != nil {
+		daylightOffset, err := parseUtcOffset(daylight.GetProperty("TZOFFSETTO").GetText())
+		if err != nil {
+			return nil, err
+		}
+		rule.DaylightBias = -int((daylightOffset - standardOffset).Minutes())
+		rule.StandardDate = observanceTransition(standard)
+		rule.DaylightDate = observanceTransition(daylight)
+		if !rule.HasDaylight() {
+			rule.DaylightBias = 0
+			rule.StandardDate, rule.DaylightDate = SystemTime{}, SystemTime{}
+		}
+	}
+
+	tzid := vtimezone.GetProperty("TZID").GetText()
+	return &TimeZoneDefinition{KeyName: windowsTimeZoneName(tzid), Rules: []*TimeZoneRule{rule}}, nil
+}
+
+/**
+ * the yearly transition of a STANDARD or DAYLIGHT observance: the local time of DTSTART and the month and day of the RRULE
+ */
+func observanceTransition(observance *ICalComponent) SystemTime {
+	start, _, _, err := ParseICalDateTime(observance.GetProperty("DTSTART").GetText())
+	if err != nil {
+		return SystemTime{}
+	}
+	s := SystemTime{Month: int(start.Month()), DayOfWeek: int(start.Weekday()), Day: nthWeekdayOfMonth(start), Hour: start.Hour(), Minute: start.Minute(), Second: start.Second()}
+
+	rule := parseRRuleParts(observance.GetProperty("RRULE").GetText())
+	if month, err := strconv.Atoi(rule["BYMONTH"]); err == nil {
+		s.Month = month
+	}
+	if byDay := rule["BYDAY"]; byDay != "" {
+		nth, weekDay := parseRRuleWeekDay(byDay)
+		if weekDay < 0 {
+			return SystemTime{}
+		}
+		s.DayOfWeek = weekDay
+		switch {
+		case nth < 0:
+			s.Day = RecurNthLast
+		case nth > 0:
+			s.Day = nth
+		}
+		// BYMONTHDAY=8,9,10,11,12,13,14;BYDAY=SU: the second Sunday
+		if days := strings.Split(rule["BYMONTHDAY"], ","); nth == 0 && days[0] != "" {
+			if first, err := strconv.Atoi(days[0]); err == nil && first > 0 {
+				s.Day = (first-1)/7 + 1
+			}
+		}
+		if setPos, err := strconv.Atoi(rule["BYSETPOS"]); err == nil && nth == 0 {
+			s.Day = setPos
+			if setPos < 0 {
+				s.Day = RecurNthLast
+			}
+		}
+	}
+	return s
+}
+
+/**
+ * the occurrence of the day of the week in the month: 1 - 4, 5 for the last one
+ */
+func nthWeekdayOfMonth(v time.Time) int {
+	if v.AddDate(0, 0, 7).Month() != v.Month() {
+		return RecurNthLast
+	}
+	return (v.Day()-1)/7 + 1
+}
+
+/**
+ * parse a UTC-OFFSET value: +HHMM[SS] / -HHMM[SS]
+ */
+func parseUtcOffset(value string) (time.Duration, error) {
+	if len(value) < 5 || (value[0] != '+' && value[0] != '-') {
+		return 0, ErrInvalidTimeZone
+	}
+	hours, err1 := strconv.Atoi(value[1:3])
+	minutes, err2 := strconv.Atoi(value[3:5])
+	if err1 != nil || err2 != nil {
+		return 0, ErrInvalidTimeZone
+	}
+	offset := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+	if value[0] == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}
+
+/**
+ * create a time zone from the rules of a Go location in the given year (requires the IANA time zone database)
+ * the transitions are found by comparing the offsets of the days of the year
+ */
+func TimeZoneFromLocation(name string, location *time.Location, year int) *TimeZoneDefinition {
+	offsetAt := func(v time.Time) time.Duration {
+		_, offset := v.In(location).Zone()
+		return time.Duration(offset) * time.Second
+	}
+
+	// the transitions: the first minute with the new offset
+	type transition struct {
+		at   time.Time
+		from time.Duration
+		to   time.Duration
+	}
+	transitions := []transition{}
+	day := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	for day.Year() == year {
+		next := day.AddDate(0, 0, 1)
+		if from, to := offsetAt(day), offsetAt(next); from != to {
+			low, high := day, next
+			for high.Sub(low) > time.Minute {
+				middle := low.Add(high.Sub(low) / 2).Truncate(time.Minute)
+				if offsetAt(middle) == from {
+					low = middle
+				} else {
+					high = middle
+				}
+			}
+			transitions = append(transitions, transition{high, from, to})
+		}
+		day = next
+	}
+
+	rule := &TimeZoneRule{Flags: TimeZoneRuleFlagEffective, Year: year, Bias: -int(offsetAt(time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)).Minutes())}
+	if len(transitions) == 2 {
+		standardOffset := transitions[0].from
+		if transitions[0].to < standardOffset {
+			standardOffset = transitions[0].to
+		}
+		rule.Bias = -int(standardOffset.Minutes())
+		for _, t := range transitions {
+			// the local time before the transition
+			local := t.at.Add(t.from)
+			s := SystemTime{Month: int(local.Month()), DayOfWeek: int(local.Weekday()), Day: nthWeekdayOfMonth(local), Hour: local.Hour(), Minute: local.Minute()}
+			if t.to > t.from {
+				rule.DaylightDate = s
+				rule.DaylightBias = -int((t.to - t.from).Minutes())
+			} else {
+				rule.StandardDate = s
+			}
+		}
+	} else if len(transitions) > 0 {
+		// a change of the standard offset: the offset at the end of the year
+		rule.Bias = -int(transitions[len(transitions)-1].to.Minutes())
+	}
+
+	return &TimeZoneDefinition{KeyName: windowsTimeZoneName(name), Rules: []*TimeZoneRule{rule}}
+}
@@ -0,0 +1,135 @@
+package tnefdecoder
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"time"
+)
+
+/**
+ * W. Europe Standard Time: UTC+1, daylight time from the last Sunday of March 2:00 to the last Sunday of October 3:00,
+ * with an older rule without daylight time
+ */
+func timeZoneTestDefinition() *TimeZoneDefinition {
+	return &TimeZoneDefinition{
+		KeyName: "W. Europe Standard Time",
+		Rules: []*TimeZoneRule{
+			{Year: 1601, Bias: -60},
+			{
+				Flags:        TimeZoneRuleFlagEffective,
+				Year:         2007,
+				Bias:         -60,
+				DaylightBias: -60,
+				StandardDate: SystemTime{Month: 10, Day: 5, Hour: 3},
+				DaylightDate: SystemTime{Month: 3, Day: 5, Hour: 2},
+			},
+		},
+	}
+}
+
+func TestDecodeTimeZoneDefinition(t *testing.T) {
+	want := timeZoneTestDefinition()
+	got, err := DecodeTimeZoneDefinition(want.Encode(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("%+v, want %+v", got, want)
+	}
+	if got.IanaName() != "Europe/Berlin" {
+		t.Errorf("IANA name %q", got.IanaName())
+	}
+
+	tests := []struct {
+		utc  time.Time
+		want time.Duration
+	}{
+		{time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC), time.Hour},
+		{time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC), 2 * time.Hour},
+		// the transitions of 2024: March 31 1:00 UTC and October 27 1:00 UTC
+		{time.Date(2024, 3, 31, 0, 59, 0, 0, time.UTC), time.Hour},
+		{time.Date(2024, 3, 31, 1, 0, 0, 0, time.UTC), 2 * time.Hour},
+		{time.Date(2024, 10, 27, 0, 59, 0, 0, time.UTC), 2 * time.Hour},
+		{time.Date(2024, 10, 27, 1, 0, 0, 0, time.UTC), time.Hour},
+		// the first rule
+		{time.Date(2000, 7, 1, 12, 0, 0, 0, time.UTC), time.Hour},
+	}
+	for _, tt := range tests {
+		if offset := got.Offset(tt.utc); offset != tt.want {
+			t.Errorf("offset at %v = %v, want %v", tt.utc, offset, tt.want)
+		}
+	}
+}
+
+func TestDecodeTimeZoneStruct(t *testing.T) {
+	d := timeZoneTestDefinition()
+	got, err := DecodeTimeZoneStruct(d.EncodeStruct(2024), d.KeyName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Rules) != 1 || got.Rules[0].Bias != -60 || got.Rules[0].DaylightDate != d.Rules[1].DaylightDate {
+		t.Errorf("%+v", got.Rules[0])
+	}
+	if _, err := DecodeTimeZoneStruct(d.EncodeStruct(2024)[:47], d.KeyName); err != ErrInvalidTimeZone {
+		t.Errorf("truncated TZSTRUCT: error %v", err)
+	}
+}
+
+func TestDecodeTimeZoneDefinitionInvalid(t *testing.T) {
+	valid := timeZoneTestDefinition().Encode(false)
+	withValue := func(offset int, v uint16) []byte {
+		b := append([]byte{}, valid...)
+		binary.LittleEndian.PutUint16(b[offset:offset+2], v)
+		return b
+	}
+	rulesOffset := 4 + int(binary.LittleEndian.Uint16(valid[2:4])) - 2
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"header only", valid[:10]},
+		{"key name longer than the data", withValue(6, 0xFFFF)},
+		{"header larger than the data", withValue(2, 0xFFFF)},
+		{"more rules than the data", withValue(rulesOffset, 3)},
+		{"no rules", withValue(rulesOffset, 0)},
+		{"truncated rule", valid[:len(valid)-1]},
+	}
+	for _, tt := range tests {
+		if _, err := DecodeTimeZoneDefinition(tt.data); err != ErrInvalidTimeZone {
+			t.Errorf("%s: error %v, want %v", tt.name, err, ErrInvalidTimeZone)
+		}
+	}
+}
+
+func TestDecodeTimeZoneDefinitionMalformed(t *testing.T) {
+	checkMalformed(t, decodeTimeZone, timeZoneTestDefinition().Encode(true))
+	checkMalformed(t, func(b []byte) {
+		if d, err := DecodeTimeZoneStruct(b, "W. Europe Standard Time"); err == nil {
+			useTimeZone(d)
+		}
+	}, timeZoneTestDefinition().EncodeStruct(2024))
+}
+
+func FuzzDecodeTimeZoneDefinition(f *testing.F) {
+	fuzzDecoder(f, decodeTimeZone, timeZoneTestDefinition().Encode(true))
+}
+
+func decodeTimeZone(data []byte) {
+	if d, err := DecodeTimeZoneDefinition(data); err == nil {
+		useTimeZone(d)
+	}
+}
+
+/**
+ * the conversions and the VTIMEZONE use the transition rules
+ */
+func useTimeZone(d *TimeZoneDefinition) {
+	for _, year := range []int{1601, 2024, 9999} {
+		utc := time.Date(year, 6, 1, 0, 0, 0, 0, time.UTC)
+		d.ToUtc(d.ToLocal(utc))
+	}
+	d.VTimeZone()
+}
@@ -2,6 +2,7 @@ package tnefdecoder
 
 import (
 	"strings"
+	"time"
 	rtf "rtfconverter"
 
 )
@@ -18,8 +19,18 @@ import (
 	// attachments extracted from AttachData
 	Attachments []*Attachment
 
+	// recipients extracted from attRecipTable
+	Recipients []*Recipient
+
 	TextBody []byte
 	HtmlBody []byte
+
+	// code page of the 8-bit strings (PrimaryCodePage from attOemCodepage); attachments inherit the code page of the message
+	Codepage int
+
+	// resolves the EX addresses to SMTP addresses (see TnefDecoder.AddressResolver); the recipients, the attachments
+	// and the embedded messages inherit the resolver of the message
+	AddressResolver AddressResolver
 }
 
 
@@ -35,12 +46,109 @@ func (t *TnefObject) GetAttribute(attrId int, attrType string) (attr *Attribute)
 	return nil
 }
 
+/**
+ * get a named MAPI property by property set GUID and name; the name is the LID (int) or the string name of the property
+ */
+func (t *TnefObject) GetNamedAttribute(guid string, name GenericValue) *Attribute {
+	for _, attr := range t.Attributes {
+		if attr.Type == "mapi" && attr.Id >= 0x8000 && strings.EqualFold(attr.GUID, guid) && attr.PropMapValue == name {
+			return attr
+		}
+	}
+	return nil
+}
+
+/**
+ * return the string value of an attribute, decoded using the code page of the object; "" if the attribute is missing
+ */
+func (t *TnefObject) GetAttributeStringValue(attrId int, attrType string) string {
+	attr := t.GetAttribute(attrId, attrType)
+	if attr == nil {
+		return ""
+	}
+	return attr.GetStringValueCodepage(t.Codepage)
+}
+
+/**
+ * replace the attribute having the same ID and type, or add it
+ */
+func (t *TnefObject) SetAttribute(attr *Attribute) {
+	for i, a := range t.Attributes {
+		if a.Id == attr.Id && a.Type == attr.Type && a.Id < 0x8000 {
+			t.Attributes[i] = attr
+			return
+		}
+	}
+	t.Attributes = append(t.Attributes, attr)
+}
+
+/**
+ * return the string value of a named property; "" if the property is missing
+ */
+func (t *TnefObject) GetNamedStringValue(guid string, name GenericValue) string {
+	attr := t.GetNamedAttribute(guid, name)
+	if attr == nil {
+		return ""
+	}
+	return attr.GetStringValueCodepage(t.Codepage)
+}
+
+/**
+ * return the int value of a named property; 0 if the property is missing
+ */
+func (t *TnefObject) GetNamedIntValue(guid string, name GenericValue) int {
+	attr := t.GetNamedAttribute(guid, name)
+	if attr == nil {
+		return 0
+	}
+	return attr.GetIntValue()
+}
+
+/**
+ * return the boolean value of a named property; false if the property is missing
+ */
+func (t *TnefObject) GetNamedBoolValue(guid string, name GenericValue) bool {
+	attr := t.GetNamedAttribute(guid, name)
+	if attr == nil {
+		return false
+	}
+	return attr.GetBoolValue()
+}
+
+/**
+ * return the time value of a named property; zero time if the property is missing
+ */
+func (t *TnefObject) GetNamedTimeValue(guid string, name GenericValue) time.Time {
+	attr := t.GetNamedAttribute(guid, name)
+	if attr == nil {
+		return time.Time{}
+	}
+	return attr.GetTimeValue()
+}
+
+/**
+ * return the binary value of a named property; nil if the property is missing
+ */
+func (t *TnefObject) GetNamedBinaryValue(guid string, name GenericValue) []byte {
+	attr := t.GetNamedAttribute(guid, name)
+	if attr == nil {
+		return nil
+	}
+	return attr.GetBinaryValue()
+}
+
+/**
+ * PidTagBodyHtml in UTF-8: the binary value is in the code page of PidTagInternetCodepage (else the code page of the
+ * message), the 8-bit string in the code page of the message
+ */
 func (t *TnefObject) GetHtmlBody() []byte {
 	if t.HtmlBody == nil {
 	   attr := t.GetAttribute(MapiPidTagBodyHtml, "mapi")
 
-	   if attr != nil {
-		   t.HtmlBody = []byte(attr.GetStringValue())
+	   if attr != nil && attr.DataType == MapiTypeBinary {
+		   t.HtmlBody = []byte(DecodeCodepage(attr.GetBinaryValue(), t.GetInternetCodepage()))
+	   } else if attr != nil {
+		   t.HtmlBody = []byte(attr.GetStringValueCodepage(t.Codepage))
 	   } else {
 		   t.HtmlBody = []byte("")
 	   }
@@ -49,17 +157,33 @@ func (t *TnefObject) GetHtmlBody() []byte {
    return t.HtmlBody
 }
 
+/**
+ * PidTagInternetCodepage if it is a known code page, else the code page of the message
+ */
+func (t *TnefObject) GetInternetCodepage() int {
+	if attr := t.GetAttribute(MapiPidTagInternetCodepage, "mapi"); attr != nil {
+		codepage := attr.GetIntValue()
+		if _, ok := codepageEncodings[codepage]; ok || codepage == CodepageUtf8 || codepage == CodepageUsAscii {
+			return codepage
+		}
+	}
+	return t.Codepage
+}
+
 func (t *TnefObject) SetHtmlBody(v []byte)  {
 	t.HtmlBody = v
 }
 
 
+/**
+ * PidTagBody in UTF-8 (the 8-bit string is in the code page of the message)
+ */
 func (t *TnefObject) GetTextBody() []byte {
    if t.TextBody == nil {
 	   attr := t.GetAttribute(MapiPidTagBody, "mapi")
 
 	   if attr != nil {
-		   t.TextBody = []byte(attr.GetStringValue())
+		   t.TextBody = []byte(attr.GetStringValueCodepage(t.Codepage))
 	   } else {
 		   t.TextBody = []byte("")
 	   }
@@ -0,0 +1,509 @@
+/**
+ * serialization of the vCards as jCard (RFC 7095) and xCard (RFC 6351)
+ * the vCard built by ExtractVCard is parsed back into properties and each property is converted with the value type
+ * given by the VALUE parameter or by the default type of the property (RFC 6350)
+ */
+
+package tnefdecoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"regexp"
+	"strings"
+	"vcard"
+)
+
+var ErrInvalidVCard = errors.New("invalid vCard")
+
+/**
+ * the output formats of the contact attachments
+ */
+const (
+	VcardFormatText = "vcard" // text/vcard
+	VcardFormatJson = "jcard" // application/vcard+json
+	VcardFormatXml  = "xcard" // application/vcard+xml
+)
+
+// the namespace of the xCard elements
+const XCardNamespace = "urn:ietf:params:xml:ns:vcard-4.0"
+
+/**
+ * the default value types of the vCard 4.0 properties; the other properties are text, the extensions (X-) unknown
+ */
+var vcardValueTypes = map[string]string{
+	"anniversary": "date-and-or-time",
+	"bday":        "date-and-or-time",
+	"caladruri":   "uri",
+	"caluri":      "uri",
+	"fburl":       "uri",
+	"geo":         "uri",
+	"impp":        "uri",
+	"key":         "uri",
+	"lang":        "language-tag",
+	"logo":        "uri",
+	"member":      "uri",
+	"photo":       "uri",
+	"related":     "uri",
+	"rev":         "timestamp",
+	"sound":       "uri",
+	"source":      "uri",
+	"uid":         "uri",
+	"url":         "uri",
+}
+
+/**
+ * the components of the structured properties (the names of the xCard elements)
+ */
+var vcardStructuredComponents = map[string][]string{
+	"n":      {"surname", "given", "additional", "prefix", "suffix"},
+	"adr":    {"pobox", "ext", "street", "locality", "region", "code", "country"},
+	"gender": {"sex", "identity"},
+	"org":    nil, // any number of text components
+}
+
+/**
+ * the properties having a list of values separated by commas
+ */
+var vcardMultiValued = map[string]bool{
+	"categories": true,
+	"nickname":   true,
+}
+
+/**
+ * a content line of a vCard
+ */
+type vcardProperty struct {
+	Group  string
+	Name   string // lower case
+	Params []vcardParameter
+	Value  string // the raw (escaped) value
+}
+
+type vcardParameter struct {
+	Name   string // lower case
+	Values []string
+}
+
+/**
+ * return the values of a parameter; nil if the property does not have the parameter
+ */
+func (p *vcardProperty) param(name string) []string {
+	for _, param := range p.Params {
+		if param.Name == name {
+			return param.Values
+		}
+	}
+	return nil
+}
+
+/**
+ * the value type of the property: the VALUE parameter, else the default type of the property
+ */
+func (p *vcardProperty) valueType() string {
+	if v := p.param("value"); len(v) > 0 {
+		return strings.ToLower(v[0])
+	}
+	if t, ok := vcardValueTypes[p.Name]; ok {
+		return t
+	}
+	if strings.HasPrefix(p.Name, "x-") {
+		return "unknown"
+	}
+	return "text"
+}
+
+/**
+ * parse the properties of a vCard (the content lines between BEGIN:VCARD and END:VCARD)
+ */
+func parseVCard(text string) ([]*vcardProperty, error) {
+	// unfold the lines
+	text = strings.NewReplacer("\r\n ", "", "\r\n\t", "", "\n ", "", "\n\t", "").Replace(text)
+
+	properties := []*vcardProperty{}
+	begin := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		p := parseVCardLine(line)
+		if p == nil {
+			continue
+		}
+		switch {
+		case p.Name == "begin" && strings.EqualFold(p.Value, "VCARD"):
+			begin = true
+		case p.Name == "end" && strings.EqualFold(p.Value, "VCARD"):
+			return properties, nil
+		case begin:
+			properties = append(properties, p)
+		}
+	}
+	return nil, ErrInvalidVCard
+}
+
+/**
+ * parse a content line: [group.]name *(;param=value[,value]):value; the parameter values may be quoted
+ */
+func parseVCardLine(line string) *vcardProperty {
+	colon := -1
+	quoted := false
+	for i, c := range line {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return nil
+	}
+
+	p := &vcardProperty{Value: line[colon+1:]}
+	parts := splitVCardQuoted(line[:colon], ';')
+	p.Name = strings.ToLower(parts[0])
+	if dot := strings.Index(p.Name, "."); dot >= 0 {
+		p.Group, p.Name = p.Name[:dot], p.Name[dot+1:]
+	}
+
+	for _, part := range parts[1:] {
+		name, value := part, ""
+		if eq := strings.Index(part, "="); eq >= 0 {
+			name, value = part[:eq], part[eq+1:]
+		} else {
+			// vCard 2.1 / 3.0 parameter without name: a TYPE value
+			name, value = "type", part
+		}
+		param := vcardParameter{Name: strings.ToLower(name)}
+		for _, v := range splitVCardQuoted(value, ',') {
+			param.Values = append(param.Values, strings.Trim(v, `"`))
+		}
+		p.Params = append(p.Params, param)
+	}
+	return p
+}
+
+/**
+ * split on a separator that is not inside double quotes
+ */
+func splitVCardQuoted(s string, sep rune) []string {
+	result := []string{}
+	quoted := false
+	start := 0
+	for i, c := range s {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == sep && !quoted {
+			result = append(result, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(result, s[start:])
+}
+
+/**
+ * split on a separator that is not escaped with a backslash; the parts are not unescaped
+ */
+func splitVCardEscaped(s string, sep byte) []string {
+	result := []string{}
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+		} else if s[i] == sep {
+			result = append(result, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(result, s[start:])
+}
+
+/**
+ * unescape a TEXT value (RFC 6350 section 3.4)
+ */
+func unescapeVCardText(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\:`, ":", `\\`, `\`).Replace(s)
+}
+
+/**
+ * the values of a property: a list for the structured properties (each component being a string or a list of strings),
+ * the values of the multi-valued properties or the single value
+ */
+func (p *vcardProperty) values() []interface{} {
+	valueType := p.valueType()
+	unescape := func(s string) string {
+		if valueType == "text" || valueType == "unknown" {
+			return unescapeVCardText(s)
+		}
+		return s
+	}
+
+	if _, ok := vcardStructuredComponents[p.Name]; ok {
+		components := []interface{}{}
+		for _, component := range splitVCardEscaped(p.Value, ';') {
+			items := splitVCardEscaped(component, ',')
+			if len(items) == 1 || p.Name == "org" {
+				components = append(components, unescape(component))
+				continue
+			}
+			list := []interface{}{}
+			for _, item := range items {
+				list = append(list, unescape(item))
+			}
+			components = append(components, list)
+		}
+		return []interface{}{components}
+	}
+
+	if vcardMultiValued[p.Name] {
+		values := []interface{}{}
+		for _, v := range splitVCardEscaped(p.Value, ',') {
+			values = append(values, unescape(v))
+		}
+		return values
+	}
+
+	return []interface{}{unescape(p.Value)}
+}
+
+var (
+	vcardDateRegexp     = regexp.MustCompile(`^(\d{4}|--)(\d{2})(\d{2})$`)
+	vcardDateTimeRegexp = regexp.MustCompile(`^(\d{4})(\d{2})(\d{2})T(\d{2})(\d{2})(\d{2})(Z|[+-]\d{2}\d{2})?$`)
+)
+
+/**
+ * convert a date / date-time from the vCard basic format to the extended format used by jCard (RFC 7095 section 3.5.3)
+ */
+func jcardDateValue(v string) string {
+	if m := vcardDateRegexp.FindStringSubmatch(v); m != nil {
+		if m[1] == "--" {
+			return "--" + m[2] + "-" + m[3]
+		}
+		return m[1] + "-" + m[2] + "-" + m[3]
+	}
+	if m := vcardDateTimeRegexp.FindStringSubmatch(v); m != nil {
+		zone := m[7]
+		if len(zone) == 5 {
+			zone = zone[:3] + ":" + zone[3:]
+		}
+		return m[1] + "-" + m[2] + "-" + m[3] + "T" + m[4] + ":" + m[5] + ":" + m[6] + zone
+	}
+	return v
+}
+
+/**
+ * convert a vCard to jCard: ["vcard", [[name, {params}, type, value...], ...]]
+ */
+func VCardToJCard(text string) ([]byte, error) {
+	properties, err := parseVCard(text)
+	if err != nil {
+		return nil, err
+	}
+
+	jProperties := []interface{}{}
+	for _, p := range properties {
+		params := map[string]interface{}{}
+		if p.Group != "" {
+			params["group"] = p.Group
+		}
+		for _, param := range p.Params {
+			if param.Name == "value" {
+				continue
+			}
+			if len(param.Values) == 1 {
+				params[param.Name] = param.Values[0]
+			} else {
+				params[param.Name] = param.Values
+			}
+		}
+
+		valueType := p.valueType()
+		jProperty := []interface{}{p.Name, params, valueType}
+		for _, v := range p.values() {
+			if components, ok := v.([]interface{}); ok && len(components) == 1 {
+				// a structured value with a single component is a simple value (RFC 7095 section 3.3.1.3)
+				v = components[0]
+			}
+			if s, ok := v.(string); ok && (valueType == "date-and-or-time" || valueType == "timestamp" || valueType == "date" || valueType == "date-time") {
+				v = jcardDateValue(s)
+			}
+			jProperty = append(jProperty, v)
+		}
+		jProperties = append(jProperties, jProperty)
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode([]interface{}{"vcard", jProperties}); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+/**
+ * convert a vCard to xCard: <vcards><vcard><name><parameters/><type>value</type></name>...</vcard></vcards>
+ * the VERSION property is not used in xCard (RFC 6351 section 4)
+ */
+func VCardToXCard(text string) ([]byte, error) {
+	properties, err := parseVCard(text)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<vcards xmlns="` + XCardNamespace + `"><vcard>`)
+
+	group := ""
+	for _, p := range properties {
+		if p.Name == "version" {
+			continue
+		}
+		if p.Group != group {
+			if group != "" {
+				buf.WriteString("</group>")
+			}
+			if p.Group != "" {
+				buf.WriteString(`<group name="` + xcardEscape(p.Group) + `">`)
+			}
+			group = p.Group
+		}
+
+		buf.WriteString("<" + p.Name + ">")
+		writeXCardParameters(buf, p)
+
+		valueType := p.valueType()
+		values := p.values()
+		if components, ok := vcardStructuredComponents[p.Name]; ok {
+			for i, component := range values[0].([]interface{}) {
+				name := "text"
+				if i < len(components) {
+					name = components[i]
+				}
+				writeXCardComponent(buf, name, component)
+			}
+		} else {
+			for _, v := range values {
+				writeXCardValue(buf, xcardValueElement(valueType, v.(string)), v.(string))
+			}
+		}
+
+		buf.WriteString("</" + p.Name + ">")
+	}
+	if group != "" {
+		buf.WriteString("</group>")
+	}
+
+	buf.WriteString("</vcard></vcards>\r\n")
+	return buf.Bytes(), nil
+}
+
+/**
+ * the parameters of an xCard property; PREF is an integer, the other parameters are text
+ */
+func writeXCardParameters(buf *bytes.Buffer, p *vcardProperty) {
+	written := false
+	for _, param := range p.Params {
+		if param.Name == "value" {
+			continue
+		}
+		if !written {
+			buf.WriteString("<parameters>")
+			written = true
+		}
+		valueElement := "text"
+		if param.Name == "pref" {
+			valueElement = "integer"
+		}
+		buf.WriteString("<" + param.Name + ">")
+		for _, v := range param.Values {
+			writeXCardValue(buf, valueElement, v)
+		}
+		buf.WriteString("</" + param.Name + ">")
+	}
+	if written {
+		buf.WriteString("</parameters>")
+	}
+}
+
+/**
+ * a component of a structured property: one element per value (an empty element for an empty component)
+ */
+func writeXCardComponent(buf *bytes.Buffer, name string, component interface{}) {
+	switch v := component.(type) {
+	case string:
+		writeXCardValue(buf, name, v)
+	case []interface{}:
+		for _, item := range v {
+			writeXCardValue(buf, name, item.(string))
+		}
+	}
+}
+
+func writeXCardValue(buf *bytes.Buffer, element string, value string) {
+	if value == "" {
+		buf.WriteString("<" + element + "/>")
+		return
+	}
+	buf.WriteString("<" + element + ">" + xcardEscape(value) + "</" + element + ">")
+}
+
+/**
+ * the element of a value: date-and-or-time is written as date, date-time or time
+ */
+func xcardValueElement(valueType string, value string) string {
+	if valueType != "date-and-or-time" {
+		return valueType
+	}
+	switch {
+	case strings.HasPrefix(value, "T"):
+		return "time"
+	case strings.Contains(value, "T"):
+		return "date-time"
+	}
+	return "date"
+}
+
+func xcardEscape(s string) string {
+	buf := &bytes.Buffer{}
+	xml.EscapeText(buf, []byte(s))
+	return buf.String()
+}
+
+/**
+ * set the vCard as the content of the attachment in the given format (VcardFormatText, VcardFormatJson or VcardFormatXml)
+ * the extension of the filename and PidTagAttachMimeTag follow the format
+ */
+func (a *Attachment) SetVCard(vc vcard.IVCard, format string) error {
+	text := vc.Build()
+	name := strings.TrimSuffix(vcardFilename(vc), ".vcf")
+
+	switch format {
+	case VcardFormatJson:
+		data, err := VCardToJCard(text)
+		if err != nil {
+			return err
+		}
+		a.SetData(data)
+		a.SetFilename(name + ".json")
+		a.SetAttribute(NewMapiStringAttribute(MapiPidTagAttachMimeTag, "application/vcard+json"))
+	case VcardFormatXml:
+		data, err := VCardToXCard(text)
+		if err != nil {
+			return err
+		}
+		a.SetData(data)
+		a.SetFilename(name + ".xml")
+		a.SetAttribute(NewMapiStringAttribute(MapiPidTagAttachMimeTag, "application/vcard+xml"))
+	default:
+		a.SetData([]byte(text))
+		a.SetFilename(name + ".vcf")
+		a.SetAttribute(NewMapiStringAttribute(MapiPidTagAttachMimeTag, "text/vcard"))
+	}
+	return nil
+}
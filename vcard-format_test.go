@@ -0,0 +1,131 @@
+package tnefdecoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"testing"
+)
+
+const vcardFormatTestCard = "BEGIN:VCARD\r\n" +
+	"VERSION:4.0\r\n" +
+	"FN:Jane Doe\r\n" +
+	"N:Doe;Jane;;Dr.;\r\n" +
+	"ORG:Example GmbH;Sales\r\n" +
+	"TEL;TYPE=work,voice;PREF=1:tel:+49-30-1234\r\n" +
+	"EMAIL;X-CN=\"Doe, Jane\":jane@example.com\r\n" +
+	"item1.ADR;TYPE=work:;;Main St. 1\\, Floor 2;Berlin;;10115;Germany\r\n" +
+	"item1.X-ABLABEL:Office\r\n" +
+	"BDAY:19800517\r\n" +
+	"REV:20240301T083000Z\r\n" +
+	"CATEGORIES:Red,Blue\\,Green\r\n" +
+	"NOTE:line 1\\nline 2 <&>\r\n" +
+	" continued\r\n" +
+	"END:VCARD\r\n"
+
+func TestVCardToJCard(t *testing.T) {
+	data, err := VCardToJCard(vcardFormatTestCard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("%s: %v", data, err)
+	}
+	var want interface{}
+	json.Unmarshal([]byte(`["vcard",[
+		["version",{},"text","4.0"],
+		["fn",{},"text","Jane Doe"],
+		["n",{},"text",["Doe","Jane","","Dr.",""]],
+		["org",{},"text",["Example GmbH","Sales"]],
+		["tel",{"pref":"1","type":["work","voice"]},"text","tel:+49-30-1234"],
+		["email",{"x-cn":"Doe, Jane"},"text","jane@example.com"],
+		["adr",{"group":"item1","type":"work"},"text",["","","Main St. 1, Floor 2","Berlin","","10115","Germany"]],
+		["x-ablabel",{"group":"item1"},"unknown","Office"],
+		["bday",{},"date-and-or-time","1980-05-17"],
+		["rev",{},"timestamp","2024-03-01T08:30:00Z"],
+		["categories",{},"text","Red","Blue,Green"],
+		["note",{},"text","line 1\nline 2 <&>continued"]]]`), &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s", data)
+	}
+}
+
+func TestVCardToXCard(t *testing.T) {
+	data, err := VCardToXCard(vcardFormatTestCard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<vcards xmlns="urn:ietf:params:xml:ns:vcard-4.0"><vcard>` +
+		`<fn><text>Jane Doe</text></fn>` +
+		`<n><surname>Doe</surname><given>Jane</given><additional/><prefix>Dr.</prefix><suffix/></n>` +
+		`<org><text>Example GmbH</text><text>Sales</text></org>` +
+		`<tel><parameters><type><text>work</text><text>voice</text></type><pref><integer>1</integer></pref></parameters>` +
+		`<text>tel:+49-30-1234</text></tel>` +
+		`<email><parameters><x-cn><text>Doe, Jane</text></x-cn></parameters><text>jane@example.com</text></email>` +
+		`<group name="item1"><adr><parameters><type><text>work</text></type></parameters>` +
+		`<pobox/><ext/><street>Main St. 1, Floor 2</street><locality>Berlin</locality><region/><code>10115</code><country>Germany</country></adr>` +
+		`<x-ablabel><unknown>Office</unknown></x-ablabel></group>` +
+		`<bday><date>19800517</date></bday>` +
+		`<rev><timestamp>20240301T083000Z</timestamp></rev>` +
+		`<categories><text>Red</text><text>Blue,Green</text></categories>` +
+		`<note><text>line 1&#xA;line 2 &lt;&amp;&gt;continued</text></note>` +
+		"</vcard></vcards>\r\n"
+	if string(data) != want {
+		t.Errorf("%s\nwant\n%s", data, want)
+	}
+
+	// well-formed: the note is decoded back with its line break and special characters
+	d := xml.NewDecoder(bytes.NewReader(data))
+	note := ""
+	inNote := false
+	for {
+		token, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			inNote = inNote || token.Name.Local == "note"
+		case xml.EndElement:
+			inNote = inNote && token.Name.Local != "note"
+		case xml.CharData:
+			if inNote {
+				note += string(token)
+			}
+		}
+	}
+	if note != "line 1\nline 2 <&>continued" {
+		t.Errorf("note %q", note)
+	}
+}
+
+func TestVCardFormatInvalid(t *testing.T) {
+	for _, text := range []string{"", "FN:Jane Doe\r\n", "BEGIN:VCARD\r\nFN:Jane Doe\r\n"} {
+		if _, err := VCardToJCard(text); err != ErrInvalidVCard {
+			t.Errorf("jCard of %q: error %v", text, err)
+		}
+		if _, err := VCardToXCard(text); err != ErrInvalidVCard {
+			t.Errorf("xCard of %q: error %v", text, err)
+		}
+	}
+}
+
+func TestVCardFormatMalformed(t *testing.T) {
+	checkMalformed(t, convertVCard, []byte(vcardFormatTestCard))
+}
+
+func FuzzVCardFormat(f *testing.F) {
+	fuzzDecoder(f, convertVCard, []byte(vcardFormatTestCard))
+}
+
+func convertVCard(data []byte) {
+	VCardToJCard(string(data))
+	VCardToXCard(string(data))
+}
@@ -0,0 +1,651 @@
+/**
+ * vCard import: the inverse of ExtractVCard; maps a vCard 3.0 / 4.0 (text or jCard) onto the properties of an
+ * IPM.Contact ([MS-OXVCARD] section 2.1.3, [MS-OXOCNTC])
+ */
+
+package tnefdecoder
+
+import (
+	b64 "encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the message class of the imported contacts
+const ContactMessageClass = "IPM.Contact"
+
+// the filename of the contact photo attachment (Outlook names it ContactPicture.jpg)
+const ContactPhotoFilename = "ContactPicture"
+
+/**
+ * create an IPM.Contact from a vCard; data is a text vCard or a jCard
+ */
+func ImportVCard(data []byte) (*TnefObject, error) {
+	var (
+		properties []*vcardProperty
+		err        error
+	)
+	text := strings.TrimSpace(string(data))
+	if strings.HasPrefix(text, "[") {
+		properties, err = parseJCard([]byte(text))
+	} else {
+		properties, err = parseVCard(text)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &contactImport{t: &TnefObject{}}
+	c.t.SetAttribute(NewMappedStringAttribute(AttrLevelMessage, AttMessageClass, ContactMessageClass))
+	for _, p := range properties {
+		c.importProperty(p)
+	}
+	c.finish()
+	return c.t, nil
+}
+
+/**
+ * encode a vCard as a TNEF stream holding an IPM.Contact
+ */
+func EncodeVCard(data []byte) ([]byte, error) {
+	t, err := ImportVCard(data)
+	if err != nil {
+		return nil, err
+	}
+	e := NewEncoder()
+	return e.Encode(t)
+}
+
+/**
+ * create an attachment embedding the object as a message (PidTagAttachMethod = afEmbeddedMessage)
+ */
+func NewEmbeddedMessageAttachment(t *TnefObject, displayName string) *Attachment {
+	a := NewAttachment()
+	a.Embedded = t
+	a.SetAttribute(NewMapiIntAttribute(MapiPidTagAttachMethod, AttachMethodEmbeddedMessage))
+	if displayName != "" {
+		a.SetAttribute(NewMapiStringAttribute(MapiPidTagDisplayName, displayName))
+	}
+	return a
+}
+
+/**
+ * the state of an import: the contact, and the values collected before being written
+ */
+type contactImport struct {
+	t *TnefObject
+
+	displayName string
+	emails      int
+	fullAddress map[int]string
+	homeNumbers int
+	workNumbers int
+	children    []string
+	categories  []string
+	certificate [][]byte
+}
+
+/**
+ * the addresses of the contact: the named properties of the work address, the properties of the home and other addresses
+ */
+var contactImportAddresses = map[int]struct {
+	Named       bool
+	FullAddress int
+	Ids         [6]int // PO box, street, city, state, postal code, country
+}{
+	PostalAddressWork:  {true, MapiPidLidWorkAddress, [6]int{MapiPidLidWorkAddressPostOfficeBox, MapiPidLidWorkAddressStreet, MapiPidLidWorkAddressCity, MapiPidLidWorkAddressState, MapiPidLidWorkAddressPostalCode, MapiPidLidWorkAddressCountry}},
+	PostalAddressHome:  {false, MapiPidLidHomeAddress, [6]int{MapiPidTagHomeAddressPostOfficeBox, MapiPidTagHomeAddressStreet, MapiPidTagHomeAddressCity, MapiPidTagHomeAddressStateOrProvince, MapiPidTagHomeAddressPostalCode, MapiPidTagHomeAddressCountry}},
+	PostalAddressOther: {false, MapiPidLidOtherAddress, [6]int{MapiPidTagOtherAddressPostOfficeBox, MapiPidTagOtherAddressStreet, MapiPidTagOtherAddressCity, MapiPidTagOtherAddressStateOrProvince, MapiPidTagOtherAddressPostalCode, MapiPidTagOtherAddressCountry}},
+}
+
+/**
+ * the email addresses: address, address type, display name, original display name, original entry ID
+ */
+var contactImportEmails = [][5]int{
+	{MapiPidLidEmail1EmailAddress, MapiPidLidEmail1AddressType, MapiPidLidEmail1DisplayName, MapiPidLidEmail1OriginalDisplayName, MapiPidLidEmail1OriginalEntryId},
+	{MapiPidLidEmail2EmailAddress, MapiPidLidEmail2AddressType, MapiPidLidEmail2DisplayName, MapiPidLidEmail2OriginalDisplayName, MapiPidLidEmail2OriginalEntryId},
+	{MapiPidLidEmail3EmailAddress, MapiPidLidEmail3AddressType, MapiPidLidEmail3DisplayName, MapiPidLidEmail3OriginalDisplayName, MapiPidLidEmail3OriginalEntryId},
+}
+
+func (c *contactImport) importProperty(p *vcardProperty) {
+	values := p.values()
+	text := vcardPropertyText(values)
+
+	switch p.Name {
+	case "fn":
+		c.displayName = text
+		c.setString(MapiPidTagDisplayName, text)
+		c.setString(MapiPidTagSubject, text)
+		c.setString(MapiPidTagNormalizedSubject, text)
+	case "n":
+		// family; given; middle; prefix; suffix
+		components := vcardComponents(values, " ")
+		for i, id := range []int{MapiPidTagSurname, MapiPidTagGivenName, MapiPidTagMiddleName, MapiPidTagDisplayNamePrefix, MapiPidTagGeneration} {
+			if i < len(components) {
+				c.setString(id, components[i])
+			}
+		}
+	case "nickname":
+		c.setString(MapiPidTagNickname, vcardFirstValue(values))
+	case "bday":
+		c.setTime(MapiPidTagBirthday, text)
+	case "anniversary", "x-ms-anniversary", "x-anniversary":
+		c.setTime(MapiPidTagWeddingAnniversary, text)
+	case "gender":
+		if components := vcardComponents(values, ""); len(components) > 0 && c.t.GetAttribute(MapiPidTagGender, "mapi") == nil {
+			switch strings.ToUpper(components[0]) {
+			case "F":
+				c.t.SetAttribute(NewMapiAttribute(MapiPidTagGender, MapiTypeInt16, MapiEncodeInt32(1)))
+			case "M":
+				c.t.SetAttribute(NewMapiAttribute(MapiPidTagGender, MapiTypeInt16, MapiEncodeInt32(2)))
+			}
+		}
+	case "adr":
+		c.importAddress(p, values)
+	case "tel", "x-ms-tel":
+		c.importTelephone(p, text)
+	case "email":
+		c.importEmail(p, text)
+	case "impp", "x-ms-imaddress":
+		c.setNamedString(PsetidAddress, MapiPidLidInstantMessagingAddress, strings.TrimPrefix(text, "sip:"))
+	case "title":
+		c.setString(MapiPidTagTitle, text)
+	case "role":
+		c.setString(MapiPidTagProfession, text)
+	case "org":
+		components := vcardComponents(values, " ")
+		if len(components) > 0 {
+			c.setString(MapiPidTagCompanyName, components[0])
+		}
+		if len(components) > 1 {
+			c.setString(MapiPidTagDepartmentName, strings.Join(components[1:], " "))
+		}
+	case "x-ms-officelocation":
+		c.setString(MapiPidTagOfficeLocation, text)
+	case "x-ms-spouse":
+		c.setString(MapiPidTagSpouseName, text)
+	case "x-ms-child":
+		c.children = append(c.children, text)
+	case "x-ms-assistant":
+		c.setString(MapiPidTagAssistant, text)
+	case "x-ms-manager":
+		c.setString(MapiPidTagManagerName, text)
+	case "related":
+		switch types := vcardTypes(p); {
+		case types["spouse"]:
+			c.setString(MapiPidTagSpouseName, text)
+		case types["child"]:
+			c.children = append(c.children, text)
+		case types["agent"]:
+			c.setString(MapiPidTagAssistant, text)
+		}
+	case "categories":
+		for _, v := range values {
+			if category, ok := v.(string); ok && category != "" {
+				c.categories = append(c.categories, category)
+			}
+		}
+	case "note":
+		c.setString(MapiPidTagBody, text)
+	case "url":
+		if vcardTypes(p)["work"] {
+			c.setString(MapiPidTagBusinessHomePage, text)
+		} else {
+			c.setString(MapiPidTagPersonalHomePage, text)
+		}
+	case "class":
+		sensitivity := map[string]int{"PUBLIC": 0, "PRIVATE": 2, "CONFIDENTIAL": 3}
+		if v, ok := sensitivity[strings.ToUpper(text)]; ok && c.t.GetAttribute(MapiPidTagSensitivity, "mapi") == nil {
+			c.t.SetAttribute(NewMapiIntAttribute(MapiPidTagSensitivity, v))
+		}
+	case "key":
+		if certificate := vcardBinaryValue(p, text); len(certificate) > 0 {
+			c.certificate = append(c.certificate, certificate)
+		}
+	case "fburl":
+		c.setNamedString(PsetidAddress, MapiPidLidFreeBusyLocation, text)
+	case "rev":
+		c.setTime(MapiPidTagLastModificationTime, text)
+	case "photo":
+		c.importPhoto(p, text)
+	case "x-ms-cardpicture":
+		if picture := vcardBinaryValue(p, text); len(picture) > 0 && c.t.GetNamedAttribute(PsetidAddress, MapiPidLidBusinessCardCardPicture) == nil {
+			c.t.SetAttribute(NewMapiBinaryAttribute(0, picture).Named(PsetidAddress, MapiPidLidBusinessCardCardPicture))
+		}
+	}
+}
+
+/**
+ * ADR: the first work address, home address and other address; the "pref" address is the mailing address
+ */
+func (c *contactImport) importAddress(p *vcardProperty, values []interface{}) {
+	types := vcardTypes(p)
+	id := PostalAddressOther
+	if types["work"] {
+		id = PostalAddressWork
+	} else if types["home"] {
+		id = PostalAddressHome
+	}
+	if _, ok := c.fullAddress[id]; ok {
+		return
+	}
+
+	// PO box; extended address; street; locality; region; postal code; country
+	components := vcardComponents(values, "\r\n")
+	for len(components) < 7 {
+		components = append(components, "")
+	}
+	street := components[2]
+	if components[1] != "" {
+		street = strings.TrimSpace(components[1] + "\r\n" + street)
+	}
+	fields := [6]string{components[0], street, components[3], components[4], components[5], components[6]}
+
+	address := contactImportAddresses[id]
+	for i, field := range fields {
+		if field == "" {
+			continue
+		}
+		if address.Named {
+			c.setNamedString(PsetidAddress, address.Ids[i], field)
+		} else {
+			c.setString(address.Ids[i], field)
+		}
+	}
+
+	// the full address: street, "city, state postal code", country
+	cityLine := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(fields[2]+", "+fields[3]), ",") + " " + fields[4])
+	cityLine = strings.TrimSpace(strings.TrimPrefix(cityLine, ","))
+	lines := []string{}
+	for _, line := range []string{fields[1], cityLine, fields[5]} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if c.fullAddress == nil {
+		c.fullAddress = map[int]string{}
+	}
+	c.fullAddress[id] = strings.Join(lines, "\r\n")
+	c.setNamedString(PsetidAddress, address.FullAddress, c.fullAddress[id])
+
+	if (types["pref"] || len(p.param("pref")) > 0) && c.t.GetNamedAttribute(PsetidAddress, MapiPidLidPostalAddressId) == nil {
+		c.t.SetAttribute(NewMapiIntAttribute(0, id).Named(PsetidAddress, MapiPidLidPostalAddressId))
+	}
+}
+
+/**
+ * TEL / X-MS-TEL: the inverse of the mapping of ExtractVCard; two home and two work numbers, the first of the others
+ */
+func (c *contactImport) importTelephone(p *vcardProperty, number string) {
+	number = strings.TrimPrefix(number, "tel:")
+	if number == "" {
+		return
+	}
+	types := vcardTypes(p)
+
+	// the numbers specific to Outlook (X-MS-TEL, or TEL in 4.0)
+	for _, tel := range vcardTelephoneNumbers {
+		if tel.Property == "X-MS-TEL" && types[strings.ToLower(tel.Types[0])] {
+			c.setString(tel.Id, number)
+			return
+		}
+	}
+
+	id := 0
+	switch {
+	case types["fax"] && types["work"]:
+		id = MapiPidTagBusinessFaxNumber
+	case types["fax"] && types["home"]:
+		id = MapiPidTagHomeFaxNumber
+	case types["fax"]:
+		id = MapiPidTagPrimaryFaxNumber
+	case types["cell"]:
+		id = MapiPidTagMobileTelephoneNumber
+	case types["pager"]:
+		id = MapiPidTagPagerTelephoneNumber
+	case types["car"]:
+		id = MapiPidTagCarTelephoneNumber
+	case types["isdn"]:
+		id = MapiPidTagIsdnNumber
+	case types["home"]:
+		c.homeNumbers++
+		id = MapiPidTagHomeTelephoneNumber
+		if c.homeNumbers == 2 {
+			id = MapiPidTagHome2TelephoneNumber
+		}
+	case types["work"]:
+		c.workNumbers++
+		id = MapiPidTagBusinessTelephoneNumber
+		if c.workNumbers == 2 {
+			id = MapiPidTagBusiness2TelephoneNumber
+		}
+	case types["pref"]:
+		id = MapiPidTagPrimaryTelephoneNumber
+	default:
+		id = MapiPidTagOtherTelephoneNumber
+	}
+	c.setString(id, number)
+}
+
+/**
+ * EMAIL: Email1 to Email3 with the address type, the display name (X-CN, else "name (address)") and a One-Off EntryID
+ */
+func (c *contactImport) importEmail(p *vcardProperty, address string) {
+	address = strings.TrimPrefix(address, "mailto:")
+	if address == "" || c.emails >= len(contactImportEmails) {
+		return
+	}
+	lids := contactImportEmails[c.emails]
+	c.emails++
+
+	addressType := "SMTP"
+	if vcardTypes(p)["x400"] {
+		addressType = "X400"
+	}
+	displayName := ""
+	if cn := p.param("x-cn"); len(cn) > 0 {
+		displayName = strings.Trim(cn[0], "\"")
+	}
+	if displayName == "" && c.displayName != "" {
+		displayName = c.displayName + " (" + address + ")"
+	}
+	if displayName == "" {
+		displayName = address
+	}
+
+	c.setNamedString(PsetidAddress, lids[0], address)
+	c.setNamedString(PsetidAddress, lids[1], addressType)
+	c.setNamedString(PsetidAddress, lids[2], displayName)
+	c.setNamedString(PsetidAddress, lids[3], address)
+	c.t.SetAttribute(NewMapiBinaryAttribute(0, EncodeOneOffEntryId(displayName, addressType, address)).Named(PsetidAddress, lids[4]))
+}
+
+/**
+ * PHOTO: the contact photo attachment (PidTagAttachmentContactPhoto); only the embedded images are imported
+ */
+func (c *contactImport) importPhoto(p *vcardProperty, value string) {
+	if c.t.GetNamedAttribute(PsetidAddress, MapiPidLidHasPicture) != nil {
+		return
+	}
+	photo := vcardBinaryValue(p, value)
+	if len(photo) == 0 {
+		return
+	}
+
+	extension := ExtensionByContentType(SniffContentType(photo))
+	if extension == "" {
+		extension = ".jpg"
+	}
+	filename := ContactPhotoFilename + extension
+
+	a := NewAttachment()
+	a.SetData(photo)
+	a.SetFilename(filename)
+	a.SetAttribute(NewMapiIntAttribute(MapiPidTagAttachMethod, AttachMethodByValue))
+	a.SetAttribute(NewMapiStringAttribute(MapiPidTagAttachLongFilename, filename))
+	a.SetAttribute(NewMapiStringAttribute(MapiPidTagAttachExtension, extension))
+	a.SetAttribute(NewMapiStringAttribute(MapiPidTagDisplayName, filename))
+	a.SetAttribute(NewMapiBoolAttribute(MapiPidTagAttachmentContactPhoto, true))
+	a.SetAttribute(NewMapiBoolAttribute(MapiPidTagAttachmentHidden, true))
+	c.t.Attachments = append(c.t.Attachments, a)
+
+	c.t.SetAttribute(NewMapiBoolAttribute(0, true).Named(PsetidAddress, MapiPidLidHasPicture))
+}
+
+/**
+ * write the values collected from several properties
+ */
+func (c *contactImport) finish() {
+	if c.displayName != "" {
+		c.setNamedString(PsetidAddress, MapiPidLidFileUnder, c.displayName)
+	}
+
+	if c.emails > 0 {
+		// PidLidAddressBookProviderEmailList: 0 = Email1, 1 = Email2, 2 = Email3
+		list := []int{}
+		arrayType := 0
+		for i := 0; i < c.emails; i++ {
+			list = append(list, i)
+			arrayType |= 1 << uint(i)
+		}
+		c.t.SetAttribute(NewMapiAttribute(0, MapiTypeMVInt32, MapiEncodeInt32Array(list)).Named(PsetidAddress, MapiPidLidAddressBookProviderEmailList))
+		c.t.SetAttribute(NewMapiIntAttribute(0, arrayType).Named(PsetidAddress, MapiPidLidAddressBookProviderArrayType))
+	}
+
+	if len(c.children) > 0 {
+		c.t.SetAttribute(NewMapiStringArrayAttribute(MapiPidTagChildrensNames, c.children))
+	}
+	if len(c.categories) > 0 {
+		c.t.SetAttribute(NewMapiStringArrayAttribute(0, c.categories).Named(PsPublicStrings, NamedKeywords))
+	}
+	if len(c.certificate) > 0 {
+		c.t.SetAttribute(NewMapiAttribute(MapiPidTagUserX509Certificate, MapiTypeMVBinary, MapiEncodeVariableValues(c.certificate)))
+	}
+}
+
+/**
+ * set a string property; the first value of the vCard wins
+ */
+func (c *contactImport) setString(id int, value string) {
+	if value == "" || c.t.GetAttribute(id, "mapi") != nil {
+		return
+	}
+	c.t.SetAttribute(NewMapiStringAttribute(id, value))
+}
+
+func (c *contactImport) setNamedString(guid string, name GenericValue, value string) {
+	if value == "" || c.t.GetNamedAttribute(guid, name) != nil {
+		return
+	}
+	c.t.SetAttribute(NewMapiStringAttribute(0, value).Named(guid, name))
+}
+
+func (c *contactImport) setTime(id int, value string) {
+	if v := parseVCardDate(value); !v.IsZero() && c.t.GetAttribute(id, "mapi") == nil {
+		c.t.SetAttribute(NewMapiTimeAttribute(id, v))
+	}
+}
+
+/**
+ * parse a vCard date or date-time, in the basic or in the extended format; the zero time if invalid
+ */
+func parseVCardDate(v string) time.Time {
+	layouts := []string{
+		"20060102", "2006-01-02",
+		"20060102T150405Z0700", "20060102T150405", "20060102T1504Z0700",
+		"2006-01-02T15:04:05Z07:00", "2006-01-02T15:04:05",
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+/**
+ * the lower case TYPE values of a property (TYPE=work,voice and TYPE=work;TYPE=voice)
+ */
+func vcardTypes(p *vcardProperty) map[string]bool {
+	types := map[string]bool{}
+	for _, v := range p.param("type") {
+		for _, item := range strings.Split(v, ",") {
+			types[strings.ToLower(strings.Trim(item, "\" "))] = true
+		}
+	}
+	return types
+}
+
+/**
+ * the components of a structured value; the lists are joined with sep
+ */
+func vcardComponents(values []interface{}, sep string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	list, ok := values[0].([]interface{})
+	if !ok {
+		if v, ok := values[0].(string); ok {
+			return []string{v}
+		}
+		return nil
+	}
+	components := []string{}
+	for _, component := range list {
+		switch value := component.(type) {
+		case string:
+			components = append(components, value)
+		case []interface{}:
+			items := []string{}
+			for _, item := range value {
+				if s, ok := item.(string); ok && s != "" {
+					items = append(items, s)
+				}
+			}
+			components = append(components, strings.Join(items, sep))
+		}
+	}
+	return components
+}
+
+/**
+ * the value of a single-valued property; the values of a multi-valued property joined with ","
+ */
+func vcardPropertyText(values []interface{}) string {
+	items := []string{}
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			items = append(items, s)
+		}
+	}
+	return strings.TrimSpace(strings.Join(items, ","))
+}
+
+func vcardFirstValue(values []interface{}) string {
+	if len(values) > 0 {
+		if s, ok := values[0].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+/**
+ * decode a binary value: a data URI, or base64 (ENCODING=b / ENCODING=BASE64); nil for the other URIs
+ */
+func vcardBinaryValue(p *vcardProperty, value string) []byte {
+	value = strings.Join(strings.Fields(value), "")
+	if strings.HasPrefix(value, "data:") {
+		comma := strings.Index(value, ",")
+		if comma < 0 || !strings.HasSuffix(value[:comma], ";base64") {
+			return nil
+		}
+		value = value[comma+1:]
+	} else if encoding := p.param("encoding"); len(encoding) == 0 || (strings.ToLower(encoding[0]) != "b" && strings.ToLower(encoding[0]) != "base64") {
+		return nil
+	}
+	data, err := b64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+/**
+ * parse a jCard (RFC 7095) into vCard properties: the values are escaped as in a text vCard, VALUE is set when the
+ * type is not the default type of the property
+ */
+func parseJCard(data []byte) ([]*vcardProperty, error) {
+	var card []interface{}
+	if err := json.Unmarshal(data, &card); err != nil || len(card) != 2 || card[0] != "vcard" {
+		return nil, ErrInvalidVCard
+	}
+	items, ok := card[1].([]interface{})
+	if !ok {
+		return nil, ErrInvalidVCard
+	}
+
+	properties := []*vcardProperty{}
+	for _, item := range items {
+		fields, ok := item.([]interface{})
+		if !ok || len(fields) < 4 {
+			return nil, ErrInvalidVCard
+		}
+		name, _ := fields[0].(string)
+		valueType, _ := fields[2].(string)
+		p := &vcardProperty{Name: strings.ToLower(name)}
+
+		if params, ok := fields[1].(map[string]interface{}); ok {
+			for paramName, paramValue := range params {
+				param := vcardParameter{Name: strings.ToLower(paramName)}
+				switch v := paramValue.(type) {
+				case []interface{}:
+					for _, s := range v {
+						param.Values = append(param.Values, jcardScalar(s))
+					}
+				default:
+					param.Values = []string{jcardScalar(v)}
+				}
+				if param.Name == "group" {
+					p.Group = strings.Join(param.Values, "")
+					continue
+				}
+				p.Params = append(p.Params, param)
+			}
+		}
+		if valueType != "" && valueType != p.valueType() {
+			p.Params = append(p.Params, vcardParameter{Name: "value", Values: []string{valueType}})
+		}
+
+		escape := func(v interface{}) string {
+			s := jcardScalar(v)
+			if t := p.valueType(); t == "text" || t == "unknown" {
+				return escapeVCardText(s)
+			}
+			return s
+		}
+		values := []string{}
+		for _, value := range fields[3:] {
+			list, ok := value.([]interface{})
+			if !ok {
+				values = append(values, escape(value))
+				continue
+			}
+			// structured value: the components separated by ";", the lists by ","
+			components := []string{}
+			for _, component := range list {
+				if sublist, ok := component.([]interface{}); ok {
+					items := []string{}
+					for _, s := range sublist {
+						items = append(items, escape(s))
+					}
+					components = append(components, strings.Join(items, ","))
+				} else {
+					components = append(components, escape(component))
+				}
+			}
+			values = append(values, strings.Join(components, ";"))
+		}
+		p.Value = strings.Join(values, ",")
+		properties = append(properties, p)
+	}
+	return properties, nil
+}
+
+func jcardScalar(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	}
+	return ""
+}
+
+func escapeVCardText(s string) string {
+	return strings.NewReplacer("\\", "\\\\", ",", "\\,", ";", "\\;", "\n", "\\n").Replace(s)
+}
@@ -0,0 +1,153 @@
+package tnefdecoder
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const vcardImportTestCard = "BEGIN:VCARD\r\n" +
+	"VERSION:4.0\r\n" +
+	"FN:Jane Doe\r\n" +
+	"N:Doe;Jane;;Dr.;\r\n" +
+	"ORG:Example GmbH;Sales\r\n" +
+	"TEL;TYPE=work,voice;PREF=1:tel:+49-30-1234\r\n" +
+	"TEL;TYPE=cell:+49 170 5678\r\n" +
+	"EMAIL;X-CN=\"Doe, Jane\":jane@example.com\r\n" +
+	"EMAIL;TYPE=home:jane.doe@example.org\r\n" +
+	"ADR;TYPE=work;PREF=1:;;Main St. 1;Berlin;;10115;Germany\r\n" +
+	"BDAY:19800517\r\n" +
+	"CATEGORIES:Customers,Berlin\r\n" +
+	"NOTE:line 1\\nline 2\r\n" +
+	"PHOTO:data:image/png;base64,iVBORw0KGgoAAAAA\r\n" +
+	"END:VCARD\r\n"
+
+func TestEncodeVCard(t *testing.T) {
+	data, err := EncodeVCard([]byte(vcardImportTestCard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDecoder()
+	contact, err := d.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if class := contact.GetAttributeStringValue(AttMessageClass, "mapped"); class != ContactMessageClass {
+		t.Errorf("message class %q", class)
+	}
+	for id, want := range map[int]string{
+		MapiPidTagDisplayName:             "Jane Doe",
+		MapiPidTagSurname:                 "Doe",
+		MapiPidTagGivenName:               "Jane",
+		MapiPidTagDisplayNamePrefix:       "Dr.",
+		MapiPidTagCompanyName:             "Example GmbH",
+		MapiPidTagDepartmentName:          "Sales",
+		MapiPidTagBusinessTelephoneNumber: "+49-30-1234",
+		MapiPidTagMobileTelephoneNumber:   "+49 170 5678",
+		MapiPidTagBody:                    "line 1\nline 2",
+	} {
+		if v := contact.GetAttributeStringValue(id, "mapi"); v != want {
+			t.Errorf("0x%04x: %q, want %q", id, v, want)
+		}
+	}
+	for name, want := range map[GenericValue]string{
+		MapiPidLidEmail1EmailAddress:    "jane@example.com",
+		MapiPidLidEmail1AddressType:     "SMTP",
+		MapiPidLidEmail1DisplayName:     "Doe, Jane",
+		MapiPidLidEmail2EmailAddress:    "jane.doe@example.org",
+		MapiPidLidEmail2DisplayName:     "Jane Doe (jane.doe@example.org)",
+		MapiPidLidWorkAddressStreet:     "Main St. 1",
+		MapiPidLidWorkAddressCity:       "Berlin",
+		MapiPidLidWorkAddressCountry:    "Germany",
+		MapiPidLidWorkAddress:           "Main St. 1\r\nBerlin 10115\r\nGermany",
+		MapiPidLidFileUnder:             "Jane Doe",
+		MapiPidLidWorkAddressPostalCode: "10115",
+	} {
+		if attr := contact.GetNamedAttribute(PsetidAddress, name); attr == nil || attr.GetStringValue() != want {
+			t.Errorf("%v: %+v, want %q", name, attr, want)
+		}
+	}
+	if attr := contact.GetNamedAttribute(PsetidAddress, MapiPidLidPostalAddressId); attr == nil || attr.GetIntValue() != PostalAddressWork {
+		t.Errorf("postal address %+v", attr)
+	}
+	if attr := contact.GetNamedAttribute(PsetidAddress, MapiPidLidEmail1OriginalEntryId); attr == nil {
+		t.Error("no Email1 entry ID")
+	} else if a, err := DecodeOneOffEntryId(attr.GetBinaryValue()); err != nil || *a != (EntryIdAddress{"Doe, Jane", "SMTP", "jane@example.com"}) {
+		t.Errorf("Email1 entry ID %+v %v", a, err)
+	}
+	if categories := contact.GetCategories(); !reflect.DeepEqual(categories, []string{"Customers", "Berlin"}) {
+		t.Errorf("categories %q", categories)
+	}
+	if attr := contact.GetAttribute(MapiPidTagBirthday, "mapi"); attr == nil || !attr.GetTimeValue().Equal(time.Date(1980, 5, 17, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("birthday %+v", attr)
+	}
+
+	// the photo: a hidden attachment flagged as the contact photo
+	if len(contact.Attachments) != 1 {
+		t.Fatalf("%d attachments, want 1", len(contact.Attachments))
+	}
+	photo := contact.Attachments[0]
+	if photo.GetFilename() != ContactPhotoFilename+".png" || string(photo.GetData()) != "\x89PNG\r\n\x1a\n\x00\x00\x00\x00" {
+		t.Errorf("photo %q %x", photo.GetFilename(), photo.GetData())
+	}
+	if attr := photo.GetAttribute(MapiPidTagAttachmentContactPhoto, "mapi"); attr == nil || !attr.GetBoolValue() {
+		t.Errorf("PidTagAttachmentContactPhoto %+v", attr)
+	}
+	if attr := contact.GetNamedAttribute(PsetidAddress, MapiPidLidHasPicture); attr == nil || !attr.GetBoolValue() {
+		t.Errorf("PidLidHasPicture %+v", attr)
+	}
+}
+
+func TestImportJCard(t *testing.T) {
+	jcard, err := VCardToJCard(vcardImportTestCard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromText, err := ImportVCard([]byte(vcardImportTestCard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJCard, err := ImportVCard(jcard)
+	if err != nil {
+		t.Fatalf("%s: %v", jcard, err)
+	}
+
+	// the same contact from both formats
+	for _, id := range []int{MapiPidTagDisplayName, MapiPidTagSurname, MapiPidTagCompanyName, MapiPidTagBusinessTelephoneNumber, MapiPidTagBody} {
+		if a, b := fromText.GetAttributeStringValue(id, "mapi"), fromJCard.GetAttributeStringValue(id, "mapi"); a != b {
+			t.Errorf("0x%04x: %q from the jCard, want %q", id, b, a)
+		}
+	}
+	for _, name := range []GenericValue{MapiPidLidEmail1EmailAddress, MapiPidLidEmail1DisplayName, MapiPidLidWorkAddress} {
+		a, b := fromText.GetNamedAttribute(PsetidAddress, name), fromJCard.GetNamedAttribute(PsetidAddress, name)
+		if a == nil || b == nil || a.GetStringValue() != b.GetStringValue() {
+			t.Errorf("%v: %+v from the jCard, want %+v", name, b, a)
+		}
+	}
+	if len(fromJCard.Attachments) != 1 {
+		t.Errorf("jCard: %d attachments, want 1", len(fromJCard.Attachments))
+	}
+}
+
+func TestImportVCardInvalid(t *testing.T) {
+	for _, data := range []string{"", "FN:Jane Doe", "BEGIN:VCARD\r\nFN:Jane Doe\r\n", "[1,2]", `["vcard",[["fn",{}]]]`, `["vcard",{}]`} {
+		if _, err := ImportVCard([]byte(data)); err != ErrInvalidVCard {
+			t.Errorf("%q: error %v", data, err)
+		}
+	}
+}
+
+func TestImportVCardMalformed(t *testing.T) {
+	jcard, _ := VCardToJCard(vcardImportTestCard)
+	checkMalformed(t, importVCard, []byte(vcardImportTestCard), jcard)
+}
+
+func FuzzImportVCard(f *testing.F) {
+	jcard, _ := VCardToJCard(vcardImportTestCard)
+	fuzzDecoder(f, importVCard, []byte(vcardImportTestCard), jcard)
+}
+
+func importVCard(data []byte) {
+	EncodeVCard(data)
+}
@@ -0,0 +1,190 @@
+/**
+ * voting buttons: the voting options of a message (PidLidVerbStream) and the vote of a reply (PidLidVerbResponse)
+ * ([MS-OXOMSG] section 2.2.1.73)
+ */
+
+package tnefdecoder
+
+import (
+	"errors"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidVerbStream = errors.New("invalid PidLidVerbStream")
+
+/**
+ * VoteOption.SendBehavior values
+ */
+const (
+	VoteSendImmediately = 0x00000001 // the response is sent without being edited
+	VotePromptUser      = 0x00000002 // the user is asked to edit the response before sending it
+)
+
+/**
+ * the IDs of the standard verbs stored in the verb stream before the voting options (reply, reply all, forward, reply to folder)
+ */
+var standardVerbIds = map[int]bool{
+	102: true,
+	103: true,
+	104: true,
+	108: true,
+}
+
+/**
+ * a verb of the verb stream (VoteOption and VoteOptionExtras)
+ */
+type VoteOption struct {
+	Id           int
+	VerbType     int
+	DisplayName  string // the label of the button (the Unicode name when the stream has it)
+	MessageClass string // the message class of the response (IPM.Note)
+	SendBehavior int    // VoteSendImmediately or VotePromptUser
+}
+
+/**
+ * the subject prefix of the response choosing the option ("Approve: ")
+ */
+func (o *VoteOption) ReplySubjectPrefix() string {
+	return o.DisplayName + ": "
+}
+
+/**
+ * check if the verb is a voting option (not a standard reply verb)
+ */
+func (o *VoteOption) IsVotingOption() bool {
+	return !standardVerbIds[o.Id]
+}
+
+/**
+ * decode a VerbStream; the ANSI strings are decoded using the code page and replaced by the Unicode strings of the
+ * VoteOptionExtras (Version2) if present
+ */
+func DecodeVerbStream(b []byte, codepage int) ([]*VoteOption, error) {
+	r := &recurrenceReader{b: b, leDecoder: new(LittleEndianDecoder)}
+	ansiString := func() string {
+		return strings.TrimRight(DecodeCodepage(r.next(int(r.byte())), codepage), "\x00")
+	}
+
+	r.uint16() // Version (0x0102)
+	count := r.uint32()
+	if count < 0 || count > len(b) {
+		return nil, ErrInvalidVerbStream
+	}
+
+	options := []*VoteOption{}
+	for i := 0; i < count && r.err == nil; i++ {
+		o := &VoteOption{}
+		o.VerbType = r.uint32()
+		o.DisplayName = ansiString()
+		o.MessageClass = ansiString()
+		ansiString() // Internal1String
+		ansiString() // DisplayNameRepeat
+		r.uint32()   // Internal2
+		r.byte()     // Internal3
+		r.uint32()   // fUseUSHeaders
+		r.uint32()   // Internal4
+		o.SendBehavior = r.uint32()
+		r.uint32() // Internal5
+		o.Id = r.uint32()
+		r.uint32() // Internal6
+		options = append(options, o)
+	}
+	if r.err != nil {
+		return nil, ErrInvalidVerbStream
+	}
+
+	// VoteOptionExtras: the Unicode display names
+	if r.offset+2 <= len(b) {
+		r.uint16() // Version2 (0x0104)
+		for _, o := range options {
+			name := r.leDecoder.Utf16(r.next(2 * int(r.byte())))
+			r.next(2 * int(r.byte())) // DisplayNameRepeat
+			if r.err != nil {
+				break
+			}
+			if name != "" {
+				o.DisplayName = name
+			}
+		}
+	}
+
+	return options, nil
+}
+
+/**
+ * return the voting options of the message; nil if the message has no voting buttons
+ */
+func (t *TnefObject) GetVotingOptions() []*VoteOption {
+	verbs, err := DecodeVerbStream(t.GetNamedBinaryValue(PsetidCommon, MapiPidLidVerbStream), t.Codepage)
+	if err != nil {
+		return nil
+	}
+
+	var options []*VoteOption
+	for _, o := range verbs {
+		if o.IsVotingOption() && o.DisplayName != "" {
+			options = append(options, o)
+		}
+	}
+	return options
+}
+
+/**
+ * return the voting option chosen in a reply (PidLidVerbResponse); "" if the message is not a vote
+ */
+func (t *TnefObject) GetVotingResponse() string {
+	return t.GetNamedStringValue(PsetidCommon, MapiPidLidVerbResponse)
+}
+
+var htmlBodyEndRegexp = regexp.MustCompile(`(?i)</body\s*>`)
+
+/**
+ * add the voting options to the bodies so that the recipients not using Outlook can vote: mailto links to the sender in the
+ * HTML body, the subjects to reply with in the text body; the bodies are UTF-8 (see GetHtmlBody and GetTextBody)
+ */
+func (t *TnefObject) RenderVotingOptions() {
+	options := t.GetVotingOptions()
+	if len(options) == 0 || t.GetVotingResponse() != "" {
+		return
+	}
+
+	subject := t.GetAttributeStringValue(MapiPidTagSubject, "mapi")
+	if subject == "" {
+		subject = t.GetAttributeStringValue(AttSubject, "mapped")
+	}
+	_, address := messageSender(t)
+
+	htmlBody := t.GetHtmlBody()
+	if len(htmlBody) > 0 {
+		var items strings.Builder
+		for _, o := range options {
+			label := html.EscapeString(o.DisplayName)
+			if address != "" {
+				href := "mailto:" + url.PathEscape(address) + "?subject=" + strings.ReplaceAll(url.QueryEscape(o.ReplySubjectPrefix()+subject), "+", "%20")
+				label = `<a href="` + html.EscapeString(href) + `">` + label + `</a>`
+			}
+			items.WriteString("<li>" + label + "</li>")
+		}
+		section := `<div class="voting-options"><p>Vote by replying with one of the following options:</p><ul>` + items.String() + "</ul></div>"
+
+		if loc := htmlBodyEndRegexp.FindIndex(htmlBody); loc != nil {
+			htmlBody = []byte(string(htmlBody[:loc[0]]) + section + string(htmlBody[loc[0]:]))
+		} else {
+			htmlBody = append(htmlBody, section...)
+		}
+		t.SetHtmlBody(htmlBody)
+	}
+
+	textBody := t.GetTextBody()
+	if len(textBody) > 0 || len(htmlBody) == 0 {
+		var section strings.Builder
+		section.WriteString("\r\n\r\nVote by replying with one of the following subjects:\r\n")
+		for _, o := range options {
+			section.WriteString("    " + o.ReplySubjectPrefix() + subject + "\r\n")
+		}
+		t.SetTextBody(append(textBody, section.String()...))
+	}
+}
@@ -0,0 +1,168 @@
+package tnefdecoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+/**
+ * a verb of the test verb streams: the 8-bit name and the Unicode name ("" to omit the VoteOptionExtras)
+ */
+type votingTestVerb struct {
+	Id          int
+	AnsiName    string
+	UnicodeName string
+}
+
+/**
+ * encode a VerbStream ([MS-OXOMSG] section 2.2.1.73)
+ */
+func votingTestStream(verbs ...votingTestVerb) []byte {
+	buf := &bytes.Buffer{}
+	le := func(v interface{}) { binary.Write(buf, binary.LittleEndian, v) }
+	ansi := func(s string) {
+		buf.WriteByte(byte(len(s)))
+		buf.WriteString(s)
+	}
+
+	le(uint16(0x0102))
+	le(uint32(len(verbs)))
+	for _, v := range verbs {
+		le(uint32(4)) // VerbType
+		ansi(v.AnsiName)
+		ansi("IPM.Note")
+		ansi("")
+		ansi(v.AnsiName)
+		le(uint32(0))
+		buf.WriteByte(0)
+		le(uint32(1))
+		le(uint32(1))
+		le(uint32(VoteSendImmediately))
+		le(uint32(0))
+		le(uint32(v.Id))
+		le(uint32(0xFFFFFFFF))
+	}
+
+	if verbs[0].UnicodeName != "" {
+		le(uint16(0x0104))
+		for _, v := range verbs {
+			for i := 0; i < 2; i++ {
+				name := utf16.Encode([]rune(v.UnicodeName))
+				buf.WriteByte(byte(len(name)))
+				le(name)
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeVerbStream(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  []byte
+		names []string
+	}{
+		{"8-bit names", votingTestStream(votingTestVerb{1, "Approve", ""}, votingTestVerb{2, "Reject", ""}), []string{"Approve", "Reject"}},
+		{"code page", votingTestStream(votingTestVerb{1, "Oui", ""}, votingTestVerb{2, "Refus\xe9", ""}), []string{"Oui", "Refusé"}},
+		{"Unicode names", votingTestStream(votingTestVerb{1, "?", "Ja"}, votingTestVerb{2, "??", "Nein ✗"}), []string{"Ja", "Nein ✗"}},
+		{"standard verbs", votingTestStream(votingTestVerb{102, "Reply", ""}, votingTestVerb{1, "Yes", ""}), []string{"Reply", "Yes"}},
+	}
+	for _, tt := range tests {
+		options, err := DecodeVerbStream(tt.data, 1252)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if len(options) != len(tt.names) {
+			t.Errorf("%s: %d options, want %d", tt.name, len(options), len(tt.names))
+			continue
+		}
+		for i, o := range options {
+			if o.DisplayName != tt.names[i] || o.MessageClass != "IPM.Note" || o.SendBehavior != VoteSendImmediately {
+				t.Errorf("%s: option %d = %+v", tt.name, i, o)
+			}
+		}
+	}
+
+	options, _ := DecodeVerbStream(votingTestStream(votingTestVerb{102, "Reply", ""}, votingTestVerb{1, "Yes", ""}), 1252)
+	if options[0].IsVotingOption() || !options[1].IsVotingOption() {
+		t.Errorf("voting options %v %v", options[0].IsVotingOption(), options[1].IsVotingOption())
+	}
+}
+
+func TestDecodeVerbStreamInvalid(t *testing.T) {
+	valid := votingTestStream(votingTestVerb{1, "Approve", ""})
+	tooMany := append([]byte{}, valid...)
+	binary.LittleEndian.PutUint32(tooMany[2:6], 0xFFFFFFFF)
+	moreThanData := append([]byte{}, valid...)
+	binary.LittleEndian.PutUint32(moreThanData[2:6], 2)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"count larger than the data", tooMany},
+		{"more verbs than the data", moreThanData},
+		{"truncated verb", valid[:len(valid)-1]},
+	}
+	for _, tt := range tests {
+		if _, err := DecodeVerbStream(tt.data, 1252); err != ErrInvalidVerbStream {
+			t.Errorf("%s: error %v, want %v", tt.name, err, ErrInvalidVerbStream)
+		}
+	}
+
+	// truncated VoteOptionExtras: the 8-bit names are kept
+	withExtras := votingTestStream(votingTestVerb{1, "Approve", "Approuver"})
+	options, err := DecodeVerbStream(withExtras[:len(withExtras)-5], 1252)
+	if err != nil || len(options) != 1 || options[0].DisplayName != "Approve" {
+		t.Errorf("truncated extras: %v %v", options, err)
+	}
+}
+
+func TestDecodeVerbStreamMalformed(t *testing.T) {
+	checkMalformed(t, decodeVerbStream, votingTestSeed())
+}
+
+func FuzzDecodeVerbStream(f *testing.F) {
+	fuzzDecoder(f, decodeVerbStream, votingTestSeed())
+}
+
+func TestRenderVotingOptions(t *testing.T) {
+	tObj := &TnefObject{Codepage: 1252}
+	tObj.SetAttribute(mimeTestString8(MapiPidTagSubject, []byte("Caf\xe9")))
+	tObj.SetAttribute(mimeTestString8(MapiPidTagBody, []byte("Gr\xfc\xdfe")))
+	tObj.SetAttribute(NewMapiBinaryAttribute(MapiPidTagBodyHtml, []byte("<html><body>Gr\xfc\xdfe</body></html>")))
+	tObj.SetAttribute(NewMapiBinaryAttribute(0, votingTestStream(votingTestVerb{1, "Approve", ""})).Named(PsetidCommon, MapiPidLidVerbStream))
+	setMessageSender(tObj, "Jane", "jane?cc=other@example.com")
+
+	tObj.RenderVotingOptions()
+	htmlBody, textBody := string(tObj.GetHtmlBody()), string(tObj.GetTextBody())
+	if !utf8.ValidString(htmlBody) || !strings.HasPrefix(htmlBody, "<html><body>Grüße<div") || !strings.HasSuffix(htmlBody, "</div></body></html>") {
+		t.Errorf("HTML body %q", htmlBody)
+	}
+	if !strings.Contains(htmlBody, `href="mailto:jane%3Fcc=other@example.com?subject=Approve%3A%20Caf%C3%A9"`) {
+		t.Errorf("link in %q", htmlBody)
+	}
+	if !utf8.ValidString(textBody) || !strings.HasPrefix(textBody, "Grüße\r\n") || !strings.Contains(textBody, "    Approve: Café\r\n") {
+		t.Errorf("text body %q", textBody)
+	}
+}
+
+func votingTestSeed() []byte {
+	return votingTestStream(votingTestVerb{102, "Reply", "Reply"}, votingTestVerb{1, "Approve", "Approuver"}, votingTestVerb{2, "Reject", "Rejeter"})
+}
+
+func decodeVerbStream(data []byte) {
+	for _, codepage := range []int{1252, 932} {
+		if options, err := DecodeVerbStream(data, codepage); err == nil {
+			for _, o := range options {
+				o.ReplySubjectPrefix()
+			}
+		}
+	}
+}
@@ -0,0 +1,901 @@
+/**
+ * WMF (Windows Metafile) decoder and renderer [MS-WMF]
+ *
+ * every attachment carries a metafile (attAttachMetaFile / PidTagAttachRendering) with the icon of the attachment,
+ * or with a preview of the object for OLE attachments. Only the records used by Outlook are rendered:
+ * bitmaps (META_STRETCHDIB, META_DIBSTRETCHBLT, META_DIBBITBLT) and basic shapes (polygons, polylines, rectangles);
+ * text, regions, clipping and palettes are ignored
+ */
+
+package tnefdecoder
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+var (
+	ErrWmfInvalid     = errors.New("invalid WMF metafile")
+	ErrWmfUnsupported = errors.New("unsupported WMF bitmap")
+	ErrWmfEmpty       = errors.New("WMF metafile has nothing to render")
+)
+
+// the rendered image is scaled down if one of its sides is larger than this value (pixels)
+const WmfMaxRenderSize = 1024
+
+/**
+ * the limits of the metafiles (untrusted input): the records after WmfMaxRecords are ignored, the polygon records
+ * with more than WmfMaxPolygonPoints points and the bitmaps with more than WmfMaxBitmapPixels pixels are not
+ * rendered, and the rendering stops once WmfMaxRenderCost pixel operations are done
+ */
+const (
+	WmfMaxRecords       = 10000
+	WmfMaxPolygonPoints = 8192
+	WmfMaxBitmapPixels  = 4096 * 4096
+	WmfMaxRenderCost    = 1 << 27
+)
+
+// placeable metafile header key ([MS-WMF] section 2.3.2.3)
+const WmfPlaceableKey = 0x9AC6CDD7
+
+/**
+ * WMF record types ([MS-WMF] section 2.1.1.1 RecordType Enumeration)
+ */
+const (
+	WmfRecordEOF                   = 0x0000
+	WmfRecordRealizePalette        = 0x0035
+	WmfRecordSetPalEntries         = 0x0037
+	WmfRecordSetBkMode             = 0x0102
+	WmfRecordSetMapMode            = 0x0103
+	WmfRecordSetRop2               = 0x0104
+	WmfRecordSetRelabs             = 0x0105
+	WmfRecordSetPolyFillMode       = 0x0106
+	WmfRecordSetStretchBltMode     = 0x0107
+	WmfRecordSetTextCharExtra      = 0x0108
+	WmfRecordRestoreDC             = 0x0127
+	WmfRecordSelectObject          = 0x012D
+	WmfRecordSetTextAlign          = 0x012E
+	WmfRecordDibCreatePatternBrush = 0x0142
+	WmfRecordDeleteObject          = 0x01F0
+	WmfRecordCreatePatternBrush    = 0x01F9
+	WmfRecordSetBkColor            = 0x0201
+	WmfRecordSetTextColor          = 0x0209
+	WmfRecordSetWindowOrg          = 0x020B
+	WmfRecordSetWindowExt          = 0x020C
+	WmfRecordSetViewportOrg        = 0x020D
+	WmfRecordSetViewportExt        = 0x020E
+	WmfRecordCreatePenIndirect     = 0x02FA
+	WmfRecordCreateFontIndirect    = 0x02FB
+	WmfRecordCreateBrushIndirect   = 0x02FC
+	WmfRecordCreatePalette         = 0x00F7
+	WmfRecordPolygon               = 0x0324
+	WmfRecordPolyline              = 0x0325
+	WmfRecordRectangle             = 0x041B
+	WmfRecordPolyPolygon           = 0x0538
+	WmfRecordCreateRegion          = 0x06FF
+	WmfRecordDibBitBlt             = 0x0940
+	WmfRecordDibStretchBlt         = 0x0B41
+	WmfRecordStretchDib            = 0x0F43
+)
+
+/**
+ * ternary raster operations used by Outlook to draw icons (mask + image)
+ */
+const (
+	WmfRopSrcCopy   = 0x00CC0020
+	WmfRopSrcPaint  = 0x00EE0086
+	WmfRopSrcAnd    = 0x008800C6
+	WmfRopSrcInvert = 0x00660046
+)
+
+/**
+ * a single metafile record; Params contains the record data after RecordSize and RecordFunction
+ */
+type WmfRecord struct {
+	Function int
+	Params   []byte
+}
+
+/**
+ * decoded metafile
+ */
+type Metafile struct {
+	// true if the metafile starts with a META_PLACEABLE header
+	Placeable bool
+
+	// bounding box (logical units) and units per inch from the placeable header
+	Bounds image.Rectangle
+	Inch   int
+
+	Records []*WmfRecord
+}
+
+/**
+ * decode a WMF metafile (with or without a placeable header)
+ *
+ * META_PLACEABLE = Key(4) HWmf(2) BoundingBox(8) Inch(2) Reserved(4) Checksum(2)
+ * META_HEADER = Type(2) HeaderSize(2) Version(2) Size(4) NumberOfObjects(2) MaxRecord(4) NumberOfMembers(2)
+ * Record = RecordSize(4, in 16-bit words) RecordFunction(2) rdParam(variable)
+ */
+func DecodeMetafile(data []byte) (*Metafile, error) {
+	leDecoder := new(LittleEndianDecoder)
+	m := &Metafile{}
+
+	offset := 0
+	if len(data) >= 22 && leDecoder.Uint32(data[0:4]) == WmfPlaceableKey {
+		m.Placeable = true
+		m.Bounds = image.Rect(
+			int(leDecoder.Int16(data[6:8])),
+			int(leDecoder.Int16(data[8:10])),
+			int(leDecoder.Int16(data[10:12])),
+			int(leDecoder.Int16(data[12:14])),
+		)
+		m.Inch = int(leDecoder.Uint16(data[14:16]))
+		offset += 22
+	}
+
+	if len(data) < offset+18 {
+		return nil, ErrWmfInvalid
+	}
+
+	headerType := int(leDecoder.Uint16(data[offset : offset+2]))
+	headerSize := int(leDecoder.Uint16(data[offset+2:offset+4])) * 2
+	if (headerType != 1 && headerType != 2) || headerSize < 18 {
+		return nil, ErrWmfInvalid
+	}
+	offset += headerSize
+
+	dataLength := len(data)
+	for offset+6 <= dataLength {
+		recordSize := int(leDecoder.Uint32(data[offset:offset+4])) * 2
+		if recordSize < 6 || offset+recordSize > dataLength {
+			return nil, ErrWmfInvalid
+		}
+
+		record := &WmfRecord{
+			Function: int(leDecoder.Uint16(data[offset+4 : offset+6])),
+			Params:   data[offset+6 : offset+recordSize],
+		}
+		offset += recordSize
+
+		if record.Function == WmfRecordEOF || len(m.Records) >= WmfMaxRecords {
+			break
+		}
+		m.Records = append(m.Records, record)
+	}
+
+	return m, nil
+}
+
+/**
+ * render the metafile to PNG
+ */
+func (m *Metafile) RenderPng() ([]byte, error) {
+	img, err := m.Render()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	if err = png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+/**
+ * render the metafile into an RGBA image (white background)
+ */
+func (m *Metafile) Render() (*image.RGBA, error) {
+	frame := m.logicalFrame()
+	if frame.Dx() == 0 || frame.Dy() == 0 {
+		return nil, ErrWmfEmpty
+	}
+
+	width, height := absInt(frame.Dx()), absInt(frame.Dy())
+	if m.Placeable && m.Inch > 0 {
+		// the placeable header gives the physical size of the picture; render it at 96 DPI
+		width = absInt(m.Bounds.Dx()) * 96 / m.Inch
+		height = absInt(m.Bounds.Dy()) * 96 / m.Inch
+	}
+	if width > WmfMaxRenderSize || height > WmfMaxRenderSize {
+		if width > height {
+			height = height * WmfMaxRenderSize / width
+			width = WmfMaxRenderSize
+		} else {
+			width = width * WmfMaxRenderSize / height
+			height = WmfMaxRenderSize
+		}
+	}
+	if width == 0 || height == 0 {
+		return nil, ErrWmfEmpty
+	}
+
+	r := &wmfRenderer{
+		img:      image.NewRGBA(image.Rect(0, 0, width, height)),
+		frame:    frame,
+		fillMode: 1,
+		pen:      &wmfPen{Color: color.RGBA{0, 0, 0, 255}, Width: 1},
+		brush:    &wmfBrush{Color: color.RGBA{255, 255, 255, 255}},
+	}
+	for i := range r.img.Pix {
+		r.img.Pix[i] = 255
+	}
+
+	for _, record := range m.Records {
+		if r.cost >= WmfMaxRenderCost {
+			break
+		}
+		r.play(record)
+	}
+
+	return r.img, nil
+}
+
+/**
+ * the logical coordinates space mapped on the output image: the window set by META_SETWINDOWORG/META_SETWINDOWEXT,
+ * the bounding box of the placeable header or, as last resort, the bounding box of all rendered records
+ */
+func (m *Metafile) logicalFrame() image.Rectangle {
+	leDecoder := new(LittleEndianDecoder)
+
+	var org, ext image.Point
+	hasExt := false
+	bounds := image.Rectangle{}
+
+	for _, record := range m.Records {
+		p := record.Params
+		switch record.Function {
+		case WmfRecordSetWindowOrg:
+			if len(p) >= 4 && !hasExt {
+				org = image.Pt(int(leDecoder.Int16(p[2:4])), int(leDecoder.Int16(p[0:2])))
+			}
+		case WmfRecordSetWindowExt:
+			if len(p) >= 4 && !hasExt {
+				ext = image.Pt(int(leDecoder.Int16(p[2:4])), int(leDecoder.Int16(p[0:2])))
+				hasExt = ext.X != 0 && ext.Y != 0
+			}
+		default:
+			if rect, ok := wmfRecordBounds(record); ok {
+				bounds = bounds.Union(rect)
+			}
+		}
+	}
+
+	if hasExt {
+		return image.Rectangle{Min: org, Max: org.Add(ext)}
+	}
+	if m.Placeable && !m.Bounds.Empty() {
+		return m.Bounds
+	}
+	return bounds
+}
+
+/**
+ * bounding box of a drawing record (logical units)
+ */
+func wmfRecordBounds(record *WmfRecord) (image.Rectangle, bool) {
+	leDecoder := new(LittleEndianDecoder)
+	p := record.Params
+	rect := image.Rectangle{}
+
+	switch record.Function {
+	case WmfRecordStretchDib:
+		if len(p) >= 22 {
+			x, y := int(leDecoder.Int16(p[20:22])), int(leDecoder.Int16(p[18:20]))
+			w, h := int(leDecoder.Int16(p[16:18])), int(leDecoder.Int16(p[14:16]))
+			return image.Rect(x, y, x+w, y+h).Canon(), true
+		}
+	case WmfRecordDibStretchBlt:
+		if len(p) >= 20 {
+			x, y := int(leDecoder.Int16(p[18:20])), int(leDecoder.Int16(p[16:18]))
+			w, h := int(leDecoder.Int16(p[14:16])), int(leDecoder.Int16(p[12:14]))
+			return image.Rect(x, y, x+w, y+h).Canon(), true
+		}
+	case WmfRecordDibBitBlt:
+		if len(p) >= 16 {
+			x, y := int(leDecoder.Int16(p[14:16])), int(leDecoder.Int16(p[12:14]))
+			w, h := int(leDecoder.Int16(p[10:12])), int(leDecoder.Int16(p[8:10]))
+			return image.Rect(x, y, x+w, y+h).Canon(), true
+		}
+	case WmfRecordRectangle:
+		if len(p) >= 8 {
+			return image.Rect(int(leDecoder.Int16(p[6:8])), int(leDecoder.Int16(p[4:6])), int(leDecoder.Int16(p[2:4])), int(leDecoder.Int16(p[0:2]))).Canon(), true
+		}
+	case WmfRecordPolygon, WmfRecordPolyline, WmfRecordPolyPolygon:
+		for _, polygon := range wmfDecodePolygons(record) {
+			for _, pt := range polygon {
+				rect = rect.Union(image.Rect(pt.X, pt.Y, pt.X+1, pt.Y+1))
+			}
+		}
+		return rect, !rect.Empty()
+	}
+
+	return rect, false
+}
+
+/**
+ * decode the points of META_POLYGON, META_POLYLINE (NumberOfPoints aPoints) or
+ * META_POLYPOLYGON (NumberOfPolygons aPointsPerPolygon aPoints)
+ */
+func wmfDecodePolygons(record *WmfRecord) [][]image.Point {
+	leDecoder := new(LittleEndianDecoder)
+	p := record.Params
+	result := [][]image.Point{}
+
+	if len(p) < 2 {
+		return result
+	}
+
+	counts := []int{}
+	offset := 0
+	if record.Function == WmfRecordPolyPolygon {
+		noPolygons := int(leDecoder.Uint16(p[0:2]))
+		offset += 2
+		for i := 0; i < noPolygons && offset+2 <= len(p); i++ {
+			counts = append(counts, int(leDecoder.Uint16(p[offset:offset+2])))
+			offset += 2
+		}
+	} else {
+		counts = append(counts, int(leDecoder.Uint16(p[0:2])))
+		offset += 2
+	}
+
+	total := 0
+	for _, c := range counts {
+		total += c
+	}
+	if total > WmfMaxPolygonPoints {
+		return result
+	}
+
+	for _, c := range counts {
+		polygon := []image.Point{}
+		for i := 0; i < c && offset+4 <= len(p); i++ {
+			polygon = append(polygon, image.Pt(int(leDecoder.Int16(p[offset:offset+2])), int(leDecoder.Int16(p[offset+2:offset+4]))))
+			offset += 4
+		}
+		result = append(result, polygon)
+	}
+
+	return result
+}
+
+type wmfPen struct {
+	Null  bool
+	Width int
+	Color color.RGBA
+}
+
+type wmfBrush struct {
+	Null  bool
+	Color color.RGBA
+}
+
+/**
+ * playback state
+ */
+type wmfRenderer struct {
+	img      *image.RGBA
+	frame    image.Rectangle
+	fillMode int // 1 = ALTERNATE, 2 = WINDING
+
+	// object table; a created object takes the lowest free index ([MS-WMF] section 3.1.4.1)
+	objects []interface{}
+	pen     *wmfPen
+	brush   *wmfBrush
+
+	// the pixel operations done so far (see WmfMaxRenderCost)
+	cost int
+}
+
+/**
+ * account for n pixel operations; false if the rendering budget is exhausted (the operation is not done)
+ */
+func (r *wmfRenderer) spend(n int) bool {
+	if n < 0 || n > WmfMaxRenderCost-r.cost {
+		r.cost = WmfMaxRenderCost
+		return false
+	}
+	r.cost += n
+	return true
+}
+
+func (r *wmfRenderer) play(record *WmfRecord) {
+	leDecoder := new(LittleEndianDecoder)
+	p := record.Params
+
+	switch record.Function {
+	case WmfRecordSetPolyFillMode:
+		if len(p) >= 2 {
+			r.fillMode = int(leDecoder.Uint16(p[0:2]))
+		}
+
+	case WmfRecordCreatePenIndirect:
+		// PenStyle(2) Width(POINTS, 4) ColorRef(4)
+		pen := &wmfPen{Width: 1}
+		if len(p) >= 10 {
+			pen.Null = leDecoder.Uint16(p[0:2])&0x000F == 5 // PS_NULL
+			pen.Width = int(leDecoder.Int16(p[2:4]))
+			pen.Color = wmfColorRef(p[6:10])
+		}
+		r.addObject(pen)
+
+	case WmfRecordCreateBrushIndirect:
+		// BrushStyle(2) ColorRef(4) BrushHatch(2)
+		brush := &wmfBrush{}
+		if len(p) >= 6 {
+			brush.Null = leDecoder.Uint16(p[0:2]) == 1 // BS_NULL
+			brush.Color = wmfColorRef(p[2:6])
+		}
+		r.addObject(brush)
+
+	case WmfRecordCreatePalette, WmfRecordCreateFontIndirect, WmfRecordCreatePatternBrush, WmfRecordDibCreatePatternBrush, WmfRecordCreateRegion:
+		// not rendered, but they still take a slot into the object table
+		r.addObject(record.Function)
+
+	case WmfRecordSelectObject:
+		if len(p) >= 2 {
+			idx := int(leDecoder.Uint16(p[0:2]))
+			if idx < len(r.objects) {
+				switch obj := r.objects[idx].(type) {
+				case *wmfPen:
+					r.pen = obj
+				case *wmfBrush:
+					r.brush = obj
+				}
+			}
+		}
+
+	case WmfRecordDeleteObject:
+		if len(p) >= 2 {
+			idx := int(leDecoder.Uint16(p[0:2]))
+			if idx < len(r.objects) {
+				r.objects[idx] = nil
+			}
+		}
+
+	case WmfRecordRectangle:
+		if rect, ok := wmfRecordBounds(record); ok {
+			polygon := []image.Point{rect.Min, image.Pt(rect.Max.X, rect.Min.Y), rect.Max, image.Pt(rect.Min.X, rect.Max.Y)}
+			r.fillPolygons([][]image.Point{polygon})
+			r.strokePolygon(polygon, true)
+		}
+
+	case WmfRecordPolygon, WmfRecordPolyPolygon:
+		polygons := wmfDecodePolygons(record)
+		r.fillPolygons(polygons)
+		for _, polygon := range polygons {
+			r.strokePolygon(polygon, true)
+		}
+
+	case WmfRecordPolyline:
+		for _, polygon := range wmfDecodePolygons(record) {
+			r.strokePolygon(polygon, false)
+		}
+
+	case WmfRecordStretchDib:
+		// RasterOperation(4) ColorUsage(2) SrcHeight(2) SrcWidth(2) YSrc(2) XSrc(2) DestHeight(2) DestWidth(2) yDst(2) xDst(2) DIB
+		if len(p) > 22 {
+			src := wmfSrcRect(p[12:14], p[10:12], p[8:10], p[6:8])
+			r.blit(p[22:], int(leDecoder.Uint32(p[0:4])), src, wmfDestRect(p[20:22], p[18:20], p[16:18], p[14:16]))
+		}
+
+	case WmfRecordDibStretchBlt:
+		// RasterOperation(4) SrcHeight(2) SrcWidth(2) YSrc(2) XSrc(2) DestHeight(2) DestWidth(2) YDest(2) XDest(2) Target
+		if len(p) > 20 {
+			src := wmfSrcRect(p[10:12], p[8:10], p[6:8], p[4:6])
+			r.blit(p[20:], int(leDecoder.Uint32(p[0:4])), src, wmfDestRect(p[18:20], p[16:18], p[14:16], p[12:14]))
+		}
+
+	case WmfRecordDibBitBlt:
+		// RasterOperation(4) YSrc(2) XSrc(2) Height(2) Width(2) YDest(2) XDest(2) Target
+		if len(p) > 16 {
+			w, h := p[10:12], p[8:10]
+			src := wmfSrcRect(p[6:8], p[4:6], w, h)
+			r.blit(p[16:], int(leDecoder.Uint32(p[0:4])), src, wmfDestRect(p[14:16], p[12:14], w, h))
+		}
+	}
+}
+
+func (r *wmfRenderer) addObject(obj interface{}) {
+	for i := range r.objects {
+		if r.objects[i] == nil {
+			r.objects[i] = obj
+			return
+		}
+	}
+	r.objects = append(r.objects, obj)
+}
+
+/**
+ * map a point from logical units to image pixels
+ */
+func (r *wmfRenderer) toDevice(pt image.Point) image.Point {
+	b := r.img.Bounds()
+	return image.Pt(
+		(pt.X-r.frame.Min.X)*b.Dx()/r.frame.Dx(),
+		(pt.Y-r.frame.Min.Y)*b.Dy()/r.frame.Dy(),
+	)
+}
+
+/**
+ * draw a DIB into the destination rectangle (logical units), stretching it when needed
+ */
+func (r *wmfRenderer) blit(dib []byte, rop int, src, dst image.Rectangle) {
+	bitmap, err := DecodeDib(dib)
+	if err != nil {
+		return
+	}
+	if src.Empty() {
+		src = bitmap.Bounds()
+	}
+
+	// DIB source coordinates are relative to the top-left corner of the (decoded) image
+	src = src.Intersect(bitmap.Bounds())
+	if src.Empty() {
+		return
+	}
+
+	p0 := r.toDevice(dst.Min)
+	p1 := r.toDevice(dst.Max)
+	flipX, flipY := p1.X < p0.X, p1.Y < p0.Y
+	devRect := image.Rectangle{Min: p0, Max: p1}.Canon()
+	if devRect.Empty() {
+		return
+	}
+
+	clip := devRect.Intersect(r.img.Bounds())
+	if !r.spend(bitmap.Bounds().Dx()*bitmap.Bounds().Dy() + clip.Dx()*clip.Dy()) {
+		return
+	}
+	for y := clip.Min.Y; y < clip.Max.Y; y++ {
+		sy := src.Min.Y + (y-devRect.Min.Y)*src.Dy()/devRect.Dy()
+		if flipY {
+			sy = src.Max.Y - 1 - (y-devRect.Min.Y)*src.Dy()/devRect.Dy()
+		}
+		for x := clip.Min.X; x < clip.Max.X; x++ {
+			sx := src.Min.X + (x-devRect.Min.X)*src.Dx()/devRect.Dx()
+			if flipX {
+				sx = src.Max.X - 1 - (x-devRect.Min.X)*src.Dx()/devRect.Dx()
+			}
+
+			s := bitmap.RGBAAt(sx, sy)
+			d := r.img.RGBAAt(x, y)
+			switch rop {
+			case WmfRopSrcAnd:
+				d = color.RGBA{d.R & s.R, d.G & s.G, d.B & s.B, 255}
+			case WmfRopSrcPaint:
+				d = color.RGBA{d.R | s.R, d.G | s.G, d.B | s.B, 255}
+			case WmfRopSrcInvert:
+				d = color.RGBA{d.R ^ s.R, d.G ^ s.G, d.B ^ s.B, 255}
+			default:
+				d = color.RGBA{s.R, s.G, s.B, 255}
+			}
+			r.img.SetRGBA(x, y, d)
+		}
+	}
+}
+
+/**
+ * fill polygons with the selected brush using the current polygon fill mode (scanline, pixel centers)
+ */
+func (r *wmfRenderer) fillPolygons(polygons [][]image.Point) {
+	if r.brush == nil || r.brush.Null {
+		return
+	}
+
+	devPolygons := [][]image.Point{}
+	bounds := image.Rectangle{}
+	for _, polygon := range polygons {
+		devPolygon := make([]image.Point, len(polygon))
+		for i, pt := range polygon {
+			devPolygon[i] = r.toDevice(pt)
+			bounds = bounds.Union(image.Rect(devPolygon[i].X, devPolygon[i].Y, devPolygon[i].X+1, devPolygon[i].Y+1))
+		}
+		devPolygons = append(devPolygons, devPolygon)
+	}
+	bounds = bounds.Intersect(r.img.Bounds())
+
+	// every pixel of the bounding box is tested against every edge
+	edges := 0
+	for _, polygon := range devPolygons {
+		edges += len(polygon)
+	}
+	if edges > WmfMaxPolygonPoints || !r.spend(bounds.Dx()*bounds.Dy()*edges) {
+		return
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		cy := float64(y) + 0.5
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			cx := float64(x) + 0.5
+			winding := 0
+			crossings := 0
+			for _, polygon := range devPolygons {
+				n := len(polygon)
+				for i := 0; i < n; i++ {
+					a, b := polygon[i], polygon[(i+1)%n]
+					ay, by := float64(a.Y), float64(b.Y)
+					if (ay <= cy) == (by <= cy) {
+						continue
+					}
+					ix := float64(a.X) + (cy-ay)*float64(b.X-a.X)/(by-ay)
+					if ix > cx {
+						crossings++
+						if by > ay {
+							winding++
+						} else {
+							winding--
+						}
+					}
+				}
+			}
+
+			inside := crossings%2 == 1
+			if r.fillMode == 2 {
+				inside = winding != 0
+			}
+			if inside {
+				r.img.SetRGBA(x, y, r.brush.Color)
+			}
+		}
+	}
+}
+
+/**
+ * draw the outline of a polygon / polyline with the selected pen
+ */
+func (r *wmfRenderer) strokePolygon(polygon []image.Point, closed bool) {
+	if r.pen == nil || r.pen.Null || len(polygon) == 0 {
+		return
+	}
+
+	width := 1
+	if r.pen.Width > 1 {
+		width = r.pen.Width * r.img.Bounds().Dx() / absInt(r.frame.Dx())
+		if width < 1 {
+			width = 1
+		}
+		if width > WmfMaxRenderSize {
+			width = WmfMaxRenderSize
+		}
+	}
+
+	n := len(polygon)
+	if !closed {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		a, b := r.toDevice(polygon[i]), r.toDevice(polygon[(i+1)%len(polygon)])
+		// a dot of width x width pixels for every point of the line
+		length := absInt(b.X-a.X) + absInt(b.Y-a.Y) + 1
+		if !r.spend(length * width * width) {
+			return
+		}
+		r.line(a, b, width)
+	}
+}
+
+/**
+ * Bresenham line
+ */
+func (r *wmfRenderer) line(a, b image.Point, width int) {
+	dx, dy := absInt(b.X-a.X), -absInt(b.Y-a.Y)
+	sx, sy := 1, 1
+	if a.X > b.X {
+		sx = -1
+	}
+	if a.Y > b.Y {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		r.dot(a, width)
+		if a == b {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			a.X += sx
+		}
+		if e2 <= dx {
+			e += dx
+			a.Y += sy
+		}
+	}
+}
+
+func (r *wmfRenderer) dot(pt image.Point, width int) {
+	rect := image.Rect(pt.X-width/2, pt.Y-width/2, pt.X-width/2+width, pt.Y-width/2+width).Intersect(r.img.Bounds())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			r.img.SetRGBA(x, y, r.pen.Color)
+		}
+	}
+}
+
+/**
+ * ColorRef = Red(1) Green(1) Blue(1) Reserved(1)
+ */
+func wmfColorRef(b []byte) color.RGBA {
+	return color.RGBA{b[0], b[1], b[2], 255}
+}
+
+func wmfSrcRect(x, y, w, h []byte) image.Rectangle {
+	leDecoder := new(LittleEndianDecoder)
+	sx, sy := int(leDecoder.Int16(x)), int(leDecoder.Int16(y))
+	return image.Rect(sx, sy, sx+int(leDecoder.Int16(w)), sy+int(leDecoder.Int16(h)))
+}
+
+/**
+ * the destination rectangle keeps the sign of width / height (a negative value mirrors the bitmap)
+ */
+func wmfDestRect(x, y, w, h []byte) image.Rectangle {
+	leDecoder := new(LittleEndianDecoder)
+	dx, dy := int(leDecoder.Int16(x)), int(leDecoder.Int16(y))
+	return image.Rectangle{Min: image.Pt(dx, dy), Max: image.Pt(dx+int(leDecoder.Int16(w)), dy+int(leDecoder.Int16(h)))}
+}
+
+/**
+ * decode a device independent bitmap (BITMAPCOREHEADER or BITMAPINFOHEADER followed by the color table and the pixels)
+ * supported: 1, 4, 8, 16, 24 and 32 bits per pixel, BI_RGB and BI_BITFIELDS
+ */
+func DecodeDib(b []byte) (*image.RGBA, error) {
+	leDecoder := new(LittleEndianDecoder)
+
+	if len(b) < 12 {
+		return nil, ErrWmfUnsupported
+	}
+
+	headerSize := int(leDecoder.Uint32(b[0:4]))
+	var width, height, bitCount, compression, colorsUsed, paletteEntrySize int
+
+	if headerSize == 12 {
+		// BITMAPCOREHEADER
+		width = int(leDecoder.Uint16(b[4:6]))
+		height = int(leDecoder.Uint16(b[6:8]))
+		bitCount = int(leDecoder.Uint16(b[10:12]))
+		paletteEntrySize = 3
+	} else if headerSize >= 40 && len(b) >= headerSize {
+		// BITMAPINFOHEADER (or a larger V4 / V5 header)
+		width = int(leDecoder.Int32(b[4:8]))
+		height = int(leDecoder.Int32(b[8:12]))
+		bitCount = int(leDecoder.Uint16(b[14:16]))
+		compression = int(leDecoder.Uint32(b[16:20]))
+		colorsUsed = int(leDecoder.Uint32(b[32:36]))
+		paletteEntrySize = 4
+	} else {
+		return nil, ErrWmfUnsupported
+	}
+
+	topDown := height < 0
+	height = absInt(height)
+	if width <= 0 || height == 0 || width > 8192 || height > 8192 || width*height > WmfMaxBitmapPixels {
+		return nil, ErrWmfUnsupported
+	}
+
+	offset := headerSize
+
+	// color masks
+	masks := []uint32{0x7C00, 0x03E0, 0x001F}
+	if bitCount == 32 || bitCount == 24 {
+		masks = []uint32{0xFF0000, 0x00FF00, 0x0000FF}
+	}
+	switch compression {
+	case 0:
+		// BI_RGB
+	case 3:
+		// BI_BITFIELDS
+		if bitCount != 16 && bitCount != 32 {
+			return nil, ErrWmfUnsupported
+		}
+		if headerSize == 40 {
+			if len(b) < offset+12 {
+				return nil, ErrWmfUnsupported
+			}
+			masks = []uint32{leDecoder.Uint32(b[offset : offset+4]), leDecoder.Uint32(b[offset+4 : offset+8]), leDecoder.Uint32(b[offset+8 : offset+12])}
+			offset += 12
+		} else {
+			// the masks are in the V2 and larger headers
+			if headerSize < 52 || len(b) < 52 {
+				return nil, ErrWmfUnsupported
+			}
+			masks = []uint32{leDecoder.Uint32(b[40:44]), leDecoder.Uint32(b[44:48]), leDecoder.Uint32(b[48:52])}
+		}
+	default:
+		return nil, ErrWmfUnsupported
+	}
+
+	// color table
+	palette := []color.RGBA{}
+	if bitCount <= 8 {
+		if colorsUsed == 0 || colorsUsed > 1<<uint(bitCount) {
+			colorsUsed = 1 << uint(bitCount)
+		}
+		for i := 0; i < colorsUsed; i++ {
+			if len(b) < offset+paletteEntrySize {
+				return nil, ErrWmfUnsupported
+			}
+			palette = append(palette, color.RGBA{b[offset+2], b[offset+1], b[offset], 255})
+			offset += paletteEntrySize
+		}
+	} else if colorsUsed > 0 {
+		// optional color table for true color bitmaps, not used
+		offset += colorsUsed * paletteEntrySize
+	}
+
+	stride := ((width*bitCount + 31) / 32) * 4
+	if len(b) < offset+stride*height {
+		return nil, ErrWmfUnsupported
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for row := 0; row < height; row++ {
+		y := height - 1 - row
+		if topDown {
+			y = row
+		}
+		line := b[offset+row*stride : offset+(row+1)*stride]
+
+		for x := 0; x < width; x++ {
+			var c color.RGBA
+			switch bitCount {
+			case 1:
+				c = wmfPaletteColor(palette, int(line[x/8]>>(7-uint(x%8))&0x01))
+			case 4:
+				c = wmfPaletteColor(palette, int(line[x/2]>>(4*(1-uint(x%2)))&0x0F))
+			case 8:
+				c = wmfPaletteColor(palette, int(line[x]))
+			case 16:
+				c = wmfMaskedColor(uint32(leDecoder.Uint16(line[x*2:x*2+2])), masks)
+			case 24:
+				c = color.RGBA{line[x*3+2], line[x*3+1], line[x*3], 255}
+			case 32:
+				c = wmfMaskedColor(leDecoder.Uint32(line[x*4:x*4+4]), masks)
+			default:
+				return nil, ErrWmfUnsupported
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	return img, nil
+}
+
+func wmfPaletteColor(palette []color.RGBA, idx int) color.RGBA {
+	if idx < len(palette) {
+		return palette[idx]
+	}
+	return color.RGBA{0, 0, 0, 255}
+}
+
+/**
+ * extract a color channel using a bit mask and scale it to 8 bits (in 64 bits: the masks can be 32 bits wide)
+ */
+func wmfMaskedColor(v uint32, masks []uint32) color.RGBA {
+	channel := func(mask uint32) uint8 {
+		if mask == 0 {
+			return 0
+		}
+		shift := uint(0)
+		for mask&1 == 0 {
+			mask >>= 1
+			shift++
+		}
+		value := (v >> shift) & mask
+		return uint8(uint64(value) * 255 / uint64(mask))
+	}
+	return color.RGBA{channel(masks[0]), channel(masks[1]), channel(masks[2]), 255}
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
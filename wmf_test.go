@@ -0,0 +1,252 @@
+package tnefdecoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image/color"
+	"testing"
+)
+
+/**
+ * a record of 16-bit parameters
+ */
+func wmfTestRecord(function int, params ...uint16) []byte {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, uint32(3+len(params)))
+	binary.Write(buf, binary.LittleEndian, uint16(function))
+	binary.Write(buf, binary.LittleEndian, params)
+	return buf.Bytes()
+}
+
+/**
+ * a record of 16-bit parameters followed by a DIB
+ */
+func wmfTestDibRecord(function int, dib []byte, params ...uint16) []byte {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, uint32(3+len(params)+len(dib)/2))
+	binary.Write(buf, binary.LittleEndian, uint16(function))
+	binary.Write(buf, binary.LittleEndian, params)
+	buf.Write(dib)
+	return buf.Bytes()
+}
+
+/**
+ * a bottom-up 24-bit BI_RGB DIB filled with a color
+ */
+func wmfTestDib(width, height int, c color.RGBA) []byte {
+	stride := ((width*24 + 31) / 32) * 4
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, []uint32{40, uint32(width), uint32(height)})
+	binary.Write(buf, binary.LittleEndian, []uint16{1, 24})
+	binary.Write(buf, binary.LittleEndian, []uint32{0, uint32(stride * height), 0, 0, 0, 0})
+	for y := 0; y < height; y++ {
+		line := make([]byte, stride)
+		for x := 0; x < width; x++ {
+			line[x*3], line[x*3+1], line[x*3+2] = c.B, c.G, c.R
+		}
+		buf.Write(line)
+	}
+	return buf.Bytes()
+}
+
+/**
+ * a 32x32 placeable metafile: a red rectangle, a green triangle and a blue bitmap
+ */
+func wmfTestMetafile() []byte {
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, uint32(WmfPlaceableKey))
+	binary.Write(buf, binary.LittleEndian, []uint16{0, 0, 0, 32, 32, 96, 0, 0, 0})
+
+	records := [][]byte{
+		wmfTestRecord(WmfRecordSetWindowOrg, 0, 0),
+		wmfTestRecord(WmfRecordSetWindowExt, 32, 32),
+		wmfTestRecord(WmfRecordCreatePenIndirect, 5, 0, 0, 0, 0),
+		wmfTestRecord(WmfRecordSelectObject, 0),
+		wmfTestRecord(WmfRecordCreateBrushIndirect, 0, 0x00FF, 0x0000, 0),
+		wmfTestRecord(WmfRecordSelectObject, 1),
+		wmfTestRecord(WmfRecordRectangle, 16, 16, 0, 0),
+		wmfTestRecord(WmfRecordCreateBrushIndirect, 0, 0xFF00, 0x0000, 0),
+		wmfTestRecord(WmfRecordSelectObject, 2),
+		wmfTestRecord(WmfRecordPolygon, 3, 16, 16, 32, 16, 32, 32),
+		wmfTestDibRecord(WmfRecordStretchDib, wmfTestDib(2, 2, color.RGBA{0, 0, 255, 255}),
+			WmfRopSrcCopy&0xFFFF, WmfRopSrcCopy>>16, 0, 2, 2, 0, 0, 8, 8, 24, 0),
+		wmfTestRecord(WmfRecordEOF),
+	}
+	size := 9
+	for _, r := range records {
+		size += len(r) / 2
+	}
+	binary.Write(buf, binary.LittleEndian, []uint16{1, 9, 0x0300})
+	binary.Write(buf, binary.LittleEndian, uint32(size))
+	binary.Write(buf, binary.LittleEndian, uint16(3))
+	binary.Write(buf, binary.LittleEndian, uint32(20))
+	binary.Write(buf, binary.LittleEndian, uint16(0))
+	for _, r := range records {
+		buf.Write(r)
+	}
+	return buf.Bytes()
+}
+
+func TestRenderMetafile(t *testing.T) {
+	m, err := DecodeMetafile(wmfTestMetafile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Placeable || m.Inch != 96 || len(m.Records) != 11 {
+		t.Fatalf("placeable %v, inch %d, %d records", m.Placeable, m.Inch, len(m.Records))
+	}
+
+	img, err := m.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 32 || img.Bounds().Dy() != 32 {
+		t.Fatalf("image size %v", img.Bounds())
+	}
+	tests := []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{4, 4, color.RGBA{255, 0, 0, 255}},
+		{28, 20, color.RGBA{0, 255, 0, 255}},
+		{4, 28, color.RGBA{0, 0, 255, 255}},
+		{20, 4, color.RGBA{255, 255, 255, 255}},
+	}
+	for _, tt := range tests {
+		if got := img.RGBAAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("pixel (%d, %d) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeMetafileInvalid(t *testing.T) {
+	valid := wmfTestMetafile()
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"placeable header only", valid[:22]},
+		{"bad header type", append(append(append([]byte{}, valid[:22]...), 9, 0), valid[24:]...)},
+		{"record larger than the data", append(append([]byte{}, valid[:40]...), 0xFF, 0xFF, 0, 0, 0x1B, 0x04)},
+	}
+	for _, tt := range tests {
+		if _, err := DecodeMetafile(tt.data); !errors.Is(err, ErrWmfInvalid) {
+			t.Errorf("%s: error %v, want %v", tt.name, err, ErrWmfInvalid)
+		}
+	}
+}
+
+func TestDecodeMetafileRecordLimit(t *testing.T) {
+	buf := bytes.NewBuffer(wmfTestMetafile()[22 : 22+18])
+	for i := 0; i < WmfMaxRecords+10; i++ {
+		buf.Write(wmfTestRecord(WmfRecordSetBkMode, 1))
+	}
+	m, err := DecodeMetafile(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Records) != WmfMaxRecords {
+		t.Errorf("%d records, want %d", len(m.Records), WmfMaxRecords)
+	}
+}
+
+func TestRenderMetafileLimits(t *testing.T) {
+	// a polygon with too many points, and bitmaps drawn many times over the whole picture
+	header := wmfTestMetafile()[:22+18]
+	buf := bytes.NewBuffer(append([]byte{}, header...))
+	buf.Write(wmfTestRecord(WmfRecordSetWindowExt, 0x7FFF, 0x7FFF))
+	points := []uint16{WmfMaxPolygonPoints + 1}
+	for i := 0; i <= WmfMaxPolygonPoints; i++ {
+		points = append(points, uint16(i), uint16(i*7))
+	}
+	buf.Write(wmfTestRecord(WmfRecordPolygon, points...))
+	for i := 0; i < 2000; i++ {
+		buf.Write(wmfTestDibRecord(WmfRecordStretchDib, wmfTestDib(64, 64, color.RGBA{0, 0, 255, 255}),
+			WmfRopSrcCopy&0xFFFF, WmfRopSrcCopy>>16, 0, 64, 64, 0, 0, 0x7FFF, 0x7FFF, 0, 0))
+	}
+
+	m, err := DecodeMetafile(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if polygons := wmfDecodePolygons(m.Records[1]); len(polygons) != 0 {
+		t.Errorf("%d polygons decoded above the limit of points", len(polygons))
+	}
+	if _, err := m.Render(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecodeDib(t *testing.T) {
+	header := func(size, width, height, bitCount, compression, colorsUsed int) []byte {
+		buf := &bytes.Buffer{}
+		binary.Write(buf, binary.LittleEndian, []uint32{uint32(size), uint32(width), uint32(height)})
+		binary.Write(buf, binary.LittleEndian, []uint16{1, uint16(bitCount)})
+		binary.Write(buf, binary.LittleEndian, []uint32{uint32(compression), 0, 0, 0, uint32(colorsUsed), 0})
+		for buf.Len() < size {
+			buf.WriteByte(0)
+		}
+		return buf.Bytes()
+	}
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+	masks565 := []byte{0x00, 0xF8, 0, 0, 0xE0, 0x07, 0, 0, 0x1F, 0, 0, 0}
+	v2Header := header(52, 1, 1, 16, 3, 0)
+	copy(v2Header[40:], masks565)
+
+	tests := []struct {
+		name string
+		data []byte
+		want color.RGBA // the color of the pixel (0, 0)
+		err  error
+	}{
+		{"24 bits", wmfTestDib(2, 2, color.RGBA{10, 20, 30, 255}), color.RGBA{10, 20, 30, 255}, nil},
+		{"1 bit", join(header(40, 1, 1, 1, 0, 2), []byte{0, 0, 0, 0, 0, 0, 255, 0}, []byte{0x80, 0, 0, 0}), color.RGBA{255, 0, 0, 255}, nil},
+		{"core header", join([]byte{12, 0, 0, 0, 1, 0, 1, 0, 1, 0, 24, 0}, []byte{0, 255, 0, 0}), color.RGBA{0, 255, 0, 255}, nil},
+		{"bit fields", join(header(40, 1, 1, 16, 3, 0), masks565, []byte{0x00, 0xF8, 0, 0}), color.RGBA{255, 0, 0, 255}, nil},
+		{"bit fields in a V2 header", join(v2Header, []byte{0x1F, 0x00, 0, 0}), color.RGBA{0, 0, 255, 255}, nil},
+		{"bit fields in a truncated V2 header", header(44, 1, 1, 16, 3, 0), color.RGBA{}, ErrWmfUnsupported},
+		{"bit fields without masks", header(40, 1, 1, 16, 3, 0), color.RGBA{}, ErrWmfUnsupported},
+		{"32-bit bit fields with a 31-bit mask", join(header(40, 1, 1, 32, 3, 0), []byte{0xFE, 0xFF, 0xFF, 0xFF}, make([]byte, 8), []byte{0, 0, 0, 0x80}), color.RGBA{127, 0, 0, 255}, nil},
+		{"bit fields with 24 bits", join(header(40, 1, 1, 24, 3, 0), masks565, []byte{0, 0, 0, 0}), color.RGBA{}, ErrWmfUnsupported},
+		{"compressed", join(header(40, 1, 1, 8, 1, 0), make([]byte, 1024)), color.RGBA{}, ErrWmfUnsupported},
+		{"truncated pixels", wmfTestDib(4, 4, color.RGBA{})[:60], color.RGBA{}, ErrWmfUnsupported},
+		{"truncated palette", join(header(40, 1, 1, 8, 0, 0), make([]byte, 100)), color.RGBA{}, ErrWmfUnsupported},
+		{"huge color table", join(header(40, 1, 1, 24, 0, 0x7FFFFFFF), make([]byte, 4)), color.RGBA{}, ErrWmfUnsupported},
+		{"too large", header(40, 8192, 8192, 24, 0, 0), color.RGBA{}, ErrWmfUnsupported},
+		{"zero width", header(40, 0, 1, 24, 0, 0), color.RGBA{}, ErrWmfUnsupported},
+		{"header larger than the data", header(40, 1, 1, 24, 0, 0)[:30], color.RGBA{}, ErrWmfUnsupported},
+	}
+	for _, tt := range tests {
+		img, err := DecodeDib(tt.data)
+		if err != tt.err {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		if err == nil && img.RGBAAt(0, 0) != tt.want {
+			t.Errorf("%s: pixel %v, want %v", tt.name, img.RGBAAt(0, 0), tt.want)
+		}
+	}
+}
+
+func TestDecodeMetafileMalformed(t *testing.T) {
+	checkMalformed(t, renderMetafile, wmfTestMetafile())
+	checkMalformed(t, func(b []byte) { DecodeDib(b) }, wmfTestDib(3, 2, color.RGBA{1, 2, 3, 255}))
+}
+
+func FuzzDecodeMetafile(f *testing.F) {
+	fuzzDecoder(f, renderMetafile, wmfTestMetafile(), wmfTestMetafile()[22:])
+}
+
+func FuzzDecodeDib(f *testing.F) {
+	fuzzDecoder(f, func(b []byte) { DecodeDib(b) }, wmfTestDib(3, 2, color.RGBA{1, 2, 3, 255}))
+}
+
+func renderMetafile(data []byte) {
+	if m, err := DecodeMetafile(data); err == nil {
+		m.Render()
+	}
+}